@@ -12,6 +12,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jung-kurt/gofpdf"
@@ -20,8 +21,21 @@ import (
 
 // App struct
 type App struct {
-	ctx context.Context
-	db  *sql.DB
+	ctx      context.Context
+	lifetime context.Context    // Cancelled at shutdown, stopping queries and background work
+	stop     context.CancelFunc // Cancels lifetime
+	db       *sql.DB
+	pool     sqlExecutor // Runs statements on db with a deadline
+	dialect  sqlDialect  // SQL differences of the configured backend
+	store    *Store      // Repositories bound to pool
+	conn     *dbMonitor  // Whether db can be used, kept up to date by the connection monitor
+
+	sessionMu sync.Mutex
+	session   *Session
+	twoFactor *twoFactorChallenge // Login waiting for its second factor
+
+	computer *computerIdentity // This install's registration, set at startup
+	offline  *offlineQueue     // Local queue used while the MySQL server is unreachable
 }
 
 // NewApp creates a new App application struct
@@ -32,16 +46,24 @@ func NewApp() *App {
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.lifetime, a.stop = context.WithCancel(ctx)
 
-	// Initialize database connection
-	db, err := InitDatabase()
-	if err != nil {
-		log.Printf("Database connection failed: %v", err)
-		log.Println("App will start but database features will be unavailable")
-	} else {
-		a.db = db
-		log.Println("Database ready")
+	// Initialize database connection; the monitor keeps retrying if it fails
+	a.connectDatabase(a.lifetime)
+}
+
+// shutdown is called when the app is closing. It cancels running queries and
+// stops the connection monitor and session reaper before closing the pool.
+func (a *App) shutdown(ctx context.Context) {
+	if a.stop != nil {
+		a.stop()
+	}
+	if a.db != nil {
+		if err := a.db.Close(); err != nil {
+			log.Printf("⚠ Failed to close database: %v", err)
+		}
 	}
+	log.Println("App shut down")
 }
 
 // ==============================================================================
@@ -66,688 +88,340 @@ type User struct {
 	ContactNumber *string `json:"contact_number"`
 	PhotoURL      *string `json:"photo_url"`
 	DepartmentCode *string `json:"department_code"`
+	IsActive      bool    `json:"is_active"`
 	Created       string  `json:"created"`
 	LoginLogID    int     `json:"login_log_id"` // Track the login session
+	SessionToken  string  `json:"session_token,omitempty"`
+
+	MustChangePassword   bool   `json:"must_change_password"`
+	PasswordChangeReason string `json:"password_change_reason,omitempty"` // "first_login" or "expired"
+
+	// Set instead of a session when the account uses two-factor; pass the token to VerifyTwoFactorLogin
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	TwoFactorToken    string `json:"two_factor_token,omitempty"`
 }
 
 // Logout logs a user out and records logout time
 func (a *App) Logout(userID int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	// Update the most recent login log for this user to set logout time
-	// Use a subquery to ensure we get the most recent login log
-	query := `UPDATE login_logs 
-			  SET logout_time = NOW()
-			  WHERE id = (
-				  SELECT id FROM (
-					  SELECT id FROM login_logs 
-					  WHERE user_id = ? AND logout_time IS NULL 
-					  ORDER BY login_time DESC 
-					  LIMIT 1
-				  ) AS subquery
-			  )`
-	result, err := a.db.Exec(query, userID)
+	session, err := a.authorizeSignOut(userID)
 	if err != nil {
-		log.Printf("Failed to log logout for user %d: %v", userID, err)
 		return err
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		log.Printf("Failed to get rows affected for logout: %v", err)
-	} else if rowsAffected == 0 {
-		log.Printf("No active login log found to update for user %d", userID)
-		// Don't return error - might be already logged out
+	// Close this session's own login log. Other open sessions of the same user are left alone.
+	return a.endLoginSession(session, "")
+}
+
+// endLoginSession closes the session's login log with the given status and ends the session
+func (a *App) endLoginSession(session *Session, status string) error {
+	// Sessions started offline are closed once their login reaches the server
+	if session.LoginLogID < 0 || (session.LoginLogID > 0 && a.workingOffline()) {
+		return a.queueLogout(session, status)
+	}
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	userID := session.UserID
+	logID := int64(session.LoginLogID)
+	err := a.closeLoginLog(logID, userID, status, time.Time{})
+	if a.lostConnection(err) {
+		return a.queueLogout(session, status)
+	}
+	if err == sql.ErrNoRows {
+		log.Printf("No open login log %d found to update for user %d", logID, userID)
+		// Don't return error - might be already logged out or closed by another login
+	} else if err != nil {
+		log.Printf("Failed to log logout for user %d: %v", userID, err)
+		return err
 	} else {
-		log.Printf("User logout successful: user_id=%d (rows affected: %d)", userID, rowsAffected)
+		log.Printf("User logout successful: user_id=%d (login log: %d)", userID, logID)
 	}
 
+	a.endSession(userID)
 	return nil
 }
 
 // RecordTimeoutLogout records logout time for timed-out sessions
 // This can be called automatically or manually to handle session timeouts
 func (a *App) RecordTimeoutLogout(userID int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	session, err := a.authorizeSignOut(userID)
+	if err != nil {
+		return err
 	}
 
-	// Same logic as Logout - update the most recent login log
-	return a.Logout(userID)
+	// Same as Logout, but the login log is marked as timed out
+	return a.endLoginSession(session, "timeout")
 }
 
 // Login authenticates a user
 func (a *App) Login(username, password string) (*User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if a.workingOffline() {
+		return a.offlineLogin(username, password)
+	}
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	var user User
-	query := `SELECT id, username, password, user_type, created_at FROM users WHERE username = ?`
-
-	var createdAt time.Time
-	err := a.db.QueryRow(query, username).Scan(&user.ID, &user.Name, &user.Password, &user.Role, &createdAt)
+	account, err := a.store.Users.FindForLogin(username)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("invalid credentials")
 		}
+		if a.lostConnection(err) {
+			return a.offlineLogin(username, password)
+		}
 		return nil, err
 	}
+	user := account.User
+	stored := user.Password
+	passwordAgeDays := account.PasswordAgeDays
+	totpEnabled := account.TOTPEnabled
 
-	// Simple password check (in production, use proper password hashing)
-	if user.Password != password {
-		return nil, fmt.Errorf("invalid credentials")
+	if err := a.checkAccountLock(user.ID); err != nil {
+		return nil, err
 	}
 
-	user.Created = createdAt.Format("2006-01-02 15:04:05")
-
-	// Get additional user details based on role
-	var detailQuery string
-	switch user.Role {
-	case "admin":
-		detailQuery = `SELECT first_name, middle_name, last_name, gender, employee_number, email, profile_photo FROM admins WHERE user_id = ?`
-	case "teacher":
-		detailQuery = `SELECT first_name, middle_name, last_name, employee_number, email, contact_number, profile_photo FROM teachers WHERE user_id = ?`
-	case "student":
-		detailQuery = `SELECT first_name, middle_name, last_name, student_number, email, contact_number, profile_photo FROM students WHERE user_id = ? AND is_working_student = FALSE`
-	case "working_student":
-		detailQuery = `SELECT first_name, middle_name, last_name, student_number, email, contact_number, profile_photo FROM students WHERE user_id = ? AND is_working_student = TRUE`
-	}
-
-	var firstName, middleName, lastName, gender sql.NullString
-	var employeeID, studentID, photoURL sql.NullString
-	var email, contactNumber sql.NullString
-
-	switch user.Role {
-	case "admin":
-		err = a.db.QueryRow(detailQuery, user.ID).Scan(&firstName, &middleName, &lastName, &gender, &employeeID, &email, &photoURL)
-		if err == nil {
-			if firstName.Valid {
-				user.FirstName = &firstName.String
-			}
-			if middleName.Valid {
-				user.MiddleName = &middleName.String
-			}
-			if lastName.Valid {
-				user.LastName = &lastName.String
-			}
-			if gender.Valid {
-				user.Gender = &gender.String
-			}
-			if employeeID.Valid {
-				user.EmployeeID = &employeeID.String
-			}
-			if email.Valid {
-				user.Email = &email.String
-			}
-			if photoURL.Valid {
-				user.PhotoURL = &photoURL.String
-			}
-		}
-	case "teacher":
-		err = a.db.QueryRow(detailQuery, user.ID).Scan(&firstName, &middleName, &lastName, &employeeID, &email, &contactNumber, &photoURL)
-		if err == nil {
-			if firstName.Valid {
-				user.FirstName = &firstName.String
-			}
-			if middleName.Valid {
-				user.MiddleName = &middleName.String
-			}
-			if lastName.Valid {
-				user.LastName = &lastName.String
-			}
-			if employeeID.Valid {
-				user.EmployeeID = &employeeID.String
-			}
-			if email.Valid {
-				user.Email = &email.String
-			}
-			if contactNumber.Valid {
-				user.ContactNumber = &contactNumber.String
-			}
-			if photoURL.Valid {
-				user.PhotoURL = &photoURL.String
-			}
-		}
-	case "student":
-		err = a.db.QueryRow(detailQuery, user.ID).Scan(&firstName, &middleName, &lastName, &studentID, &email, &contactNumber, &photoURL)
-		if err == nil {
-			if firstName.Valid {
-				user.FirstName = &firstName.String
-			}
-			if middleName.Valid {
-				user.MiddleName = &middleName.String
-			}
-			if lastName.Valid {
-				user.LastName = &lastName.String
-			}
-			if studentID.Valid {
-				user.StudentID = &studentID.String
-			}
-			if email.Valid {
-				user.Email = &email.String
-			}
-			if contactNumber.Valid {
-				user.ContactNumber = &contactNumber.String
-			}
-			if photoURL.Valid {
-				user.PhotoURL = &photoURL.String
-			}
-		}
-	case "working_student":
-		err = a.db.QueryRow(detailQuery, user.ID).Scan(&firstName, &middleName, &lastName, &studentID, &email, &contactNumber, &photoURL)
-		if err == nil {
-			if firstName.Valid {
-				user.FirstName = &firstName.String
-			}
-			if middleName.Valid {
-				user.MiddleName = &middleName.String
-			}
-			if lastName.Valid {
-				user.LastName = &lastName.String
-			}
-			if studentID.Valid {
-				user.StudentID = &studentID.String
-			}
-			if email.Valid {
-				user.Email = &email.String
-			}
-			if contactNumber.Valid {
-				user.ContactNumber = &contactNumber.String
-			}
-			if photoURL.Valid {
-				user.PhotoURL = &photoURL.String
-			}
+	ok, needsRehash := VerifyPassword(user.Password, password)
+	if !ok {
+		if lockErr := a.recordFailedLogin(user.ID, username); lockErr != nil {
+			return nil, lockErr
 		}
+		return nil, fmt.Errorf("invalid credentials")
 	}
-
-	// Get the hostname (PC number) of this device
-	hostname, err := os.Hostname()
-	if err != nil {
-		log.Printf("Failed to get hostname: %v", err)
-		hostname = "Unknown"
+	// With two-factor the failure count is only cleared once the code is accepted,
+	// so retrying the password does not reset the code attempts
+	twoFactor := totpEnabled && twoFactorRole(user.Role)
+	if !twoFactor {
+		a.resetFailedLogins(user.ID)
 	}
 
-	// Create a login log entry
-	insertLog := `INSERT INTO login_logs (user_id, pc_number, login_time, login_status) 
-				  VALUES (?, ?, NOW(), 'success')`
-	result, err := a.db.Exec(insertLog, user.ID, hostname)
-	if err != nil {
-		log.Printf("❌ Failed to create login log for user %d (username: %s): %v", user.ID, username, err)
-		// Don't fail the login if logging fails
-	} else {
-		// Get the log ID for this session
-		logID, err := result.LastInsertId()
-		if err == nil {
-			user.LoginLogID = int(logID)
-			log.Printf("✅ Login logged successfully - ID: %d, User: %s (ID: %d), Role: %s, PC: %s", logID, username, user.ID, user.Role, hostname)
-		} else {
-			log.Printf("⚠️ Login log created but failed to get log ID: %v", err)
-		}
+	// Only reveal the deactivated state once the password has been verified
+	if !user.IsActive {
+		log.Printf("⚠ Login rejected for deactivated account: %s", username)
+		// Nor can it sign in on this PC while the server is unreachable
+		a.offline.forget(user.ID)
+		return nil, fmt.Errorf("this account has been deactivated, please contact the administrator")
 	}
 
-	// Auto-record attendance for students if they log in during class time
-	if user.Role == "student" || user.Role == "working_student" {
-		go a.autoRecordAttendanceOnLogin(user.ID, hostname)
+	// Transparently upgrade legacy plaintext and outdated hashes
+	if needsRehash {
+		a.rehashPassword(user.ID, user.Password, password)
 	}
+	// Never send the stored credential back to the frontend
+	user.Password = ""
 
-	log.Printf("User login successful: %s (role: %s, pc: %s)", username, user.Role, hostname)
-	return &user, nil
-}
-
-// autoRecordAttendanceOnLogin automatically records attendance when a student logs in
-// if they are enrolled in classes with attendance initialized for today
-func (a *App) autoRecordAttendanceOnLogin(studentID int, pcNumber string) {
-	if a.db == nil {
-		return
+	// The session stays limited to ChangePassword/Logout until a new password is chosen
+	if user.MustChangePassword {
+		user.PasswordChangeReason = "first_login"
+	} else if policy := GetPasswordPolicyConfig(); policy.ExpiryDays > 0 && passwordAgeDays >= policy.ExpiryDays {
+		user.MustChangePassword = true
+		user.PasswordChangeReason = "expired"
+		log.Printf("⚠ Password expired for %s (%d days old)", username, passwordAgeDays)
 	}
 
-	today := time.Now().Format("2006-01-02")
-	currentTime := time.Now()
-
-	// Get all enrolled classes for this student with attendance initialized for today
-	query := `
-		SELECT 
-			cl.class_id,
-			c.schedule,
-			c.school_year,
-			c.semester
-		FROM classlist cl
-		JOIN classes c ON cl.class_id = c.class_id
-		LEFT JOIN attendance a ON cl.class_id = a.class_id AND cl.student_user_id = a.student_user_id AND a.date = ?
-		WHERE cl.student_user_id = ? 
-			AND cl.status = 'active'
-			AND c.is_active = TRUE
-			AND a.class_id IS NOT NULL
-	`
-
-	rows, err := a.db.Query(query, today, studentID)
-	if err != nil {
-		log.Printf("Failed to query enrolled classes for auto-attendance: %v", err)
-		return
+	// Get additional user details based on role
+	if err := a.store.Users.LoadProfile(&user); err != nil {
+		log.Printf("⚠ Failed to load profile for %s: %v", username, err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var classID int
-		var schedule sql.NullString
-		var schoolYear, semester sql.NullString
-
-		err := rows.Scan(&classID, &schedule, &schoolYear, &semester)
-		if err != nil {
-			continue
-		}
+	// Accounts still using their username or student number as the password,
+	// as older accounts were issued, must choose a new one
+	if !user.MustChangePassword && isIssuedPassword(&user, password) {
+		user.MustChangePassword = true
+		user.PasswordChangeReason = "first_login"
+		log.Printf("⚠ %s signed in with their username or student number as the password", username)
+	}
 
-		// Check if current time matches class schedule
-		if schedule.Valid && a.isWithinClassSchedule(schedule.String, currentTime) {
-			// Update attendance to present with login time and PC number using composite key
-			updateQuery := `
-				UPDATE attendance 
-				SET time_in = CURTIME(),
-					pc_number = ?,
-					status = 'present',
-					updated_at = CURRENT_TIMESTAMP
-				WHERE class_id = ? AND student_user_id = ? AND date = ?
-			`
-			_, err := a.db.Exec(updateQuery, pcNumber, classID, studentID, today)
-			if err != nil {
-				log.Printf("Failed to auto-record attendance for student %d, class %d: %v", studentID, classID, err)
-			} else {
-				log.Printf("Auto-recorded attendance: student=%d, class=%d, pc=%s", studentID, classID, pcNumber)
-			}
-		}
+	if twoFactor {
+		// Two-factor accounts always sign in online
+		a.offline.forget(user.ID)
+		return a.beginTwoFactorChallenge(&user)
 	}
+	loggedIn, err := a.completeLogin(&user)
+	if err == nil {
+		a.rememberOfflineAccount(loggedIn, stored, password, needsRehash)
+	}
+	return loggedIn, err
 }
 
-// isWithinClassSchedule checks if the current time is within the class schedule time window
-// Schedule format examples: "MWF 1:00-2:00 PM", "TTh 10:00-11:30 AM", "1:00-2:00 PM"
-func (a *App) isWithinClassSchedule(schedule string, checkTime time.Time) bool {
-	if schedule == "" {
-		return false
-	}
+// completeLogin records the login log and starts the session for an authenticated user
+func (a *App) completeLogin(user *User) (*User, error) {
+	username := user.Name
 
-	// Check day of week if specified in schedule
-	weekday := int(checkTime.Weekday()) // 0=Sunday, 1=Monday, ..., 6=Saturday
-	scheduleUpper := strings.ToUpper(strings.TrimSpace(schedule))
+	// Identify this PC; registered lab PCs are logged under their display name
+	pc := a.currentComputer()
+	hostname := pc.pcNumber()
+	unregisteredPC, err := applyComputerPolicy(user, pc)
+	if err != nil {
+		return nil, err
+	}
 
-	// Check if schedule contains day abbreviations
-	hasDaySpec := false
-	matchesDay := false
+	// Close this user's stale sessions first so they are not mistaken for concurrent ones
+	if n, err := a.reapStaleSessions(user.ID, hostname); err != nil {
+		log.Printf("⚠ Failed to close stale sessions for user %d: %v", user.ID, err)
+	} else if n > 0 {
+		log.Printf("🔒 Closed %d stale session(s) for user %d", n, user.ID)
+	}
 
-	// Check for common patterns first (longer patterns first to avoid partial matches)
-	if strings.Contains(scheduleUpper, "MTWTF") || strings.Contains(scheduleUpper, "MON-TUE-WED-THU-FRI") {
-		hasDaySpec = true
-		if weekday >= 1 && weekday <= 5 {
-			matchesDay = true
-		}
-	} else if strings.Contains(scheduleUpper, "TTH") || strings.Contains(scheduleUpper, "TTHU") || strings.Contains(scheduleUpper, "TU-TH") {
-		hasDaySpec = true
-		if weekday == 2 || weekday == 4 {
-			matchesDay = true
-		}
-	} else if strings.Contains(scheduleUpper, "MWF") || strings.Contains(scheduleUpper, "MON-WED-FRI") {
-		hasDaySpec = true
-		if weekday == 1 || weekday == 3 || weekday == 5 {
-			matchesDay = true
+	// Create a login log entry, applying the concurrent session policy
+	logID, err := a.openLoginLog(user, pc, unregisteredPC)
+	if authErr, ok := err.(*AuthError); ok {
+		return nil, authErr
+	} else if a.lostConnection(err) {
+		// The server went away mid-login; the login log is sent once it is back
+		if err := a.queueLogin(user, pc, unregisteredPC); err != nil {
+			log.Printf("❌ Failed to queue login log for user %d (username: %s): %v", user.ID, username, err)
 		}
+	} else if err != nil {
+		log.Printf("❌ Failed to create login log for user %d (username: %s): %v", user.ID, username, err)
+		// Don't fail the login if logging fails
 	} else {
-		// Check individual day abbreviations (check longer ones first)
-		dayChecks := []struct {
-			pattern string
-			days    []int
-		}{
-			{"THU", []int{4}}, // Thursday
-			{"TUE", []int{2}}, // Tuesday
-			{"WED", []int{3}}, // Wednesday
-			{"FRI", []int{5}}, // Friday
-			{"MON", []int{1}}, // Monday
-			{"SAT", []int{6}}, // Saturday
-			{"SUN", []int{0}}, // Sunday
-			{"TH", []int{4}},  // Thursday (abbrev)
-			{"SU", []int{0}},  // Sunday (abbrev)
-		}
-
-		for _, check := range dayChecks {
-			if strings.Contains(scheduleUpper, check.pattern) {
-				hasDaySpec = true
-				for _, d := range check.days {
-					if weekday == d {
-						matchesDay = true
-						break
-					}
-				}
-				if matchesDay {
-					break
-				}
-			}
-		}
-
-		// Check single-letter day codes if no multi-letter match found
-		if !hasDaySpec {
-			if strings.Contains(scheduleUpper, " M ") || strings.HasPrefix(scheduleUpper, "M ") || scheduleUpper[0] == 'M' {
-				hasDaySpec = true
-				if weekday == 1 {
-					matchesDay = true
-				}
-			} else if strings.Contains(scheduleUpper, " T ") || strings.HasPrefix(scheduleUpper, "T ") {
-				hasDaySpec = true
-				if weekday == 2 {
-					matchesDay = true
-				}
-			} else if strings.Contains(scheduleUpper, " W ") || strings.HasPrefix(scheduleUpper, "W ") {
-				hasDaySpec = true
-				if weekday == 3 {
-					matchesDay = true
-				}
-			} else if strings.Contains(scheduleUpper, " F ") || strings.HasPrefix(scheduleUpper, "F ") {
-				hasDaySpec = true
-				if weekday == 5 {
-					matchesDay = true
-				}
-			}
-		}
+		user.LoginLogID = int(logID)
+		log.Printf("✅ Login logged successfully - ID: %d, User: %s (ID: %d), Role: %s, PC: %s", logID, username, user.ID, user.Role, hostname)
 	}
 
-	// If day is specified but doesn't match, return false
-	if hasDaySpec && !matchesDay {
-		return false
+	session, err := a.startSession(user)
+	if err != nil {
+		return nil, err
 	}
+	user.SessionToken = session.Token
 
-	// Extract time range from schedule (e.g., "1:00-2:00 PM" or "10:00-11:30 AM")
-	timePattern := regexp.MustCompile(`(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\s*(AM|PM)`)
-	matches := timePattern.FindStringSubmatch(schedule)
-	if len(matches) != 6 {
-		// Try simpler pattern without AM/PM
-		timePattern2 := regexp.MustCompile(`(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})`)
-		matches = timePattern2.FindStringSubmatch(schedule)
-		if len(matches) != 5 {
-			return false // Cannot parse schedule
-		}
-		// Parse 24-hour format
-		startHour, _ := strconv.Atoi(matches[1])
-		startMin, _ := strconv.Atoi(matches[2])
-		endHour, _ := strconv.Atoi(matches[3])
-		endMin, _ := strconv.Atoi(matches[4])
-
-		currentHour := checkTime.Hour()
-		currentMin := checkTime.Minute()
-
-		currentMinutes := currentHour*60 + currentMin
-		startMinutes := startHour*60 + startMin
-		endMinutes := endHour*60 + endMin
-
-		// Allow 30 minutes before and after class time
-		return currentMinutes >= (startMinutes-30) && currentMinutes <= (endMinutes+30)
+	// Auto-record attendance for students if they log in during class time.
+	// A queued login records it when it is replayed.
+	if (user.Role == "student" || user.Role == "working_student") && user.LoginLogID >= 0 {
+		go a.autoRecordAttendanceOnLogin(user.ID, user.LoginLogID, hostname, unregisteredPC)
 	}
 
-	// Parse 12-hour format with AM/PM
-	startHour, _ := strconv.Atoi(matches[1])
-	startMin, _ := strconv.Atoi(matches[2])
-	endHour, _ := strconv.Atoi(matches[3])
-	endMin, _ := strconv.Atoi(matches[4])
-	period := matches[5]
+	log.Printf("User login successful: %s (role: %s, pc: %s)", username, user.Role, hostname)
+	return user, nil
+}
 
-	// Convert to 24-hour format
-	if period == "PM" && startHour != 12 {
-		startHour += 12
-	}
-	if period == "PM" && endHour != 12 {
-		endHour += 12
+// rehashPassword replaces a user's stored password with a fresh argon2id hash.
+// The update is conditional on the old value so a concurrent password change is never overwritten.
+func (a *App) rehashPassword(userID int, oldStored, password string) {
+	hashed, err := HashPassword(password)
+	if err != nil {
+		log.Printf("⚠ Failed to rehash password for user %d: %v", userID, err)
+		return
 	}
-	if period == "AM" && startHour == 12 {
-		startHour = 0
+
+	if err := a.store.Users.ReplacePassword(userID, oldStored, hashed); err != nil {
+		log.Printf("⚠ Failed to store upgraded password hash for user %d: %v", userID, err)
+		return
 	}
-	if period == "AM" && endHour == 12 {
-		endHour = 0
+
+	if isHashedPassword(oldStored) {
+		log.Printf("✓ Password hash parameters upgraded for user %d", userID)
+	} else {
+		log.Printf("✓ Plaintext password migrated to argon2id for user %d", userID)
 	}
+}
 
-	currentHour := checkTime.Hour()
-	currentMin := checkTime.Minute()
+// autoRecordAttendanceOnLogin automatically records attendance when a student logs in
+// if they are enrolled in classes with attendance initialized for today. Unless the
+// attendance scheduler is off, classes in session are initialized here if needed.
+// Logins from unregistered PCs are noted in the remarks for the teacher.
+// The rows are tied to the login log so the logout records the time out.
+func (a *App) autoRecordAttendanceOnLogin(studentID, loginLogID int, pcNumber string, unregisteredPC bool) {
+	if a.requireDB() != nil {
+		return
+	}
 
-	currentMinutes := currentHour*60 + currentMin
-	startMinutes := startHour*60 + startMin
-	endMinutes := endHour*60 + endMin
+	today := time.Now().Format("2006-01-02")
+	currentTime := time.Now()
 
-	// Allow 30 minutes before and after class time for flexibility
-	return currentMinutes >= (startMinutes-30) && currentMinutes <= (endMinutes+30)
-}
+	// The scheduler may not have opened a class that just started
+	if GetAttendanceAutoSchedule() {
+		a.openStudentClassDays(studentID, currentTime)
+	}
 
-// GetUsers returns all users with complete details
-func (a *App) GetUsers() ([]User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			id, username, user_type, created_at,
-			first_name, middle_name, last_name,
-			employee_number, student_number,
-			email, contact_number, department_code
-		FROM v_users_complete
-		ORDER BY created_at DESC
-	`
-	rows, err := a.db.Query(query)
+	// Get all enrolled classes for this student with attendance initialized for today
+	classIDs, err := a.store.Attendance.InitializedClasses(studentID, today)
 	if err != nil {
-		return nil, err
+		log.Printf("Failed to query enrolled classes for auto-attendance: %v", err)
+		return
 	}
-	defer rows.Close()
 
-	var users []User
-	for rows.Next() {
-		var user User
-		var createdAt time.Time
-		var firstName, middleName, lastName sql.NullString
-		var employeeID, studentID sql.NullString
-		var email, contactNumber, departmentCode sql.NullString
+	// Keep the classes meeting now
+	classes, err := a.classesInSession(classIDs, currentTime)
+	if err != nil {
+		log.Printf("Failed to load class meetings for auto-attendance: %v", err)
+		return
+	}
 
-		err := rows.Scan(&user.ID, &user.Name, &user.Role, &createdAt,
-			&firstName, &middleName, &lastName,
-			&employeeID, &studentID,
-			&email, &contactNumber, &departmentCode)
+	for _, class := range classes {
+		// Record the login time and PC, with the status the class's rules give it.
+		// A relogin merges like a replayed one: the first login and a teacher's entries are kept.
+		classID := class.ClassID
+		status, remark := class.status(currentTime, unregisteredPC)
+		err := a.mergeTap(a.currentSession(), "auto_record", classID, studentID, loginLogID, currentTime, pcNumber, status, remark)
 		if err != nil {
-			continue
+			log.Printf("Failed to auto-record attendance for student %d, class %d: %v", studentID, classID, err)
+		} else {
+			log.Printf("Auto-recorded attendance: student=%d, class=%d, pc=%s, status=%s", studentID, classID, pcNumber, status)
 		}
+	}
+}
 
-		user.Created = createdAt.Format("2006-01-02 15:04:05")
-
-		if firstName.Valid {
-			user.FirstName = &firstName.String
-		}
-		if middleName.Valid {
-			user.MiddleName = &middleName.String
-		}
-		if lastName.Valid {
-			user.LastName = &lastName.String
-		}
-		if employeeID.Valid {
-			user.EmployeeID = &employeeID.String
-		}
-		if studentID.Valid {
-			user.StudentID = &studentID.String
-		}
-		if email.Valid {
-			user.Email = &email.String
-		}
-		if contactNumber.Valid {
-			user.ContactNumber = &contactNumber.String
-		}
-		if departmentCode.Valid {
-			user.DepartmentCode = &departmentCode.String
-		}
+// GetUsers returns all users with complete details
+// status filters by account state: "active", "inactive", or "" for all
+func (a *App) GetUsers(status string) ([]User, error) {
+	if _, err := a.authorize(PermManageUsers); err != nil {
+		return nil, err
+	}
 
-		users = append(users, user)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	return a.store.Users.List(userFilter{Status: status})
 }
 
 // GetUsersByType returns users filtered by type with complete details
 func (a *App) GetUsersByType(userType string) ([]User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			id, username, user_type, created_at,
-			first_name, middle_name, last_name,
-			employee_number, student_number,
-			email, contact_number, department_code
-		FROM v_users_complete
-		WHERE user_type = ?
-		ORDER BY created_at DESC
-	`
-	rows, err := a.db.Query(query, userType)
-	if err != nil {
+	if _, err := a.authorize(PermManageUsers); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var users []User
-	for rows.Next() {
-		var user User
-		var createdAt time.Time
-		var firstName, middleName, lastName sql.NullString
-		var employeeID, studentID sql.NullString
-		var email, contactNumber, departmentCode sql.NullString
-
-		err := rows.Scan(&user.ID, &user.Name, &user.Role, &createdAt,
-			&firstName, &middleName, &lastName,
-			&employeeID, &studentID,
-			&email, &contactNumber, &departmentCode)
-		if err != nil {
-			continue
-		}
-
-		user.Created = createdAt.Format("2006-01-02 15:04:05")
-
-		if firstName.Valid {
-			user.FirstName = &firstName.String
-		}
-		if middleName.Valid {
-			user.MiddleName = &middleName.String
-		}
-		if lastName.Valid {
-			user.LastName = &lastName.String
-		}
-		if employeeID.Valid {
-			user.EmployeeID = &employeeID.String
-		}
-		if studentID.Valid {
-			user.StudentID = &studentID.String
-		}
-		if email.Valid {
-			user.Email = &email.String
-		}
-		if contactNumber.Valid {
-			user.ContactNumber = &contactNumber.String
-		}
-		if departmentCode.Valid {
-			user.DepartmentCode = &departmentCode.String
-		}
 
-		users = append(users, user)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	return a.store.Users.List(userFilter{Role: userType})
 }
 
 // SearchUsers searches users by name, ID, gender, or date with complete details
-func (a *App) SearchUsers(searchTerm, userType string) ([]User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			id, username, user_type, created_at,
-			first_name, middle_name, last_name,
-			employee_number, student_number,
-			email, contact_number, department_code
-		FROM v_users_complete
-		WHERE (
-			username LIKE ? OR
-			first_name LIKE ? OR
-			last_name LIKE ? OR
-			middle_name LIKE ? OR
-			employee_number LIKE ? OR
-			student_number LIKE ? OR
-			DATE_FORMAT(created_at, '%Y-%m-%d') LIKE ?
-		)
-	`
-	searchPattern := "%" + searchTerm + "%"
-	args := []interface{}{searchPattern, searchPattern, searchPattern, searchPattern,
-		searchPattern, searchPattern, searchPattern}
-
-	if userType != "" {
-		query += ` AND user_type = ?`
-		args = append(args, userType)
-	}
-
-	query += ` ORDER BY created_at DESC`
-
-	rows, err := a.db.Query(query, args...)
-	if err != nil {
+// status filters by account state: "active", "inactive", or "" for all
+func (a *App) SearchUsers(searchTerm, userType, status string) ([]User, error) {
+	if _, err := a.authorize(PermManageUsers); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var users []User
-	for rows.Next() {
-		var user User
-		var createdAt time.Time
-		var firstName, middleName, lastName sql.NullString
-		var employeeID, studentID sql.NullString
-		var email, contactNumber, departmentCode sql.NullString
-
-		err := rows.Scan(&user.ID, &user.Name, &user.Role, &createdAt,
-			&firstName, &middleName, &lastName,
-			&employeeID, &studentID,
-			&email, &contactNumber, &departmentCode)
-		if err != nil {
-			continue
-		}
-
-		user.Created = createdAt.Format("2006-01-02 15:04:05")
-
-		if firstName.Valid {
-			user.FirstName = &firstName.String
-		}
-		if middleName.Valid {
-			user.MiddleName = &middleName.String
-		}
-		if lastName.Valid {
-			user.LastName = &lastName.String
-		}
-		if employeeID.Valid {
-			user.EmployeeID = &employeeID.String
-		}
-		if studentID.Valid {
-			user.StudentID = &studentID.String
-		}
-		if email.Valid {
-			user.Email = &email.String
-		}
-		if contactNumber.Valid {
-			user.ContactNumber = &contactNumber.String
-		}
-		if departmentCode.Valid {
-			user.DepartmentCode = &departmentCode.String
-		}
 
-		users = append(users, user)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	return a.store.Users.List(userFilter{Role: userType, Status: status, Search: searchTerm})
 }
 
 // CreateUser creates a new user
 func (a *App) CreateUser(password, name, firstName, middleName, lastName, gender, role, employeeID, studentID, year, section, email, contactNumber string, departmentCode string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	session, err := a.authorize(PermRegisterStudents)
+	if err != nil {
+		return err
+	}
+	switch role {
+	case "admin", "teacher", "student", "working_student":
+	default:
+		return fmt.Errorf("invalid user role %q", role)
+	}
+	if role != "student" && !hasPermission(session.Role, PermManageUsers) {
+		return errForbidden("only administrators can create " + role + " accounts")
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	// Log the incoming data for debugging
@@ -772,44 +446,55 @@ func (a *App) CreateUser(password, name, firstName, middleName, lastName, gender
 		}
 	}
 
-	// Insert into users table
-	query := `INSERT INTO users (username, password, user_type) VALUES (?, ?, ?)`
-	result, err := a.db.Exec(query, username, password, role)
+	hashedPassword, err := HashPassword(password)
 	if err != nil {
-		log.Printf("Failed to insert into users table: %v", err)
-		return fmt.Errorf("failed to create user account: %w", err)
+		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	userID, _ := result.LastInsertId()
-	log.Printf("Created user account with ID: %d", userID)
-
-	// Insert into respective table based on role
-	switch role {
-	case "admin":
-		query = `INSERT INTO admins (user_id, employee_number, first_name, middle_name, last_name, gender, email) VALUES (?, ?, ?, ?, ?, ?, ?)`
-		_, err = a.db.Exec(query, userID, nullString(employeeID), firstName, nullString(middleName), lastName, nullString(gender), nullString(email))
-	case "teacher":
-		query = `INSERT INTO teachers (user_id, employee_number, first_name, middle_name, last_name, email, contact_number, department_code) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-		_, err = a.db.Exec(query, userID, nullString(employeeID), firstName, nullString(middleName), lastName, nullString(email), nullString(contactNumber), nullString(departmentCode))
-	case "student":
-		query = `INSERT INTO students (user_id, student_number, first_name, middle_name, last_name, email, contact_number, is_working_student) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-		_, err = a.db.Exec(query, userID, nullString(studentID), firstName, nullString(middleName), lastName, nullString(email), nullString(contactNumber), false)
-	case "working_student":
-		query = `INSERT INTO students (user_id, student_number, first_name, middle_name, last_name, email, contact_number, is_working_student) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-		log.Printf("📝 Inserting working student - user_id: %d, student_number: %s, name: %s %s, email: %s",
-			userID, studentID, firstName, lastName, email)
-		_, err = a.db.Exec(query, userID, nullString(studentID), firstName, nullString(middleName), lastName, nullString(email), nullString(contactNumber), true)
+	account := userAccount{
+		Username:       username,
+		Password:       hashedPassword,
+		Role:           role,
+		FirstName:      firstName,
+		MiddleName:     middleName,
+		LastName:       lastName,
+		EmployeeID:     employeeID,
+		StudentID:      studentID,
+		Email:          email,
+		ContactNumber:  contactNumber,
+		DepartmentCode: departmentCode,
 	}
 
+	var userID int64
+	err = a.withTx(func(tx sqlExecutor) error {
+		userID, err = a.createUserAccount(tx, account)
+		return err
+	})
 	if err != nil {
-		log.Printf("Failed to insert into %s table: %v", role, err)
-		return fmt.Errorf("failed to create %s profile: %w", role, err)
+		return err
 	}
 
 	log.Printf("Successfully created %s: %s %s (ID: %d)", role, firstName, lastName, userID)
 	return nil
 }
 
+// createUserAccount inserts the login and role profile of a new user and
+// audits it. q must be a transaction so a failed profile leaves no login behind.
+func (a *App) createUserAccount(q sqlExecutor, account userAccount) (int64, error) {
+	userID, err := a.txStore(q).Users.Create(account)
+	if err != nil {
+		log.Printf("Failed to create %s account: %v", account.Role, err)
+		return 0, err
+	}
+	log.Printf("Created user account with ID: %d", userID)
+
+	after, err := auditSnapshot(q, userAuditQuery, userID)
+	if err != nil {
+		return 0, err
+	}
+	return userID, a.recordAudit(q, "create", "user", strconv.FormatInt(userID, 10), nil, after)
+}
+
 // BulkStudentData represents a single student entry for bulk registration
 type BulkStudentData struct {
 	StudentCode   string `json:"student_code"`
@@ -817,6 +502,7 @@ type BulkStudentData struct {
 	MiddleName    string `json:"middle_name"`
 	LastName      string `json:"last_name"`
 	Gender        string `json:"gender"`
+	Email         string `json:"email"`
 	ContactNumber string `json:"contact_number"`
 }
 
@@ -992,9 +678,14 @@ func parseDOCX(fileData []byte) (string, error) {
 // CreateUsersBulkFromFile creates multiple students from uploaded file (PDF, DOCX, CSV, TXT)
 // fileData: base64 encoded file content
 // fileName: original file name to detect file type
-func (a *App) CreateUsersBulkFromFile(fileDataBase64 string, fileName string) (map[string]interface{}, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+// mode: ImportAtomic to create all rows or none, ImportPerRow to keep the rows that succeed
+func (a *App) CreateUsersBulkFromFile(fileDataBase64 string, fileName string, mode string) (map[string]interface{}, error) {
+	if _, err := a.authorize(PermRegisterStudents); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	// Decode base64 file data
@@ -1126,9 +817,7 @@ func (a *App) CreateUsersBulkFromFile(fileDataBase64 string, fileName string) (m
 		}
 	}
 
-	var successCount int
-	var errorCount int
-	var errors []string
+	var rows []bulkStudentRow
 
 	// Helper function to get column value safely
 	getColumnValue := func(record []string, colIdx int, found bool) string {
@@ -1155,79 +844,29 @@ func (a *App) CreateUsersBulkFromFile(fileDataBase64 string, fileName string) (m
 		contactIdx, hasContact := columnMap["contact"]
 		emailIdx, hasEmail := columnMap["email"]
 
-		studentCode := getColumnValue(record, studentCodeIdx, hasStudentCode)
-		firstName := getColumnValue(record, firstNameIdx, hasFirstName)
-		lastName := getColumnValue(record, lastNameIdx, hasLastName)
-		middleName := getColumnValue(record, middleNameIdx, hasMiddleName)
-		contactNumber := getColumnValue(record, contactIdx, hasContact)
-		email := getColumnValue(record, emailIdx, hasEmail)
-
-		// Validate required fields
-		if studentCode == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: Student Code is required", rowNum))
-			continue
-		}
-		if firstName == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: First Name is required", rowNum))
-			continue
-		}
-		if lastName == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: Last Name is required", rowNum))
-			continue
-		}
-
-		// Create the student using existing CreateUser function
-		fullName := fmt.Sprintf("%s, %s", lastName, firstName)
-		if middleName != "" {
-			fullName = fmt.Sprintf("%s, %s %s", lastName, firstName, middleName)
-		}
-
-		// Use student code as password (default password)
-		err := a.CreateUser(
-			studentCode, // password
-			fullName,    // name
-			firstName,
-			middleName,
-			lastName,
-			"", // gender (removed)
-			"student",
-			"",          // employeeID
-			studentCode, // studentID
-			"",          // year level
-			"",          // section
-			email,       // email (now detected from headers)
-			contactNumber,
-			"", // departmentCode
-		)
-
-		if err != nil {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d (%s): %v", rowNum, studentCode, err))
-			log.Printf("❌ Failed to create student at row %d: %v", rowNum, err)
-		} else {
-			successCount++
-			log.Printf("✅ Created student: %s %s (Code: %s)", firstName, lastName, studentCode)
+		student := BulkStudentData{
+			StudentCode:   getColumnValue(record, studentCodeIdx, hasStudentCode),
+			FirstName:     getColumnValue(record, firstNameIdx, hasFirstName),
+			LastName:      getColumnValue(record, lastNameIdx, hasLastName),
+			MiddleName:    getColumnValue(record, middleNameIdx, hasMiddleName),
+			ContactNumber: getColumnValue(record, contactIdx, hasContact),
+			Email:         getColumnValue(record, emailIdx, hasEmail),
 		}
+		rows = append(rows, bulkStudentRow{Row: rowNum, Student: student, Problem: student.missingField()})
 	}
 
-	result := map[string]interface{}{
-		"success_count": successCount,
-		"error_count":   errorCount,
-		"total_count":   len(records) - startIndex,
-		"errors":        errors,
-	}
-
-	return result, nil
+	return a.importStudents(rows, mode)
 }
 
 // CreateUsersBulk creates multiple students from CSV data (kept for backward compatibility)
 // CSV format: Student Code, First Name, Middle Name (optional), Last Name, Contact Number (optional)
 func (a *App) CreateUsersBulk(csvData string) (map[string]interface{}, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermRegisterStudents); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	// Parse CSV data
@@ -1252,9 +891,7 @@ func (a *App) CreateUsersBulk(csvData string) (map[string]interface{}, error) {
 		}
 	}
 
-	var successCount int
-	var errorCount int
-	var errors []string
+	var rows []bulkStudentRow
 
 	// Process each record
 	for i, record := range records[startIndex:] {
@@ -1262,126 +899,215 @@ func (a *App) CreateUsersBulk(csvData string) (map[string]interface{}, error) {
 
 		// Validate minimum required fields (at least Student Code, First Name, Last Name)
 		if len(record) < 3 {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: Insufficient columns (need at least Student Code, First Name, Last Name)", rowNum))
+			rows = append(rows, bulkStudentRow{Row: rowNum, Problem: "Insufficient columns (need at least Student Code, First Name, Last Name)"})
 			continue
 		}
 
-		studentCode := strings.TrimSpace(record[0])
-		firstName := strings.TrimSpace(record[1])
-		middleName := ""
-		lastName := ""
-		contactNumber := ""
-		email := "" // Email not supported in legacy CreateUsersBulk function
-
-		// Parse based on number of columns
-		if len(record) >= 3 {
-			lastName = strings.TrimSpace(record[2])
+		// Email not supported in legacy CreateUsersBulk function
+		student := BulkStudentData{
+			StudentCode: strings.TrimSpace(record[0]),
+			FirstName:   strings.TrimSpace(record[1]),
+			LastName:    strings.TrimSpace(record[2]),
 		}
 		if len(record) >= 4 {
-			middleName = strings.TrimSpace(record[3])
+			student.MiddleName = strings.TrimSpace(record[3])
 		}
 		if len(record) >= 5 {
-			contactNumber = strings.TrimSpace(record[4])
+			student.ContactNumber = strings.TrimSpace(record[4])
 		}
+		rows = append(rows, bulkStudentRow{Row: rowNum, Student: student, Problem: student.missingField()})
+	}
 
-		// Validate required fields
-		if studentCode == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: Student Code is required", rowNum))
-			continue
-		}
-		if firstName == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: First Name is required", rowNum))
-			continue
-		}
-		if lastName == "" {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d: Last Name is required", rowNum))
-			continue
+	return a.importStudents(rows, ImportPerRow)
+}
+
+// UpdateUser updates an existing user.
+// Users without PermManageUsers editing their own account can only change their
+// email and contact number; names, ID numbers and the department stay as the admin set them.
+func (a *App) UpdateUser(id int, name, firstName, middleName, lastName, gender, role, employeeID, studentID, year, section, email, contactNumber string, departmentCode string) error {
+	session, err := a.authorizeSelfOr(id, PermManageUsers)
+	if err != nil {
+		return err
+	}
+	selfService := !hasPermission(session.Role, PermManageUsers)
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	return a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, userAuditQuery, id)
+		if err != nil {
+			return err
+		}
+
+		if selfService {
+			err = a.txStore(tx).Users.UpdateContact(id, session.Role, email, contactNumber)
+		} else {
+			account := userAccount{
+				Role:           role,
+				FirstName:      firstName,
+				MiddleName:     middleName,
+				LastName:       lastName,
+				EmployeeID:     employeeID,
+				StudentID:      studentID,
+				Email:          email,
+				ContactNumber:  contactNumber,
+				DepartmentCode: departmentCode,
+			}
+			err = a.txStore(tx).Users.UpdateProfile(id, account)
+		}
+		if err != nil {
+			return err
 		}
 
-		// Create the student using existing CreateUser function
-		fullName := fmt.Sprintf("%s, %s", lastName, firstName)
-		if middleName != "" {
-			fullName = fmt.Sprintf("%s, %s %s", lastName, firstName, middleName)
+		after, err := auditSnapshot(tx, userAuditQuery, id)
+		if err != nil {
+			return err
 		}
+		return a.recordAudit(tx, "update", "user", strconv.Itoa(id), before, after)
+	})
+}
 
-		// Use student code as password (default password)
-		err := a.CreateUser(
-			studentCode, // password
-			fullName,    // name
-			firstName,
-			middleName,
-			lastName,
-			"", // gender (removed)
-			"student",
-			"",          // employeeID
-			studentCode, // studentID
-			"",          // year level
-			"",          // section
-			email,       // email
-			contactNumber,
-			"", // departmentCode
-		)
+// DeleteUser permanently deletes a user.
+// Accounts with attendance, enrollment, feedback, class, login or audit history are refused
+// unless force is set, since deleting cascades that history away; deactivate them instead.
+// Accounts whose login logs or attendance are in the integrity chain are always refused.
+func (a *App) DeleteUser(id int, force bool) error {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	if id == session.UserID {
+		return fmt.Errorf("you cannot delete your own account")
+	}
+
+	chained, err := a.store.Users.ChainedCount(id)
+	if err != nil {
+		return fmt.Errorf("failed to check user history: %w", err)
+	}
+	if chained > 0 {
+		return fmt.Errorf("user has %d login or attendance records in the integrity chain and cannot be deleted; deactivate the account instead", chained)
+	}
 
+	if !force {
+		history, err := a.userHistoryCount(id)
 		if err != nil {
-			errorCount++
-			errors = append(errors, fmt.Sprintf("Row %d (%s): %v", rowNum, studentCode, err))
-			log.Printf("❌ Failed to create student at row %d: %v", rowNum, err)
-		} else {
-			successCount++
-			log.Printf("✅ Created student: %s %s (Code: %s)", firstName, lastName, studentCode)
+			return err
+		}
+		if history > 0 {
+			return fmt.Errorf("user has %d attendance, enrollment, feedback, class, login or audit records; deactivate the account instead or force the deletion", history)
 		}
 	}
 
-	result := map[string]interface{}{
-		"success_count": successCount,
-		"error_count":   errorCount,
-		"total_count":   len(records) - startIndex,
-		"errors":        errors,
+	err = a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, userAuditQuery, id)
+		if err != nil {
+			return err
+		}
+
+		if err := a.txStore(tx).Users.Delete(id); err != nil {
+			return err
+		}
+
+		action := "delete"
+		if force {
+			action = "force_delete"
+		}
+		return a.recordAudit(tx, action, "user", strconv.Itoa(id), before, nil)
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to delete user %d: %v", id, err)
+		return err
 	}
 
-	return result, nil
+	a.offline.forget(id)
+	log.Printf("✓ User %d deleted (forced: %t)", id, force)
+	return nil
 }
 
-// UpdateUser updates an existing user
-func (a *App) UpdateUser(id int, name, firstName, middleName, lastName, gender, role, employeeID, studentID, year, section, email, contactNumber string, departmentCode string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+// userHistoryCount counts the records that would be cascaded away by deleting a user
+func (a *App) userHistoryCount(userID int) (int, error) {
+	count, err := a.store.Users.HistoryCount(userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check user history: %w", err)
 	}
+	return count, nil
+}
 
-	// Update in respective table based on role
-	var query string
-	var err error
-	switch role {
-	case "admin":
-		query = `UPDATE admins SET first_name = ?, middle_name = ?, last_name = ?, gender = ?, employee_number = ?, email = ? WHERE user_id = ?`
-		_, err = a.db.Exec(query, firstName, nullString(middleName), lastName, nullString(gender), nullString(employeeID), nullString(email), id)
-	case "teacher":
-		query = `UPDATE teachers SET first_name = ?, middle_name = ?, last_name = ?, employee_number = ?, email = ?, contact_number = ?, department_code = ? WHERE user_id = ?`
-		_, err = a.db.Exec(query, firstName, nullString(middleName), lastName, nullString(employeeID), nullString(email), nullString(contactNumber), nullString(departmentCode), id)
-	case "student":
-		query = `UPDATE students SET first_name = ?, middle_name = ?, last_name = ?, student_number = ?, email = ?, contact_number = ? WHERE user_id = ? AND is_working_student = FALSE`
-		_, err = a.db.Exec(query, firstName, nullString(middleName), lastName, nullString(studentID), nullString(email), nullString(contactNumber), id)
-	case "working_student":
-		query = `UPDATE students SET first_name = ?, middle_name = ?, last_name = ?, student_number = ?, email = ?, contact_number = ? WHERE user_id = ? AND is_working_student = TRUE`
-		_, err = a.db.Exec(query, firstName, nullString(middleName), lastName, nullString(studentID), nullString(email), nullString(contactNumber), id)
-	}
-
-	return err
+// DeactivateUser disables an account without removing any of its history
+func (a *App) DeactivateUser(id int) error {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	if id == session.UserID {
+		return fmt.Errorf("you cannot deactivate your own account")
+	}
+
+	return a.setUserActive(id, false)
+}
+
+// ReactivateUser re-enables a deactivated account
+func (a *App) ReactivateUser(id int) error {
+	if _, err := a.authorize(PermManageUsers); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	return a.setUserActive(id, true)
 }
 
-// DeleteUser deletes a user
-func (a *App) DeleteUser(id int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+// setUserActive updates the is_active flag of a user
+func (a *App) setUserActive(id int, active bool) error {
+	if exists, err := a.store.Users.Exists(id); err != nil || !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	action := "deactivate"
+	if active {
+		action = "reactivate"
+	}
+
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, userAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Users.SetActive(id, active); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, userAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, action, "user", strconv.Itoa(id), before, after)
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to update active state for user %d: %v", id, err)
+		return err
 	}
 
-	query := `DELETE FROM users WHERE id = ?`
-	_, err := a.db.Exec(query, id)
-	return err
+	if active {
+		log.Printf("✓ User %d reactivated", id)
+	} else {
+		a.offline.forget(id)
+		log.Printf("✓ User %d deactivated", id)
+	}
+	return nil
 }
 
 // ==============================================================================
@@ -1398,36 +1124,25 @@ type AdminDashboard struct {
 
 // GetAdminDashboard returns admin dashboard statistics
 func (a *App) GetAdminDashboard() (AdminDashboard, error) {
-	var dashboard AdminDashboard
-
-	if a.db == nil {
-		return dashboard, fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermViewAdminDashboard); err != nil {
+		return AdminDashboard{}, err
 	}
 
-	// Count total students
-	err := a.db.QueryRow(`SELECT COUNT(*) FROM students`).Scan(&dashboard.TotalStudents)
-	if err != nil {
-		log.Printf("⚠ Failed to count students: %v", err)
-	}
+	var dashboard AdminDashboard
 
-	// Count total teachers
-	err = a.db.QueryRow(`SELECT COUNT(*) FROM teachers`).Scan(&dashboard.TotalTeachers)
-	if err != nil {
-		log.Printf("⚠ Failed to count teachers: %v", err)
+	if err := a.requireDB(); err != nil {
+		return dashboard, err
 	}
 
-	// Count working students
-	err = a.db.QueryRow(`SELECT COUNT(*) FROM students WHERE is_working_student = TRUE`).Scan(&dashboard.WorkingStudents)
+	counts, err := a.store.Users.CountByRole()
 	if err != nil {
-		log.Printf("⚠ Failed to count working students: %v", err)
+		log.Printf("⚠ Failed to count users: %v", err)
+	} else {
+		dashboard = counts
 	}
 
 	// Count recent logins (last 24 hours)
-	err = a.db.QueryRow(`
-		SELECT COUNT(*) 
-		FROM login_logs 
-		WHERE login_time >= DATE_SUB(NOW(), INTERVAL 24 HOUR)
-	`).Scan(&dashboard.RecentLogins)
+	dashboard.RecentLogins, err = a.store.Logs.RecentLoginCount(24)
 	if err != nil {
 		log.Printf("⚠ Failed to count recent logins: %v", err)
 	}
@@ -1451,56 +1166,41 @@ type Department struct {
 
 // GetDepartments returns all departments
 func (a *App) GetDepartments() ([]Department, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT department_code, department_name, description, is_active, created_at, updated_at
-		FROM departments
-		ORDER BY department_code
-	`
-	rows, err := a.db.Query(query)
-	if err != nil {
+	if _, err := a.authorizeAuthenticated(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var departments []Department
-	for rows.Next() {
-		var dept Department
-		var description sql.NullString
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(&dept.DepartmentCode, &dept.DepartmentName, &description, &dept.IsActive, &createdAt, &updatedAt)
-		if err != nil {
-			continue
-		}
-
-		if description.Valid {
-			dept.Description = &description.String
-		}
-		dept.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
-		dept.UpdatedAt = updatedAt.Format("2006-01-02 15:04:05")
 
-		departments = append(departments, dept)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return departments, nil
+	return a.store.Departments.List()
 }
 
 // CreateDepartment creates a new department
 func (a *App) CreateDepartment(departmentCode, departmentName, description string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermManageDepartments); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	if departmentCode == "" || departmentName == "" {
 		return fmt.Errorf("department code and name are required")
 	}
 
-	query := `INSERT INTO departments (department_code, department_name, description) VALUES (?, ?, ?)`
-	_, err := a.db.Exec(query, departmentCode, departmentName, nullString(description))
+	err := a.withTx(func(tx sqlExecutor) error {
+		if err := a.txStore(tx).Departments.Create(departmentCode, departmentName, description); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, departmentAuditQuery, departmentCode)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "create", "department", departmentCode, nil, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to create department: %v", err)
 		return err
@@ -1512,16 +1212,32 @@ func (a *App) CreateDepartment(departmentCode, departmentName, description strin
 
 // UpdateDepartment updates an existing department
 func (a *App) UpdateDepartment(oldDepartmentCode, departmentCode, departmentName, description string, isActive bool) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermManageDepartments); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	if departmentCode == "" || departmentName == "" {
 		return fmt.Errorf("department code and name are required")
 	}
 
-	query := `UPDATE departments SET department_code = ?, department_name = ?, description = ?, is_active = ? WHERE department_code = ?`
-	_, err := a.db.Exec(query, departmentCode, departmentName, nullString(description), isActive, oldDepartmentCode)
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, departmentAuditQuery, oldDepartmentCode)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Departments.Update(oldDepartmentCode, departmentCode, departmentName, description, isActive); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, departmentAuditQuery, departmentCode)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "update", "department", oldDepartmentCode, before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to update department: %v", err)
 		return err
@@ -1533,12 +1249,24 @@ func (a *App) UpdateDepartment(oldDepartmentCode, departmentCode, departmentName
 
 // DeleteDepartment deletes a department
 func (a *App) DeleteDepartment(departmentCode string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermManageDepartments); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
-	query := `DELETE FROM departments WHERE department_code = ?`
-	_, err := a.db.Exec(query, departmentCode)
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, departmentAuditQuery, departmentCode)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Departments.Delete(departmentCode); err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "delete", "department", departmentCode, before, nil)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to delete department: %v", err)
 		return err
@@ -1562,89 +1290,25 @@ type LoginLog struct {
 	PCNumber     *string `json:"pc_number"`
 	LoginTime    string  `json:"login_time"`
 	LogoutTime   *string `json:"logout_time"`
+	LoginStatus  string  `json:"login_status"`
+	// UnregisteredPC marks logins allowed from a PC not approved in the computers registry
+	UnregisteredPC bool `json:"unregistered_pc"`
 }
 
 // GetAllLogs returns all login logs with user details
 func (a *App) GetAllLogs() ([]LoginLog, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	// Query login_logs directly with joins to ensure all logs are returned
-	// even if user profile data is missing
-	query := `
-		SELECT 
-			ll.id, 
-			ll.user_id, 
-			COALESCE(u.user_type, 'unknown') as user_type, 
-			ll.pc_number, 
-			ll.login_time, 
-			ll.logout_time,
-			COALESCE(
-				CASE WHEN s.last_name IS NOT NULL AND s.first_name IS NOT NULL
-					THEN CONCAT(s.last_name, ', ', s.first_name,
-						CASE WHEN s.middle_name IS NOT NULL THEN CONCAT(' ', s.middle_name) ELSE '' END)
-					ELSE NULL END,
-				CASE WHEN t.last_name IS NOT NULL AND t.first_name IS NOT NULL
-					THEN CONCAT(t.last_name, ', ', t.first_name,
-						CASE WHEN t.middle_name IS NOT NULL THEN CONCAT(' ', t.middle_name) ELSE '' END)
-					ELSE NULL END,
-				CASE WHEN a.last_name IS NOT NULL AND a.first_name IS NOT NULL
-					THEN CONCAT(a.last_name, ', ', a.first_name,
-						CASE WHEN a.middle_name IS NOT NULL THEN CONCAT(' ', a.middle_name) ELSE '' END)
-					ELSE NULL END,
-				u.username
-			) as full_name,
-			COALESCE(
-				s.student_number,
-				t.employee_number,
-				a.employee_number,
-				u.username
-			) as user_id_number
-		FROM login_logs ll
-		JOIN users u ON ll.user_id = u.id
-		LEFT JOIN students s ON u.id = s.user_id AND u.user_type IN ('student', 'working_student')
-		LEFT JOIN teachers t ON u.id = t.user_id AND u.user_type = 'teacher'
-		LEFT JOIN admins a ON u.id = a.user_id AND u.user_type = 'admin'
-		ORDER BY ll.login_time DESC 
-		LIMIT 1000
-	`
-	rows, err := a.db.Query(query)
-	if err != nil {
-		log.Printf("Error querying login logs in GetAllLogs: %v", err)
-		return nil, fmt.Errorf("failed to query login logs: %w", err)
+	if _, err := a.authorize(PermViewAllLogs); err != nil {
+		return nil, err
 	}
-	defer rows.Close()
-
-	var logs []LoginLog
-	for rows.Next() {
-		var logEntry LoginLog
-		var pcNumber sql.NullString
-		var loginTime time.Time
-		var logoutTime sql.NullTime
-		var userIDNumber sql.NullString
-
-		err := rows.Scan(&logEntry.ID, &logEntry.UserID, &logEntry.UserType, &pcNumber, &loginTime, &logoutTime, &logEntry.UserName, &userIDNumber)
-		if err != nil {
-			log.Printf("Error scanning login log row in GetAllLogs: %v", err)
-			continue
-		}
 
-		logEntry.LoginTime = loginTime.Format("2006-01-02 15:04:05")
-		if pcNumber.Valid {
-			logEntry.PCNumber = &pcNumber.String
-		}
-		if logoutTime.Valid {
-			formattedLogoutTime := logoutTime.Time.Format("2006-01-02 15:04:05")
-			logEntry.LogoutTime = &formattedLogoutTime
-		}
-		if userIDNumber.Valid {
-			logEntry.UserIDNumber = userIDNumber.String
-		} else {
-			logEntry.UserIDNumber = logEntry.UserName // Fallback to username if ID number not found
-		}
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
 
-		logs = append(logs, logEntry)
+	logs, err := a.store.Logs.List(0, 1000)
+	if err != nil {
+		log.Printf("Error querying login logs in GetAllLogs: %v", err)
+		return nil, fmt.Errorf("failed to query login logs: %w", err)
 	}
 
 	log.Printf("GetAllLogs returning %d logs", len(logs))
@@ -1653,88 +1317,21 @@ func (a *App) GetAllLogs() ([]LoginLog, error) {
 
 // GetStudentLoginLogs returns login logs for a specific student
 func (a *App) GetStudentLoginLogs(userID int) ([]LoginLog, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if _, err := a.authorizeSelfOr(userID, PermViewRecords); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	log.Printf("GetStudentLoginLogs called for userID: %d", userID)
 
-	// Query the login_logs table directly and join with users to get the name
-	query := `
-		SELECT 
-			ll.id, 
-			ll.user_id, 
-			COALESCE(u.user_type, 'unknown') as user_type, 
-			ll.pc_number, 
-			ll.login_time, 
-			ll.logout_time,
-			COALESCE(
-				CASE WHEN s.last_name IS NOT NULL AND s.first_name IS NOT NULL
-					THEN CONCAT(s.last_name, ', ', s.first_name,
-						CASE WHEN s.middle_name IS NOT NULL THEN CONCAT(' ', s.middle_name) ELSE '' END)
-					ELSE NULL END,
-				CASE WHEN t.last_name IS NOT NULL AND t.first_name IS NOT NULL
-					THEN CONCAT(t.last_name, ', ', t.first_name,
-						CASE WHEN t.middle_name IS NOT NULL THEN CONCAT(' ', t.middle_name) ELSE '' END)
-					ELSE NULL END,
-				CASE WHEN a.last_name IS NOT NULL AND a.first_name IS NOT NULL
-					THEN CONCAT(a.last_name, ', ', a.first_name,
-						CASE WHEN a.middle_name IS NOT NULL THEN CONCAT(' ', a.middle_name) ELSE '' END)
-					ELSE NULL END,
-				u.username
-			) as full_name,
-			COALESCE(
-				s.student_number,
-				t.employee_number,
-				a.employee_number,
-				u.username
-			) as user_id_number
-		FROM login_logs ll
-		JOIN users u ON ll.user_id = u.id
-		LEFT JOIN students s ON u.id = s.user_id AND u.user_type IN ('student', 'working_student')
-		LEFT JOIN teachers t ON u.id = t.user_id AND u.user_type = 'teacher'
-		LEFT JOIN admins a ON u.id = a.user_id AND u.user_type = 'admin'
-		WHERE ll.user_id = ?
-		ORDER BY ll.login_time DESC 
-		LIMIT 100
-	`
-	rows, err := a.db.Query(query, userID)
+	logs, err := a.store.Logs.List(userID, 100)
 	if err != nil {
 		log.Printf("Error querying login logs: %v", err)
 		return nil, fmt.Errorf("failed to query login logs: %w", err)
 	}
-	defer rows.Close()
-
-	var logs []LoginLog
-	for rows.Next() {
-		var logEntry LoginLog
-		var pcNumber sql.NullString
-		var loginTime time.Time
-		var logoutTime sql.NullTime
-		var userIDNumber sql.NullString
-
-		err := rows.Scan(&logEntry.ID, &logEntry.UserID, &logEntry.UserType, &pcNumber, &loginTime, &logoutTime, &logEntry.UserName, &userIDNumber)
-		if err != nil {
-			log.Printf("Error scanning login log row: %v", err)
-			continue
-		}
-
-		logEntry.LoginTime = loginTime.Format("2006-01-02 15:04:05")
-		if pcNumber.Valid {
-			logEntry.PCNumber = &pcNumber.String
-		}
-		if logoutTime.Valid {
-			formattedLogoutTime := logoutTime.Time.Format("2006-01-02 15:04:05")
-			logEntry.LogoutTime = &formattedLogoutTime
-		}
-		if userIDNumber.Valid {
-			logEntry.UserIDNumber = userIDNumber.String
-		} else {
-			logEntry.UserIDNumber = logEntry.UserName // Fallback to username if ID number not found
-		}
-
-		logs = append(logs, logEntry)
-	}
 
 	log.Printf("GetStudentLoginLogs returning %d logs for userID: %d", len(logs), userID)
 	return logs, nil
@@ -1769,187 +1366,38 @@ type Feedback struct {
 
 // GetFeedback returns all forwarded feedback (for admins)
 func (a *App) GetFeedback() ([]Feedback, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			f.id, 
-			f.student_user_id, 
-			COALESCE(s.student_number, 'N/A') as student_id_str,
-			s.first_name, 
-			s.middle_name, 
-			s.last_name, 
-			f.pc_number, 
-			f.equipment_condition, 
-			f.monitor_condition, 
-			f.keyboard_condition, 
-			f.mouse_condition, 
-			f.comments, 
-			f.date_submitted,
-			f.status,
-			f.forwarded_by_user_id,
-			f.forwarded_at,
-			f.working_student_notes,
-			CONCAT(
-				COALESCE(s_fwd.last_name, t_fwd.last_name, a_fwd.last_name, ''), 
-				CASE WHEN COALESCE(s_fwd.last_name, t_fwd.last_name, a_fwd.last_name) IS NOT NULL THEN ', ' ELSE '' END,
-				COALESCE(s_fwd.first_name, t_fwd.first_name, a_fwd.first_name, ''),
-				CASE WHEN COALESCE(s_fwd.middle_name, t_fwd.middle_name, a_fwd.middle_name) IS NOT NULL 
-					THEN CONCAT(' ', COALESCE(s_fwd.middle_name, t_fwd.middle_name, a_fwd.middle_name)) 
-					ELSE '' END
-			) as forwarded_by_name
-		FROM feedback f
-		LEFT JOIN students s ON f.student_user_id = s.user_id
-		LEFT JOIN users u_fwd ON f.forwarded_by_user_id = u_fwd.id
-		LEFT JOIN students s_fwd ON u_fwd.id = s_fwd.user_id AND u_fwd.user_type IN ('student', 'working_student')
-		LEFT JOIN teachers t_fwd ON u_fwd.id = t_fwd.user_id AND u_fwd.user_type = 'teacher'
-		LEFT JOIN admins a_fwd ON u_fwd.id = a_fwd.user_id AND u_fwd.user_type = 'admin'
-		WHERE f.status = 'forwarded'
-		ORDER BY f.date_submitted DESC 
-		LIMIT 1000`
-	rows, err := a.db.Query(query)
-	if err != nil {
+	if _, err := a.authorize(PermReviewFeedback); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var feedbacks []Feedback
-	for rows.Next() {
-		var fb Feedback
-		var middleName, comments, studentIDStr, forwardedByName, workingStudentNotes sql.NullString
-		var dateSubmitted time.Time
-		var forwardedBy sql.NullInt64
-		var forwardedAt sql.NullTime
-
-		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
-			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
-			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted, &fb.Status,
-			&forwardedBy, &forwardedAt, &workingStudentNotes, &forwardedByName)
-		if err != nil {
-			continue
-		}
-
-		if studentIDStr.Valid {
-			fb.StudentIDStr = studentIDStr.String
-		} else {
-			fb.StudentIDStr = "N/A"
-		}
-		if middleName.Valid {
-			fb.MiddleName = &middleName.String
-		}
-		if comments.Valid {
-			fb.Comments = &comments.String
-		}
-		if forwardedBy.Valid {
-			forwardedByInt := int(forwardedBy.Int64)
-			fb.ForwardedByUserID = &forwardedByInt
-		}
-		if forwardedAt.Valid {
-			forwardedAtStr := forwardedAt.Time.Format("2006-01-02 15:04:05")
-			fb.ForwardedAt = &forwardedAtStr
-		}
-		if forwardedByName.Valid && forwardedByName.String != "" {
-			fb.ForwardedByName = &forwardedByName.String
-		}
-		if workingStudentNotes.Valid {
-			fb.WorkingStudentNotes = &workingStudentNotes.String
-		}
-
-		fb.StudentName = fmt.Sprintf("%s, %s", fb.LastName, fb.FirstName)
-		if middleName.Valid {
-			fb.StudentName += " " + middleName.String
-		}
-		fb.DateSubmitted = dateSubmitted.Format("2006-01-02 15:04:05")
 
-		feedbacks = append(feedbacks, fb)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return feedbacks, nil
+	return a.store.Feedback.Forwarded()
 }
 
 // GetStudentFeedback returns feedback history for a specific student
 func (a *App) GetStudentFeedback(studentID int) ([]Feedback, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			f.id, 
-			f.student_user_id, 
-			COALESCE(s.student_number, 'N/A') as student_id_str,
-			s.first_name,
-			s.middle_name,
-			s.last_name,
-			f.pc_number, 
-			f.equipment_condition, 
-			f.monitor_condition, 
-			f.keyboard_condition, 
-			f.mouse_condition, 
-			f.comments, 
-			f.date_submitted 
-		FROM feedback f
-		LEFT JOIN students s ON f.student_user_id = s.user_id
-		WHERE f.student_user_id = ? 
-		ORDER BY f.date_submitted DESC`
-	rows, err := a.db.Query(query, studentID)
-	if err != nil {
+	if _, err := a.authorizeSelfOr(studentID, PermViewRecords); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var feedbacks []Feedback
-	for rows.Next() {
-		var fb Feedback
-		var middleName, comments, studentIDStr sql.NullString
-		var dateSubmitted time.Time
 
-		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
-			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
-			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted)
-		if err != nil {
-			continue
-		}
-
-		if studentIDStr.Valid {
-			fb.StudentIDStr = studentIDStr.String
-		} else {
-			fb.StudentIDStr = "N/A"
-		}
-		if middleName.Valid {
-			fb.MiddleName = &middleName.String
-		}
-		if comments.Valid {
-			fb.Comments = &comments.String
-		}
-
-		fb.StudentName = fmt.Sprintf("%s, %s", fb.LastName, fb.FirstName)
-		if middleName.Valid {
-			fb.StudentName += " " + middleName.String
-		}
-		fb.DateSubmitted = dateSubmitted.Format("2006-01-02 15:04:05")
-
-		feedbacks = append(feedbacks, fb)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return feedbacks, nil
+	return a.store.Feedback.ForStudent(studentID)
 }
 
 // SaveEquipmentFeedback saves equipment feedback from a student
 func (a *App) SaveEquipmentFeedback(userID int, userName, computerStatus, computerIssue, mouseStatus, mouseIssue, keyboardStatus, keyboardIssue, monitorStatus, monitorIssue, additionalComments string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeSelf(userID); err != nil {
+		return err
 	}
 
-	// Get the hostname (PC number) of this device
-	hostname, err := os.Hostname()
-	if err != nil {
-		log.Printf("⚠ Failed to get hostname: %v", err)
-		hostname = "Unknown"
-	}
-	pcNumber := hostname
+	// PC number of this device, its registered name if it has one
+	pcNumber := a.currentComputer().pcNumber()
 
 	// Determine equipment conditions based on status
 	// ENUM values: 'Good', 'Minor Issue', 'Not Working'
@@ -1999,15 +1447,27 @@ func (a *App) SaveEquipmentFeedback(userID int, userName, computerStatus, comput
 		}
 	}
 
-	// Insert feedback into database
-	query := `INSERT INTO feedback (student_user_id, pc_number, 
-			  equipment_condition, monitor_condition, keyboard_condition, mouse_condition, 
-			  comments, date_submitted) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, NOW())`
-
-	_, err = a.db.Exec(query, userID, pcNumber,
-		equipmentCondition, monitorCondition, keyboardCondition, mouseCondition, nullString(combinedComments))
+	fb := Feedback{
+		StudentUserID:      userID,
+		PCNumber:           pcNumber,
+		EquipmentCondition: equipmentCondition,
+		MonitorCondition:   monitorCondition,
+		KeyboardCondition:  keyboardCondition,
+		MouseCondition:     mouseCondition,
+		Comments:           &combinedComments,
+	}
+	if a.workingOffline() {
+		return a.queueFeedback(fb)
+	}
+	if err := a.requireDB(); err != nil {
+		return err
+	}
 
+	// Insert feedback into database
+	err := a.store.Feedback.Create(fb)
+	if a.lostConnection(err) {
+		return a.queueFeedback(fb)
+	}
 	if err != nil {
 		log.Printf("Failed to save equipment feedback: %v", err)
 		return fmt.Errorf("failed to save feedback: %w", err)
@@ -2019,101 +1479,56 @@ func (a *App) SaveEquipmentFeedback(userID int, userName, computerStatus, comput
 
 // GetPendingFeedback returns all pending feedback for working students to review
 func (a *App) GetPendingFeedback() ([]Feedback, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			f.id, 
-			f.student_user_id, 
-			COALESCE(s.student_number, 'N/A') as student_id_str,
-			s.first_name, 
-			s.middle_name, 
-			s.last_name, 
-			f.pc_number, 
-			f.equipment_condition, 
-			f.monitor_condition, 
-			f.keyboard_condition, 
-			f.mouse_condition, 
-			f.comments, 
-			f.date_submitted,
-			f.status
-		FROM feedback f
-		LEFT JOIN students s ON f.student_user_id = s.user_id
-		WHERE f.status = 'pending'
-		ORDER BY f.date_submitted DESC 
-		LIMIT 1000`
-	rows, err := a.db.Query(query)
-	if err != nil {
+	if _, err := a.authorize(PermTriageFeedback); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var feedbacks []Feedback
-	for rows.Next() {
-		var fb Feedback
-		var middleName, comments, studentIDStr sql.NullString
-		var dateSubmitted time.Time
-
-		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
-			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
-			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted, &fb.Status)
-		if err != nil {
-			continue
-		}
-
-		if studentIDStr.Valid {
-			fb.StudentIDStr = studentIDStr.String
-		} else {
-			fb.StudentIDStr = "N/A"
-		}
-		if middleName.Valid {
-			fb.MiddleName = &middleName.String
-		}
-		if comments.Valid {
-			fb.Comments = &comments.String
-		}
-
-		fb.StudentName = fmt.Sprintf("%s, %s", fb.LastName, fb.FirstName)
-		if middleName.Valid {
-			fb.StudentName += " " + middleName.String
-		}
-		fb.DateSubmitted = dateSubmitted.Format("2006-01-02 15:04:05")
 
-		feedbacks = append(feedbacks, fb)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return feedbacks, nil
+	return a.store.Feedback.Pending()
 }
 
 // ForwardFeedbackToAdmin forwards feedback from working student to admin
 func (a *App) ForwardFeedbackToAdmin(feedbackID int, workingStudentID int, notes string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	// Update feedback to forwarded status
-	query := `UPDATE feedback 
-			  SET status = 'forwarded', 
-			      forwarded_by_user_id = ?, 
-			      forwarded_at = NOW(), 
-			      working_student_notes = ?
-			  WHERE id = ? AND status = 'pending'`
-
-	result, err := a.db.Exec(query, workingStudentID, nullString(notes), feedbackID)
+	session, err := a.authorize(PermTriageFeedback)
 	if err != nil {
-		log.Printf("⚠ Failed to forward feedback %d: %v", feedbackID, err)
-		return fmt.Errorf("failed to forward feedback: %w", err)
+		return err
+	}
+	if session.UserID != workingStudentID {
+		return errForbidden("feedback can only be forwarded under your own account")
 	}
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := a.requireDB(); err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("feedback not found or already forwarded")
+	// Update feedback to forwarded status
+	err = a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, feedbackAuditQuery, feedbackID)
+		if err != nil {
+			return err
+		}
+
+		forwarded, err := a.txStore(tx).Feedback.Forward(feedbackID, workingStudentID, notes)
+		if err != nil {
+			log.Printf("⚠ Failed to forward feedback %d: %v", feedbackID, err)
+			return fmt.Errorf("failed to forward feedback: %w", err)
+		}
+
+		if !forwarded {
+			return fmt.Errorf("feedback not found or already forwarded")
+		}
+
+		after, err := auditSnapshot(tx, feedbackAuditQuery, feedbackID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "forward", "feedback", strconv.Itoa(feedbackID), before, after)
+	})
+	if err != nil {
+		return err
 	}
 
 	log.Printf("✓ Feedback %d forwarded to admin by working student %d", feedbackID, workingStudentID)
@@ -2122,45 +1537,54 @@ func (a *App) ForwardFeedbackToAdmin(feedbackID int, workingStudentID int, notes
 
 // ForwardMultipleFeedbackToAdmin forwards multiple feedback items from working student to admin in batch
 func (a *App) ForwardMultipleFeedbackToAdmin(feedbackIDs []int, workingStudentID int, notes string) (int, error) {
-	if a.db == nil {
-		return 0, fmt.Errorf("database not connected")
+	session, err := a.authorize(PermTriageFeedback)
+	if err != nil {
+		return 0, err
 	}
-
-	if len(feedbackIDs) == 0 {
-		return 0, fmt.Errorf("no feedback IDs provided")
+	if session.UserID != workingStudentID {
+		return 0, errForbidden("feedback can only be forwarded under your own account")
 	}
 
-	// Build placeholders for the IN clause
-	placeholders := make([]string, len(feedbackIDs))
-	args := make([]interface{}, 0, len(feedbackIDs)+2)
-	
-	// Add workingStudentID and notes first (for SET clause)
-	args = append(args, workingStudentID)
-	args = append(args, nullString(notes))
-	
-	// Add feedback IDs for WHERE clause
-	for i, id := range feedbackIDs {
-		placeholders[i] = "?"
-		args = append(args, id)
-	}
-
-	// Update all feedback items to forwarded status in a single query
-	query := fmt.Sprintf(`UPDATE feedback 
-			  SET status = 'forwarded', 
-			      forwarded_by_user_id = ?, 
-			      forwarded_at = NOW(), 
-			      working_student_notes = ?
-			  WHERE id IN (%s) AND status = 'pending'`, strings.Join(placeholders, ","))
-
-	result, err := a.db.Exec(query, args...)
-	if err != nil {
-		log.Printf("⚠ Failed to forward multiple feedback: %v", err)
-		return 0, fmt.Errorf("failed to forward feedback: %w", err)
+	if err := a.requireDB(); err != nil {
+		return 0, err
+	}
+
+	if len(feedbackIDs) == 0 {
+		return 0, fmt.Errorf("no feedback IDs provided")
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	// Forward each pending item in one transaction, auditing every item that changed
+	var rowsAffected int64
+	err = a.withTx(func(tx sqlExecutor) error {
+		feedback := a.txStore(tx).Feedback
+		for _, id := range feedbackIDs {
+			before, err := auditSnapshot(tx, feedbackAuditQuery, id)
+			if err != nil {
+				return err
+			}
+
+			forwarded, err := feedback.Forward(id, workingStudentID, notes)
+			if err != nil {
+				return err
+			}
+			if !forwarded {
+				continue
+			}
+			rowsAffected++
+
+			after, err := auditSnapshot(tx, feedbackAuditQuery, id)
+			if err != nil {
+				return err
+			}
+			if err := a.recordAudit(tx, "forward", "feedback", strconv.Itoa(id), before, after); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return 0, err
+		log.Printf("⚠ Failed to forward multiple feedback: %v", err)
+		return 0, fmt.Errorf("failed to forward feedback: %w", err)
 	}
 
 	if rowsAffected == 0 {
@@ -2177,13 +1601,16 @@ func (a *App) ForwardMultipleFeedbackToAdmin(feedbackIDs []int, workingStudentID
 
 // ExportLogsCSV exports login logs to CSV
 func (a *App) ExportLogsCSV() (string, error) {
+	if _, err := a.authorize(PermViewAllLogs); err != nil {
+		return "", err
+	}
+
 	logs, err := a.GetAllLogs()
 	if err != nil {
 		return "", err
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	filename := filepath.Join(homeDir, "Downloads", fmt.Sprintf("login_logs_%s.csv", time.Now().Format("20060102_150405")))
+	filename := exportPath(fmt.Sprintf("login_logs_%s.csv", time.Now().Format("20060102_150405")))
 
 	file, err := os.Create(filename)
 	if err != nil {
@@ -2223,6 +1650,10 @@ func (a *App) ExportLogsCSV() (string, error) {
 
 // ExportLogsPDF exports login logs to PDF
 func (a *App) ExportLogsPDF() (string, error) {
+	if _, err := a.authorize(PermViewAllLogs); err != nil {
+		return "", err
+	}
+
 	logs, err := a.GetAllLogs()
 	if err != nil {
 		return "", err
@@ -2263,21 +1694,34 @@ func (a *App) ExportLogsPDF() (string, error) {
 		pdf.Ln(-1)
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	filename := filepath.Join(homeDir, "Downloads", fmt.Sprintf("login_logs_%s.pdf", time.Now().Format("20060102_150405")))
+	// Stamp the integrity chain head so the report can be checked against VerifyLogIntegrity later
+	head, err := a.chainHead()
+	if err != nil {
+		return "", err
+	}
+	pdf.Ln(6)
+	pdf.SetFont("Courier", "", 8)
+	pdf.Cell(0, 5, "Integrity chain head: "+head.String())
+	pdf.Ln(-1)
+	pdf.Cell(0, 5, "Generated: "+time.Now().Format("2006-01-02 15:04:05"))
+
+	filename := exportPath(fmt.Sprintf("login_logs_%s.pdf", time.Now().Format("20060102_150405")))
 	err = pdf.OutputFileAndClose(filename)
 	return filename, err
 }
 
 // ExportFeedbackCSV exports feedback to CSV
 func (a *App) ExportFeedbackCSV() (string, error) {
+	if _, err := a.authorize(PermReviewFeedback); err != nil {
+		return "", err
+	}
+
 	feedbacks, err := a.GetFeedback()
 	if err != nil {
 		return "", err
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	filename := filepath.Join(homeDir, "Downloads", fmt.Sprintf("feedback_%s.csv", time.Now().Format("20060102_150405")))
+	filename := exportPath(fmt.Sprintf("feedback_%s.csv", time.Now().Format("20060102_150405")))
 
 	file, err := os.Create(filename)
 	if err != nil {
@@ -2317,6 +1761,10 @@ func (a *App) ExportFeedbackCSV() (string, error) {
 
 // ExportFeedbackPDF exports feedback to PDF
 func (a *App) ExportFeedbackPDF() (string, error) {
+	if _, err := a.authorize(PermReviewFeedback); err != nil {
+		return "", err
+	}
+
 	feedbacks, err := a.GetFeedback()
 	if err != nil {
 		return "", err
@@ -2352,8 +1800,7 @@ func (a *App) ExportFeedbackPDF() (string, error) {
 		pdf.Ln(-1)
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	filename := filepath.Join(homeDir, "Downloads", fmt.Sprintf("feedback_%s.pdf", time.Now().Format("20060102_150405")))
+	filename := exportPath(fmt.Sprintf("feedback_%s.pdf", time.Now().Format("20060102_150405")))
 	err = pdf.OutputFileAndClose(filename)
 	return filename, err
 }
@@ -2380,23 +1827,24 @@ type Subject struct {
 
 // CourseClass represents a specific instance of a subject (with schedule, room, etc.)
 type CourseClass struct {
-	ClassID         int     `json:"class_id"`
-	SubjectCode     string  `json:"subject_code"`
-	SubjectName     string  `json:"subject_name"`
-	OfferingCode    *string `json:"offering_code,omitempty"`
-	TeacherUserID   int     `json:"teacher_user_id"`
-	TeacherCode     *string `json:"teacher_code,omitempty"`
-	TeacherName     string  `json:"teacher_name"`
-	Schedule        *string `json:"schedule,omitempty"`
-	Room            *string `json:"room,omitempty"`
-	YearLevel       *string `json:"year_level,omitempty"`
-	Section         *string `json:"section,omitempty"`
-	Semester        *string `json:"semester,omitempty"`
-	SchoolYear      *string `json:"school_year,omitempty"`
-	EnrolledCount   int     `json:"enrolled_count"`
-	IsActive        bool    `json:"is_active"`
-	CreatedByUserID *int    `json:"created_by_user_id,omitempty"`
-	CreatedAt       string  `json:"created_at"`
+	ClassID         int            `json:"class_id"`
+	SubjectCode     string         `json:"subject_code"`
+	SubjectName     string         `json:"subject_name"`
+	OfferingCode    *string        `json:"offering_code,omitempty"`
+	TeacherUserID   int            `json:"teacher_user_id"`
+	TeacherCode     *string        `json:"teacher_code,omitempty"`
+	TeacherName     string         `json:"teacher_name"`
+	Schedule        *string        `json:"schedule,omitempty"` // Summary of the meetings
+	Meetings        []ClassMeeting `json:"meetings"`
+	Room            *string        `json:"room,omitempty"`
+	YearLevel       *string        `json:"year_level,omitempty"`
+	Section         *string        `json:"section,omitempty"`
+	Semester        *string        `json:"semester,omitempty"`
+	SchoolYear      *string        `json:"school_year,omitempty"`
+	EnrolledCount   int            `json:"enrolled_count"`
+	IsActive        bool           `json:"is_active"`
+	CreatedByUserID *int           `json:"created_by_user_id,omitempty"`
+	CreatedAt       string         `json:"created_at"`
 }
 
 // ClasslistEntry represents a student's enrollment in a class
@@ -2446,14 +1894,23 @@ type Attendance struct {
 	Status        string  `json:"status"`
 	Remarks       *string `json:"remarks,omitempty"`
 	RecordedBy    *int    `json:"recorded_by,omitempty"`
+
+	// Minutes between time in and out within the class meeting, and whether
+	// they fall below the class's minimum stay
+	MinutesPresent *int `json:"minutes_present,omitempty"`
+	ShortStay      bool `json:"short_stay"`
 }
 
 // GetTeacherDashboard returns teacher dashboard data
 func (a *App) GetTeacherDashboard(teacherID int) (TeacherDashboard, error) {
+	if _, err := a.authorizeTeacher(teacherID); err != nil {
+		return TeacherDashboard{}, err
+	}
+
 	var dashboard TeacherDashboard
 
-	if a.db == nil {
-		return dashboard, fmt.Errorf("database not connected")
+	if err := a.requireDB(); err != nil {
+		return dashboard, err
 	}
 
 	// Get teacher's classes
@@ -2465,107 +1922,41 @@ func (a *App) GetTeacherDashboard(teacherID int) (TeacherDashboard, error) {
 	dashboard.Classes = classes
 
 	// Get today's attendance for all teacher's classes
-	query := `
-		SELECT 
-			a.class_id, a.student_user_id, a.date, a.time_in, a.time_out, a.status, a.remarks,
-			vcl.class_id, vcl.student_user_id, vcl.student_number, 
-			vcl.first_name, vcl.middle_name, vcl.last_name,
-			vc.subject_code, vc.subject_name
-		FROM attendance a
-		JOIN v_classlist_complete vcl ON a.class_id = vcl.class_id AND a.student_user_id = vcl.student_user_id
-		JOIN v_classes_complete vc ON a.class_id = vc.class_id
-		WHERE vc.teacher_user_id = ? AND a.date = CURDATE()
-		ORDER BY a.time_in DESC
-		LIMIT 100
-	`
-	rows, err := a.db.Query(query, teacherID)
+	attendance, err := a.store.Attendance.TodayForTeacher(teacherID)
+	if err == nil {
+		attendance, err = a.withoutCancelledDays(attendance)
+	}
 	if err != nil {
 		log.Printf("⚠ Failed to query attendance: %v", err)
 		// Return dashboard with just classes
 		return dashboard, nil
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var att Attendance
-		var timeIn, timeOut, remarks, middleName sql.NullString
-		err := rows.Scan(
-			&att.ClassID, &att.StudentUserID, &att.Date, &timeIn, &timeOut, &att.Status, &remarks,
-			&att.ClassID, &att.StudentUserID, &att.StudentCode,
-			&att.FirstName, &middleName, &att.LastName,
-			&att.SubjectCode, &att.SubjectName,
-		)
-		if err != nil {
-			continue
-		}
-
-		if timeIn.Valid {
-			att.TimeIn = &timeIn.String
-		}
-		if timeOut.Valid {
-			att.TimeOut = &timeOut.String
-		}
-		if remarks.Valid {
-			att.Remarks = &remarks.String
-		}
-		if middleName.Valid {
-			att.MiddleName = &middleName.String
-		}
-
-		dashboard.Attendance = append(dashboard.Attendance, att)
-	}
+	dashboard.Attendance = attendance
 
 	return dashboard, nil
 }
 
 // GetSubjects returns all subjects
 func (a *App) GetSubjects() ([]Subject, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			s.subject_code, s.subject_name, s.teacher_user_id, s.created_at,
-			CONCAT(t.last_name, ', ', t.first_name, 
-			       CASE WHEN t.middle_name IS NOT NULL THEN CONCAT(' ', t.middle_name) ELSE '' END) AS teacher_name,
-			s.description
-		FROM subjects s
-		JOIN teachers t ON s.teacher_user_id = t.user_id
-		ORDER BY s.subject_code
-	`
-	rows, err := a.db.Query(query)
-	if err != nil {
+	if _, err := a.authorize(PermManageClasses); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var subjects []Subject
-	for rows.Next() {
-		var subj Subject
-		var teacherName, description sql.NullString
-		var createdAt time.Time
-		err := rows.Scan(&subj.Code, &subj.Name, &subj.TeacherUserID, &createdAt, &teacherName, &description)
-		if err != nil {
-			continue
-		}
-		subj.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
-		if teacherName.Valid {
-			subj.TeacherName = &teacherName.String
-		}
-		if description.Valid {
-			subj.Description = &description.String
-		}
-		subjects = append(subjects, subj)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return subjects, nil
+	return a.store.Classes.Subjects()
 }
 
 // GetTeacherClassesByUserID returns all classes for a teacher given their user ID
 func (a *App) GetTeacherClassesByUserID(userID int) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if _, err := a.authorizeTeacher(userID); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	// First get the teacher ID from the user ID
@@ -2580,83 +1971,25 @@ func (a *App) GetTeacherClassesByUserID(userID int) ([]CourseClass, error) {
 
 // GetTeacherClasses returns all classes for a specific teacher
 func (a *App) GetTeacherClasses(teacherID int) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name, c.offering_code,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id
-		FROM classes c
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE c.teacher_user_id = ? AND c.is_active = TRUE 
-		ORDER BY s.subject_code, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query, teacherID)
-	if err != nil {
+	if _, err := a.authorizeTeacher(teacherID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdBy sql.NullInt64
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdBy,
-		)
-		if err != nil {
-			continue
-		}
-		if offeringCode.Valid {
-			class.OfferingCode = &offeringCode.String
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdBy.Valid {
-			createdByInt := int(createdBy.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		classes = append(classes, class)
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return classes, nil
+	return a.store.Classes.ByTeacher(teacherID)
 }
 
 // GetTeacherClassesCreatedByWorkingStudents returns classes assigned to a teacher that were created by working students
 func (a *App) GetTeacherClassesCreatedByWorkingStudents(teacherUserID int) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if _, err := a.authorizeTeacher(teacherUserID); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	// First get the teacher ID from the user ID
@@ -2665,153 +1998,26 @@ func (a *App) GetTeacherClassesCreatedByWorkingStudents(teacherUserID int) ([]Co
 		return nil, fmt.Errorf("teacher not found for user ID %d: %v", teacherUserID, err)
 	}
 
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name, c.offering_code,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id, c.created_at
-		FROM classes c
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			WHERE status = 'active'
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE c.teacher_user_id = ? AND c.is_active = TRUE AND c.created_by_user_id IS NOT NULL
-		ORDER BY c.created_at DESC, s.subject_code, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query, teacherID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdBy sql.NullInt64
-		var createdAt time.Time
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdBy, &createdAt,
-		)
-		if err != nil {
-			continue
-		}
-		if offeringCode.Valid {
-			class.OfferingCode = &offeringCode.String
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdBy.Valid {
-			createdByInt := int(createdBy.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		class.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
-		classes = append(classes, class)
-	}
-
-	return classes, nil
+	return a.store.Classes.CreatedForTeacher(teacherID)
 }
 
 // GetAllClasses returns all active classes
 func (a *App) GetAllClasses() ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermManageClasses); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
 	log.Printf("🔍 GetAllClasses: Starting query...")
 
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name, c.offering_code,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id
-		FROM classes c
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE c.is_active = TRUE 
-		ORDER BY s.subject_code, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query)
+	classes, err := a.store.Classes.Active()
 	if err != nil {
 		log.Printf("❌ GetAllClasses: Query failed: %v", err)
 		return nil, err
 	}
-	defer rows.Close()
-
-	log.Printf("🔍 GetAllClasses: Query executed successfully, processing rows...")
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdBy sql.NullInt64
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdBy,
-		)
-		if err != nil {
-			continue
-		}
-		if offeringCode.Valid {
-			class.OfferingCode = &offeringCode.String
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdBy.Valid {
-			createdByInt := int(createdBy.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		classes = append(classes, class)
-	}
 
 	log.Printf("✅ GetAllClasses: Successfully retrieved %d classes", len(classes))
 	return classes, nil
@@ -2819,15 +2025,16 @@ func (a *App) GetAllClasses() ([]CourseClass, error) {
 
 // GetWorkingStudentID returns the working student user_id for a given user ID
 func (a *App) GetWorkingStudentID(userID int) (int, error) {
-	if a.db == nil {
-		return 0, fmt.Errorf("database not connected")
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return 0, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return 0, err
 	}
 
 	// Verify the user is actually a working student (students table with is_working_student = TRUE)
-	var exists int
-	query := `SELECT 1 FROM students WHERE user_id = ? AND is_working_student = TRUE`
-	err := a.db.QueryRow(query, userID).Scan(&exists)
-	if err != nil {
+	if err := a.store.Users.HasRole(userID, "working_student"); err != nil {
 		return 0, err
 	}
 	return userID, nil
@@ -2835,16 +2042,17 @@ func (a *App) GetWorkingStudentID(userID int) (int, error) {
 
 // GetTeacherID returns the teacher user_id for a given user ID (now just returns the user_id since there's no separate id)
 func (a *App) GetTeacherID(userID int) (int, error) {
-	if a.db == nil {
-		return 0, fmt.Errorf("database not connected")
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return 0, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return 0, err
 	}
 
 	// Since teachers table now uses user_id as PK, we just return the user_id
 	// But first verify the user is actually a teacher
-	var exists int
-	query := `SELECT 1 FROM teachers WHERE user_id = ?`
-	err := a.db.QueryRow(query, userID).Scan(&exists)
-	if err != nil {
+	if err := a.store.Users.HasRole(userID, "teacher"); err != nil {
 		return 0, err
 	}
 	return userID, nil
@@ -2852,156 +2060,61 @@ func (a *App) GetTeacherID(userID int) (int, error) {
 
 // GetClassesByCreator returns classes created by a specific working student
 func (a *App) GetClassesByCreator(createdBy int) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id
-		FROM classes c
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE c.is_active = TRUE AND c.created_by_user_id = ?
-		ORDER BY s.subject_code, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query, createdBy)
-	if err != nil {
+	if _, err := a.authorizeSelfOr(createdBy, PermManageClasses); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdByField sql.NullInt64
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdByField,
-		)
-		if err != nil {
-			continue
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdByField.Valid {
-			createdByInt := int(createdByField.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		classes = append(classes, class)
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return classes, nil
+	return a.store.Classes.ByCreator(createdBy)
 }
 
 // GetClassStudents returns students enrolled in a specific class
 func (a *App) GetClassStudents(classID int) ([]ClasslistEntry, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			vcl.class_id, vcl.student_user_id, vcl.student_number,
-			vcl.first_name, vcl.middle_name, vcl.last_name,
-			vcl.enrollment_status,
-			cl.enrollment_date,
-			s.email,
-			s.contact_number,
-			sub.subject_name as course
-		FROM v_classlist_complete vcl
-		JOIN classlist cl ON vcl.class_id = cl.class_id AND vcl.student_user_id = cl.student_user_id
-		JOIN classes c ON cl.class_id = c.class_id
-		JOIN subjects sub ON c.subject_code = sub.subject_code
-		LEFT JOIN students s ON vcl.student_user_id = s.user_id
-		WHERE vcl.class_id = ? AND vcl.enrollment_status = 'active'
-		ORDER BY vcl.last_name, vcl.first_name
-	`
-
-	rows, err := a.db.Query(query, classID)
-	if err != nil {
+	if _, err := a.authorizeClass(classID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var students []ClasslistEntry
-	for rows.Next() {
-		var student ClasslistEntry
-		var middleName, email, contactNumber, course sql.NullString
-		var enrollmentDate time.Time
-		err := rows.Scan(
-			&student.ClassID, &student.StudentUserID, &student.StudentCode,
-			&student.FirstName, &middleName, &student.LastName,
-			&student.Status,
-			&enrollmentDate, &email, &contactNumber, &course,
-		)
-		if err != nil {
-			continue
-		}
-		if middleName.Valid {
-			student.MiddleName = &middleName.String
-		}
-		if email.Valid {
-			student.Email = &email.String
-		}
-		if contactNumber.Valid {
-			student.ContactNumber = &contactNumber.String
-		}
-		if course.Valid {
-			student.Course = &course.String
-		}
-		student.EnrollmentDate = enrollmentDate.Format("2006-01-02")
-		students = append(students, student)
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return students, nil
+	return a.store.Classes.Students(classID)
 }
 
 // CreateSubject creates a new subject (or updates if exists)
 // Note: Teacher assignment is now handled at the class level, not subject level
 func (a *App) CreateSubject(code, name string, teacherUserID int, description string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorize(PermManageClasses); err != nil {
+		return err
 	}
 
-	// Use INSERT ... ON DUPLICATE KEY UPDATE to handle existing subjects gracefully
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	// Upsert to handle existing subjects gracefully
 	// Note: teacher_user_id removed from subjects table - teacher assignment is at class level
-	query := `
-		INSERT INTO subjects (subject_code, subject_name, description) 
-		VALUES (?, ?, ?)
-		ON DUPLICATE KEY UPDATE 
-			subject_name = VALUES(subject_name),
-			description = VALUES(description)
-	`
-	_, err := a.db.Exec(query, code, name, nullString(description))
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, subjectAuditQuery, code)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.SaveSubject(code, name, description); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, subjectAuditQuery, code)
+		if err != nil {
+			return err
+		}
+		action := "create"
+		if before != nil {
+			action = "update"
+		}
+		return a.recordAudit(tx, action, "subject", code, before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to create/update subject: %v", err)
 		return err
@@ -3010,40 +2123,63 @@ func (a *App) CreateSubject(code, name string, teacherUserID int, description st
 	return nil
 }
 
-// CreateClass creates a new class instance (by working student)
-func (a *App) CreateClass(subjectCode string, teacherUserID int, offeringCode, schedule, room, yearLevel, section, semester, schoolYear string, createdBy int) (int, error) {
-	if a.db == nil {
-		return 0, fmt.Errorf("database not connected")
+// CreateClass creates a new class instance meeting at the given weekly times,
+// recorded as created by the signed-in user. Teachers can only create their own classes.
+func (a *App) CreateClass(subjectCode string, teacherUserID int, offeringCode string, meetings []ClassMeeting, room, yearLevel, section, semester, schoolYear string) (int, error) {
+	session, err := a.authorize(PermManageClasses)
+	if err != nil {
+		return 0, err
 	}
 
-	query := `
-		INSERT INTO classes (subject_code, teacher_user_id, offering_code, schedule, room, year_level, section, semester, school_year, created_by_user_id, is_active)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, TRUE)
-	`
-	// Handle created_by_user_id
-	var createdByValue interface{}
-	if createdBy == 0 {
-		createdByValue = nil
-	} else {
-		createdByValue = createdBy
-	}
-
-	result, err := a.db.Exec(
-		query,
-		subjectCode, teacherUserID,
-		nullString(offeringCode),
-		nullString(schedule), nullString(room),
-		nullString(yearLevel), nullString(section),
-		nullString(semester), nullString(schoolYear),
-		createdByValue,
-	)
+	if err := a.requireDB(); err != nil {
+		return 0, err
+	}
+
+	if session.Role == "teacher" && teacherUserID != session.UserID {
+		log.Printf("⚠ Denied class for teacher %d created by teacher %d", teacherUserID, session.UserID)
+		return 0, errForbidden("you can only create classes for yourself")
+	}
+	if err := a.store.Users.HasRole(teacherUserID, "teacher"); err != nil {
+		return 0, fmt.Errorf("teacher not found for user ID %d: %v", teacherUserID, err)
+	}
+
+	meetings, err = normalizeMeetings(meetings)
 	if err != nil {
-		log.Printf("⚠ Failed to create class: %v", err)
 		return 0, err
 	}
 
-	classID, err := result.LastInsertId()
+	class := classFields{
+		SubjectCode:   subjectCode,
+		TeacherUserID: teacherUserID,
+		OfferingCode:  offeringCode,
+		Schedule:      formatMeetings(meetings),
+		Room:          room,
+		YearLevel:     yearLevel,
+		Section:       section,
+		Semester:      semester,
+		SchoolYear:    schoolYear,
+		CreatedBy:     session.UserID,
+	}
+
+	var classID int64
+	err = a.withTx(func(tx sqlExecutor) error {
+		id, err := a.txStore(tx).Classes.Create(class)
+		if err != nil {
+			return err
+		}
+		classID = id
+		if err := a.txStore(tx).Classes.SetMeetings(int(classID), meetings); err != nil {
+			return err
+		}
+
+		after, err := auditSnapshot(tx, classAuditQuery, classID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "create", "class", strconv.FormatInt(classID, 10), nil, after)
+	})
 	if err != nil {
+		log.Printf("⚠ Failed to create class: %v", err)
 		return 0, err
 	}
 
@@ -3051,24 +2187,47 @@ func (a *App) CreateClass(subjectCode string, teacherUserID int, offeringCode, s
 	return int(classID), nil
 }
 
-// UpdateClass updates a class
-func (a *App) UpdateClass(classID int, schedule, room, yearLevel, section, semester, schoolYear string, isActive bool) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := `
-		UPDATE classes 
-		SET schedule = ?, room = ?, year_level = ?, section = ?, semester = ?, school_year = ?, is_active = ?
-		WHERE class_id = ?
-	`
-	_, err := a.db.Exec(
-		query,
-		nullString(schedule), nullString(room),
-		nullString(yearLevel), nullString(section),
-		nullString(semester), nullString(schoolYear),
-		isActive, classID,
-	)
+// UpdateClass updates a class and replaces its weekly meetings
+func (a *App) UpdateClass(classID int, meetings []ClassMeeting, room, yearLevel, section, semester, schoolYear string, isActive bool) error {
+	if _, err := a.authorizeClass(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	meetings, err := normalizeMeetings(meetings)
+	if err != nil {
+		return err
+	}
+
+	class := classFields{
+		Schedule:   formatMeetings(meetings),
+		Room:       room,
+		YearLevel:  yearLevel,
+		Section:    section,
+		Semester:   semester,
+		SchoolYear: schoolYear,
+		IsActive:   isActive,
+	}
+	err = a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, classAuditQuery, classID)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.Update(classID, class); err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.SetMeetings(classID, meetings); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, classAuditQuery, classID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "update", "class", strconv.Itoa(classID), before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to update class: %v", err)
 		return err
@@ -3080,12 +2239,28 @@ func (a *App) UpdateClass(classID int, schedule, room, yearLevel, section, semes
 
 // DeleteClass soft-deletes a class by setting is_active to false
 func (a *App) DeleteClass(classID int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClass(classID); err != nil {
+		return err
 	}
 
-	query := `UPDATE classes SET is_active = FALSE WHERE class_id = ?`
-	_, err := a.db.Exec(query, classID)
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, classAuditQuery, classID)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.Deactivate(classID); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, classAuditQuery, classID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "delete", "class", strconv.Itoa(classID), before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to delete class: %v", err)
 		return err
@@ -3097,16 +2272,22 @@ func (a *App) DeleteClass(classID int) error {
 
 // EnrollStudentInClass enrolls a student in a specific class
 func (a *App) EnrollStudentInClass(studentID int, classID int, enrolledBy int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClass(classID); err != nil {
+		return err
 	}
 
-	query := `
-		INSERT INTO classlist (class_id, student_user_id, status)
-		VALUES (?, ?, 'active')
-		ON DUPLICATE KEY UPDATE status = 'active', updated_at = CURRENT_TIMESTAMP
-	`
-	_, err := a.db.Exec(query, classID, studentID)
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	return a.enrollStudentInClass(studentID, classID)
+}
+
+// enrollStudentInClass inserts or reactivates a classlist entry without an authorization check
+func (a *App) enrollStudentInClass(studentID int, classID int) error {
+	err := a.withTx(func(tx sqlExecutor) error {
+		return a.enrollStudentTx(tx, studentID, classID)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to enroll student %d in class %d: %v", studentID, classID, err)
 		return err
@@ -3116,37 +2297,44 @@ func (a *App) EnrollStudentInClass(studentID int, classID int, enrolledBy int) e
 	return nil
 }
 
-// EnrollMultipleStudents enrolls multiple students in a class at once
-func (a *App) EnrollMultipleStudents(studentIDs []int, classID int, enrolledBy int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+// enrollStudentTx enrolls a student inside an existing transaction and audits the change
+func (a *App) enrollStudentTx(tx sqlExecutor, studentID int, classID int) error {
+	before, err := auditSnapshot(tx, enrollmentAuditQuery, classID, studentID)
+	if err != nil {
+		return err
 	}
 
-	tx, err := a.db.Begin()
-	if err != nil {
+	if err := a.txStore(tx).Classes.Enroll(classID, studentID); err != nil {
 		return err
 	}
-	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`
-		INSERT INTO classlist (class_id, student_user_id, status)
-		VALUES (?, ?, 'active')
-		ON DUPLICATE KEY UPDATE status = 'active', updated_at = CURRENT_TIMESTAMP
-	`)
+	after, err := auditSnapshot(tx, enrollmentAuditQuery, classID, studentID)
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	return a.recordAudit(tx, "enroll", "enrollment", fmt.Sprintf("%d/%d", classID, studentID), before, after)
+}
 
-	for _, studentID := range studentIDs {
-		_, err = stmt.Exec(classID, studentID)
-		if err != nil {
-			log.Printf("⚠ Failed to enroll student %d: %v", studentID, err)
-			return err
-		}
+// EnrollMultipleStudents enrolls multiple students in a class at once
+func (a *App) EnrollMultipleStudents(studentIDs []int, classID int, enrolledBy int) error {
+	if _, err := a.authorizeClass(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
-	if err = tx.Commit(); err != nil {
+	err := a.withTx(func(tx sqlExecutor) error {
+		for _, studentID := range studentIDs {
+			if err := a.enrollStudentTx(tx, studentID, classID); err != nil {
+				log.Printf("⚠ Failed to enroll student %d: %v", studentID, err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
@@ -3156,16 +2344,33 @@ func (a *App) EnrollMultipleStudents(studentIDs []int, classID int, enrolledBy i
 
 // UnenrollStudentFromClass removes a student from a class
 func (a *App) UnenrollStudentFromClass(classlistID int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClass(classlistID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	// classlistID is now a composite key, so we need class_id and student_user_id
 	// For now, we'll need to update the function signature or parse the ID
 	// Since we can't easily get both from a single ID, let's update to use composite key
 	// This function signature needs to change - for now, assuming classlistID represents class_id
-	query := `UPDATE classlist SET status = 'dropped' WHERE class_id = ?`
-	_, err := a.db.Exec(query, classlistID)
+	err := a.withTx(func(tx sqlExecutor) error {
+		snapshotQuery := `SELECT class_id, student_user_id, status FROM classlist WHERE class_id = ? ORDER BY student_user_id`
+		before, err := auditSnapshot(tx, snapshotQuery, classlistID)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.DropAll(classlistID); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, snapshotQuery, classlistID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "unenroll", "enrollment", strconv.Itoa(classlistID)+"/", before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to unenroll student (class_id=%d): %v", classlistID, err)
 		return err
@@ -3177,12 +2382,28 @@ func (a *App) UnenrollStudentFromClass(classlistID int) error {
 
 // UnenrollStudentFromClassByIDs removes a student from a specific class by student_id and class_id
 func (a *App) UnenrollStudentFromClassByIDs(studentID int, classID int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClass(classID); err != nil {
+		return err
 	}
 
-	query := `UPDATE classlist SET status = 'dropped' WHERE student_user_id = ? AND class_id = ?`
-	_, err := a.db.Exec(query, studentID, classID)
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	err := a.withTx(func(tx sqlExecutor) error {
+		before, err := auditSnapshot(tx, enrollmentAuditQuery, classID, studentID)
+		if err != nil {
+			return err
+		}
+		if err := a.txStore(tx).Classes.Drop(classID, studentID); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, enrollmentAuditQuery, classID, studentID)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "unenroll", "enrollment", fmt.Sprintf("%d/%d", classID, studentID), before, after)
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to unenroll student %d from class %d: %v", studentID, classID, err)
 		return err
@@ -3194,251 +2415,65 @@ func (a *App) UnenrollStudentFromClassByIDs(studentID int, classID int) error {
 
 // GetAvailableStudents returns students not enrolled in a specific class
 func (a *App) GetAvailableStudents(classID int) ([]ClassStudent, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			s.user_id as id, s.student_number, s.first_name, s.middle_name, s.last_name,
-			EXISTS(
-				SELECT 1 FROM classlist cl 
-				WHERE cl.student_user_id = s.user_id AND cl.class_id = ? AND cl.status = 'active'
-			) as is_enrolled
-		FROM students s
-		WHERE NOT EXISTS (
-			SELECT 1 FROM classlist cl 
-			WHERE cl.student_user_id = s.user_id AND cl.class_id = ? AND cl.status = 'active'
-		)
-		ORDER BY last_name, first_name
-	`
-
-	rows, err := a.db.Query(query, classID, classID)
-	if err != nil {
+	if _, err := a.authorizeClass(classID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var students []ClassStudent
-	for rows.Next() {
-		var student ClassStudent
-		var middleName sql.NullString
-		err := rows.Scan(&student.ID, &student.StudentID, &student.FirstName, &middleName, &student.LastName, &student.IsEnrolled)
-		if err != nil {
-			continue
-		}
-		if middleName.Valid {
-			student.MiddleName = &middleName.String
-		}
-		students = append(students, student)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return students, nil
+	return a.store.Classes.Candidates(classID, true)
 }
 
 // GetAllStudentsForEnrollment returns all students with their enrollment status for a specific class
 func (a *App) GetAllStudentsForEnrollment(classID int) ([]ClassStudent, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			s.user_id as id, s.student_number, s.first_name, s.middle_name, s.last_name,
-			EXISTS(
-				SELECT 1 FROM classlist cl 
-				WHERE cl.student_user_id = s.user_id AND cl.class_id = ? AND cl.status = 'active'
-			) as is_enrolled
-		FROM students s
-		ORDER BY last_name, first_name
-	`
-
-	rows, err := a.db.Query(query, classID, classID)
-	if err != nil {
+	if _, err := a.authorizeClass(classID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var students []ClassStudent
-	for rows.Next() {
-		var student ClassStudent
-		var middleName sql.NullString
-		err := rows.Scan(&student.ID, &student.StudentID, &student.FirstName, &middleName,
-			&student.LastName, &student.IsEnrolled)
-		if err != nil {
-			continue
-		}
-		if middleName.Valid {
-			student.MiddleName = &middleName.String
-		}
-		students = append(students, student)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return students, nil
+	return a.store.Classes.Candidates(classID, false)
 }
 
 // GetClassesBySubjectCode returns all active classes for a given subject code
 func (a *App) GetClassesBySubjectCode(subjectCode string) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name, c.offering_code,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id, c.created_at
-		FROM classes c
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			WHERE status = 'active'
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE c.subject_code = ? AND c.is_active = TRUE
-		ORDER BY c.created_at DESC, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query, subjectCode)
-	if err != nil {
+	if _, err := a.authorizeAuthenticated(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdBy sql.NullInt64
-		var createdAt time.Time
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdBy, &createdAt,
-		)
-		if err != nil {
-			continue
-		}
-		if offeringCode.Valid {
-			class.OfferingCode = &offeringCode.String
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdBy.Valid {
-			createdByInt := int(createdBy.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		class.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
-		classes = append(classes, class)
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return classes, nil
+	return a.store.Classes.BySubjectCode(subjectCode)
 }
 
 // GetStudentClasses returns all classes a student is enrolled in
 func (a *App) GetStudentClasses(studentUserID int) ([]CourseClass, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			c.class_id, c.subject_code, s.subject_name, c.offering_code,
-			c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
-			c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
-			COALESCE(enrollment_count.count, 0) as enrolled_count,
-			c.is_active, c.created_by_user_id, c.created_at
-		FROM classlist cl
-		JOIN classes c ON cl.class_id = c.class_id
-		LEFT JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
-		LEFT JOIN (
-			SELECT class_id, COUNT(*) as count 
-			FROM classlist 
-			WHERE status = 'active'
-			GROUP BY class_id
-		) enrollment_count ON c.class_id = enrollment_count.class_id
-		WHERE cl.student_user_id = ? AND cl.status = 'active' AND c.is_active = TRUE
-		ORDER BY s.subject_code, c.year_level, c.section
-	`
-	rows, err := a.db.Query(query, studentUserID)
-	if err != nil {
+	if _, err := a.authorizeSelfOr(studentUserID, PermViewRecords); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var classes []CourseClass
-	for rows.Next() {
-		var class CourseClass
-		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
-		var createdBy sql.NullInt64
-		var createdAt time.Time
-		err := rows.Scan(
-			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
-			&class.TeacherUserID, &class.TeacherName,
-			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
-			&class.EnrolledCount, &class.IsActive, &createdBy, &createdAt,
-		)
-		if err != nil {
-			continue
-		}
-		if offeringCode.Valid {
-			class.OfferingCode = &offeringCode.String
-		}
-		if schedule.Valid {
-			class.Schedule = &schedule.String
-		}
-		if room.Valid {
-			class.Room = &room.String
-		}
-		if yearLevel.Valid {
-			class.YearLevel = &yearLevel.String
-		}
-		if section.Valid {
-			class.Section = &section.String
-		}
-		if semester.Valid {
-			class.Semester = &semester.String
-		}
-		if schoolYear.Valid {
-			class.SchoolYear = &schoolYear.String
-		}
-		if createdBy.Valid {
-			createdByInt := int(createdBy.Int64)
-			class.CreatedByUserID = &createdByInt
-		}
-		class.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
-		classes = append(classes, class)
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return classes, nil
+	return a.store.Classes.ForStudent(studentUserID)
 }
 
 // JoinClassBySubjectCode enrolls a student in a class by subject code
 // If multiple classes exist for the subject code, it enrolls in the first active one
 func (a *App) JoinClassBySubjectCode(studentUserID int, subjectCode string) (int, error) {
-	if a.db == nil {
-		return 0, fmt.Errorf("database not connected")
+	if _, err := a.authorizeSelf(studentUserID); err != nil {
+		return 0, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return 0, err
 	}
 
 	// First, find active classes with this subject code
@@ -3453,10 +2488,7 @@ func (a *App) JoinClassBySubjectCode(studentUserID int, subjectCode string) (int
 
 	// Check if student is already enrolled in any of these classes
 	for _, class := range classes {
-		var exists int
-		checkQuery := `SELECT 1 FROM classlist WHERE class_id = ? AND student_user_id = ? AND status = 'active'`
-		err := a.db.QueryRow(checkQuery, class.ClassID, studentUserID).Scan(&exists)
-		if err == nil {
+		if enrolled, _ := a.store.Classes.IsEnrolled(class.ClassID, studentUserID); enrolled {
 			// Already enrolled
 			return class.ClassID, nil
 		}
@@ -3464,7 +2496,7 @@ func (a *App) JoinClassBySubjectCode(studentUserID int, subjectCode string) (int
 
 	// Enroll in the first available class
 	classID := classes[0].ClassID
-	err = a.EnrollStudentInClass(studentUserID, classID, studentUserID)
+	err = a.enrollStudentInClass(studentUserID, classID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to enroll student: %v", err)
 	}
@@ -3474,129 +2506,79 @@ func (a *App) JoinClassBySubjectCode(studentUserID int, subjectCode string) (int
 
 // GetAllTeachers returns all teachers for assignment purposes
 func (a *App) GetAllTeachers() ([]User, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			t.user_id, t.user_id as id, t.employee_number, t.first_name, t.middle_name, t.last_name
-		FROM teachers t
-		ORDER BY t.last_name, t.first_name
-	`
-
-	rows, err := a.db.Query(query)
-	if err != nil {
+	if _, err := a.authorize(PermManageClasses); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var teachers []User
-	for rows.Next() {
-		var teacher User
-		var middleName, employeeID sql.NullString
-		var teachersTableID int
-		err := rows.Scan(&teacher.ID, &teachersTableID, &employeeID, &teacher.FirstName, &middleName, &teacher.LastName)
-		if err != nil {
-			continue
-		}
-		if middleName.Valid {
-			teacher.MiddleName = &middleName.String
-		}
-		if employeeID.Valid {
-			teacher.EmployeeID = &employeeID.String
-		}
-		teacher.Role = "teacher"
-		teachers = append(teachers, teacher)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return teachers, nil
+	return a.store.Users.Teachers()
 }
 
 // GetAllRegisteredStudents returns all registered students with optional year level filter
 func (a *App) GetAllRegisteredStudents(yearLevelFilter, sectionFilter string) ([]ClassStudent, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	// Base query that gets all students
-	query := `
-		SELECT 
-			s.user_id as id, s.student_number, s.first_name, s.middle_name, s.last_name, 
-			s.email, s.contact_number, s.profile_photo
-		FROM students s
-		ORDER BY s.last_name, s.first_name
-	`
-
-	rows, err := a.db.Query(query)
-
-	if err != nil {
+	if _, err := a.authorize(PermViewRecords); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var students []ClassStudent
-	for rows.Next() {
-		var student ClassStudent
-		var middleName, email, contactNumber, profilePhoto sql.NullString
-		err := rows.Scan(&student.ID, &student.StudentID, &student.FirstName, &middleName,
-			&student.LastName, &email, &contactNumber, &profilePhoto)
-		if err != nil {
-			continue
-		}
-		if middleName.Valid {
-			student.MiddleName = &middleName.String
-		}
-		if email.Valid {
-			student.Email = &email.String
-		}
-		if contactNumber.Valid {
-			student.ContactNumber = &contactNumber.String
-		}
-		if profilePhoto.Valid {
-			student.ProfilePhoto = &profilePhoto.String
-		}
-		students = append(students, student)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
-	return students, nil
+	return a.store.Classes.RegisteredStudents()
 }
 
 // GetAvailableSections returns all unique sections from students and working_students tables
 // Note: section column no longer exists in the schema, returning empty array
 func (a *App) GetAvailableSections() ([]string, error) {
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return nil, err
+	}
+
 	return []string{}, nil
 }
 
 // RecordAttendance records attendance for a student in a class
 func (a *App) RecordAttendance(classID, studentID int, timeIn, timeOut, status, remarks string, recordedBy int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	// Verify student is enrolled in the class
-	var exists int
-	err := a.db.QueryRow(
-		`SELECT 1 FROM classlist WHERE class_id = ? AND student_user_id = ? AND status = 'active' LIMIT 1`,
-		classID, studentID,
-	).Scan(&exists)
-	if err != nil {
+	enrolled, err := a.store.Classes.IsEnrolled(classID, studentID)
+	if err != nil || !enrolled {
 		log.Printf("⚠ Student %d not enrolled in class %d: %v", studentID, classID, err)
 		return fmt.Errorf("student not enrolled in this class")
 	}
 
+	// The database's today is within a day of the local one
+	now := time.Now()
+	meter, err := a.loadStayMeter([]int{classID}, now.AddDate(0, 0, -1), now.AddDate(0, 0, 1))
+	if err != nil {
+		return err
+	}
+
 	// Record or update attendance using composite key (class_id, student_user_id, date)
-	query := `
-		INSERT INTO attendance (class_id, student_user_id, date, time_in, time_out, status, remarks)
-		VALUES (?, ?, CURDATE(), ?, ?, ?, ?)
-		ON DUPLICATE KEY UPDATE 
-			time_in = COALESCE(VALUES(time_in), time_in),
-			time_out = COALESCE(VALUES(time_out), time_out),
-			status = VALUES(status),
-			remarks = VALUES(remarks),
-			updated_at = CURRENT_TIMESTAMP
-	`
-	_, err = a.db.Exec(query, classID, studentID, nullString(timeIn), nullString(timeOut), status, nullString(remarks))
+	err = a.withTx(func(tx sqlExecutor) error {
+		today, err := currentDBDate(tx, a.dialect)
+		if err != nil {
+			return err
+		}
+		key := attendanceKey(classID, studentID, today)
+		return a.auditAttendanceChange(tx, "record", key, attendanceAuditQuery, []interface{}{classID, studentID, today}, func() error {
+			store := a.txStore(tx)
+			if err := store.Attendance.Record(classID, studentID, today, timeIn, timeOut, status, remarks); err != nil {
+				return err
+			}
+			return meter.remeasure(store, classID, today, studentID)
+		})
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to record attendance: %v", err)
 		return err
@@ -3608,18 +2590,29 @@ func (a *App) RecordAttendance(classID, studentID int, timeIn, timeOut, status,
 
 // UpdateAttendanceTime updates time in/out for an attendance record
 func (a *App) UpdateAttendanceTime(classID, studentUserID int, date, timeIn, timeOut string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := `
-		UPDATE attendance 
-		SET time_in = COALESCE(?, time_in), 
-		    time_out = COALESCE(?, time_out),
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE class_id = ? AND student_user_id = ? AND date = ?
-	`
-	_, err := a.db.Exec(query, nullString(timeIn), nullString(timeOut), classID, studentUserID, date)
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	meter, err := a.classStayMeter(classID, date)
+	if err != nil {
+		return err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := attendanceKey(classID, studentUserID, date)
+		return a.auditAttendanceChange(tx, "update_time", key, attendanceAuditQuery, []interface{}{classID, studentUserID, date}, func() error {
+			store := a.txStore(tx)
+			if err := store.Attendance.UpdateTimes(classID, studentUserID, date, timeIn, timeOut); err != nil {
+				return err
+			}
+			return meter.remeasure(store, classID, date, studentUserID)
+		})
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to update attendance time: %v", err)
 		return err
@@ -3631,112 +2624,52 @@ func (a *App) UpdateAttendanceTime(classID, studentUserID int, date, timeIn, tim
 
 // GetClassAttendance gets attendance records for a specific class on a specific date
 func (a *App) GetClassAttendance(classID int, date string) ([]Attendance, error) {
-	if a.db == nil {
-		return nil, fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			cl.class_id,
-			cl.student_user_id,
-			COALESCE(a.date, ?) as date,
-			vcl.student_number,
-			vcl.first_name,
-			vcl.middle_name,
-			vcl.last_name,
-			s.subject_code,
-			s.subject_name,
-			a.time_in,
-			a.time_out,
-			a.pc_number,
-			a.status,
-			a.remarks
-		FROM classlist cl
-		JOIN v_classlist_complete vcl ON cl.class_id = vcl.class_id AND cl.student_user_id = vcl.student_user_id
-		JOIN classes c ON cl.class_id = c.class_id
-		JOIN subjects s ON c.subject_code = s.subject_code
-		LEFT JOIN attendance a ON cl.class_id = a.class_id AND cl.student_user_id = a.student_user_id AND a.date = ?
-		WHERE cl.class_id = ? AND cl.status = 'active'
-		ORDER BY vcl.last_name, vcl.first_name
-	`
-
-	rows, err := a.db.Query(query, date, date, classID)
-	if err != nil {
-		log.Printf("⚠ Failed to query attendance: %v", err)
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var attendances []Attendance
-	for rows.Next() {
-		var att Attendance
-		var middleName, timeIn, timeOut, pcNumber, remarks, status sql.NullString
 
-		err := rows.Scan(
-			&att.ClassID, &att.StudentUserID, &att.Date,
-			&att.StudentCode, &att.FirstName, &middleName, &att.LastName,
-			&att.SubjectCode, &att.SubjectName,
-			&timeIn, &timeOut, &pcNumber, &status, &remarks,
-		)
-		if err != nil {
-			log.Printf("⚠ Failed to scan attendance row: %v", err)
-			continue
-		}
-
-		if middleName.Valid {
-			att.MiddleName = &middleName.String
-		}
-		if timeIn.Valid {
-			att.TimeIn = &timeIn.String
-		}
-		if timeOut.Valid {
-			att.TimeOut = &timeOut.String
-		}
-		if pcNumber.Valid {
-			att.PCNumber = &pcNumber.String
-		}
-		if remarks.Valid {
-			att.Remarks = &remarks.String
-		}
-		if status.Valid {
-			att.Status = status.String
-		} else {
-			att.Status = "" // Empty string when no status is set yet
-		}
-
-		attendances = append(attendances, att)
+	if err := a.requireDB(); err != nil {
+		return nil, err
 	}
 
+	attendances, err := a.store.Attendance.ForClass(classID, date)
+	if err != nil {
+		log.Printf("⚠ Failed to query attendance: %v", err)
+		return nil, err
+	}
 	return attendances, nil
 }
 
 // InitializeAttendanceForClass creates attendance records for all students in a class for a date
 // Status is initially set to 'absent' so teachers can mark who is present
 func (a *App) InitializeAttendanceForClass(classID int, date string, recordedBy int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := `
-		INSERT INTO attendance (class_id, student_user_id, date, status, remarks, created_at)
-		SELECT 
-			cl.class_id,
-			cl.student_user_id,
-			?,
-			'absent',
-			'Not yet logged in',
-			CURRENT_TIMESTAMP
-		FROM classlist cl
-		WHERE cl.class_id = ? AND cl.status = 'active'
-		ON DUPLICATE KEY UPDATE 
-			remarks = CASE 
-				WHEN time_in IS NULL AND (remarks IS NULL OR remarks = '') THEN 'Not yet logged in'
-				ELSE remarks
-			END,
-			class_id=class_id
-	`
-
-	_, err := a.db.Exec(query, date, classID)
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	// Nobody is marked absent on a holiday, suspension or outside the term
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return fmt.Errorf("invalid date format: %w", err)
+	}
+	classDay, err := a.classDay(classID, day)
+	if err != nil {
+		return err
+	}
+	if classDay.Cancelled {
+		return fmt.Errorf("no class on %s: %s", date, classDay.Reason)
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := fmt.Sprintf("%d/*/%s", classID, date)
+		return a.auditAttendanceChange(tx, "initialize", key, classAttendanceAuditQuery, []interface{}{classID, date}, func() error {
+			return a.txStore(tx).Attendance.Initialize(classID, date)
+		})
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to initialize attendance: %v", err)
 		return err
@@ -3748,22 +2681,29 @@ func (a *App) InitializeAttendanceForClass(classID int, date string, recordedBy
 
 // UpdateAttendanceRecord updates a specific attendance record with new details
 func (a *App) UpdateAttendanceRecord(classID, studentUserID int, date, timeIn, timeOut, pcNumber, status, remarks string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
-	}
-
-	query := `
-		UPDATE attendance 
-		SET time_in = ?,
-		    time_out = ?,
-		    pc_number = ?,
-		    status = ?,
-		    remarks = ?,
-		    updated_at = CURRENT_TIMESTAMP
-		WHERE class_id = ? AND student_user_id = ? AND date = ?
-	`
-
-	_, err := a.db.Exec(query, nullString(timeIn), nullString(timeOut), nullString(pcNumber), status, nullString(remarks), classID, studentUserID, date)
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	meter, err := a.classStayMeter(classID, date)
+	if err != nil {
+		return err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := attendanceKey(classID, studentUserID, date)
+		return a.auditAttendanceChange(tx, "update", key, attendanceAuditQuery, []interface{}{classID, studentUserID, date}, func() error {
+			store := a.txStore(tx)
+			if err := store.Attendance.Update(classID, studentUserID, date, timeIn, timeOut, pcNumber, status, remarks); err != nil {
+				return err
+			}
+			return meter.remeasure(store, classID, date, studentUserID)
+		})
+	})
 	if err != nil {
 		log.Printf("⚠ Failed to update attendance record: %v", err)
 		return err
@@ -3773,102 +2713,92 @@ func (a *App) UpdateAttendanceRecord(classID, studentUserID int, date, timeIn, t
 	return nil
 }
 
-// RecordStudentLogin records when a student logs in during class time
-func (a *App) RecordStudentLogin(classID, studentID int, pcNumber string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+// RecordStudentLogin records a student's login to a class in session from this
+// PC, for a teacher signing a student in by hand. The PC is this machine as the
+// computers registry knows it, the status follows the class's attendance rules,
+// and a status a teacher already set is kept.
+func (a *App) RecordStudentLogin(classID, studentID int) error {
+	session, err := a.authorizeClassAttendance(classID)
+	if err != nil {
+		return err
 	}
 
-	// Verify student is enrolled in the class
-	var exists int
-	err := a.db.QueryRow(
-		`SELECT 1 FROM classlist WHERE class_id = ? AND student_user_id = ? AND status = 'active' LIMIT 1`,
-		classID, studentID,
-	).Scan(&exists)
+	pc := a.currentComputer()
+	unregisteredPC, err := applyComputerPolicy(&User{ID: studentID, Name: fmt.Sprintf("student %d", studentID), Role: "student"}, pc)
 	if err != nil {
+		return err
+	}
+	pcNumber := pc.pcNumber()
+
+	if a.workingOffline() {
+		return a.queueAttendanceTap(classID, studentID, pcNumber, unregisteredPC)
+	}
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	// Verify student is enrolled in the class
+	enrolled, err := a.store.Classes.IsEnrolled(classID, studentID)
+	if a.lostConnection(err) {
+		return a.queueAttendanceTap(classID, studentID, pcNumber, unregisteredPC)
+	}
+	if err != nil || !enrolled {
 		return fmt.Errorf("student not enrolled in this class")
 	}
 
-	// Record attendance as present with login time using composite key
-	// Clear "Not yet logged in" remark when student logs in
-	query := `
-		INSERT INTO attendance (class_id, student_user_id, date, time_in, pc_number, status, remarks)
-		VALUES (?, ?, CURDATE(), CURTIME(), ?, 'present', NULL)
-		ON DUPLICATE KEY UPDATE 
-			time_in = COALESCE(time_in, CURTIME()),
-			pc_number = VALUES(pc_number),
-			status = 'present',
-			remarks = CASE 
-				WHEN remarks = 'Not yet logged in' THEN NULL
-				ELSE remarks
-			END,
-			updated_at = CURRENT_TIMESTAMP
-	`
-
-	_, err = a.db.Exec(query, classID, studentID, pcNumber)
+	now := time.Now()
+	classes, err := a.classesInSession([]int{classID}, now)
+	if a.lostConnection(err) {
+		return a.queueAttendanceTap(classID, studentID, pcNumber, unregisteredPC)
+	}
+	if err != nil {
+		return err
+	}
+	if len(classes) == 0 {
+		return fmt.Errorf("the class is not in session")
+	}
+
+	// Merged like any other login: the first login and a teacher's entries are kept
+	status, remark := classes[0].status(now, unregisteredPC)
+	err = a.mergeTap(session, "record_login", classID, studentID, 0, now, pcNumber, status, remark)
+	if a.lostConnection(err) {
+		return a.queueAttendanceTap(classID, studentID, pcNumber, unregisteredPC)
+	}
 	if err != nil {
 		log.Printf("⚠ Failed to record student login: %v", err)
 		return err
 	}
 
-	log.Printf("✓ Student login recorded: student=%d, class=%d, pc=%s", studentID, classID, pcNumber)
+	log.Printf("✓ Student login recorded: student=%d, class=%d, pc=%s, status=%s", studentID, classID, pcNumber, status)
 	return nil
 }
 
 // ExportAttendanceCSV exports attendance to CSV for a specific class
 func (a *App) ExportAttendanceCSV(classID int) (string, error) {
-	if a.db == nil {
-		return "", fmt.Errorf("database not connected")
-	}
-
-	query := `
-		SELECT 
-			a.class_id, a.student_user_id, a.date, a.time_in, a.time_out, a.status, a.remarks,
-			vcl.student_number, vcl.first_name, vcl.middle_name, vcl.last_name,
-			vc.subject_code, vc.subject_name
-		FROM attendance a
-		JOIN v_classlist_complete vcl ON a.class_id = vcl.class_id AND a.student_user_id = vcl.student_user_id
-		JOIN v_classes_complete vc ON a.class_id = vc.class_id
-		WHERE a.class_id = ?
-		ORDER BY a.date DESC, vcl.last_name, vcl.first_name
-	`
-	rows, err := a.db.Query(query, classID)
-	if err != nil {
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
 		return "", err
 	}
-	defer rows.Close()
-
-	var attendances []Attendance
-	for rows.Next() {
-		var att Attendance
-		var timeIn, timeOut, remarks, middleName sql.NullString
-		err := rows.Scan(
-			&att.ClassID, &att.StudentUserID, &att.Date, &timeIn, &timeOut, &att.Status, &remarks,
-			&att.StudentCode, &att.FirstName, &middleName, &att.LastName,
-			&att.SubjectCode, &att.SubjectName,
-		)
-		if err != nil {
-			continue
-		}
 
-		if timeIn.Valid {
-			att.TimeIn = &timeIn.String
-		}
-		if timeOut.Valid {
-			att.TimeOut = &timeOut.String
-		}
-		if remarks.Valid {
-			att.Remarks = &remarks.String
-		}
-		if middleName.Valid {
-			att.MiddleName = &middleName.String
-		}
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+
+	attendances, err := a.store.Attendance.ForExport(classID)
+	if err != nil {
+		return "", err
+	}
+	attendances, err = a.withoutCancelledDays(attendances)
+	if err != nil {
+		return "", err
+	}
 
-		attendances = append(attendances, att)
+	// Read the chain head after the rows so it covers everything exported
+	head, err := a.chainHead()
+	if err != nil {
+		return "", err
 	}
 
-	homeDir, _ := os.UserHomeDir()
-	filename := filepath.Join(homeDir, "Downloads", fmt.Sprintf("attendance_%s.csv", time.Now().Format("20060102_150405")))
+	filename := exportPath(fmt.Sprintf("attendance_%s.csv", time.Now().Format("20060102_150405")))
 
 	file, err := os.Create(filename)
 	if err != nil {
@@ -3880,7 +2810,7 @@ func (a *App) ExportAttendanceCSV(classID int) (string, error) {
 	defer writer.Flush()
 
 	// Write header
-	writer.Write([]string{"Date", "Student ID", "First Name", "Middle Name", "Last Name", "Subject", "Time In", "Time Out", "Status", "Remarks"})
+	writer.Write([]string{"Date", "Student ID", "First Name", "Middle Name", "Last Name", "Subject", "Time In", "Time Out", "Minutes Present", "Status", "Remarks"})
 
 	// Write data
 	for _, att := range attendances {
@@ -3892,6 +2822,13 @@ func (a *App) ExportAttendanceCSV(classID int) (string, error) {
 		if att.TimeOut != nil {
 			timeOut = *att.TimeOut
 		}
+		minutesPresent := ""
+		if att.MinutesPresent != nil {
+			minutesPresent = strconv.Itoa(*att.MinutesPresent)
+			if att.ShortStay {
+				minutesPresent += " (short)"
+			}
+		}
 		middleName := ""
 		if att.MiddleName != nil {
 			middleName = *att.MiddleName
@@ -3910,40 +2847,74 @@ func (a *App) ExportAttendanceCSV(classID int) (string, error) {
 			fmt.Sprintf("%s - %s", att.SubjectCode, att.SubjectName),
 			timeIn,
 			timeOut,
+			minutesPresent,
 			att.Status,
 			remarks,
 		})
 	}
 
+	writer.Write([]string{})
+	writer.Write([]string{"Integrity chain head", head.String()})
+	writer.Write([]string{"Generated", time.Now().Format("2006-01-02 15:04:05")})
+
 	log.Printf("✓ Attendance exported to CSV: %s", filename)
 	return filename, nil
 }
 
-// GenerateAttendanceFromLogs generates attendance records for a class based on login logs
-// It determines status: present (login before 10 min to scheduled time), late (login 10 min after scheduled time), absent (no login)
+// GenerateAttendanceFromLogs generates attendance records for a class based on login logs.
+// Each student's first login that counts towards one of the day's meetings is given its
+// status by the class's attendance policy, exactly as at login time; a student without
+// one is absent.
+// A session still open is tied to the row, so its logout records the time out.
 func (a *App) GenerateAttendanceFromLogs(classID int, date string, recordedBy int) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return err
 	}
 
-	// Get class information including schedule
-	var schedule sql.NullString
-	err := a.db.QueryRow("SELECT schedule FROM classes WHERE class_id = ?", classID).Scan(&schedule)
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	// Validate the date format
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return fmt.Errorf("invalid date format: %w", err)
+	}
+
+	// Get the class meeting held that day, if the calendar holds one
+	calendar, err := a.loadCalendar([]int{classID}, day, day)
 	if err != nil {
 		log.Printf("⚠ Failed to get class schedule: %v", err)
 		return fmt.Errorf("failed to get class schedule: %w", err)
 	}
-
-	if !schedule.Valid || schedule.String == "" {
+	if len(calendar.meetings[classID]) == 0 {
 		return fmt.Errorf("class schedule not set")
 	}
-
-	// Parse schedule to get start time
-	scheduleStr := schedule.String
-	startTime, err := parseScheduleStartTime(scheduleStr)
+	classDay := calendar.day(classID, day)
+	if !classDay.Meets {
+		return fmt.Errorf("no class on %s: %s", date, classDay.Reason)
+	}
+	policies, err := a.classPolicies([]int{classID})
 	if err != nil {
-		log.Printf("⚠ Failed to parse schedule: %v", err)
-		return fmt.Errorf("failed to parse schedule: %w", err)
+		return fmt.Errorf("failed to get attendance policy: %w", err)
+	}
+	// The login window of each meeting held that day, in order
+	type meetingWindow struct {
+		class      classSession
+		start, end time.Time
+	}
+	var windows []meetingWindow
+	for _, meeting := range classDay.Meetings {
+		class := classSession{ClassID: classID, Meeting: meeting, Policy: policies[classID]}
+		from, until, err := class.Policy.window(meeting)
+		if err != nil {
+			return fmt.Errorf("failed to parse schedule: %w", err)
+		}
+		windows = append(windows, meetingWindow{
+			class: class,
+			start: day.Add(time.Duration(from) * time.Minute),
+			end:   day.Add(time.Duration(until+1)*time.Minute - time.Second),
+		})
 	}
 
 	// Get all enrolled students
@@ -3952,159 +2923,75 @@ func (a *App) GenerateAttendanceFromLogs(classID int, date string, recordedBy in
 		return fmt.Errorf("failed to get class students: %w", err)
 	}
 
-	// Validate the date format
-	_, err = time.Parse("2006-01-02", date)
-	if err != nil {
-		return fmt.Errorf("invalid date format: %w", err)
-	}
-
-	// For each student, check login logs and create attendance record
-	for _, student := range students {
-		// Get login logs for this student on the attendance date
-		query := `
-			SELECT login_time, logout_time, pc_number
-			FROM login_logs
-			WHERE user_id = ? 
-			AND DATE(login_time) = ?
-			AND login_status = 'success'
-			ORDER BY login_time ASC
-			LIMIT 1
-		`
-		var loginTime sql.NullTime
-		var logoutTime sql.NullTime
-		var pcNumber sql.NullString
-
-		err := a.db.QueryRow(query, student.StudentUserID, date).Scan(&loginTime, &logoutTime, &pcNumber)
-		
-		var status string
-		var timeInStr, timeOutStr string
-		var pcNumberStr string
-
-		if err == nil && loginTime.Valid {
-			// Student logged in - determine if present or late
-			loginDateTime := loginTime.Time
-			loginTimeOnly := time.Date(0, 1, 1, loginDateTime.Hour(), loginDateTime.Minute(), loginDateTime.Second(), 0, time.UTC)
-			
-			// Calculate 10 minutes after scheduled time (cutoff for late)
-			tenMinutesAfter := startTime.Add(10 * time.Minute)
-
-			timeInStr = loginDateTime.Format("15:04:05")
-			if logoutTime.Valid {
-				timeOutStr = logoutTime.Time.Format("15:04:05")
-			}
-			if pcNumber.Valid {
-				pcNumberStr = pcNumber.String
-			}
+	// Upsert every student's record in one transaction so the generation is audited as a whole
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := fmt.Sprintf("%d/*/%s", classID, date)
+		return a.auditAttendanceChange(tx, "generate_from_logs", key, classAttendanceAuditQuery, []interface{}{classID, date}, func() error {
+			store := a.txStore(tx)
+			// For each student, check login logs and create attendance record
+			for _, student := range students {
+				// Get the first login counting towards one of the meetings
+				var logID int
+				var loginTime, logoutTime sql.NullTime
+				var pcNumber sql.NullString
+				var class classSession
+				var err error
+				for _, w := range windows {
+					logID, loginTime, logoutTime, pcNumber, err = store.Logs.FirstLogin(student.StudentUserID, w.start, w.end)
+					if err == nil && loginTime.Valid {
+						class = w.class
+						break
+					}
+				}
 
-			// Determine status based on login time
-			// Present: login within 10 minutes after scheduled time (allows 10 min before and 10 min after)
-			// Late: login more than 10 minutes after scheduled time
-			if loginTimeOnly.Before(tenMinutesAfter) || loginTimeOnly.Equal(tenMinutesAfter) {
-				// Login before or within 10 minutes after scheduled time = Present
-				status = "present"
-			} else {
-				// Login more than 10 minutes after scheduled time = Late
-				status = "late"
-			}
-		} else {
-			// No login found = Absent
-			status = "absent"
-		}
+				var status string
+				var timeInStr, timeOutStr string
+				var pcNumberStr string
+				var remark sql.NullString
+
+				if err == nil && loginTime.Valid {
+					// Student logged in - the class's rules decide present, late or absent
+					status, remark = class.status(loginTime.Time, false)
+					timeInStr = loginTime.Time.Format("15:04:05")
+					if logoutTime.Valid {
+						timeOutStr = logoutTime.Time.Format("15:04:05")
+					}
+					if pcNumber.Valid {
+						pcNumberStr = pcNumber.String
+					}
+				} else {
+					// No login found = Absent
+					status = "absent"
+				}
 
-		// Set remarks for students who haven't logged in yet
-		var remarksStr string
-		if status == "absent" {
-			remarksStr = "Not yet logged in"
-		}
+				// Set remarks for students who haven't logged in yet
+				remarksStr := remark.String
+				if status == "absent" && timeInStr == "" {
+					remarksStr = "Not yet logged in"
+				}
 
-		// Insert or update attendance record
-		// If student has logged in (time_in exists), clear the "Not yet logged in" remark
-		insertQuery := `
-			INSERT INTO attendance (class_id, student_user_id, date, time_in, time_out, pc_number, status, remarks, created_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
-			ON DUPLICATE KEY UPDATE
-				time_in = COALESCE(VALUES(time_in), time_in),
-				time_out = COALESCE(VALUES(time_out), time_out),
-				pc_number = COALESCE(VALUES(pc_number), pc_number),
-				status = VALUES(status),
-				remarks = CASE 
-					WHEN VALUES(time_in) IS NOT NULL AND (remarks = 'Not yet logged in' OR remarks IS NULL OR remarks = '') THEN NULL
-					WHEN VALUES(time_in) IS NOT NULL THEN remarks
-					WHEN VALUES(remarks) IS NOT NULL AND VALUES(remarks) != '' THEN VALUES(remarks)
-					WHEN remarks IS NULL OR remarks = '' THEN VALUES(remarks)
-					ELSE remarks
-				END,
-				updated_at = CURRENT_TIMESTAMP
-		`
-		_, err = a.db.Exec(insertQuery, classID, student.StudentUserID, date,
-			nullString(timeInStr), nullString(timeOutStr), nullString(pcNumberStr), status, nullString(remarksStr))
-		if err != nil {
-			log.Printf("⚠ Failed to create attendance for student %d: %v", student.StudentUserID, err)
-			continue
-		}
+				// Insert or update attendance record
+				// If student has logged in (time_in exists), clear the "Not yet logged in" remark
+				err = store.Attendance.Merge(classID, student.StudentUserID, logID, date, timeInStr, timeOutStr, pcNumberStr, status, remarksStr)
+				if err != nil {
+					log.Printf("⚠ Failed to create attendance for student %d: %v", student.StudentUserID, err)
+					return err
+				}
+			}
+			// Measure how long each student stayed from the times just written
+			meter := &stayMeter{calendar: calendar, policies: policies}
+			return meter.remeasure(store, classID, date, 0)
+		})
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to generate attendance for class %d: %v", classID, err)
+		return err
 	}
 
 	log.Printf("✓ Attendance generated from logs for class %d on %s", classID, date)
 	return nil
 }
 
-// parseScheduleStartTime parses a schedule string and returns the start time
-// Schedule format examples: "MWF 1:00-2:00 PM", "TTh 10:00-11:30 AM", "1:00-2:00 PM", "MWF 7:30 AM-8:30 AM"
-func parseScheduleStartTime(schedule string) (time.Time, error) {
-	// First try pattern with AM/PM after each time (e.g., "7:30 AM-8:30 AM")
-	timePattern1 := regexp.MustCompile(`(\d{1,2}):(\d{2})\s*(AM|PM)\s*-\s*(\d{1,2}):(\d{2})\s*(AM|PM)`)
-	matches := timePattern1.FindStringSubmatch(schedule)
-	
-	if len(matches) == 7 {
-		// 12-hour format with AM/PM after each time
-		startHour, _ := strconv.Atoi(matches[1])
-		startMin, _ := strconv.Atoi(matches[2])
-		startPeriod := matches[3]
-
-		// Convert to 24-hour format
-		if startPeriod == "PM" && startHour != 12 {
-			startHour += 12
-		}
-		if startPeriod == "AM" && startHour == 12 {
-			startHour = 0
-		}
-
-		return time.Date(0, 1, 1, startHour, startMin, 0, 0, time.UTC), nil
-	}
-
-	// Try pattern with AM/PM at the end (e.g., "1:00-2:00 PM" or "10:00-11:30 AM")
-	timePattern2 := regexp.MustCompile(`(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})\s*(AM|PM)`)
-	matches = timePattern2.FindStringSubmatch(schedule)
-	
-	if len(matches) == 6 {
-		// 12-hour format with AM/PM at the end
-		startHour, _ := strconv.Atoi(matches[1])
-		startMin, _ := strconv.Atoi(matches[2])
-		period := matches[5]
-
-		// Convert to 24-hour format
-		if period == "PM" && startHour != 12 {
-			startHour += 12
-		}
-		if period == "AM" && startHour == 12 {
-			startHour = 0
-		}
-
-		return time.Date(0, 1, 1, startHour, startMin, 0, 0, time.UTC), nil
-	}
-
-	// Try simpler pattern without AM/PM (24-hour format)
-	timePattern3 := regexp.MustCompile(`(\d{1,2}):(\d{2})\s*-\s*(\d{1,2}):(\d{2})`)
-	matches = timePattern3.FindStringSubmatch(schedule)
-	if len(matches) == 5 {
-		startHour, _ := strconv.Atoi(matches[1])
-		startMin, _ := strconv.Atoi(matches[2])
-		return time.Date(0, 1, 1, startHour, startMin, 0, 0, time.UTC), nil
-	}
-
-	return time.Time{}, fmt.Errorf("could not parse schedule time from: %s", schedule)
-}
-
 // ==============================================================================
 // STUDENT DASHBOARD
 // ==============================================================================
@@ -4117,51 +3004,32 @@ type StudentDashboard struct {
 
 // GetStudentDashboard returns student dashboard data
 func (a *App) GetStudentDashboard(userID int) (StudentDashboard, error) {
+	if _, err := a.authorizeSelfOr(userID, PermViewRecords); err != nil {
+		return StudentDashboard{}, err
+	}
+
 	var dashboard StudentDashboard
 
-	if a.db == nil {
-		return dashboard, fmt.Errorf("database not connected")
+	if err := a.requireDB(); err != nil {
+		return dashboard, err
 	}
 
 	// Get all attendance for this student
-	query := `
-		SELECT 
-			a.class_id, 
-			a.student_user_id,
-			a.date, 
-			a.time_in, 
-			a.time_out, 
-			a.status 
-		FROM attendance a
-		WHERE a.student_user_id = ? 
-		ORDER BY a.date DESC 
-		LIMIT 100`
-	rows, err := a.db.Query(query, userID)
+	attendance, err := a.store.Attendance.ForStudent(userID, 100)
 	if err != nil {
 		return dashboard, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var att Attendance
-		var timeIn, timeOut sql.NullString
-		err := rows.Scan(&att.ClassID, &att.StudentUserID, &att.Date, &timeIn, &timeOut, &att.Status)
-		if err != nil {
-			continue
-		}
-
-		if timeIn.Valid {
-			att.TimeIn = &timeIn.String
-		}
-		if timeOut.Valid {
-			att.TimeOut = &timeOut.String
-		}
-
-		dashboard.Attendance = append(dashboard.Attendance, att)
+	attendance, err = a.withoutCancelledDays(attendance)
+	if err != nil {
+		return dashboard, err
+	}
+	dashboard.Attendance = attendance
 
-		// Check if this is today's log
-		if att.Date == time.Now().Format("2006-01-02") && dashboard.TodayLog == nil {
-			dashboard.TodayLog = &att
+	// Check for today's log
+	for i := range dashboard.Attendance {
+		if dashboard.Attendance[i].Date == time.Now().Format("2006-01-02") {
+			dashboard.TodayLog = &dashboard.Attendance[i]
+			break
 		}
 	}
 
@@ -4180,17 +3048,21 @@ type WorkingStudentDashboard struct {
 
 // GetWorkingStudentDashboard returns working student dashboard data
 func (a *App) GetWorkingStudentDashboard() (WorkingStudentDashboard, error) {
+	if _, err := a.authorize(PermViewWorkingDashboard); err != nil {
+		return WorkingStudentDashboard{}, err
+	}
+
 	var dashboard WorkingStudentDashboard
 
-	if a.db == nil {
-		return dashboard, fmt.Errorf("database not connected")
+	if err := a.requireDB(); err != nil {
+		return dashboard, err
 	}
 
 	// Count students
-	a.db.QueryRow(`SELECT COUNT(*) FROM students`).Scan(&dashboard.StudentsRegistered)
+	dashboard.StudentsRegistered, _ = a.store.Users.StudentCount()
 
 	// Count classlists
-	a.db.QueryRow(`SELECT COUNT(*) FROM classlist`).Scan(&dashboard.ClasslistsCreated)
+	dashboard.ClasslistsCreated, _ = a.store.Classes.EnrollmentCount()
 
 	return dashboard, nil
 }
@@ -4201,52 +3073,85 @@ func (a *App) GetWorkingStudentDashboard() (WorkingStudentDashboard, error) {
 
 // UpdateUserPhoto updates a user's profile photo
 func (a *App) UpdateUserPhoto(userID int, userRole, photoURL string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	if _, err := a.authorizeSelfOr(userID, PermManageUsers); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
-	// Update photo in respective table based on role
-	var query string
 	switch userRole {
-	case "admin":
-		query = `UPDATE admins SET profile_photo = ? WHERE user_id = ?`
-	case "teacher":
-		query = `UPDATE teachers SET profile_photo = ? WHERE user_id = ?`
-	case "student":
-		query = `UPDATE students SET profile_photo = ? WHERE user_id = ?`
-	case "working_student":
-		query = `UPDATE students SET profile_photo = ? WHERE user_id = ? AND is_working_student = TRUE`
+	case "admin", "teacher", "student", "working_student":
 	default:
 		return fmt.Errorf("invalid user role")
 	}
 
-	_, err := a.db.Exec(query, photoURL, userID)
-	return err
+	// The photo itself is not copied into the audit trail, only the fact that it changed
+	return a.withTx(func(tx sqlExecutor) error {
+		if err := a.txStore(tx).Users.UpdatePhoto(userID, userRole, photoURL); err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "update_photo", "user", strconv.Itoa(userID), nil, nil)
+	})
 }
 
 // ChangePassword changes a user's password
 func (a *App) ChangePassword(username, oldPassword, newPassword string) error {
-	if a.db == nil {
-		return fmt.Errorf("database not connected")
+	session, err := a.requireSession(true)
+	if err != nil {
+		return err
+	}
+	if session.Username != username {
+		return errForbidden("you can only change your own password")
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
 	}
 
 	// Verify old password
-	var currentPassword string
-	query := `SELECT password FROM users WHERE username = ?`
-	err := a.db.QueryRow(query, username).Scan(&currentPassword)
+	currentPassword, studentNumber, err := a.store.Users.PasswordForChange(username)
 	if err != nil {
 		return fmt.Errorf("user not found")
 	}
 
-	// Simple password check (in production, use proper password hashing)
-	if currentPassword != oldPassword {
+	if ok, _ := VerifyPassword(currentPassword, oldPassword); !ok {
 		return fmt.Errorf("incorrect old password")
 	}
 
+	if newPassword == oldPassword {
+		return fmt.Errorf("new password must be different from the old password")
+	}
+	if err := ValidatePassword(GetPasswordPolicyConfig(), newPassword, username, studentNumber); err != nil {
+		return err
+	}
+
+	hashedPassword, err := HashPassword(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
 	// Update password
-	query = `UPDATE users SET password = ? WHERE username = ?`
-	_, err = a.db.Exec(query, newPassword, username)
-	return err
+	err = a.withTx(func(tx sqlExecutor) error {
+		if err := a.txStore(tx).Users.SetPassword(username, hashedPassword); err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "change_password", "user", strconv.Itoa(session.UserID), nil, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	a.clearPasswordChangeRequirement(session.UserID)
+	// Cached again with the new password at the next online sign-in
+	a.offline.forget(session.UserID)
+	return nil
+}
+
+// GetPasswordPolicy returns the rules a new password must satisfy
+func (a *App) GetPasswordPolicy() PasswordPolicy {
+	return GetPasswordPolicyConfig()
 }
 
 // ==============================================================================
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginRejectsDeactivatedAccount(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	mustExec(t, a, `UPDATE users SET is_active = FALSE WHERE id = 2`)
+
+	if _, err := a.Login("teacher1", "Correct#1"); err == nil {
+		t.Fatal("deactivated account signed in")
+	}
+	if _, err := a.Login("nobody", "Correct#1"); err == nil || err.Error() != "invalid credentials" {
+		t.Errorf("unknown user: err = %v, want invalid credentials", err)
+	}
+}
+
+func TestDeleteUserWithChainedRecords(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+
+	if n, err := a.store.Users.ChainedCount(3); err != nil || n != 1 {
+		t.Errorf("student chained count = %d (err %v), want 1", n, err)
+	}
+	// The teacher's own login and the attendance of their class
+	if n, err := a.store.Users.ChainedCount(2); err != nil || n != 2 {
+		t.Errorf("teacher chained count = %d (err %v), want 2", n, err)
+	}
+
+	signInAs(t, a, 1, "admin", "admin")
+	for _, id := range []int{2, 3} {
+		err := a.DeleteUser(id, true)
+		if err == nil || !strings.Contains(err.Error(), "deactivate the account instead") {
+			t.Errorf("forced delete of user %d: err = %v, want a refusal", id, err)
+		}
+	}
+	if err := a.DeleteUser(4, false); err != nil {
+		t.Errorf("delete user without history: %v", err)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	signInAs(t, a, 1, "admin", "admin")
+
+	if err := a.CreateUser("Teach#123", "", "Tess", "", "Cruz", "", "teacher", "T-001", "", "", "", "tess@example.com", "", ""); err != nil {
+		t.Fatalf("create teacher: %v", err)
+	}
+	var first, email string
+	err := a.pool.QueryRow(`SELECT t.first_name, t.email FROM users u JOIN teachers t ON t.user_id = u.id WHERE u.username = 'T-001'`).Scan(&first, &email)
+	if err != nil || first != "Tess" || email != "tess@example.com" {
+		t.Errorf("teacher profile = %q %q (err %v)", first, email, err)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'create' AND entity_type = 'user' AND actor_user_id = 1`); n != 1 {
+		t.Errorf("%d create audit entries, want 1", n)
+	}
+	if _, err := a.Login("T-001", "Teach#123"); err != nil {
+		t.Errorf("new teacher cannot sign in: %v", err)
+	}
+
+	signInAs(t, a, 1, "admin", "admin")
+	if err := a.CreateUser("Super#123", "", "Sy", "", "Admin", "", "superuser", "S-001", "", "", "", "", "", ""); err == nil {
+		t.Error("an account with an unknown role was created")
+	}
+	if _, err := a.store.Users.Create(userAccount{Username: "S-002", Password: "x", Role: "Admin", FirstName: "Sy", LastName: "Admin"}); err == nil {
+		t.Error("the repository created an account with an unknown role")
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM users WHERE username IN ('S-001', 'S-002')`); n != 0 {
+		t.Errorf("%d accounts with unknown roles left behind", n)
+	}
+}
+
+func TestCreateUserRollsBackFailedProfile(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	signInAs(t, a, 1, "admin", "admin")
+
+	// The login is inserted, then the profile fails on the unknown department
+	if err := a.CreateUser("Teach#123", "", "Tess", "", "Cruz", "", "teacher", "T-001", "", "", "", "", "", "NOPE"); err == nil {
+		t.Fatal("a teacher with an unknown department was created")
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM users WHERE username = 'T-001'`); n != 0 {
+		t.Error("the login of a failed account was left behind")
+	}
+
+	if err := a.CreateUser("Study#123", "", "Sam", "", "Reyes", "", "student", "", "2024-001", "", "", "", "", ""); err != nil {
+		t.Fatalf("create student: %v", err)
+	}
+	if err := a.CreateUser("Study#123", "", "Sue", "", "Lim", "", "student", "", "2024-001", "", "", "", "", ""); err == nil {
+		t.Error("a second student with the same code was created")
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM students`); n != 1 {
+		t.Errorf("%d students, want 1", n)
+	}
+}
+
+func TestCreateUserPermissions(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 3, "ws1", "Work#123", "working_student")
+	seedUser(t, a, 4, "student1", "Study#123", "student")
+
+	signInAs(t, a, 4, "student1", "student")
+	if err := a.CreateUser("Study#123", "", "Sam", "", "Reyes", "", "student", "", "2024-001", "", "", "", "", ""); err == nil {
+		t.Error("a student registered a student")
+	}
+
+	signInAs(t, a, 3, "ws1", "working_student")
+	if err := a.CreateUser("Teach#123", "", "Tess", "", "Cruz", "", "teacher", "T-001", "", "", "", "", "", ""); err == nil {
+		t.Error("a working student created a teacher")
+	}
+	if err := a.CreateUser("Study#123", "", "Sam", "", "Reyes", "", "student", "", "", "", "", "", "", ""); err == nil {
+		t.Error("a student without a student ID was created")
+	}
+	if err := a.CreateUser("Study#123", "", "Sam", "", "Reyes", "", "student", "", "2024-001", "", "", "", "", ""); err != nil {
+		t.Errorf("working student registering a student: %v", err)
+	}
+}
+
+func TestRecordStudentLogin(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-15 * time.Minute).Truncate(time.Minute)
+	end := start.Add(time.Hour)
+	if start.Day() != now.Day() || end.Day() != now.Day() {
+		t.Skip("a meeting around now would cross midnight")
+	}
+
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedUser(t, a, 5, "teacher2", "Teach#123", "teacher")
+	seedClass(t, a, 10, 2, 3, 4)
+	seedClass(t, a, 11, 5, 3)
+	seedMeeting(t, a, 10, int(now.Weekday()), start.Format("15:04"), end.Format("15:04"))
+	today := now.Format("2006-01-02")
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status, remarks) VALUES (10, 4, ?, 'excused', 'Medical')`, today)
+
+	signInAs(t, a, 3, "student1", "student")
+	if err := a.RecordStudentLogin(10, 3); err == nil {
+		t.Error("a student marked their own login")
+	}
+	signInAs(t, a, 5, "teacher2", "teacher")
+	if err := a.RecordStudentLogin(10, 3); err == nil {
+		t.Error("a teacher recorded a login to another teacher's class")
+	}
+	if err := a.RecordStudentLogin(11, 3); err == nil {
+		t.Error("a login was recorded to a class not in session")
+	}
+
+	// This PC is not registered, and the class started 15 minutes ago
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.RecordStudentLogin(10, 3); err != nil {
+		t.Fatalf("record login: %v", err)
+	}
+	if _, pc, status, remarks := attendanceRow(t, a, 10, 3, today); pc != localHostname() || status != "late" || remarks != "Signed in from an unregistered PC" {
+		t.Errorf("row = %s %s %q, want late on this PC, flagged", pc, status, remarks)
+	}
+	if err := a.RecordStudentLogin(10, 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, status, remarks := attendanceRow(t, a, 10, 4, today); status != "excused" || remarks != "Medical" {
+		t.Errorf("excused row = %s %q, want it kept", status, remarks)
+	}
+}
+
+func TestClassOwnership(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "teacher2", "Teach#123", "teacher")
+	seedUser(t, a, 4, "working1", "Work#123", "working_student")
+	seedUser(t, a, 5, "working2", "Work#123", "working_student")
+	seedUser(t, a, 6, "student1", "Study#123", "student")
+	seedClass(t, a, 10, 2, 6)
+	meetings := []ClassMeeting{{Weekday: 1, StartTime: "08:00", EndTime: "09:00"}}
+
+	signInAs(t, a, 3, "teacher2", "teacher")
+	if _, err := a.CreateClass("IT101", 2, "", meetings, "Lab 1", "", "B", "1st", "2026-2027"); err == nil {
+		t.Error("a teacher created a class for another teacher")
+	}
+	if _, err := a.GetClassStudents(10); err == nil {
+		t.Error("a teacher read another teacher's class list")
+	}
+	if err := a.UpdateClass(10, meetings, "Lab 9", "", "A", "1st", "2026-2027", true); err == nil {
+		t.Error("a teacher edited another teacher's class")
+	}
+	if err := a.DeleteClass(10); err == nil {
+		t.Error("a teacher deleted another teacher's class")
+	}
+	if _, err := a.GetTeacherDashboard(2); err == nil {
+		t.Error("a teacher read another teacher's dashboard")
+	}
+
+	// The creator is whoever is signed in, not who the caller claims
+	signInAs(t, a, 4, "working1", "working_student")
+	if _, err := a.CreateClass("IT101", 6, "", meetings, "Lab 1", "", "B", "1st", "2026-2027"); err == nil {
+		t.Error("a class was assigned to a student")
+	}
+	classID, err := a.CreateClass("IT101", 2, "", meetings, "Lab 1", "", "B", "1st", "2026-2027")
+	if err != nil {
+		t.Fatalf("create class: %v", err)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM classes WHERE class_id = ? AND created_by_user_id = 4`, classID); n != 1 {
+		t.Error("the class was not recorded as created by the signed-in working student")
+	}
+	if err := a.EnrollStudentInClass(6, classID, 4); err != nil {
+		t.Errorf("creator enroll: %v", err)
+	}
+	if _, err := a.GetClassStudents(10); err == nil {
+		t.Error("a working student read a class they did not create")
+	}
+
+	signInAs(t, a, 5, "working2", "working_student")
+	if err := a.UpdateClass(classID, meetings, "Lab 9", "", "B", "1st", "2026-2027", true); err == nil {
+		t.Error("a working student edited a class they did not create")
+	}
+
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if students, err := a.GetClassStudents(classID); err != nil || len(students) != 1 {
+		t.Errorf("assigned teacher class list = %d students, %v", len(students), err)
+	}
+	if err := a.UpdateClass(10, meetings, "Lab 9", "", "A", "1st", "2026-2027", true); err != nil {
+		t.Errorf("own class update: %v", err)
+	}
+
+	signInAs(t, a, 1, "admin", "admin")
+	if err := a.DeleteClass(10); err != nil {
+		t.Errorf("admin delete: %v", err)
+	}
+	if _, err := a.GetTeacherDashboard(2); err != nil {
+		t.Errorf("admin dashboard: %v", err)
+	}
+}
+
+func TestLoginForcesChangeOfIssuedPassword(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "teacher1", "teacher")
+	seedUser(t, a, 3, "juan", "2024-0001", "student")
+	mustExec(t, a, `UPDATE students SET student_number = '2024-0001' WHERE user_id = 3`)
+	seedUser(t, a, 4, "maria", "Study#123", "student")
+
+	tests := []struct {
+		username, password string
+		mustChange         bool
+	}{
+		{"teacher1", "teacher1", true},
+		{"juan", "2024-0001", true},
+		{"maria", "Study#123", false},
+	}
+	for _, tt := range tests {
+		user, err := a.Login(tt.username, tt.password)
+		if err != nil {
+			t.Fatalf("login %s: %v", tt.username, err)
+		}
+		if user.MustChangePassword != tt.mustChange || (tt.mustChange && user.PasswordChangeReason != "first_login") {
+			t.Errorf("%s must change password = %v (%q), want %v", tt.username, user.MustChangePassword, user.PasswordChangeReason, tt.mustChange)
+		}
+		if err := a.Logout(user.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ==============================================================================
+// ATTENDANCE DURATION
+// ==============================================================================
+//
+// An attendance row recorded from a login remembers the login session. When the
+// session ends, whether by logout, timeout, the stale session reaper, a login on
+// another PC or a logout replayed from the offline queue, the row gets the
+// session's logout time as its time out.
+//
+// The minutes present are the part of the stay within the class meeting: coming
+// early or staying after the end does not count. A student who signs out and in
+// again keeps the first time in and gets the last time out, but only the minutes
+// of each session are added up, so the time between sessions does not count.
+// Times a teacher entered for a student without a session count in full. A stay
+// shorter than the class's MinimumMinutes is flagged short_stay for the teacher.
+// Generating attendance from the logs and a teacher editing the times measure the
+// stay again.
+
+// attendanceStay is the time in and out of one attendance row
+type attendanceStay struct {
+	ClassID       int
+	StudentUserID int
+	Date          string
+	TimeIn        string
+	TimeOut       string
+	LogoutTime    time.Time   // Logout of the session that recorded the time in, if it ended
+	Sessions      []loginSpan // The student's ended sessions on Date, by login time
+}
+
+// loginSpan is when a login session started and ended
+type loginSpan struct {
+	Login  time.Time
+	Logout time.Time
+}
+
+// stayMeter measures stays against the class meetings and attendance policies
+type stayMeter struct {
+	calendar *schoolCalendar
+	policies map[int]AttendancePolicy
+}
+
+// shortStay reports whether minutes present fall below the policy's minimum
+func (p AttendancePolicy) shortStay(minutes int) bool {
+	return p.MinimumMinutes > 0 && minutes < p.MinimumMinutes
+}
+
+// loadStayMeter loads the meetings and policies of classIDs for the dates from to to
+func (a *App) loadStayMeter(classIDs []int, from, to time.Time) (*stayMeter, error) {
+	calendar, err := a.loadCalendar(classIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := a.classPolicies(classIDs)
+	if err != nil {
+		return nil, err
+	}
+	return &stayMeter{calendar: calendar, policies: policies}, nil
+}
+
+// classStayMeter loads the stay meter of one class on date
+func (a *App) classStayMeter(classID int, date string) (*stayMeter, error) {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format: %w", err)
+	}
+	return a.loadStayMeter([]int{classID}, day, day)
+}
+
+// measure returns the minutes of a stay within the class meeting it counts towards,
+// and whether they are too few. Without a time out or a meeting the minutes are NULL.
+func (m *stayMeter) measure(s attendanceStay) (sql.NullInt64, bool) {
+	day, err := time.Parse("2006-01-02", s.Date)
+	if err != nil || s.TimeIn == "" || s.TimeOut == "" {
+		return sql.NullInt64{}, false
+	}
+	in, err := clockMinutes(s.TimeIn)
+	if err != nil {
+		return sql.NullInt64{}, false
+	}
+	out, err := clockMinutes(s.TimeOut)
+	if err != nil {
+		return sql.NullInt64{}, false
+	}
+
+	policy := m.policies[s.ClassID]
+	for _, meeting := range m.calendar.day(s.ClassID, day).Meetings {
+		from, until, err := policy.window(meeting)
+		if err != nil || in < from || in > until {
+			continue
+		}
+		start, _ := clockMinutes(meeting.StartTime)
+		minutes := s.presentMinutes(max(in, start), min(out, until))
+		return sql.NullInt64{Int64: int64(minutes), Valid: true}, policy.shortStay(minutes)
+	}
+	return sql.NullInt64{}, false
+}
+
+// presentMinutes adds up the minutes from from until until the student was signed
+// in, or counts them all when the student had no session that day
+func (s attendanceStay) presentMinutes(from, until int) int {
+	if until <= from {
+		return 0
+	}
+	if len(s.Sessions) == 0 {
+		return until - from
+	}
+
+	// Sessions are sorted by login, so one pass finds their union
+	minutes, covered := 0, from
+	for _, session := range s.Sessions {
+		login := max(minutesOn(s.Date, session.Login), covered)
+		logout := min(minutesOn(s.Date, session.Logout), until)
+		if logout > login {
+			minutes += logout - login
+			covered = logout
+		}
+	}
+	return minutes
+}
+
+// minutesOn returns the minutes after midnight of t on date (YYYY-MM-DD), or the
+// start or end of date when t falls before or after it
+func minutesOn(date string, t time.Time) int {
+	switch day := t.Format("2006-01-02"); {
+	case day < date:
+		return 0
+	case day > date:
+		return 24 * 60
+	}
+	return t.Hour()*60 + t.Minute()
+}
+
+// loadSessions fills in the ended sessions of a stay's student on its date
+func loadSessions(store *Store, s *attendanceStay) error {
+	day, err := time.ParseInLocation("2006-01-02", s.Date, time.Local)
+	if err != nil {
+		return err
+	}
+	s.Sessions, err = store.Logs.Sessions(s.StudentUserID, day, day.AddDate(0, 0, 1))
+	return err
+}
+
+// remeasure measures again the stays of a class on date, of one student or of
+// everyone when studentUserID is 0, after their times changed
+func (m *stayMeter) remeasure(store *Store, classID int, date string, studentUserID int) error {
+	stays, err := store.Attendance.Stays(classID, date)
+	if err != nil {
+		return err
+	}
+	for _, s := range stays {
+		if studentUserID > 0 && s.StudentUserID != studentUserID {
+			continue
+		}
+		if err := loadSessions(store, &s); err != nil {
+			return err
+		}
+		minutes, short := m.measure(s)
+		if err := store.Attendance.SetStay(s.ClassID, s.StudentUserID, s.Date, "", minutes, short); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordTimeOut fills in the time out and minutes present of the attendance rows
+// a login session recorded, once it has ended. Failures are logged; the logout stands.
+func (a *App) recordTimeOut(logID int64) {
+	if a.requireDB() != nil || logID <= 0 {
+		return
+	}
+
+	stays, err := a.store.Attendance.SessionStays(int(logID))
+	if err != nil {
+		log.Printf("⚠ Failed to find attendance of login log %d: %v", logID, err)
+		return
+	}
+	if len(stays) == 0 {
+		return
+	}
+
+	var classIDs []int
+	seen := make(map[int]bool)
+	var from, to time.Time
+	for i, s := range stays {
+		day, err := time.Parse("2006-01-02", s.Date)
+		if err != nil {
+			continue
+		}
+		if !seen[s.ClassID] {
+			seen[s.ClassID] = true
+			classIDs = append(classIDs, s.ClassID)
+		}
+		if from.IsZero() || day.Before(from) {
+			from = day
+		}
+		if day.After(to) {
+			to = day
+		}
+		// A session that ran past midnight stayed until the end of the day
+		stays[i].TimeOut = "23:59:59"
+		if s.LogoutTime.Format("2006-01-02") == s.Date {
+			stays[i].TimeOut = s.LogoutTime.Format("15:04:05")
+		}
+		if err := loadSessions(a.store, &stays[i]); err != nil {
+			log.Printf("⚠ Failed to load the sessions of student %d: %v", s.StudentUserID, err)
+			return
+		}
+	}
+	meter, err := a.loadStayMeter(classIDs, from, to)
+	if err != nil {
+		log.Printf("⚠ Failed to load class meetings for login log %d: %v", logID, err)
+		return
+	}
+
+	for _, s := range stays {
+		minutes, short := meter.measure(s)
+		err := a.withTx(func(tx sqlExecutor) error {
+			key := attendanceKey(s.ClassID, s.StudentUserID, s.Date)
+			// Sessions also end by the reaper, a kick or a replayed logout, so this
+			// is not attributed to whoever is signed in
+			return a.auditAttendanceChangeAs(tx, nil, "record_time_out", key, attendanceAuditQuery, []interface{}{s.ClassID, s.StudentUserID, s.Date}, func() error {
+				return a.txStore(tx).Attendance.SetStay(s.ClassID, s.StudentUserID, s.Date, s.TimeOut, minutes, short)
+			})
+		})
+		if err != nil {
+			log.Printf("⚠ Failed to record time out for student %d, class %d: %v", s.StudentUserID, s.ClassID, err)
+			continue
+		}
+		present := "unknown"
+		if minutes.Valid {
+			present = fmt.Sprintf("%d min", minutes.Int64)
+		}
+		if short {
+			log.Printf("⚠ Short stay: student=%d, class=%d, time_out=%s, present=%s", s.StudentUserID, s.ClassID, s.TimeOut, present)
+		} else {
+			log.Printf("✓ Time out recorded: student=%d, class=%d, time_out=%s, present=%s", s.StudentUserID, s.ClassID, s.TimeOut, present)
+		}
+	}
+}
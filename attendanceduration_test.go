@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+// seedLoginLog inserts an open login session of userID and returns its ID
+func seedLoginLog(t *testing.T, a *App, userID int, pcNumber string, loginTime time.Time) int {
+	t.Helper()
+	res, err := a.pool.Exec(`INSERT INTO login_logs (user_id, pc_number, login_time) VALUES (?, ?, ?)`, userID, pcNumber, dbClock(loginTime))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return int(id)
+}
+
+// attendanceStayRow is the session, time out and measured stay of a row
+type attendanceStayRow struct {
+	LoginLogID int
+	TimeOut    string
+	Minutes    sql.NullInt64
+	Short      bool
+}
+
+// stayRow reads the session, time out and measured stay of a row
+func stayRow(t *testing.T, a *App, classID, studentID int, date string) attendanceStayRow {
+	t.Helper()
+	var row attendanceStayRow
+	var logID sql.NullInt64
+	var timeOut sql.NullString
+	err := a.pool.QueryRow(`SELECT login_log_id, time_out, minutes_present, short_stay FROM attendance WHERE class_id = ? AND student_user_id = ? AND date = ?`,
+		classID, studentID, date).Scan(&logID, &timeOut, &row.Minutes, &row.Short)
+	if err != nil {
+		t.Fatalf("attendance %d/%d/%s: %v", classID, studentID, date, err)
+	}
+	row.LoginLogID, row.TimeOut = int(logID.Int64), timeOut.String
+	return row
+}
+
+// seedStayClass sets up class 10 meeting on Mondays from 08:00 to 09:00 with
+// students 3 and 4, who must stay 45 minutes
+func seedStayClass(t *testing.T, a *App) {
+	t.Helper()
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	mustExec(t, a, `INSERT INTO class_attendance_policies (class_id, minimum_minutes) VALUES (10, 45)`)
+}
+
+func TestTimeOutMeasuresStay(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedStayClass(t, a)
+
+	// Early and overstaying minutes do not count
+	short := seedLoginLog(t, a, 3, "PC-03", at(monday, 7, 50))
+	long := seedLoginLog(t, a, 4, "PC-04", at(monday, 8, 5))
+	if err := a.mergeTap(nil, "auto_record", 10, 3, short, at(monday, 7, 50), "PC-03", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.mergeTap(nil, "auto_record", 10, 4, long, at(monday, 8, 5), "PC-04", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Whoever is signed in when a session ends did not record its time out
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.closeLoginLog(int64(short), 3, "logout", at(monday, 8, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.closeLoginLog(int64(long), 4, "logout", at(monday, 9, 20)); err != nil {
+		t.Fatal(err)
+	}
+
+	if row := stayRow(t, a, 10, 3, "2026-03-02"); row.TimeOut != "08:30:00" || row.Minutes.Int64 != 30 || !row.Short {
+		t.Errorf("short stay = %+v, want out at 08:30:00 after 30 minutes, flagged", row)
+	}
+	if row := stayRow(t, a, 10, 4, "2026-03-02"); row.TimeOut != "09:20:00" || row.Minutes.Int64 != 55 || row.Short {
+		t.Errorf("long stay = %+v, want out at 09:20:00 after 55 minutes", row)
+	}
+
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'record_time_out' AND actor_user_id IS NULL`); n != 2 {
+		t.Errorf("%d time outs recorded as the system, want 2", n)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'record_time_out' AND actor_user_id IS NOT NULL`); n != 0 {
+		t.Errorf("%d time outs attributed to a user", n)
+	}
+	if issues := integrityIssues(t, a); len(issues) != 0 {
+		t.Errorf("measured stays break the chain: %v", issues)
+	}
+}
+
+func TestReloginReopensStay(t *testing.T) {
+	a := newTestApp(t)
+	seedStayClass(t, a)
+
+	first := seedLoginLog(t, a, 3, "PC-03", at(monday, 7, 55))
+	if err := a.mergeTap(nil, "auto_record", 10, 3, first, at(monday, 7, 55), "PC-03", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A login while the first session is still open changes nothing
+	other := seedLoginLog(t, a, 3, "PC-09", at(monday, 8, 10))
+	if err := a.mergeTap(nil, "auto_record", 10, 3, other, at(monday, 8, 10), "PC-09", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	if timeIn, pc, _, _ := attendanceRow(t, a, 10, 3, "2026-03-02"); timeIn != "07:55:00" || pc != "PC-03" || stayRow(t, a, 10, 3, "2026-03-02").LoginLogID != first {
+		t.Errorf("second login replaced the first: %s %s", timeIn, pc)
+	}
+	// Its minutes overlap the first session's and count once
+	if err := a.closeLoginLog(int64(other), 3, "logout", at(monday, 8, 15)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.closeLoginLog(int64(first), 3, "logout", at(monday, 8, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if row := stayRow(t, a, 10, 3, "2026-03-02"); row.TimeOut != "08:20:00" || !row.Short {
+		t.Fatalf("first stay = %+v, want out at 08:20:00 and short", row)
+	}
+
+	// Signing in again after the time out continues the stay from the first time in,
+	// but the five minutes away do not count
+	again := seedLoginLog(t, a, 3, "PC-07", at(monday, 8, 25))
+	if err := a.mergeTap(nil, "auto_record", 10, 3, again, at(monday, 8, 25), "PC-07", "late", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	timeIn, pc, status, _ := attendanceRow(t, a, 10, 3, "2026-03-02")
+	row := stayRow(t, a, 10, 3, "2026-03-02")
+	if timeIn != "07:55:00" || pc != "PC-07" || status != "present" || row.LoginLogID != again || row.TimeOut != "" || row.Minutes.Valid || row.Short {
+		t.Fatalf("reopened stay = %s %s %s %+v, want in at 07:55:00 on PC-07, present, with no time out", timeIn, pc, status, row)
+	}
+
+	if err := a.closeLoginLog(int64(again), 3, "logout", at(monday, 9, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if row := stayRow(t, a, 10, 3, "2026-03-02"); row.TimeOut != "09:00:00" || row.Minutes.Int64 != 55 || row.Short {
+		t.Errorf("stay after relogin = %+v, want out at 09:00:00 after 55 minutes", row)
+	}
+}
+
+func TestLoginKeepsEarlierAndTeacherEntries(t *testing.T) {
+	a := newTestApp(t)
+	seedStayClass(t, a)
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status, remarks) VALUES (10, 4, '2026-03-02', 'excused', 'Medical')`)
+
+	// A replayed tap earlier than the live login becomes the time in
+	logID := seedLoginLog(t, a, 3, "PC-03", at(monday, 8, 15))
+	if err := a.mergeTap(nil, "auto_record", 10, 3, logID, at(monday, 8, 15), "PC-03", "late", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.mergeTap(nil, "record_login", 10, 3, 0, at(monday, 8, 2), "PC-01", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	if timeIn, pc, _, _ := attendanceRow(t, a, 10, 3, "2026-03-02"); timeIn != "08:02:00" || pc != "PC-01" {
+		t.Errorf("row = %s %s, want the earlier tap 08:02:00 PC-01", timeIn, pc)
+	}
+
+	logID = seedLoginLog(t, a, 4, "PC-04", at(monday, 8, 5))
+	if err := a.mergeTap(nil, "auto_record", 10, 4, logID, at(monday, 8, 5), "PC-04", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, status, remarks := attendanceRow(t, a, 10, 4, "2026-03-02"); status != "excused" || remarks != "Medical" {
+		t.Errorf("excused row = %s %q, want it kept", status, remarks)
+	}
+}
+
+func TestAutoRecordAttendanceOnLogin(t *testing.T) {
+	now := time.Now()
+	start := now.Add(-5 * time.Minute).Truncate(time.Minute)
+	end := start.Add(time.Hour)
+	if start.Day() != now.Day() || end.Day() != now.Day() {
+		t.Skip("a meeting around now would cross midnight")
+	}
+
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3)
+	seedClass(t, a, 11, 2, 3)
+	seedMeeting(t, a, 10, int(now.Weekday()), start.Format("15:04"), end.Format("15:04"))
+	seedMeeting(t, a, 11, int(now.Weekday()), start.Format("15:04"), end.Format("15:04"))
+	today := now.Format("2006-01-02")
+
+	// Only classes whose attendance was opened today are recorded
+	if err := a.openClassDay(10, today); err != nil {
+		t.Fatal(err)
+	}
+	logID := seedLoginLog(t, a, 3, "PC-03", now)
+	a.autoRecordAttendanceOnLogin(3, logID, "PC-03", true)
+
+	_, pc, status, remarks := attendanceRow(t, a, 10, 3, today)
+	if pc != "PC-03" || status != "present" || remarks != "Signed in from an unregistered PC" || stayRow(t, a, 10, 3, today).LoginLogID != logID {
+		t.Errorf("row = %s %s %q, want present on PC-03 tied to the login", pc, status, remarks)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM attendance WHERE class_id = 11`); n != 0 {
+		t.Error("attendance was recorded for a class not opened today")
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// ==============================================================================
+// ATTENDANCE POLICY
+// ==============================================================================
+//
+// The rules turning a login into attendance are the school defaults from the
+// ATTENDANCE_* settings, which a class may override one by one:
+//
+//   - a login counts towards a meeting from EarlyMinutes before it starts
+//     until it ends
+//   - it is present up to LateMinutes after the start, then late
+//   - after AbsentMinutes it is recorded, but as absent (0 keeps it late)
+//   - MinimumMinutes is how long the student must stay (0 sets no minimum)
+//
+// Auto-attendance at login, replayed offline logins and attendance generated
+// from the login logs all go through AttendancePolicy.arrival, so a login
+// gets the same status whichever path records it.
+
+const classPolicyAuditQuery = `
+	SELECT class_id, early_minutes, late_minutes, absent_minutes, minimum_minutes
+	FROM class_attendance_policies WHERE class_id = ?`
+
+// AttendancePolicyOverride holds the rules a class sets itself; nil fields use the school default
+type AttendancePolicyOverride struct {
+	EarlyMinutes   *int `json:"early_minutes"`
+	LateMinutes    *int `json:"late_minutes"`
+	AbsentMinutes  *int `json:"absent_minutes"`
+	MinimumMinutes *int `json:"minimum_minutes"`
+}
+
+// ClassAttendancePolicy is a class's overrides, the school defaults, and the rules they result in
+type ClassAttendancePolicy struct {
+	ClassID   int                      `json:"class_id"`
+	Overrides AttendancePolicyOverride `json:"overrides"`
+	Defaults  AttendancePolicy         `json:"defaults"`
+	Effective AttendancePolicy         `json:"effective"`
+}
+
+// empty reports whether the class overrides nothing
+func (o AttendancePolicyOverride) empty() bool {
+	return o.EarlyMinutes == nil && o.LateMinutes == nil && o.AbsentMinutes == nil && o.MinimumMinutes == nil
+}
+
+// with returns the policy with a class's overrides applied
+func (p AttendancePolicy) with(o AttendancePolicyOverride) AttendancePolicy {
+	if o.EarlyMinutes != nil {
+		p.EarlyMinutes = *o.EarlyMinutes
+	}
+	if o.LateMinutes != nil {
+		p.LateMinutes = *o.LateMinutes
+	}
+	if o.AbsentMinutes != nil {
+		p.AbsentMinutes = *o.AbsentMinutes
+	}
+	if o.MinimumMinutes != nil {
+		p.MinimumMinutes = *o.MinimumMinutes
+	}
+	return p
+}
+
+// validate checks that the rules agree with each other
+func (p AttendancePolicy) validate() error {
+	if p.EarlyMinutes < 0 || p.LateMinutes < 0 || p.AbsentMinutes < 0 || p.MinimumMinutes < 0 {
+		return fmt.Errorf("minutes cannot be negative")
+	}
+	if p.AbsentMinutes > 0 && p.AbsentMinutes <= p.LateMinutes {
+		return fmt.Errorf("absent after (%d minutes) must be later than late after (%d minutes)", p.AbsentMinutes, p.LateMinutes)
+	}
+	return nil
+}
+
+// window returns the minutes after midnight from which and until which a login
+// counts towards the meeting
+func (p AttendancePolicy) window(m ClassMeeting) (int, int, error) {
+	start, err := clockMinutes(m.StartTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := clockMinutes(m.EndTime)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start - p.EarlyMinutes, end, nil
+}
+
+// arrival returns the status of a login at t to the meeting, and how many
+// minutes after the start it came
+func (p AttendancePolicy) arrival(m ClassMeeting, t time.Time) (string, int) {
+	start, err := clockMinutes(m.StartTime)
+	if err != nil {
+		return "present", 0
+	}
+	after := t.Hour()*60 + t.Minute() - start
+	switch {
+	case after <= p.LateMinutes:
+		return "present", after
+	case p.AbsentMinutes > 0 && after > p.AbsentMinutes:
+		return "absent", after
+	}
+	return "late", after
+}
+
+// arrivalRemark is the remark recorded with a login: an arrival too late to
+// count, or one from an unregistered PC
+func arrivalRemark(status string, minutesLate int, unregisteredPC bool) sql.NullString {
+	remark := ""
+	if status == "absent" {
+		remark = fmt.Sprintf("Arrived %d minutes after the start", minutesLate)
+	}
+	if unregisteredPC {
+		if remark != "" {
+			remark += "; "
+		}
+		remark += "Signed in from an unregistered PC"
+	}
+	return nullString(remark)
+}
+
+// classPolicies returns the attendance rules of each class, the school defaults
+// with the class's overrides applied
+func (a *App) classPolicies(classIDs []int) (map[int]AttendancePolicy, error) {
+	overrides, err := a.store.Classes.AttendancePolicies(classIDs...)
+	if err != nil {
+		return nil, err
+	}
+	defaults := GetAttendancePolicy()
+	policies := make(map[int]AttendancePolicy, len(classIDs))
+	for _, classID := range classIDs {
+		policies[classID] = defaults.with(overrides[classID])
+	}
+	return policies, nil
+}
+
+// GetClassAttendancePolicy returns the attendance rules of a class and which of them it overrides
+func (a *App) GetClassAttendancePolicy(classID int) (ClassAttendancePolicy, error) {
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return ClassAttendancePolicy{}, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return ClassAttendancePolicy{}, err
+	}
+
+	overrides, err := a.store.Classes.AttendancePolicies(classID)
+	if err != nil {
+		return ClassAttendancePolicy{}, err
+	}
+	defaults := GetAttendancePolicy()
+	return ClassAttendancePolicy{
+		ClassID:   classID,
+		Overrides: overrides[classID],
+		Defaults:  defaults,
+		Effective: defaults.with(overrides[classID]),
+	}, nil
+}
+
+// SetClassAttendancePolicy sets the rules a class overrides; nil fields follow the school defaults
+func (a *App) SetClassAttendancePolicy(classID int, overrides AttendancePolicyOverride) error {
+	session, err := a.authorizeClassAttendance(classID)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+	if err := GetAttendancePolicy().with(overrides).validate(); err != nil {
+		return err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "update", "class_attendance_policy", strconv.Itoa(classID), classPolicyAuditQuery, []interface{}{classID}, func() error {
+			return a.txStore(tx).Classes.SetAttendancePolicy(classID, overrides, session.UserID)
+		})
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to set attendance policy of class %d: %v", classID, err)
+		return err
+	}
+
+	log.Printf("✓ Attendance policy of class %d updated by user %d", classID, session.UserID)
+	return nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import "testing"
+
+// minutes returns a pointer to n, for policy overrides
+func minutes(n int) *int {
+	return &n
+}
+
+func TestClassSessionStatus(t *testing.T) {
+	session := classSession{
+		ClassID: 10,
+		Meeting: ClassMeeting{Weekday: 1, StartTime: "08:00", EndTime: "09:00"},
+		Policy:  AttendancePolicy{EarlyMinutes: 30, LateMinutes: 10, AbsentMinutes: 30},
+	}
+	tests := []struct {
+		after  int
+		status string
+		remark string
+	}{
+		{-20, "present", ""},
+		{10, "present", ""},
+		{11, "late", ""},
+		{30, "late", ""},
+		{31, "absent", "Arrived 31 minutes after the start"},
+	}
+	for _, tt := range tests {
+		status, remark := session.status(at(monday, 8, tt.after), false)
+		if status != tt.status || remark.String != tt.remark {
+			t.Errorf("%d minutes after the start = %s %q, want %s %q", tt.after, status, remark.String, tt.status, tt.remark)
+		}
+	}
+
+	if _, remark := session.status(at(monday, 8, 0), true); remark.String != "Signed in from an unregistered PC" {
+		t.Errorf("unregistered PC remark = %q", remark.String)
+	}
+	if _, remark := session.status(at(monday, 8, 45), true); remark.String != "Arrived 45 minutes after the start; Signed in from an unregistered PC" {
+		t.Errorf("late unregistered PC remark = %q", remark.String)
+	}
+
+	// Without an absent cutoff a late login stays late
+	session.Policy.AbsentMinutes = 0
+	if status, _ := session.status(at(monday, 8, 50), false); status != "late" {
+		t.Errorf("status with no absent cutoff = %s, want late", status)
+	}
+}
+
+func TestClassAttendancePolicy(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "teacher2", "Teach#123", "teacher")
+	seedClass(t, a, 10, 2)
+	seedClass(t, a, 11, 3)
+	defaults := GetAttendancePolicy()
+
+	signInAs(t, a, 3, "teacher2", "teacher")
+	if err := a.SetClassAttendancePolicy(10, AttendancePolicyOverride{LateMinutes: minutes(5)}); err == nil {
+		t.Error("a teacher changed the policy of another teacher's class")
+	}
+
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.SetClassAttendancePolicy(10, AttendancePolicyOverride{LateMinutes: minutes(20), AbsentMinutes: minutes(15)}); err == nil {
+		t.Error("an absent cutoff before the late one was accepted")
+	}
+	err := a.SetClassAttendancePolicy(10, AttendancePolicyOverride{EarlyMinutes: minutes(60), LateMinutes: minutes(5), AbsentMinutes: minutes(20)})
+	if err != nil {
+		t.Fatalf("set policy: %v", err)
+	}
+
+	policy, err := a.GetClassAttendancePolicy(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AttendancePolicy{EarlyMinutes: 60, LateMinutes: 5, AbsentMinutes: 20, MinimumMinutes: defaults.MinimumMinutes}
+	if policy.Effective != want || policy.Defaults != defaults || policy.Overrides.MinimumMinutes != nil {
+		t.Errorf("policy = %+v, want effective %+v over %+v", policy, want, defaults)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'update' AND entity_type = 'class_attendance_policy' AND actor_user_id = 2`); n != 1 {
+		t.Errorf("%d policy audit entries, want 1", n)
+	}
+
+	policies, err := a.classPolicies([]int{10, 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policies[10] != want || policies[11] != defaults {
+		t.Errorf("class policies = %+v, want %+v and the defaults %+v", policies, want, defaults)
+	}
+
+	// The class's own window and cutoffs decide where and how a login counts
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	seedMeeting(t, a, 11, 1, "08:00", "09:00")
+	classes, err := a.classesInSession([]int{10, 11}, at(monday, 7, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(classes) != 1 || classes[0].ClassID != 10 {
+		t.Fatalf("classes in session 50 minutes early = %v, want only class 10", classes)
+	}
+	for after, status := range map[int]string{5: "present", 6: "late", 21: "absent"} {
+		if got, _ := classes[0].status(at(monday, 8, after), false); got != status {
+			t.Errorf("%d minutes after the start = %s, want %s", after, got, status)
+		}
+	}
+
+	// Clearing the overrides returns the class to the defaults
+	if err := a.SetClassAttendancePolicy(10, AttendancePolicyOverride{}); err != nil {
+		t.Fatal(err)
+	}
+	if policies, _ := a.classPolicies([]int{10}); policies[10] != defaults {
+		t.Errorf("cleared policy = %+v, want the defaults %+v", policies[10], defaults)
+	}
+}
+
+func TestUpdateSettingsValidatesAttendancePolicy(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	signInAs(t, a, 1, "admin", "admin")
+
+	if err := a.UpdateSettings(map[string]string{"ATTENDANCE_LATE_MINUTES": "15", "ATTENDANCE_ABSENT_MINUTES": "30"}); err != nil {
+		t.Fatalf("update settings: %v", err)
+	}
+	// Each value is valid alone, but absent would no longer come after late
+	for _, values := range []map[string]string{
+		{"ATTENDANCE_ABSENT_MINUTES": "15"},
+		{"ATTENDANCE_LATE_MINUTES": "40"},
+		{"ATTENDANCE_LATE_MINUTES": "20", "ATTENDANCE_ABSENT_MINUTES": "10"},
+	} {
+		if err := a.UpdateSettings(values); err == nil {
+			t.Errorf("%v was accepted with absent before late", values)
+		}
+	}
+	if policy := GetAttendancePolicy(); policy.LateMinutes != 15 || policy.AbsentMinutes != 30 {
+		t.Errorf("policy = %+v after rejected updates, want late 15 and absent 30", policy)
+	}
+
+	// Clearing the absent cutoff falls back to its default of none
+	if err := a.UpdateSettings(map[string]string{"ATTENDANCE_LATE_MINUTES": "40", "ATTENDANCE_ABSENT_MINUTES": ""}); err != nil {
+		t.Errorf("clearing the absent cutoff: %v", err)
+	}
+}
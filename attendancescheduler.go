@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ==============================================================================
+// ATTENDANCE SCHEDULER
+// ==============================================================================
+//
+// Every app instance checks the class schedules once a minute, so teachers no
+// longer initialize each day's attendance by hand:
+//
+//   - when the login window of a class's first meeting of the day opens, every
+//     enrolled student without a row gets the absent "Not yet logged in" one
+//   - when its last meeting ends, students still without a login are matched
+//     once more against the login logs, catching logins made before the rows
+//     existed, and the rest are finalized as absent
+//
+// Days left unfinalized because no app was running when a class ended are
+// finalized on the next check, going back attendanceCatchUpDays. A finalized
+// absence is still a placeholder: a login replayed from the offline queue later
+// marks the student present.
+//
+// Holidays, suspensions and days outside the term come from the academic
+// calendar and are skipped. Both steps only touch rows that are missing or
+// still placeholders, so several PCs running them agree and whatever a teacher
+// entered is kept. ATTENDANCE_AUTO_SCHEDULE=false turns the scheduler off.
+
+const attendanceSchedulerInterval = time.Minute
+
+// attendanceCatchUpDays is how many days back unfinalized attendance is finalized
+const attendanceCatchUpDays = 7
+
+// finalizedAbsentRemark replaces "Not yet logged in" once the class is over
+const finalizedAbsentRemark = "No login during class"
+
+// classDayWindow returns the minutes after midnight at which the login window of
+// the day's first meeting opens and its last meeting ends
+func classDayWindow(meetings []ClassMeeting, policy AttendancePolicy) (int, int, bool) {
+	opens, ends, ok := 0, 0, false
+	for _, m := range meetings {
+		from, until, err := policy.window(m)
+		if err != nil {
+			continue
+		}
+		if !ok || from < opens {
+			opens = from
+		}
+		if !ok || until > ends {
+			ends = until
+		}
+		ok = true
+	}
+	return opens, ends, ok
+}
+
+// scheduleAttendance opens the attendance of the active classes meeting on the
+// day of now, and finalizes it for them and for earlier days left unfinalized
+func (a *App) scheduleAttendance(now time.Time) error {
+	if a.requireDB() != nil {
+		return nil
+	}
+
+	classes, err := a.store.Classes.Active()
+	if err != nil {
+		return err
+	}
+	classIDs := make([]int, 0, len(classes))
+	for _, c := range classes {
+		classIDs = append(classIDs, c.ClassID)
+	}
+	if len(classIDs) == 0 {
+		return nil
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	firstDay := today.AddDate(0, 0, -attendanceCatchUpDays)
+	calendar, err := a.loadCalendar(classIDs, firstDay, today)
+	if err != nil {
+		return err
+	}
+	policies, err := a.classPolicies(classIDs)
+	if err != nil {
+		return err
+	}
+
+	date := now.Format("2006-01-02")
+	minute := now.Hour()*60 + now.Minute()
+	for _, classID := range classIDs {
+		opens, _, ok := classDayWindow(calendar.day(classID, today).Meetings, policies[classID])
+		if !ok || minute < opens {
+			continue
+		}
+		if err := a.openClassDay(classID, date); err != nil {
+			log.Printf("⚠ Failed to open attendance of class %d on %s: %v", classID, date, err)
+		}
+	}
+
+	unfinalized, err := a.store.Attendance.UnfinalizedDays(firstDay.Format("2006-01-02"), date)
+	if err != nil {
+		return err
+	}
+	for _, unf := range unfinalized {
+		policy, active := policies[unf.ClassID]
+		day, err := time.ParseInLocation("2006-01-02", unf.Date, now.Location())
+		if !active || err != nil {
+			continue
+		}
+		// A day the calendar no longer holds the class is left to the teacher
+		meetings := calendar.day(unf.ClassID, day).Meetings
+		_, ends, ok := classDayWindow(meetings, policy)
+		if !ok || (unf.Date == date && minute <= ends) {
+			continue
+		}
+		if err := a.finalizeClassDay(unf.ClassID, unf.Date, meetings, policy); err != nil {
+			log.Printf("⚠ Failed to finalize attendance of class %d on %s: %v", unf.ClassID, unf.Date, err)
+		}
+	}
+	return nil
+}
+
+// openClassDay creates the placeholder rows of the students of a class without
+// attendance on date. Nothing is written or audited when every student has one.
+func (a *App) openClassDay(classID int, date string) error {
+	missing, err := a.store.Attendance.Uninitialized(classID, date)
+	if err != nil || missing == 0 {
+		return err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := fmt.Sprintf("%d/*/%s", classID, date)
+		return a.auditAttendanceChangeAs(tx, nil, "auto_initialize", key, classAttendanceAuditQuery, []interface{}{classID, date}, func() error {
+			return a.txStore(tx).Attendance.Initialize(classID, date)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("📅 Attendance opened for class %d on %s (%d student(s))", classID, date, missing)
+	return nil
+}
+
+// finalizeClassDay records the logins of placeholder students that the login
+// logs show during the meetings, then finalizes the others as absent
+func (a *App) finalizeClassDay(classID int, date string, meetings []ClassMeeting, policy AttendancePolicy) error {
+	students, err := a.store.Attendance.Placeholders(classID, date)
+	if err != nil || len(students) == 0 {
+		return err
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return err
+	}
+
+	for _, studentID := range students {
+		for _, meeting := range meetings {
+			from, until, err := policy.window(meeting)
+			if err != nil {
+				continue
+			}
+			windowStart := day.Add(time.Duration(from) * time.Minute)
+			windowEnd := day.Add(time.Duration(until+1)*time.Minute - time.Second)
+			logID, loginTime, _, pcNumber, err := a.store.Logs.FirstLogin(studentID, windowStart, windowEnd)
+			if err != nil || !loginTime.Valid {
+				continue
+			}
+
+			class := classSession{ClassID: classID, Meeting: meeting, Policy: policy}
+			status, remark := class.status(loginTime.Time, false)
+			if err := a.mergeTap(nil, "auto_record", classID, studentID, logID, loginTime.Time, pcNumber.String, status, remark); err != nil {
+				return err
+			}
+			// The session may be over already
+			a.recordTimeOut(int64(logID))
+			break
+		}
+	}
+
+	var finalized int64
+	err = a.withTx(func(tx sqlExecutor) error {
+		key := fmt.Sprintf("%d/*/%s", classID, date)
+		return a.auditAttendanceChangeAs(tx, nil, "auto_finalize", key, classAttendanceAuditQuery, []interface{}{classID, date}, func() error {
+			var err error
+			finalized, err = a.txStore(tx).Attendance.FinalizeAbsent(classID, date, finalizedAbsentRemark)
+			return err
+		})
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("📅 Attendance finalized for class %d on %s (%d absent)", classID, date, finalized)
+	return nil
+}
+
+// openStudentClassDays opens the attendance of a student's classes in session at
+// t, so a login made before the scheduler's next check is still recorded
+func (a *App) openStudentClassDays(studentID int, t time.Time) {
+	classes, err := a.store.Classes.ForStudent(studentID)
+	if err != nil {
+		log.Printf("⚠ Failed to load classes of student %d: %v", studentID, err)
+		return
+	}
+	classIDs := make([]int, 0, len(classes))
+	for _, c := range classes {
+		classIDs = append(classIDs, c.ClassID)
+	}
+	inSession, err := a.classesInSession(classIDs, t)
+	if err != nil {
+		log.Printf("⚠ Failed to load class meetings of student %d: %v", studentID, err)
+		return
+	}
+	for _, class := range inSession {
+		if err := a.openClassDay(class.ClassID, t.Format("2006-01-02")); err != nil {
+			log.Printf("⚠ Failed to open attendance of class %d: %v", class.ClassID, err)
+		}
+	}
+}
+
+// runAttendanceScheduler opens and finalizes class days every minute until ctx is done
+func (a *App) runAttendanceScheduler(ctx context.Context) {
+	ticker := time.NewTicker(attendanceSchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		if GetAttendanceAutoSchedule() {
+			if err := a.scheduleAttendance(time.Now()); err != nil {
+				log.Printf("⚠ Attendance scheduler failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
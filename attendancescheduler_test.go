@@ -0,0 +1,173 @@
+package main
+
+import "testing"
+
+// placeholderCount counts the rows of a class on date still waiting for a login
+func placeholderCount(t *testing.T, a *App, classID int, date string) int {
+	t.Helper()
+	return queryInt(t, a, `SELECT COUNT(*) FROM attendance WHERE class_id = ? AND date = ? AND remarks = 'Not yet logged in'`, classID, date)
+}
+
+func TestScheduleAttendanceOpensAndFinalizes(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedUser(t, a, 5, "student3", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4, 5)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	early := GetAttendancePolicy().EarlyMinutes
+
+	if err := a.scheduleAttendance(at(monday, 8, -early-1)); err != nil {
+		t.Fatal(err)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM attendance`); n != 0 {
+		t.Fatalf("%d rows before the login window opened", n)
+	}
+	if err := a.scheduleAttendance(at(monday, 8, -early)); err != nil {
+		t.Fatal(err)
+	}
+	if n := placeholderCount(t, a, 10, "2026-03-02"); n != 3 {
+		t.Fatalf("%d placeholders when the window opened, want 3", n)
+	}
+
+	// Student 3's login is recorded live; student 4's session is in the logs only
+	logID := seedLoginLog(t, a, 3, "PC-03", at(monday, 8, 5))
+	class := classSession{ClassID: 10, Meeting: ClassMeeting{Weekday: 1, StartTime: "08:00", EndTime: "09:00"}, Policy: GetAttendancePolicy()}
+	status, remark := class.status(at(monday, 8, 5), false)
+	if err := a.mergeTap(nil, "auto_record", 10, 3, logID, at(monday, 8, 5), "PC-03", status, remark); err != nil {
+		t.Fatal(err)
+	}
+	seedLoginLog(t, a, 4, "PC-04", at(monday, 8, 20))
+
+	if err := a.scheduleAttendance(at(monday, 9, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if n := placeholderCount(t, a, 10, "2026-03-02"); n != 2 {
+		t.Fatalf("%d placeholders left before the class ended, want 2", n)
+	}
+	if err := a.scheduleAttendance(at(monday, 9, 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		studentID      int
+		timeIn, status string
+		remarks        string
+	}{
+		{3, "08:05:00", "present", ""},
+		{4, "08:20:00", "late", ""},
+		{5, "", "absent", finalizedAbsentRemark},
+	}
+	for _, tt := range tests {
+		if timeIn, _, status, remarks := attendanceRow(t, a, 10, tt.studentID, "2026-03-02"); timeIn != tt.timeIn || status != tt.status || remarks != tt.remarks {
+			t.Errorf("student %d = %q %s %q, want %q %s %q", tt.studentID, timeIn, status, remarks, tt.timeIn, tt.status, tt.remarks)
+		}
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'auto_finalize' AND actor_user_id IS NULL`); n != 1 {
+		t.Errorf("%d finalize audit entries, want 1", n)
+	}
+
+	// A tap made offline during class still counts once it reaches the server,
+	// as late as it was made
+	queueTap(t, a, 10, 5, "PC-05", at(monday, 8, 40))
+	replay(t, a)
+	if timeIn, pc, status, remarks := attendanceRow(t, a, 10, 5, "2026-03-02"); timeIn != "08:40:00" || pc != "PC-05" || status != "late" || remarks != "" {
+		t.Errorf("replayed tap after finalizing = %q %s %s %q, want late from 08:40:00", timeIn, pc, status, remarks)
+	}
+}
+
+func TestScheduleAttendanceCatchesUp(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	seedMeeting(t, a, 10, 3, "08:00", "09:00")
+
+	// Opened, but no app ran when these classes ended
+	for _, day := range []string{"2026-02-23", "2026-03-02", "2026-03-04"} {
+		if err := a.openClassDay(10, day); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The Wednesday was declared a holiday once its rows existed
+	mustExec(t, a, `INSERT INTO calendar_events (event_type, start_date, end_date, description) VALUES ('holiday', '2026-03-04', '2026-03-04', 'Founding Day')`)
+	// The student's login on the Monday is in the logs
+	seedLoginLog(t, a, 3, "PC-03", at(monday, 7, 58))
+
+	// Checking on the Sunday goes back to 1 March
+	if err := a.scheduleAttendance(at(monday.AddDate(0, 0, 6), 6, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if timeIn, _, status, _ := attendanceRow(t, a, 10, 3, "2026-03-02"); timeIn != "07:58:00" || status != "present" {
+		t.Errorf("missed day = %q %s, want finalized with the login at 07:58:00", timeIn, status)
+	}
+	if n := placeholderCount(t, a, 10, "2026-03-04"); n != 1 {
+		t.Error("a day the calendar no longer holds was finalized")
+	}
+	if n := placeholderCount(t, a, 10, "2026-02-23"); n != 1 {
+		t.Error("a day past the catch-up days was finalized")
+	}
+}
+
+func TestGenerateAttendanceFromLogs(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedUser(t, a, 5, "student3", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4, 5)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	seedMeeting(t, a, 10, 1, "13:00", "14:00")
+
+	// Student 3 attends the morning meeting, student 4 only the afternoon one
+	morning := seedLoginLog(t, a, 3, "PC-03", at(monday, 8, 5))
+	afternoon := seedLoginLog(t, a, 4, "PC-04", at(monday, 13, 20))
+	if err := a.closeLoginLog(int64(morning), 3, "logout", at(monday, 8, 55)); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.closeLoginLog(int64(afternoon), 4, "logout", at(monday, 13, 50)); err != nil {
+		t.Fatal(err)
+	}
+
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.GenerateAttendanceFromLogs(10, "2026-03-02", 2); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	tests := []struct {
+		studentID      int
+		timeIn, status string
+	}{
+		{3, "08:05:00", "present"},
+		{4, "13:20:00", "late"},
+		{5, "", "absent"},
+	}
+	for _, tt := range tests {
+		if timeIn, _, status, _ := attendanceRow(t, a, 10, tt.studentID, "2026-03-02"); timeIn != tt.timeIn || status != tt.status {
+			t.Errorf("student %d = %q %s, want %q %s", tt.studentID, timeIn, status, tt.timeIn, tt.status)
+		}
+	}
+}
+
+func TestGenerateAttendanceFromLogsRollsBack(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	mustExec(t, a, `CREATE TRIGGER block_student2 BEFORE INSERT ON attendance WHEN NEW.student_user_id = 4 BEGIN SELECT RAISE(ABORT, 'blocked'); END`)
+
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.GenerateAttendanceFromLogs(10, "2026-03-02", 2); err == nil {
+		t.Fatal("generation succeeded although a student's row failed")
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM attendance`); n != 0 {
+		t.Errorf("%d rows kept from a failed generation", n)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'generate_from_logs'`); n != 0 {
+		t.Errorf("%d audit entries for a failed generation", n)
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// ==============================================================================
+// AUDIT TRAIL
+// ==============================================================================
+//
+// Every administrative mutation writes an audit_log row inside the same
+// transaction as the change itself, so the change and its record either both
+// commit or both roll back. Rows are never updated or deleted by the app.
+
+// sqlExecutor runs statements on the pool or inside a transaction, under a deadline
+type sqlExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*dbRows, error)
+	QueryRow(query string, args ...interface{}) *dbRow
+}
+
+// Snapshot queries used for the before/after state of audited entities.
+// Password hashes and profile photos are deliberately left out.
+const (
+	// attendanceSnapshotColumns are the attendance columns audited and chained.
+	// The login session and stay columns joined them in 0012; see chainColumnsSince.
+	attendanceSnapshotColumns = `class_id, student_user_id, date, time_in, time_out, pc_number, login_log_id, status, remarks, minutes_present, short_stay`
+
+	userAuditQuery = `
+		SELECT u.id, u.username, u.user_type, u.is_active, u.must_change_password, u.account_locked_until,
+			COALESCE(ad.employee_number, t.employee_number) AS employee_number, s.student_number,
+			COALESCE(ad.first_name, t.first_name, s.first_name) AS first_name,
+			COALESCE(ad.middle_name, t.middle_name, s.middle_name) AS middle_name,
+			COALESCE(ad.last_name, t.last_name, s.last_name) AS last_name,
+			COALESCE(ad.email, t.email, s.email) AS email,
+			COALESCE(ad.contact_number, t.contact_number, s.contact_number) AS contact_number,
+			t.department_code
+		FROM users u
+		LEFT JOIN admins ad ON ad.user_id = u.id
+		LEFT JOIN teachers t ON t.user_id = u.id
+		LEFT JOIN students s ON s.user_id = u.id
+		WHERE u.id = ?`
+	departmentAuditQuery = `SELECT department_code, department_name, description, is_active FROM departments WHERE department_code = ?`
+	subjectAuditQuery    = `SELECT subject_code, subject_name, description FROM subjects WHERE subject_code = ?`
+	classAuditQuery      = `
+		SELECT class_id, subject_code, teacher_user_id, offering_code, schedule, room, year_level, section,
+			semester, school_year, is_active, created_by_user_id
+		FROM classes WHERE class_id = ?`
+	enrollmentAuditQuery = `SELECT class_id, student_user_id, status FROM classlist WHERE class_id = ? AND student_user_id = ?`
+	attendanceAuditQuery = `
+		SELECT ` + attendanceSnapshotColumns + `
+		FROM attendance WHERE class_id = ? AND student_user_id = ? AND date = ?`
+	classAttendanceAuditQuery = `
+		SELECT ` + attendanceSnapshotColumns + `
+		FROM attendance WHERE class_id = ? AND date = ? ORDER BY student_user_id`
+	feedbackAuditQuery = `SELECT id, status, forwarded_by_user_id, forwarded_at, working_student_notes FROM feedback WHERE id = ?`
+)
+
+// withTx runs fn inside a transaction, committing only if fn succeeds.
+// The whole transaction shares one deadline.
+func (a *App) withTx(fn func(tx sqlExecutor) error) error {
+	timeout := time.Duration(GetDBConfig().TransactionTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(a.lifetimeContext(), timeout)
+	defer cancel()
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return deadlineError(ctx, err)
+	}
+	if err := fn(dbExecutor{q: tx, ctx: ctx}); err != nil {
+		tx.Rollback()
+		return deadlineError(ctx, err)
+	}
+	return deadlineError(ctx, tx.Commit())
+}
+
+// auditSnapshot loads the rows matched by query for a before/after record.
+// It returns nil for no rows, a single column map for one row, or a list of maps.
+func auditSnapshot(q sqlExecutor, query string, args ...interface{}) (interface{}, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to snapshot audit state: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		record := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			switch v := values[i].(type) {
+			case []byte:
+				record[column] = string(v)
+			case time.Time:
+				record[column] = v.Format("2006-01-02 15:04:05")
+			default:
+				record[column] = v
+			}
+		}
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	switch len(records) {
+	case 0:
+		return nil, nil
+	case 1:
+		return records[0], nil
+	default:
+		return records, nil
+	}
+}
+
+// auditChange snapshots the rows selected by snapshotQuery, runs mutate, snapshots
+// them again and records both states. All statements run on tx.
+func (a *App) auditChange(tx sqlExecutor, action, entityType, entityKey, snapshotQuery string, snapshotArgs []interface{}, mutate func() error) error {
+	before, err := auditSnapshot(tx, snapshotQuery, snapshotArgs...)
+	if err != nil {
+		return err
+	}
+	if err := mutate(); err != nil {
+		return err
+	}
+	after, err := auditSnapshot(tx, snapshotQuery, snapshotArgs...)
+	if err != nil {
+		return err
+	}
+	return a.recordAudit(tx, action, entityType, entityKey, before, after)
+}
+
+// currentDBDate returns the database server's current date as YYYY-MM-DD
+func currentDBDate(q sqlExecutor, d sqlDialect) (string, error) {
+	var today dbTime
+	if err := q.QueryRow(`SELECT ` + d.today()).Scan(&today); err != nil {
+		return "", err
+	}
+	return today.Format("2006-01-02"), nil
+}
+
+// attendanceKey is the audit entity key of an attendance row
+func attendanceKey(classID, studentUserID int, date string) string {
+	return fmt.Sprintf("%d/%d/%s", classID, studentUserID, date)
+}
+
+// recordAudit writes an audit_log row for the signed-in user.
+// Pass the transaction of the mutation so both commit together.
+func (a *App) recordAudit(q sqlExecutor, action, entityType, entityKey string, before, after interface{}) error {
+	return recordAuditAs(q, a.currentSession(), action, entityType, entityKey, before, after)
+}
+
+// recordAuditAs writes an audit_log row attributed to actor, which may be nil.
+// Changes replayed from the offline queue are attributed to the user who made them.
+func recordAuditAs(q sqlExecutor, actor *Session, action, entityType, entityKey string, before, after interface{}) error {
+	var actorID sql.NullInt64
+	var actorName sql.NullString
+	if actor != nil {
+		actorID = sql.NullInt64{Int64: int64(actor.UserID), Valid: true}
+		actorName = sql.NullString{String: actor.Username, Valid: true}
+	}
+
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "Unknown"
+	}
+
+	query := `
+		INSERT INTO audit_log (actor_user_id, actor_username, action, entity_type, entity_key, before_data, after_data, pc_hostname)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = q.Exec(query, actorID, actorName, action, entityType, entityKey, beforeJSON, afterJSON, hostname)
+	if err != nil {
+		log.Printf("❌ Failed to write audit log (%s %s %s): %v", action, entityType, entityKey, err)
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// auditJSON encodes a snapshot for a JSON column, keeping NULL for nil
+func auditJSON(v interface{}) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to encode audit data: %w", err)
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
+
+// AuditEntry represents an audit_log row
+type AuditEntry struct {
+	ID            int     `json:"id"`
+	ActorUserID   *int    `json:"actor_user_id"`
+	ActorUsername *string `json:"actor_username"`
+	Action        string  `json:"action"`
+	EntityType    string  `json:"entity_type"`
+	EntityKey     string  `json:"entity_key"`
+	Before        *string `json:"before"`
+	After         *string `json:"after"`
+	PCHostname    *string `json:"pc_hostname"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// AuditLogFilter narrows GetAuditLog results. Empty fields are ignored.
+type AuditLogFilter struct {
+	ActorUserID int    `json:"actor_user_id"`
+	Action      string `json:"action"`
+	EntityType  string `json:"entity_type"`
+	EntityKey   string `json:"entity_key"` // Prefix match, e.g. "12/" for every attendance row of class 12
+	DateFrom    string `json:"date_from"`  // YYYY-MM-DD, inclusive
+	DateTo      string `json:"date_to"`    // YYYY-MM-DD, inclusive
+	Limit       int    `json:"limit"`
+}
+
+// GetAuditLog returns audit entries matching the filter, newest first
+func (a *App) GetAuditLog(filter AuditLogFilter) ([]AuditEntry, error) {
+	if _, err := a.authorize(PermViewAuditLog); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	return a.queryAuditLog(filter)
+}
+
+// queryAuditLog runs the filtered audit query without an authorization check
+func (a *App) queryAuditLog(filter AuditLogFilter) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorUserID > 0 {
+		conditions = append(conditions, "actor_user_id = ?")
+		args = append(args, filter.ActorUserID)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityKey != "" {
+		conditions = append(conditions, "entity_key LIKE ?"+a.dialect.likeEscape())
+		args = append(args, strings.NewReplacer("%", `\%`, "_", `\_`).Replace(filter.EntityKey)+"%")
+	}
+	if filter.DateFrom != "" {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.DateFrom)
+	}
+	if filter.DateTo != "" {
+		conditions = append(conditions, "created_at < "+a.dialect.dateAdd("?", "1", "day"))
+		args = append(args, filter.DateTo)
+	}
+
+	query := `
+		SELECT id, actor_user_id, actor_username, action, entity_type, entity_key, before_data, after_data, pc_hostname, created_at
+		FROM audit_log
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 5000 {
+		limit = 1000
+	}
+	query += " LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := a.pool.Query(query, args...)
+	if err != nil {
+		log.Printf("⚠ Failed to query audit log: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		var actorID sql.NullInt64
+		var actorName, before, after, hostname sql.NullString
+		var createdAt time.Time
+
+		err := rows.Scan(&entry.ID, &actorID, &actorName, &entry.Action, &entry.EntityType, &entry.EntityKey,
+			&before, &after, &hostname, &createdAt)
+		if err != nil {
+			log.Printf("⚠ Failed to scan audit row: %v", err)
+			continue
+		}
+
+		if actorID.Valid {
+			id := int(actorID.Int64)
+			entry.ActorUserID = &id
+		}
+		if actorName.Valid {
+			entry.ActorUsername = &actorName.String
+		}
+		if before.Valid {
+			entry.Before = &before.String
+		}
+		if after.Valid {
+			entry.After = &after.String
+		}
+		if hostname.Valid {
+			entry.PCHostname = &hostname.String
+		}
+		entry.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ExportAuditLogCSV exports the filtered audit log to CSV, including the full before/after data
+func (a *App) ExportAuditLogCSV(filter AuditLogFilter) (string, error) {
+	if _, err := a.authorize(PermViewAuditLog); err != nil {
+		return "", err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+
+	entries, err := a.queryAuditLog(filter)
+	if err != nil {
+		return "", err
+	}
+
+	filename := exportPath(fmt.Sprintf("audit_log_%s.csv", time.Now().Format("20060102_150405")))
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	writer.Write([]string{"ID", "Timestamp", "Actor ID", "Actor", "Action", "Entity Type", "Entity Key", "PC Hostname", "Before", "After"})
+
+	for _, entry := range entries {
+		writer.Write([]string{
+			strconv.Itoa(entry.ID),
+			entry.CreatedAt,
+			optionalInt(entry.ActorUserID),
+			optionalString(entry.ActorUsername),
+			entry.Action,
+			entry.EntityType,
+			entry.EntityKey,
+			optionalString(entry.PCHostname),
+			optionalString(entry.Before),
+			optionalString(entry.After),
+		})
+	}
+
+	return filename, nil
+}
+
+// ExportAuditLogPDF exports the filtered audit log to PDF.
+// Before/after data is summarised as the changed fields to keep rows readable.
+func (a *App) ExportAuditLogPDF(filter AuditLogFilter) (string, error) {
+	if _, err := a.authorize(PermViewAuditLog); err != nil {
+		return "", err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+
+	entries, err := a.queryAuditLog(filter)
+	if err != nil {
+		return "", err
+	}
+
+	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Audit Log Report")
+	pdf.Ln(12)
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.Cell(35, 7, "Timestamp")
+	pdf.Cell(30, 7, "Actor")
+	pdf.Cell(28, 7, "Action")
+	pdf.Cell(22, 7, "Entity")
+	pdf.Cell(35, 7, "Key")
+	pdf.Cell(30, 7, "PC")
+	pdf.Cell(97, 7, "Changes")
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	for _, entry := range entries {
+		pdf.Cell(35, 6, entry.CreatedAt)
+		pdf.Cell(30, 6, optionalString(entry.ActorUsername))
+		pdf.Cell(28, 6, entry.Action)
+		pdf.Cell(22, 6, entry.EntityType)
+		pdf.Cell(35, 6, entry.EntityKey)
+		pdf.Cell(30, 6, optionalString(entry.PCHostname))
+		pdf.MultiCell(97, 6, summarizeAuditChange(entry.Before, entry.After), "", "L", false)
+	}
+
+	filename := exportPath(fmt.Sprintf("audit_log_%s.pdf", time.Now().Format("20060102_150405")))
+	err = pdf.OutputFileAndClose(filename)
+	return filename, err
+}
+
+// summarizeAuditChange lists the fields that differ between two single-row snapshots
+func summarizeAuditChange(before, after *string) string {
+	var b, a map[string]interface{}
+	if before != nil {
+		json.Unmarshal([]byte(*before), &b)
+	}
+	if after != nil {
+		json.Unmarshal([]byte(*after), &a)
+	}
+
+	switch {
+	case b == nil && a == nil:
+		if before != nil || after != nil {
+			return "multiple rows (see CSV export)"
+		}
+		return ""
+	case b == nil:
+		return "created"
+	case a == nil:
+		return "removed"
+	}
+
+	var changes []string
+	for key, newValue := range a {
+		if key == "updated_at" {
+			continue
+		}
+		oldValue := b[key]
+		if fmt.Sprint(oldValue) != fmt.Sprint(newValue) {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", key, auditValue(oldValue), auditValue(newValue)))
+		}
+	}
+	if len(changes) == 0 {
+		return "no field changes"
+	}
+	sort.Strings(changes)
+	return strings.Join(changes, "; ")
+}
+
+// auditValue renders a snapshot value for the PDF summary
+func auditValue(v interface{}) string {
+	if v == nil {
+		return "(empty)"
+	}
+	return fmt.Sprint(v)
+}
+
+// optionalString dereferences an optional string for export
+func optionalString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// optionalInt formats an optional int for export
+func optionalInt(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
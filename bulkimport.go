@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ==============================================================================
+// BULK IMPORT
+// ==============================================================================
+//
+// A student list is imported in one transaction, each row under its own
+// savepoint so a row that fails is undone without touching the others. In
+// atomic mode a single failed row rolls the whole list back; in per-row mode
+// the rows that succeeded are kept. Either way the result lists the outcome
+// of every row.
+//
+// Passwords are hashed before the transaction opens so a long list does not
+// hold it past the transaction deadline.
+
+// Bulk import modes
+const (
+	ImportAtomic = "atomic"  // All rows or none
+	ImportPerRow = "per_row" // Keep the rows that succeed
+)
+
+// Row outcomes of a bulk import
+const (
+	RowCreated    = "created"
+	RowFailed     = "failed"
+	RowRolledBack = "rolled_back" // Valid, but undone because another row failed
+)
+
+// errImportRolledBack rolls back an atomic import with failed rows
+var errImportRolledBack = errors.New("import rolled back")
+
+// BulkImportRow is the outcome of one row of a bulk import
+type BulkImportRow struct {
+	Row         int    `json:"row"`
+	StudentCode string `json:"student_code"`
+	Status      string `json:"status"`
+	UserID      int64  `json:"user_id,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// bulkStudentRow is a parsed row waiting to be imported
+type bulkStudentRow struct {
+	Row     int
+	Student BulkStudentData
+	Problem string // Set when the row failed validation
+}
+
+// missingField describes the first required field left empty, if any
+func (s BulkStudentData) missingField() string {
+	switch {
+	case s.StudentCode == "":
+		return "Student Code is required"
+	case s.FirstName == "":
+		return "First Name is required"
+	case s.LastName == "":
+		return "Last Name is required"
+	}
+	return ""
+}
+
+// importStudents creates a student account for every valid row
+func (a *App) importStudents(rows []bulkStudentRow, mode string) (map[string]interface{}, error) {
+	if mode == "" {
+		mode = ImportPerRow
+	}
+	if mode != ImportAtomic && mode != ImportPerRow {
+		return nil, fmt.Errorf("unknown import mode %q", mode)
+	}
+
+	outcomes := make([]BulkImportRow, len(rows))
+	accounts := make([]*userAccount, len(rows))
+	seen := make(map[string]int)
+	for i, r := range rows {
+		outcomes[i] = BulkImportRow{Row: r.Row, StudentCode: r.Student.StudentCode}
+		if r.Problem != "" {
+			outcomes[i].Status, outcomes[i].Error = RowFailed, r.Problem
+			continue
+		}
+		if first, ok := seen[r.Student.StudentCode]; ok {
+			outcomes[i].Status = RowFailed
+			outcomes[i].Error = fmt.Sprintf("Student Code %s is already on row %d", r.Student.StudentCode, first)
+			continue
+		}
+		seen[r.Student.StudentCode] = r.Row
+
+		// Use student code as password (default password)
+		hashedPassword, err := HashPassword(r.Student.StudentCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		accounts[i] = &userAccount{
+			Username:      r.Student.StudentCode,
+			Password:      hashedPassword,
+			Role:          "student",
+			FirstName:     r.Student.FirstName,
+			MiddleName:    r.Student.MiddleName,
+			LastName:      r.Student.LastName,
+			StudentID:     r.Student.StudentCode,
+			Email:         r.Student.Email,
+			ContactNumber: r.Student.ContactNumber,
+		}
+	}
+
+	failed := 0
+	for i := range outcomes {
+		if accounts[i] == nil {
+			failed++
+		}
+	}
+
+	if mode != ImportAtomic || failed == 0 {
+		err := a.withTx(func(tx sqlExecutor) error {
+			for i, account := range accounts {
+				if account == nil {
+					continue
+				}
+				if _, err := tx.Exec(`SAVEPOINT import_row`); err != nil {
+					return err
+				}
+				id, err := a.createUserAccount(tx, *account)
+				if err != nil {
+					if _, err := tx.Exec(`ROLLBACK TO SAVEPOINT import_row`); err != nil {
+						return err
+					}
+					outcomes[i].Status, outcomes[i].Error = RowFailed, err.Error()
+					failed++
+					log.Printf("❌ Failed to create student at row %d: %v", outcomes[i].Row, err)
+				} else {
+					outcomes[i].Status = RowCreated
+					outcomes[i].UserID = id
+				}
+				if _, err := tx.Exec(`RELEASE SAVEPOINT import_row`); err != nil {
+					return err
+				}
+			}
+			if mode == ImportAtomic && failed > 0 {
+				return errImportRolledBack
+			}
+			return nil
+		})
+		if err != nil && err != errImportRolledBack {
+			return nil, fmt.Errorf("import failed, no students were created: %w", err)
+		}
+	}
+
+	committed := mode != ImportAtomic || failed == 0
+	var created int
+	var problems []string
+	for i, outcome := range outcomes {
+		switch {
+		case outcome.Status == RowCreated && committed:
+			created++
+			log.Printf("✅ Created student: %s %s (Code: %s)", rows[i].Student.FirstName, rows[i].Student.LastName, outcome.StudentCode)
+		case outcome.Status == RowFailed:
+			if outcome.StudentCode == "" {
+				problems = append(problems, fmt.Sprintf("Row %d: %s", outcome.Row, outcome.Error))
+			} else {
+				problems = append(problems, fmt.Sprintf("Row %d (%s): %s", outcome.Row, outcome.StudentCode, outcome.Error))
+			}
+		default:
+			outcomes[i].Status = RowRolledBack
+			outcomes[i].UserID = 0
+		}
+	}
+	if !committed {
+		log.Printf("⚠ Atomic import rolled back: %d of %d rows failed", failed, len(rows))
+	}
+
+	return map[string]interface{}{
+		"mode":          mode,
+		"committed":     committed,
+		"success_count": created,
+		"error_count":   failed,
+		"total_count":   len(rows),
+		"errors":        problems,
+		"rows":          outcomes,
+	}, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import "testing"
+
+// importList imports parsed students, numbering the rows after a header row
+func importList(t *testing.T, a *App, mode string, students ...BulkStudentData) map[string]interface{} {
+	t.Helper()
+	rows := make([]bulkStudentRow, len(students))
+	for i, s := range students {
+		rows[i] = bulkStudentRow{Row: i + 2, Student: s, Problem: s.missingField()}
+	}
+	result, err := a.importStudents(rows, mode)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	return result
+}
+
+// rowStatuses lists the outcome of each imported row
+func rowStatuses(result map[string]interface{}) []string {
+	var statuses []string
+	for _, row := range result["rows"].([]BulkImportRow) {
+		statuses = append(statuses, row.Status)
+	}
+	return statuses
+}
+
+// mixedList has two good rows, one missing a name, one repeating a code and
+// one the database refuses once 2024-009 is registered
+var mixedList = []BulkStudentData{
+	{StudentCode: "2024-001", FirstName: "Ana", LastName: "Santos"},
+	{StudentCode: "2024-002", FirstName: "Ben", LastName: "Reyes"},
+	{StudentCode: "2024-003", LastName: "Cruz"},
+	{StudentCode: "2024-002", FirstName: "Cara", LastName: "Lim"},
+	{StudentCode: "2024-009", FirstName: "Dan", LastName: "Tan"},
+}
+
+func TestImportStudentsPerRow(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	// Already registered, so the database refuses the row
+	seedUser(t, a, 5, "2024-009", "Study#123", "student")
+
+	signInAs(t, a, 1, "admin", "admin")
+	result := importList(t, a, ImportPerRow, mixedList...)
+	want := []string{RowCreated, RowCreated, RowFailed, RowFailed, RowFailed}
+	if got := rowStatuses(result); !equalStrings(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if result["committed"] != true || result["success_count"] != 2 || result["error_count"] != 3 {
+		t.Errorf("result = %v", result)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM students`); n != 3 {
+		t.Errorf("%d students, want the 2 imported and the one before", n)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'create' AND entity_type = 'user'`); n != 2 {
+		t.Errorf("%d create audit entries, want 2", n)
+	}
+
+	// The student code is the first password
+	if _, err := a.Login("2024-001", "2024-001"); err != nil {
+		t.Errorf("imported student cannot sign in: %v", err)
+	}
+}
+
+func TestImportStudentsAtomic(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 5, "2024-009", "Study#123", "student")
+
+	signInAs(t, a, 1, "admin", "admin")
+	// Rows failing validation stop the import before the database is touched
+	result := importList(t, a, ImportAtomic, mixedList...)
+	want := []string{RowRolledBack, RowRolledBack, RowFailed, RowFailed, RowRolledBack}
+	if got := rowStatuses(result); !equalStrings(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if result["committed"] != false || result["success_count"] != 0 {
+		t.Errorf("result = %v", result)
+	}
+
+	// A row the database refuses undoes the rows created before it
+	result = importList(t, a, ImportAtomic, mixedList[0], mixedList[1], mixedList[4])
+	want = []string{RowRolledBack, RowRolledBack, RowFailed}
+	if got := rowStatuses(result); !equalStrings(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM users WHERE user_type = 'student'`); n != 1 {
+		t.Errorf("%d students, want only the one before", n)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log`); n != 0 {
+		t.Errorf("%d audit entries left by a rolled back import", n)
+	}
+
+	// Without failures the whole list goes in
+	result = importList(t, a, ImportAtomic, mixedList[:2]...)
+	if result["committed"] != true || result["success_count"] != 2 {
+		t.Errorf("result = %v", result)
+	}
+}
+
+func TestCreateUsersBulkCSV(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	csv := "Student Code,First Name,Last Name,Middle Name\n2024-001,Ana,Santos,Cruz\n2024-002,Ben\n"
+
+	signInAs(t, a, 3, "student1", "student")
+	if _, err := a.CreateUsersBulk(csv); err == nil {
+		t.Error("a student imported a student list")
+	}
+
+	signInAs(t, a, 1, "admin", "admin")
+	if _, err := a.CreateUsersBulk(csv); err == nil {
+		t.Fatal("a CSV with a short row was read")
+	}
+	csv = "Student Code,First Name,Last Name,Middle Name\n2024-001,Ana,Santos,Cruz\n2024-002,Ben,,\n"
+	result, err := a.CreateUsersBulk(csv)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if got := rowStatuses(result); !equalStrings(got, []string{RowCreated, RowFailed}) {
+		t.Errorf("rows = %v", got)
+	}
+	var middle string
+	a.pool.QueryRow(`SELECT middle_name FROM students WHERE student_number = '2024-001'`).Scan(&middle)
+	if middle != "Cruz" {
+		t.Errorf("middle name = %q, want Cruz", middle)
+	}
+
+	if _, err := a.importStudents(nil, "all_or_nothing"); err == nil {
+		t.Error("an unknown import mode was accepted")
+	}
+}
+
+// equalStrings reports whether two string slices hold the same values in order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
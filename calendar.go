@@ -0,0 +1,534 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// ACADEMIC CALENDAR
+// ==============================================================================
+//
+// Whether a class is held on a date depends on more than its weekly meetings.
+// An academic term gives the first and last class day of a semester; a class
+// has no class days outside the term matching its school year and semester,
+// and is unrestricted if no such term was entered. Holidays and suspensions
+// cancel classes for the whole school, a department (the teacher's) or a room
+// (the meeting's room, or the class room). A make-up day holds the meetings of
+// another weekday, so a Saturday can make up for a suspended Monday.
+//
+// schoolCalendar.day is the one place that applies these rules. Logins only
+// count towards a class on its class days, attendance is not initialized or
+// generated for the days the calendar cancels, and attendance reports leave
+// those days out.
+
+// Calendar event types
+const (
+	EventHoliday    = "holiday"
+	EventSuspension = "suspension"
+	EventMakeup     = "makeup"
+)
+
+// Calendar event scopes
+const (
+	ScopeSchool     = "school"
+	ScopeDepartment = "department"
+	ScopeRoom       = "room"
+)
+
+const (
+	termAuditQuery          = `SELECT term_id, school_year, semester, start_date, end_date FROM academic_terms WHERE term_id = ?`
+	calendarEventAuditQuery = `
+		SELECT event_id, event_type, scope, scope_value, start_date, end_date, makeup_weekday, description
+		FROM calendar_events WHERE event_id = ?`
+)
+
+// AcademicTerm is the span of class days of a semester
+type AcademicTerm struct {
+	TermID     int    `json:"term_id"`
+	SchoolYear string `json:"school_year"`
+	Semester   string `json:"semester"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date"`
+}
+
+// CalendarEvent is a holiday, class suspension or make-up day, possibly spanning several days
+type CalendarEvent struct {
+	EventID       int    `json:"event_id"`
+	EventType     string `json:"event_type"`
+	Scope         string `json:"scope"`
+	ScopeValue    string `json:"scope_value"` // Department code or room
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	MakeupWeekday *int   `json:"makeup_weekday,omitempty"` // Weekday whose meetings a make-up day holds
+	Description   string `json:"description"`
+}
+
+// ClassDay tells whether a class is held on a date, and which meetings
+type ClassDay struct {
+	ClassID   int            `json:"class_id"`
+	Date      string         `json:"date"`
+	Meets     bool           `json:"meets"`
+	Cancelled bool           `json:"cancelled"` // Cancelled by the calendar, not just no meeting that weekday
+	Reason    string         `json:"reason,omitempty"`
+	Meetings  []ClassMeeting `json:"meetings"`
+}
+
+// label describes an event for the reason a class is not held
+func (ev CalendarEvent) label() string {
+	switch ev.EventType {
+	case EventHoliday:
+		return "Holiday: " + ev.Description
+	case EventSuspension:
+		return "Classes suspended: " + ev.Description
+	}
+	return "Make-up day: " + ev.Description
+}
+
+// covers reports whether the event falls on date (YYYY-MM-DD)
+func (ev CalendarEvent) covers(date string) bool {
+	return ev.StartDate <= date && date <= ev.EndDate
+}
+
+// schoolCalendar holds what is needed to decide the class days of some classes
+// over a range of dates
+type schoolCalendar struct {
+	terms    []AcademicTerm
+	events   []CalendarEvent
+	scopes   map[int]classScope
+	meetings map[int][]ClassMeeting
+}
+
+// loadCalendar reads the terms, the events between from and to, and the scopes
+// and meetings of classIDs
+func (a *App) loadCalendar(classIDs []int, from, to time.Time) (*schoolCalendar, error) {
+	terms, err := a.store.Calendar.Terms()
+	if err != nil {
+		return nil, err
+	}
+	events, err := a.store.Calendar.Events(from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	scopes, err := a.store.Calendar.ClassScopes(classIDs...)
+	if err != nil {
+		return nil, err
+	}
+	meetings, err := a.store.Classes.Meetings(classIDs...)
+	if err != nil {
+		return nil, err
+	}
+	return &schoolCalendar{terms: terms, events: events, scopes: scopes, meetings: meetings}, nil
+}
+
+// term returns the academic term of a class, or nil if none was entered
+func (c *schoolCalendar) term(scope classScope) *AcademicTerm {
+	for i, term := range c.terms {
+		if strings.EqualFold(strings.TrimSpace(scope.SchoolYear), term.SchoolYear) &&
+			strings.EqualFold(strings.TrimSpace(scope.Semester), term.Semester) {
+			return &c.terms[i]
+		}
+	}
+	return nil
+}
+
+// applies reports whether an event's scope includes a class meeting in room
+func (ev CalendarEvent) applies(scope classScope, room string) bool {
+	switch ev.Scope {
+	case ScopeDepartment:
+		return scope.Department != "" && strings.EqualFold(ev.ScopeValue, scope.Department)
+	case ScopeRoom:
+		return room != "" && strings.EqualFold(ev.ScopeValue, room)
+	}
+	return true
+}
+
+// day decides whether a class is held on date and returns the meetings held,
+// with their weekday set to the date's
+func (c *schoolCalendar) day(classID int, date time.Time) ClassDay {
+	scope := c.scopes[classID]
+	ymd := date.Format("2006-01-02")
+	day := ClassDay{ClassID: classID, Date: ymd}
+
+	if term := c.term(scope); term != nil && (ymd < term.StartDate || ymd > term.EndDate) {
+		day.Cancelled = true
+		day.Reason = fmt.Sprintf("Outside the %s %s term (%s to %s)", term.Semester, term.SchoolYear, term.StartDate, term.EndDate)
+		return day
+	}
+
+	// A make-up day in scope replaces the date's weekday
+	weekday := int(date.Weekday())
+	for _, ev := range c.events {
+		if ev.EventType == EventMakeup && ev.MakeupWeekday != nil && ev.covers(ymd) && ev.applies(scope, scope.Room) {
+			weekday = *ev.MakeupWeekday
+		}
+	}
+
+	// A holiday or suspension cancels every meeting in its scope
+	var cancelledBy *CalendarEvent
+	for _, m := range c.meetings[classID] {
+		if m.Weekday != weekday {
+			continue
+		}
+		room := m.Room
+		if room == "" {
+			room = scope.Room
+		}
+		cancelled := false
+		for i, ev := range c.events {
+			if ev.EventType != EventMakeup && ev.covers(ymd) && ev.applies(scope, room) {
+				cancelledBy, cancelled = &c.events[i], true
+				break
+			}
+		}
+		if !cancelled {
+			m.Weekday = int(date.Weekday())
+			day.Meetings = append(day.Meetings, m)
+		}
+	}
+
+	switch {
+	case len(day.Meetings) > 0:
+		day.Meets = true
+	case cancelledBy != nil:
+		day.Cancelled = true
+		day.Reason = cancelledBy.label()
+	default:
+		// No meeting that day, but an event in scope still explains the day off
+		for _, ev := range c.events {
+			if ev.EventType != EventMakeup && ev.covers(ymd) && ev.applies(scope, scope.Room) {
+				day.Cancelled = true
+				day.Reason = ev.label()
+				return day
+			}
+		}
+		day.Reason = fmt.Sprintf("No class meeting on %ss", time.Weekday(weekday))
+	}
+	return day
+}
+
+// classDay decides whether a class is held on date
+func (a *App) classDay(classID int, date time.Time) (ClassDay, error) {
+	calendar, err := a.loadCalendar([]int{classID}, date, date)
+	if err != nil {
+		return ClassDay{}, err
+	}
+	return calendar.day(classID, date), nil
+}
+
+// withoutCancelledDays drops attendance records on days the calendar cancelled
+// their class
+func (a *App) withoutCancelledDays(records []Attendance) ([]Attendance, error) {
+	if len(records) == 0 {
+		return records, nil
+	}
+
+	var classIDs []int
+	seen := make(map[int]bool)
+	from, to := recordDate(records[0]), recordDate(records[0])
+	for _, r := range records {
+		if !seen[r.ClassID] {
+			seen[r.ClassID] = true
+			classIDs = append(classIDs, r.ClassID)
+		}
+		if date := recordDate(r); date < from {
+			from = date
+		} else if date > to {
+			to = date
+		}
+	}
+	start, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := a.loadCalendar(classIDs, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := records[:0]
+	for _, r := range records {
+		date, err := time.Parse("2006-01-02", recordDate(r))
+		if err != nil || !calendar.day(r.ClassID, date).Cancelled {
+			kept = append(kept, r)
+		}
+	}
+	return kept, nil
+}
+
+// recordDate is the YYYY-MM-DD date of an attendance record, which the driver
+// may have scanned with a time of day attached
+func recordDate(r Attendance) string {
+	if len(r.Date) > 10 {
+		return r.Date[:10]
+	}
+	return r.Date
+}
+
+// parseCalendarDates checks a YYYY-MM-DD date range
+func parseCalendarDates(start, end string) (string, string, error) {
+	from, err := time.Parse("2006-01-02", strings.TrimSpace(start))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid start date: use YYYY-MM-DD")
+	}
+	to, err := time.Parse("2006-01-02", strings.TrimSpace(end))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid end date: use YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return "", "", fmt.Errorf("end date is before start date")
+	}
+	return from.Format("2006-01-02"), to.Format("2006-01-02"), nil
+}
+
+// validateTerm checks an academic term and trims its fields
+func (a *App) validateTerm(term *AcademicTerm) error {
+	term.SchoolYear = strings.TrimSpace(term.SchoolYear)
+	term.Semester = strings.TrimSpace(term.Semester)
+	if term.SchoolYear == "" || term.Semester == "" {
+		return fmt.Errorf("school year and semester are required")
+	}
+	var err error
+	if term.StartDate, term.EndDate, err = parseCalendarDates(term.StartDate, term.EndDate); err != nil {
+		return err
+	}
+
+	exists, err := a.store.Calendar.TermExists(term.SchoolYear, term.Semester, term.TermID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("the %s %s term already exists", term.Semester, term.SchoolYear)
+	}
+	return nil
+}
+
+// validateEvent checks a calendar event and trims its fields
+func (a *App) validateEvent(ev *CalendarEvent) error {
+	switch ev.EventType {
+	case EventHoliday, EventSuspension:
+		ev.MakeupWeekday = nil
+	case EventMakeup:
+		if ev.MakeupWeekday == nil || *ev.MakeupWeekday < 0 || *ev.MakeupWeekday > 6 {
+			return fmt.Errorf("a make-up day needs the weekday whose classes it holds")
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", ev.EventType)
+	}
+
+	ev.ScopeValue = strings.TrimSpace(ev.ScopeValue)
+	switch ev.Scope {
+	case "", ScopeSchool:
+		ev.Scope, ev.ScopeValue = ScopeSchool, ""
+	case ScopeDepartment:
+		exists, err := a.store.Departments.Exists(ev.ScopeValue)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("department %q not found", ev.ScopeValue)
+		}
+	case ScopeRoom:
+		if ev.ScopeValue == "" {
+			return fmt.Errorf("room is required")
+		}
+		if len(ev.ScopeValue) > 50 {
+			return fmt.Errorf("room must be at most 50 characters")
+		}
+	default:
+		return fmt.Errorf("unknown scope %q", ev.Scope)
+	}
+
+	ev.Description = strings.TrimSpace(ev.Description)
+	if ev.Description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if len(ev.Description) > 255 {
+		return fmt.Errorf("description must be at most 255 characters")
+	}
+
+	var err error
+	ev.StartDate, ev.EndDate, err = parseCalendarDates(ev.StartDate, ev.EndDate)
+	return err
+}
+
+// GetAcademicTerms returns every academic term, latest first
+func (a *App) GetAcademicTerms() ([]AcademicTerm, error) {
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	return a.store.Calendar.Terms()
+}
+
+// SaveAcademicTerm creates a term, or updates it when TermID is set, and returns its ID
+func (a *App) SaveAcademicTerm(term AcademicTerm) (int, error) {
+	session, err := a.authorize(PermManageCalendar)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return 0, err
+	}
+	if err := a.validateTerm(&term); err != nil {
+		return 0, err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		store := a.txStore(tx)
+		if term.TermID != 0 {
+			return a.auditChange(tx, "update", "academic_term", strconv.Itoa(term.TermID), termAuditQuery, []interface{}{term.TermID}, func() error {
+				found, err := store.Calendar.UpdateTerm(term)
+				if err == nil && !found {
+					err = fmt.Errorf("term not found")
+				}
+				return err
+			})
+		}
+
+		id, err := store.Calendar.CreateTerm(term, session.UserID)
+		if err != nil {
+			return err
+		}
+		term.TermID = int(id)
+		after, err := auditSnapshot(tx, termAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "create", "academic_term", strconv.FormatInt(id, 10), nil, after)
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to save academic term: %v", err)
+		return 0, err
+	}
+
+	log.Printf("📅 Academic term saved: %s %s, %s to %s", term.Semester, term.SchoolYear, term.StartDate, term.EndDate)
+	return term.TermID, nil
+}
+
+// DeleteAcademicTerm removes a term; its classes are then unrestricted by dates
+func (a *App) DeleteAcademicTerm(termID int) error {
+	if _, err := a.authorize(PermManageCalendar); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	return a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "delete", "academic_term", strconv.Itoa(termID), termAuditQuery, []interface{}{termID}, func() error {
+			return a.txStore(tx).Calendar.DeleteTerm(termID)
+		})
+	})
+}
+
+// GetCalendarEvents returns the holidays, suspensions and make-up days between
+// two YYYY-MM-DD dates
+func (a *App) GetCalendarEvents(from, to string) ([]CalendarEvent, error) {
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+	from, to, err := parseCalendarDates(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.store.Calendar.Events(from, to)
+}
+
+// SaveCalendarEvent creates an event, or updates it when EventID is set, and returns its ID
+func (a *App) SaveCalendarEvent(ev CalendarEvent) (int, error) {
+	session, err := a.authorize(PermManageCalendar)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return 0, err
+	}
+	if err := a.validateEvent(&ev); err != nil {
+		return 0, err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		store := a.txStore(tx)
+		if ev.EventID != 0 {
+			return a.auditChange(tx, "update", "calendar_event", strconv.Itoa(ev.EventID), calendarEventAuditQuery, []interface{}{ev.EventID}, func() error {
+				found, err := store.Calendar.UpdateEvent(ev)
+				if err == nil && !found {
+					err = fmt.Errorf("event not found")
+				}
+				return err
+			})
+		}
+
+		id, err := store.Calendar.CreateEvent(ev, session.UserID)
+		if err != nil {
+			return err
+		}
+		ev.EventID = int(id)
+		after, err := auditSnapshot(tx, calendarEventAuditQuery, id)
+		if err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "create", "calendar_event", strconv.FormatInt(id, 10), nil, after)
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to save calendar event: %v", err)
+		return 0, err
+	}
+
+	log.Printf("📅 Calendar event saved: %s, %s to %s", ev.label(), ev.StartDate, ev.EndDate)
+	return ev.EventID, nil
+}
+
+// DeleteCalendarEvent removes a holiday, suspension or make-up day
+func (a *App) DeleteCalendarEvent(eventID int) error {
+	if _, err := a.authorize(PermManageCalendar); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	return a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "delete", "calendar_event", strconv.Itoa(eventID), calendarEventAuditQuery, []interface{}{eventID}, func() error {
+			return a.txStore(tx).Calendar.DeleteEvent(eventID)
+		})
+	})
+}
+
+// GetClassDay tells whether a class is held on a YYYY-MM-DD date, and if not, why
+func (a *App) GetClassDay(classID int, date string) (ClassDay, error) {
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return ClassDay{}, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return ClassDay{}, err
+	}
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return ClassDay{}, fmt.Errorf("invalid date format: %w", err)
+	}
+
+	return a.classDay(classID, day)
+}
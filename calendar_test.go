@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// seedCalendar sets up class 10 of a CCS teacher in the 2nd semester of
+// 2025-2026, meeting on Mondays in its room Lab 1 and on Wednesdays in Lab 2,
+// and class 11 without a term or department, meeting on Mondays. The term and
+// events are those of March 2026.
+func seedCalendar(t *testing.T, a *App) {
+	t.Helper()
+	mustExec(t, a, `INSERT INTO departments (department_code, department_name) VALUES ('CCS', 'College of Computer Studies')`)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "teacher2", "Teach#123", "teacher")
+	mustExec(t, a, `UPDATE teachers SET department_code = 'CCS' WHERE user_id = 2`)
+	seedClass(t, a, 10, 2)
+	seedClass(t, a, 11, 3)
+	mustExec(t, a, `UPDATE classes SET school_year = '2025-2026', semester = '2nd', room = 'Lab 1' WHERE class_id = 10`)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	seedMeeting(t, a, 10, 3, "08:00", "09:00")
+	mustExec(t, a, `UPDATE class_meetings SET room = 'Lab 2' WHERE class_id = 10 AND weekday = 3`)
+	seedMeeting(t, a, 11, 1, "08:00", "09:00")
+
+	mustExec(t, a, `INSERT INTO academic_terms (school_year, semester, start_date, end_date) VALUES ('2025-2026', '2nd', '2026-01-12', '2026-05-15')`)
+	events := []struct {
+		eventType, scope, value, start, end string
+		weekday                             interface{}
+		description                         string
+	}{
+		{EventHoliday, ScopeSchool, "", "2026-03-09", "2026-03-09", nil, "Founding Day"},
+		{EventSuspension, ScopeRoom, "Lab 2", "2026-03-04", "2026-03-04", nil, "Aircon repair"},
+		{EventMakeup, ScopeSchool, "", "2026-03-14", "2026-03-14", 1, "For Founding Day"},
+		{EventSuspension, ScopeDepartment, "ccs", "2026-03-16", "2026-03-17", nil, "CCS week"},
+		{EventSuspension, ScopeRoom, "Lab 1", "2026-03-23", "2026-03-23", nil, "Network outage"},
+	}
+	for _, ev := range events {
+		mustExec(t, a, `INSERT INTO calendar_events (event_type, scope, scope_value, start_date, end_date, makeup_weekday, description) VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?)`,
+			ev.eventType, ev.scope, ev.value, ev.start, ev.end, ev.weekday, ev.description)
+	}
+}
+
+func TestCalendarDay(t *testing.T) {
+	a := newTestApp(t)
+	seedCalendar(t, a)
+	calendar, err := a.loadCalendar([]int{10, 11}, monday.AddDate(0, 0, -60), monday.AddDate(0, 0, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date      time.Time
+		classID   int
+		meets     bool
+		cancelled bool
+		reason    string
+	}{
+		{monday, 10, true, false, ""},
+		{monday.AddDate(0, 0, 1), 10, false, false, "No class meeting on Tuesdays"},
+		// The Wednesday meeting is in the suspended room
+		{monday.AddDate(0, 0, 2), 10, false, true, "Classes suspended: Aircon repair"},
+		{monday.AddDate(0, 0, 7), 10, false, true, "Holiday: Founding Day"},
+		{monday.AddDate(0, 0, 7), 11, false, true, "Holiday: Founding Day"},
+		// The Saturday make-up holds the Monday meetings
+		{monday.AddDate(0, 0, 12), 10, true, false, ""},
+		{monday.AddDate(0, 0, 12), 11, true, false, ""},
+		{monday.AddDate(0, 0, 14), 10, false, true, "Classes suspended: CCS week"},
+		{monday.AddDate(0, 0, 14), 11, true, false, ""},
+		// No meeting on the Tuesday, but the suspension still explains the day
+		{monday.AddDate(0, 0, 15), 10, false, true, "Classes suspended: CCS week"},
+		// The Monday meeting has no room of its own, so it is in the class room
+		{monday.AddDate(0, 0, 21), 10, false, true, "Classes suspended: Network outage"},
+		{monday.AddDate(0, 0, 21), 11, true, false, ""},
+		// Class 11 has no term entered and is not restricted
+		{monday.AddDate(0, 0, 91), 10, false, true, "Outside the 2nd 2025-2026 term (2026-01-12 to 2026-05-15)"},
+		{monday.AddDate(0, 0, 91), 11, true, false, ""},
+	}
+	for _, tt := range tests {
+		day := calendar.day(tt.classID, tt.date)
+		if day.Meets != tt.meets || day.Cancelled != tt.cancelled || (!tt.meets && day.Reason != tt.reason) {
+			t.Errorf("class %d on %s = meets %v, cancelled %v, %q; want %v, %v, %q",
+				tt.classID, tt.date.Format("Mon 2006-01-02"), day.Meets, day.Cancelled, day.Reason, tt.meets, tt.cancelled, tt.reason)
+		}
+		for _, m := range day.Meetings {
+			if m.Weekday != int(tt.date.Weekday()) {
+				t.Errorf("class %d on %s holds a meeting on weekday %d", tt.classID, tt.date.Format("Mon 2006-01-02"), m.Weekday)
+			}
+		}
+	}
+}
+
+func TestClassesInSessionSkipDays(t *testing.T) {
+	a := newTestApp(t)
+	seedCalendar(t, a)
+
+	tests := []struct {
+		at   time.Time
+		want []int
+	}{
+		{at(monday, 8, 30), []int{10, 11}},
+		{at(monday.AddDate(0, 0, 2), 8, 30), nil},
+		{at(monday.AddDate(0, 0, 7), 8, 30), nil},
+		{at(monday.AddDate(0, 0, 12), 8, 30), []int{10, 11}},
+		{at(monday.AddDate(0, 0, 14), 8, 30), []int{11}},
+		{at(monday.AddDate(0, 0, 91), 8, 30), []int{11}},
+	}
+	for _, tt := range tests {
+		classes, err := a.classesInSession([]int{10, 11}, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, c := range classes {
+			got = append(got, c.ClassID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("classes in session on %s = %v, want %v", tt.at.Format("Mon 2006-01-02 15:04"), got, tt.want)
+		}
+	}
+}
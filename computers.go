@@ -0,0 +1,380 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==============================================================================
+// LAB COMPUTER REGISTRY
+// ==============================================================================
+//
+// A hostname alone proves nothing: any laptop can be renamed "PC-12". On first
+// start each install files a registration request carrying a random enrollment
+// secret, which it keeps in the user config directory, and a fingerprint of the
+// machine ID. An admin approves the request and gives the PC a display name.
+// The PC counts as registered only while the secret and fingerprint both match
+// an approved row. The display name is then recorded as the PC number in login
+// logs and attendance instead of the raw hostname.
+
+const computerAuditQuery = `SELECT id, asset_tag, display_name, room, hostname, status, approved_by FROM computers WHERE id = ?`
+
+// maxComputerNameLength matches attendance.pc_number
+const maxComputerNameLength = 20
+
+// computerIdentity is this install's registration, persisted between runs
+type computerIdentity struct {
+	ComputerID  int    `json:"computer_id"`
+	Secret      string `json:"secret"`
+	fingerprint string
+}
+
+// labComputer is this PC as known to the registry at the time of the call
+type labComputer struct {
+	ID          int
+	DisplayName string
+	Hostname    string
+	Status      string // "pending", "approved", "revoked", or empty when not registered
+}
+
+// approved reports whether this PC is an approved lab computer
+func (c labComputer) approved() bool {
+	return c.ID > 0 && c.Status == "approved"
+}
+
+// pcNumber is the name recorded for this PC in login logs, attendance and feedback
+func (c labComputer) pcNumber() string {
+	if c.approved() && c.DisplayName != "" {
+		return c.DisplayName
+	}
+	return c.Hostname
+}
+
+// Computer is a row of the computers registry
+type Computer struct {
+	ID           int     `json:"id"`
+	AssetTag     *string `json:"asset_tag,omitempty"`
+	DisplayName  *string `json:"display_name,omitempty"`
+	Room         *string `json:"room,omitempty"`
+	Hostname     string  `json:"hostname"`
+	MACAddresses *string `json:"mac_addresses,omitempty"`
+	Status       string  `json:"status"`
+	RequestedAt  string  `json:"requested_at"`
+	ApprovedBy   *string `json:"approved_by,omitempty"`
+	ApprovedAt   *string `json:"approved_at,omitempty"`
+	LastSeenAt   *string `json:"last_seen_at,omitempty"`
+	IsThisPC     bool    `json:"is_this_pc"`
+}
+
+// machineFingerprint hashes the OS machine ID, falling back to the MAC addresses.
+// The MAC addresses are also returned so admins can recognise the machine.
+func machineFingerprint() (string, string) {
+	var macs []string
+	if interfaces, err := net.Interfaces(); err == nil {
+		for _, iface := range interfaces {
+			if iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+				continue
+			}
+			macs = append(macs, iface.HardwareAddr.String())
+		}
+	}
+	sort.Strings(macs)
+	macList := strings.Join(macs, ",")
+
+	source := "mac:" + macList
+	if id, err := machineID(); err == nil && id != "" {
+		source = "machine-id:" + strings.ToLower(id)
+	} else {
+		log.Printf("⚠ Machine ID unavailable, fingerprinting by MAC address: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:]), macList
+}
+
+// computerIdentityPath is where this install keeps its registration
+func computerIdentityPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "DigitalLogbook", "computer.json"), nil
+}
+
+// loadComputerIdentity reads this install's registration, or returns nil if there is none
+func loadComputerIdentity() *computerIdentity {
+	path, err := computerIdentityPath()
+	if err != nil {
+		return nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var identity computerIdentity
+	if err := json.Unmarshal(b, &identity); err != nil || identity.ComputerID == 0 || identity.Secret == "" {
+		log.Printf("⚠ Ignoring unreadable computer identity file %s", path)
+		return nil
+	}
+	return &identity
+}
+
+// saveComputerIdentity writes this install's registration, readable only by the current user
+func saveComputerIdentity(identity *computerIdentity) error {
+	path, err := computerIdentityPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(identity, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
+
+// hashEnrollmentSecret hashes an install's enrollment secret for storage
+func hashEnrollmentSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// localHostname returns the OS hostname, or "Unknown"
+func localHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Printf("⚠ Failed to get hostname: %v", err)
+		return "Unknown"
+	}
+	return hostname
+}
+
+// registerComputer confirms this install's registration at startup, filing a new
+// request if it has none or if its identity file was copied from another machine
+func (a *App) registerComputer() {
+	fingerprint, macs := machineFingerprint()
+	hostname := localHostname()
+
+	if identity := loadComputerIdentity(); identity != nil {
+		stored, err := a.store.Computers.Fingerprint(identity.ComputerID, hashEnrollmentSecret(identity.Secret))
+		switch {
+		case err == nil && stored == fingerprint:
+			identity.fingerprint = fingerprint
+			a.computer = identity
+			if err := a.store.Computers.Seen(identity.ComputerID, hostname, macs); err != nil {
+				log.Printf("⚠ Failed to update computer last seen: %v", err)
+			}
+			return
+		case err == nil:
+			log.Printf("⚠ Computer identity file belongs to another machine, requesting a new registration")
+		case err == sql.ErrNoRows:
+			log.Printf("⚠ Computer registration %d no longer exists, requesting a new one", identity.ComputerID)
+		default:
+			log.Printf("⚠ Failed to check computer registration: %v", err)
+			return
+		}
+	}
+
+	secret, err := newSessionToken()
+	if err != nil {
+		log.Printf("❌ Failed to generate enrollment secret: %v", err)
+		return
+	}
+
+	// A machine that registered before (e.g. reinstalled) keeps its row but must be approved again
+	id, err := a.store.Computers.Request(hostname, fingerprint, macs, hashEnrollmentSecret(secret))
+	if err != nil {
+		log.Printf("❌ Failed to request computer registration: %v", err)
+		return
+	}
+
+	identity := &computerIdentity{ComputerID: int(id), Secret: secret, fingerprint: fingerprint}
+	if err := saveComputerIdentity(identity); err != nil {
+		log.Printf("❌ Failed to save computer identity: %v", err)
+		return
+	}
+	a.computer = identity
+	log.Printf("🖥 Registration requested for this PC (%s, request %d), waiting for admin approval", hostname, id)
+}
+
+// currentComputer looks up this PC in the registry. While offline it returns
+// the last lookup made online.
+func (a *App) currentComputer() labComputer {
+	pc := labComputer{Hostname: localHostname()}
+	if a.workingOffline() {
+		return a.offline.lastComputer(pc)
+	}
+	if a.requireDB() != nil || a.computer == nil {
+		return pc
+	}
+
+	displayName, status, err := a.store.Computers.Lookup(a.computer.ComputerID, hashEnrollmentSecret(a.computer.Secret), a.computer.fingerprint)
+	if a.lostConnection(err) {
+		return a.offline.lastComputer(pc)
+	}
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("⚠ Failed to look up this computer: %v", err)
+		}
+		return pc
+	}
+	pc.ID = a.computer.ComputerID
+	pc.DisplayName = displayName.String
+	pc.Status = status
+	a.offline.rememberComputer(pc)
+	return pc
+}
+
+// applyComputerPolicy applies the unregistered PC policy to a login of user on pc.
+// It reports whether the login is flagged, or returns an error when it is refused.
+func applyComputerPolicy(user *User, pc labComputer) (bool, error) {
+	if pc.approved() {
+		return false, nil
+	}
+	switch GetComputerPolicy().ForRole(user.Role) {
+	case UnregisteredPCReject:
+		log.Printf("⚠ Login of %s rejected: %s is not a registered lab PC", user.Name, pc.Hostname)
+		return false, fmt.Errorf("this PC is not a registered lab computer, please ask an administrator to approve it")
+	case UnregisteredPCFlag:
+		return true, nil
+	}
+	return false, nil
+}
+
+// GetComputers returns the computers registry, pending requests first
+func (a *App) GetComputers() ([]Computer, error) {
+	if _, err := a.authorize(PermManageComputers); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	computers, err := a.store.Computers.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range computers {
+		computers[i].IsThisPC = a.computer != nil && a.computer.ComputerID == computers[i].ID
+	}
+	return computers, nil
+}
+
+// validateComputerDetails checks the admin-entered details of a computer
+func (a *App) validateComputerDetails(computerID int, displayName string) error {
+	displayName = strings.TrimSpace(displayName)
+	if displayName == "" {
+		return fmt.Errorf("display name is required")
+	}
+	if len(displayName) > maxComputerNameLength {
+		return fmt.Errorf("display name must be at most %d characters", maxComputerNameLength)
+	}
+
+	taken, err := a.store.Computers.NameTaken(displayName, computerID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return fmt.Errorf("display name %s is already used by another computer", displayName)
+	}
+	return nil
+}
+
+// ApproveComputer approves a registration request and names the computer
+func (a *App) ApproveComputer(computerID int, displayName, room, assetTag string) error {
+	session, err := a.authorize(PermManageComputers)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+	if err := a.validateComputerDetails(computerID, displayName); err != nil {
+		return err
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "approve", "computer", strconv.Itoa(computerID), computerAuditQuery, []interface{}{computerID}, func() error {
+			found, err := a.txStore(tx).Computers.Approve(computerID, strings.TrimSpace(displayName), strings.TrimSpace(room), strings.TrimSpace(assetTag), session.UserID)
+			if err != nil {
+				return err
+			}
+			if !found {
+				return fmt.Errorf("computer not found")
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🖥 Computer %d approved as %s by admin %d", computerID, displayName, session.UserID)
+	return nil
+}
+
+// UpdateComputer changes the display name, room and asset tag of a computer
+func (a *App) UpdateComputer(computerID int, displayName, room, assetTag string) error {
+	if _, err := a.authorize(PermManageComputers); err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+	if err := a.validateComputerDetails(computerID, displayName); err != nil {
+		return err
+	}
+
+	if exists, err := a.store.Computers.Exists(computerID); err != nil || !exists {
+		return fmt.Errorf("computer not found")
+	}
+
+	return a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "update", "computer", strconv.Itoa(computerID), computerAuditQuery, []interface{}{computerID}, func() error {
+			return a.txStore(tx).Computers.UpdateDetails(computerID, strings.TrimSpace(displayName), strings.TrimSpace(room), strings.TrimSpace(assetTag))
+		})
+	})
+}
+
+// RevokeComputer withdraws a computer's approval; logins from it are then treated as unregistered
+func (a *App) RevokeComputer(computerID int) error {
+	session, err := a.authorize(PermManageComputers)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	if exists, err := a.store.Computers.Exists(computerID); err != nil || !exists {
+		return fmt.Errorf("computer not found")
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "revoke", "computer", strconv.Itoa(computerID), computerAuditQuery, []interface{}{computerID}, func() error {
+			return a.txStore(tx).Computers.Revoke(computerID)
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🖥 Computer %d revoked by admin %d", computerID, session.UserID)
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestComputerRegistry(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	signInAs(t, a, 1, "admin", "admin")
+
+	// Connecting filed a registration request for this PC
+	computers, err := a.GetComputers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(computers) != 1 || computers[0].Status != "pending" || !computers[0].IsThisPC {
+		t.Fatalf("computers = %+v, want this PC pending", computers)
+	}
+	id := computers[0].ID
+	if a.currentComputer().approved() {
+		t.Error("a pending PC counts as registered")
+	}
+
+	mustExec(t, a, `INSERT INTO computers (hostname, machine_fingerprint, enrollment_secret_hash, display_name, status) VALUES ('lab-2', 'other', 'x', 'PC-02', 'approved')`)
+	if err := a.ApproveComputer(id, "PC-02", "Lab 1", ""); err == nil {
+		t.Error("a second computer was given the same display name")
+	}
+	if err := a.ApproveComputer(id+100, "PC-09", "Lab 1", ""); err == nil {
+		t.Error("a computer not in the registry was approved")
+	}
+	if err := a.ApproveComputer(id, "PC-01", "Lab 1", "A-1"); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if pc := a.currentComputer(); !pc.approved() || pc.pcNumber() != "PC-01" {
+		t.Errorf("approved PC = %+v, want it registered as PC-01", pc)
+	}
+
+	if err := a.UpdateComputer(id, "PC-03", "Lab 2", ""); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if pc := a.currentComputer(); pc.pcNumber() != "PC-03" {
+		t.Errorf("renamed PC = %s, want PC-03", pc.pcNumber())
+	}
+	if err := a.RevokeComputer(id); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if pc := a.currentComputer(); pc.approved() || pc.pcNumber() != localHostname() {
+		t.Errorf("revoked PC = %+v, want it unregistered under its hostname", pc)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE entity_type = 'computer' AND actor_user_id = 1`); n != 3 {
+		t.Errorf("%d computer audit entries, want 3", n)
+	}
+}
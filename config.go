@@ -4,62 +4,284 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
+	"strings"
+)
 
-	_ "github.com/go-sql-driver/mysql"
+// Database backends selected by DB_DRIVER
+const (
+	DriverMySQL  = "mysql"  // Shared MySQL server for a networked lab
+	DriverSQLite = "sqlite" // Local database file for a standalone lab PC
 )
 
 // DBConfig holds database configuration
 type DBConfig struct {
+	Driver string
+	Path   string // SQLite database file
+
 	Host     string
 	Port     string
 	Username string
 	Password string
 	Database string
+
+	TLSMode       string // One of the TLS* modes
+	TLSCA         string // PEM file of the CA that signed the server certificate; system CAs when empty
+	TLSCert       string // PEM client certificate, for servers that require one
+	TLSKey        string // PEM private key of TLSCert
+	TLSServerName string // Name expected in the server certificate when it differs from Host
+
+	AutoMigrate bool // Apply pending schema migrations at startup
+
+	MaxOpenConns           int // Connections open at once, in use or idle
+	MaxIdleConns           int // Idle connections kept for reuse
+	ConnMaxLifetimeMinutes int // Connections are replaced after this long, before the server drops them
+	ConnMaxIdleMinutes     int // Idle connections are closed after this long
+
+	QueryTimeoutSeconds       int // Deadline of a statement outside a transaction
+	TransactionTimeoutSeconds int // Deadline of a whole transaction
 }
 
-// GetDBConfig returns database configuration from environment variables or defaults
+// GetDBConfig returns the database configuration from the settings.
+// A password encrypted by the setup wizard is decrypted here.
 func GetDBConfig() DBConfig {
+	password, err := decryptSecret(settingString("DB_PASSWORD"))
+	if err != nil {
+		log.Printf("❌ Failed to decrypt DB_PASSWORD: %v", err)
+	}
 	return DBConfig{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "3306"),
-		Username: getEnv("DB_USERNAME", "root"),
-		Password: getEnv("DB_PASSWORD", "root"),
-		Database: getEnv("DB_DATABASE", "logbookdb"),
+		Driver: settingString("DB_DRIVER"),
+		Path:   settingString("DB_SQLITE_PATH"),
+
+		Host:     settingString("DB_HOST"),
+		Port:     settingString("DB_PORT"),
+		Username: settingString("DB_USERNAME"),
+		Password: password,
+		Database: settingString("DB_DATABASE"),
+
+		TLSMode:       settingString("DB_TLS_MODE"),
+		TLSCA:         settingString("DB_TLS_CA"),
+		TLSCert:       settingString("DB_TLS_CERT"),
+		TLSKey:        settingString("DB_TLS_KEY"),
+		TLSServerName: settingString("DB_TLS_SERVER_NAME"),
+
+		AutoMigrate: settingBool("DB_AUTO_MIGRATE"),
+
+		MaxOpenConns:           settingInt("DB_MAX_OPEN_CONNS"),
+		MaxIdleConns:           settingInt("DB_MAX_IDLE_CONNS"),
+		ConnMaxLifetimeMinutes: settingInt("DB_CONN_MAX_LIFETIME_MINUTES"),
+		ConnMaxIdleMinutes:     settingInt("DB_CONN_MAX_IDLE_MINUTES"),
+
+		QueryTimeoutSeconds:       settingInt("DB_QUERY_TIMEOUT_SECONDS"),
+		TransactionTimeoutSeconds: settingInt("DB_TRANSACTION_TIMEOUT_SECONDS"),
+	}
+}
+
+// ConnectionPolicy holds how the connection monitor checks on the database
+type ConnectionPolicy struct {
+	HealthCheckSeconds int // Ping interval while the database answers
+	RetryMinSeconds    int // First retry delay after a failure, doubled on each further failure
+	RetryMaxSeconds    int // Longest retry delay
+	DegradedLatencyMS  int // Pings slower than this report the connection as degraded
+}
+
+// GetConnectionPolicy returns the connection monitor configuration from the settings
+func GetConnectionPolicy() ConnectionPolicy {
+	policy := ConnectionPolicy{
+		HealthCheckSeconds: settingInt("DB_HEALTH_CHECK_SECONDS"),
+		RetryMinSeconds:    settingInt("DB_RETRY_MIN_SECONDS"),
+		RetryMaxSeconds:    settingInt("DB_RETRY_MAX_SECONDS"),
+		DegradedLatencyMS:  settingInt("DB_DEGRADED_LATENCY_MS"),
+	}
+	if policy.RetryMaxSeconds < policy.RetryMinSeconds {
+		policy.RetryMaxSeconds = policy.RetryMinSeconds
+	}
+	return policy
+}
+
+// OfflinePolicy holds the offline mode configuration of lab PCs on the shared MySQL server
+type OfflinePolicy struct {
+	Enabled        bool   // Queue sign-ins locally while the server is unreachable
+	Path           string // Local queue and credential cache file
+	CredentialDays int    // Cached credentials older than this are not accepted offline
+}
+
+// GetOfflinePolicy returns the offline mode configuration from the settings
+func GetOfflinePolicy() OfflinePolicy {
+	return OfflinePolicy{
+		Enabled:        settingBool("OFFLINE_MODE"),
+		Path:           settingString("OFFLINE_QUEUE_PATH"),
+		CredentialDays: settingInt("OFFLINE_CREDENTIAL_DAYS"),
 	}
 }
 
-// getEnv gets an environment variable or returns a default value
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// LockoutPolicy holds the failed-login lockout configuration
+type LockoutPolicy struct {
+	MaxFailedAttempts int // Failures allowed within the window before the account is locked
+	WindowMinutes     int // Sliding window in which failures are counted
+	LockoutMinutes    int // How long the account stays locked
+}
+
+// GetLockoutPolicy returns the lockout policy from the settings
+func GetLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		MaxFailedAttempts: settingInt("LOGIN_MAX_FAILED_ATTEMPTS"),
+		WindowMinutes:     settingInt("LOGIN_FAILURE_WINDOW_MINUTES"),
+		LockoutMinutes:    settingInt("LOGIN_LOCKOUT_MINUTES"),
+	}
+}
+
+// PasswordPolicy holds the rules enforced when a user chooses a new password
+type PasswordPolicy struct {
+	MinLength           int  `json:"min_length"`
+	RequireUppercase    bool `json:"require_uppercase"`
+	RequireLowercase    bool `json:"require_lowercase"`
+	RequireDigit        bool `json:"require_digit"`
+	RequireSymbol       bool `json:"require_symbol"`
+	DisallowStudentCode bool `json:"disallow_student_code"` // Reject the username / student code as a password
+	ExpiryDays          int  `json:"expiry_days"`           // 0 disables password expiry
+}
+
+// GetPasswordPolicyConfig returns the password policy from the settings
+func GetPasswordPolicyConfig() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:           settingInt("PASSWORD_MIN_LENGTH"),
+		RequireUppercase:    settingBool("PASSWORD_REQUIRE_UPPERCASE"),
+		RequireLowercase:    settingBool("PASSWORD_REQUIRE_LOWERCASE"),
+		RequireDigit:        settingBool("PASSWORD_REQUIRE_DIGIT"),
+		RequireSymbol:       settingBool("PASSWORD_REQUIRE_SYMBOL"),
+		DisallowStudentCode: settingBool("PASSWORD_DISALLOW_STUDENT_CODE"),
+		ExpiryDays:          settingInt("PASSWORD_EXPIRY_DAYS"),
 	}
-	return value
 }
 
-// InitDatabase initializes and returns a database connection
-func InitDatabase() (*sql.DB, error) {
+// Concurrent session policies applied when a student signs in while already signed in on another PC
+const (
+	ConcurrentSessionAllow = "allow" // Keep both sessions open
+	ConcurrentSessionDeny  = "deny"  // Refuse the new login
+	ConcurrentSessionKick  = "kick"  // Close the older session and continue
+)
+
+// SessionPolicy holds the concurrent session and stale session configuration
+type SessionPolicy struct {
+	Concurrent          string // One of the ConcurrentSession* policies
+	MaxSessionMinutes   int    // Open sessions older than this are closed as timed out; 0 disables
+	LabClosingTime      string // "HH:MM"; sessions still open after closing time are closed; empty disables
+	ReapIntervalMinutes int    // How often the background reaper looks for stale sessions
+}
+
+// GetSessionPolicy returns the session policy from the settings
+func GetSessionPolicy() SessionPolicy {
+	return SessionPolicy{
+		Concurrent:          settingString("CONCURRENT_SESSION_POLICY"),
+		MaxSessionMinutes:   settingInt("SESSION_MAX_MINUTES"),
+		LabClosingTime:      settingString("LAB_CLOSING_TIME"),
+		ReapIntervalMinutes: settingInt("SESSION_REAP_INTERVAL_MINUTES"),
+	}
+}
+
+// Policies for logins from PCs that are not approved in the computers registry
+const (
+	UnregisteredPCAllow  = "allow"  // Sign in normally
+	UnregisteredPCFlag   = "flag"   // Sign in, but mark the login log and attendance
+	UnregisteredPCReject = "reject" // Refuse the login
+)
+
+// ComputerPolicy holds the unregistered PC policy for each role
+type ComputerPolicy struct {
+	Roles map[string]string
+}
+
+// GetComputerPolicy returns the computer policy from the settings.
+// UNREGISTERED_PC_POLICY sets every role; UNREGISTERED_PC_POLICY_<ROLE> overrides one role.
+func GetComputerPolicy() ComputerPolicy {
+	fallback := settingString("UNREGISTERED_PC_POLICY")
+	policy := ComputerPolicy{Roles: make(map[string]string)}
+	for _, role := range []string{"admin", "teacher", "student", "working_student"} {
+		value := settingString("UNREGISTERED_PC_POLICY_" + strings.ToUpper(role))
+		if value == "" {
+			value = fallback
+		}
+		policy.Roles[role] = value
+	}
+	return policy
+}
+
+// ForRole returns the policy applied to a role
+func (p ComputerPolicy) ForRole(role string) string {
+	if value, ok := p.Roles[role]; ok {
+		return value
+	}
+	return UnregisteredPCFlag
+}
+
+// AttendancePolicy holds the rules for matching logins to class meetings
+type AttendancePolicy struct {
+	EarlyMinutes   int `json:"early_minutes"`   // A login this long before the class starts counts towards it
+	LateMinutes    int `json:"late_minutes"`    // Logins more than this long after the start are marked late
+	AbsentMinutes  int `json:"absent_minutes"`  // Logins more than this long after the start are marked absent; 0 never
+	MinimumMinutes int `json:"minimum_minutes"` // Minutes a student must stay for the attendance to count in full; 0 no minimum
+}
+
+// GetAttendancePolicy returns the school's default attendance rules from the settings.
+// Classes may override them; see classPolicies.
+func GetAttendancePolicy() AttendancePolicy {
+	return AttendancePolicy{
+		EarlyMinutes:   settingInt("ATTENDANCE_EARLY_MINUTES"),
+		LateMinutes:    settingInt("ATTENDANCE_LATE_MINUTES"),
+		AbsentMinutes:  settingInt("ATTENDANCE_ABSENT_MINUTES"),
+		MinimumMinutes: settingInt("ATTENDANCE_MINIMUM_MINUTES"),
+	}
+}
+
+// GetAttendanceAutoSchedule reports whether the attendance scheduler opens and
+// finalizes class days by itself
+func GetAttendanceAutoSchedule() bool {
+	return settingBool("ATTENDANCE_AUTO_SCHEDULE")
+}
+
+// GetExportDir returns the folder exported reports are saved to
+func GetExportDir() string {
+	return settingString("EXPORT_DIR")
+}
+
+// openDatabase opens the connection pool of the configured backend without connecting yet
+func openDatabase(config DBConfig) (*sql.DB, sqlDialect, error) {
+	var db *sql.DB
+	var dialect sqlDialect
+	var err error
+	switch config.Driver {
+	case DriverSQLite:
+		db, err = openSQLite(config)
+		dialect = sqliteDialect{}
+	default:
+		db, err = openMySQL(config)
+		dialect = mysqlDialect{}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	configurePool(db, config)
+	return db, dialect, nil
+}
+
+// InitDatabase initializes and returns a database connection and its SQL dialect
+func InitDatabase() (*sql.DB, sqlDialect, error) {
 	config := GetDBConfig()
-	dsn := fmt.Sprintf(
-		"%s:%s@tcp(%s:%s)/%s?parseTime=true&charset=utf8mb4",
-		config.Username,
-		config.Password,
-		config.Host,
-		config.Port,
-		config.Database,
-	)
-
-	db, err := sql.Open("mysql", dsn)
+	db, dialect, err := openDatabase(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, nil, err
 	}
 
 	// Test the connection
 	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Database connection established successfully")
-	return db, nil
+	if config.Driver == DriverSQLite {
+		log.Printf("Database connection established successfully (SQLite: %s)", config.Path)
+	} else {
+		log.Println("Database connection established successfully")
+	}
+	return db, dialect, nil
 }
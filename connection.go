@@ -0,0 +1,408 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==============================================================================
+// DATABASE CONNECTION
+// ==============================================================================
+//
+// The connection pool is opened once at startup and never replaced; the
+// connection monitor tracks whether the server behind it can be used. Until the
+// first successful ping it retries with exponential backoff, then prepares the
+// schema and runs the startup work. After that it pings periodically, backing off
+// again whenever the server stops answering.
+//
+// Every change of state is sent to the frontend as a "db:status" event so the
+// login page can show it. Bound methods call requireDB, which fails with
+// ErrDatabaseUnavailable until the database is usable again.
+
+// Connection states reported in "db:status" events
+const (
+	DBConnected    = "connected"    // Pings succeed
+	DBDegraded     = "degraded"     // Pings succeed, but slower than DB_DEGRADED_LATENCY_MS
+	DBDisconnected = "disconnected" // The server is unreachable or the schema could not be prepared
+)
+
+// dbStatusEvent is the Wails event emitted on every connection state change
+const dbStatusEvent = "db:status"
+
+// ErrDatabaseUnavailable is returned by bound methods while the database cannot be used
+var ErrDatabaseUnavailable = errors.New("database not connected")
+
+// ErrDatabaseTimeout is returned when a statement or transaction runs past its deadline
+var ErrDatabaseTimeout = errors.New("database timeout: the server took too long to respond")
+
+// ConnectionStatus is the payload of a "db:status" event
+type ConnectionStatus struct {
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMS int64  `json:"latency_ms"`
+	Offline   bool   `json:"offline"` // Sign-ins are queued locally until the server is back
+	Since     string `json:"since"`   // When the current state began
+}
+
+// dbMonitor holds the connection state shared by the monitor and bound methods
+type dbMonitor struct {
+	mu     sync.Mutex
+	status ConnectionStatus
+	ready  bool // Schema prepared and startup work done
+	wake   chan struct{}
+	notify func(ConnectionStatus)
+}
+
+func newDBMonitor(notify func(ConnectionStatus)) *dbMonitor {
+	return &dbMonitor{
+		status: ConnectionStatus{Status: DBDisconnected, Since: time.Now().Format("2006-01-02 15:04:05")},
+		wake:   make(chan struct{}, 1),
+		notify: notify,
+	}
+}
+
+// usable reports whether bound methods may use the database
+func (m *dbMonitor) usable() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ready && m.status.Status != DBDisconnected
+}
+
+// isReady reports whether the schema has been prepared
+func (m *dbMonitor) isReady() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ready
+}
+
+// markReady records that the schema has been prepared and the startup work done
+func (m *dbMonitor) markReady() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ready = true
+}
+
+// current returns the connection state
+func (m *dbMonitor) current() ConnectionStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// set records the result of a ping or a failed query, logging and emitting
+// the new state when it differs from the previous one
+func (m *dbMonitor) set(state string, cause error, latency time.Duration, offline bool) {
+	m.mu.Lock()
+	previous := m.status
+	m.status.Status = state
+	m.status.LatencyMS = latency.Milliseconds()
+	m.status.Offline = offline && state == DBDisconnected
+	m.status.Error = ""
+	if cause != nil {
+		m.status.Error = cause.Error()
+	}
+	changed := previous.Status != state || previous.Error != m.status.Error
+	if previous.Status != state {
+		m.status.Since = time.Now().Format("2006-01-02 15:04:05")
+	}
+	status := m.status
+	m.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	switch {
+	case state == DBDisconnected && cause != nil:
+		log.Printf("⚠ Database unavailable: %v", cause)
+	case state == DBDegraded:
+		log.Printf("⚠ Database responding slowly (%d ms)", status.LatencyMS)
+	case previous.Status == DBDisconnected:
+		log.Println("✅ Database connected")
+	}
+	if m.notify != nil {
+		m.notify(status)
+	}
+}
+
+// requestCheck wakes the monitor for an immediate ping without waiting for it
+func (m *dbMonitor) requestCheck() {
+	if m == nil {
+		return
+	}
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// requireDB returns ErrDatabaseUnavailable unless the database can be used
+func (a *App) requireDB() error {
+	if a.db == nil || !a.conn.usable() {
+		return ErrDatabaseUnavailable
+	}
+	return nil
+}
+
+// lostConnection reports whether err means the server went away. If so the
+// database is marked disconnected until the monitor reaches it again.
+func (a *App) lostConnection(err error) bool {
+	if a.conn == nil || err == nil || !isConnectionError(err) {
+		return false
+	}
+	a.conn.set(DBDisconnected, err, 0, a.offline != nil)
+	return true
+}
+
+// GetConnectionStatus returns the database connection state. It needs no session
+// so the login page can show it before anyone signs in.
+func (a *App) GetConnectionStatus() ConnectionStatus {
+	if a.conn == nil {
+		return ConnectionStatus{Status: DBDisconnected, Error: ErrDatabaseUnavailable.Error()}
+	}
+	return a.conn.current()
+}
+
+// ==============================================================================
+// QUERY DEADLINES
+// ==============================================================================
+//
+// Statements run through a dbExecutor instead of calling *sql.DB directly. On
+// the pool each statement gets its own deadline (DB_QUERY_TIMEOUT_SECONDS); a
+// transaction gets one deadline for all of its statements
+// (DB_TRANSACTION_TIMEOUT_SECONDS). Both derive from the app's lifetime
+// context, so shutting the app down cancels whatever is still running.
+
+// contextQuerier is satisfied by *sql.DB and *sql.Tx
+type contextQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dbExecutor runs statements on the pool or a transaction under ctx
+type dbExecutor struct {
+	q       contextQuerier
+	ctx     context.Context
+	timeout time.Duration // Deadline of each statement; 0 inside a transaction, which has its own
+}
+
+// newDBExecutor returns the executor for statements on db outside a transaction
+func newDBExecutor(ctx context.Context, db *sql.DB, config DBConfig) dbExecutor {
+	return dbExecutor{q: db, ctx: ctx, timeout: time.Duration(config.QueryTimeoutSeconds) * time.Second}
+}
+
+// statementContext returns the context of one statement
+func (e dbExecutor) statementContext() (context.Context, context.CancelFunc) {
+	ctx := e.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if e.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, e.timeout)
+}
+
+func (e dbExecutor) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := e.statementContext()
+	defer cancel()
+	result, err := e.q.ExecContext(ctx, query, args...)
+	return result, deadlineError(ctx, err)
+}
+
+func (e dbExecutor) Query(query string, args ...interface{}) (*dbRows, error) {
+	ctx, cancel := e.statementContext()
+	rows, err := e.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, deadlineError(ctx, err)
+	}
+	return &dbRows{Rows: rows, ctx: ctx, cancel: cancel}, nil
+}
+
+func (e dbExecutor) QueryRow(query string, args ...interface{}) *dbRow {
+	ctx, cancel := e.statementContext()
+	return &dbRow{row: e.q.QueryRowContext(ctx, query, args...), ctx: ctx, cancel: cancel}
+}
+
+// dbRows releases the statement's context when the rows are closed
+type dbRows struct {
+	*sql.Rows
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (r *dbRows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+func (r *dbRows) Err() error {
+	return deadlineError(r.ctx, r.Rows.Err())
+}
+
+// dbRow releases the statement's context once it is scanned
+type dbRow struct {
+	row    *sql.Row
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (r *dbRow) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return deadlineError(r.ctx, r.row.Scan(dest...))
+}
+
+// deadlineError replaces the error of a statement that ran past its deadline with ErrDatabaseTimeout
+func deadlineError(ctx context.Context, err error) error {
+	if err != nil && err != ErrDatabaseTimeout && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		log.Printf("⚠ Database statement timed out: %v", err)
+		return ErrDatabaseTimeout
+	}
+	return err
+}
+
+// lifetimeContext returns the context cancelled when the app shuts down
+func (a *App) lifetimeContext() context.Context {
+	if a.lifetime == nil {
+		return context.Background()
+	}
+	return a.lifetime
+}
+
+// configurePool applies the connection pool limits of config
+func configurePool(db *sql.DB, config DBConfig) {
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetimeMinutes) * time.Minute)
+	db.SetConnMaxIdleTime(time.Duration(config.ConnMaxIdleMinutes) * time.Minute)
+}
+
+// connectDatabase opens the connection pool and makes the first connection
+// attempt, then leaves the monitor to retry and check on it
+func (a *App) connectDatabase(ctx context.Context) {
+	a.conn = newDBMonitor(func(status ConnectionStatus) {
+		runtime.EventsEmit(a.ctx, dbStatusEvent, status)
+	})
+
+	config := GetDBConfig()
+	db, dialect, err := openDatabase(config)
+	if err != nil {
+		log.Printf("Database connection failed: %v", err)
+		log.Println("App will start but database features will be unavailable")
+		a.conn.set(DBDisconnected, err, 0, false)
+		return
+	}
+	a.db = db
+	a.dialect = dialect
+	a.pool = newDBExecutor(ctx, db, config)
+	a.store = newStore(a.pool, dialect)
+
+	// Lab PCs on the shared server keep signing users in offline while it is unreachable
+	a.openOfflineQueue()
+
+	healthy := a.checkDatabase(ctx)
+	if !healthy {
+		log.Println("App will start and keep trying to reach the database")
+	}
+	go a.monitorDatabase(ctx, healthy)
+}
+
+// monitorDatabase pings the database until ctx is cancelled: at the health check
+// interval while it answers, and with exponential backoff while it does not
+func (a *App) monitorDatabase(ctx context.Context, healthy bool) {
+	policy := GetConnectionPolicy()
+	retryMin := time.Duration(policy.RetryMinSeconds) * time.Second
+	retryMax := time.Duration(policy.RetryMaxSeconds) * time.Second
+	backoff := retryMin
+
+	for {
+		wait := time.Duration(policy.HealthCheckSeconds) * time.Second
+		if healthy {
+			backoff = retryMin
+		} else {
+			wait = backoff
+			if backoff *= 2; backoff > retryMax {
+				backoff = retryMax
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-a.conn.wake:
+			timer.Stop()
+		}
+		healthy = a.checkDatabase(ctx)
+	}
+}
+
+// checkDatabase pings the database and records the result. The first successful
+// ping prepares the schema and runs the startup work; every one reloads the
+// settings table and replays the offline queue. It reports whether the database is usable.
+func (a *App) checkDatabase(ctx context.Context) bool {
+	policy := GetConnectionPolicy()
+	offline := a.offline != nil
+
+	pingCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	start := time.Now()
+	err := a.db.PingContext(pingCtx)
+	latency := time.Since(start)
+	cancel()
+	if err != nil {
+		a.conn.set(DBDisconnected, err, 0, offline)
+		return false
+	}
+
+	// This PC is registered before bound methods can see the database as usable
+	firstConnect := !a.conn.isReady()
+	if firstConnect {
+		if err := prepareSchema(a.db, a.dialect); err != nil {
+			log.Printf("❌ Schema migration failed: %v", err)
+			a.conn.set(DBDisconnected, err, 0, offline)
+			return false
+		}
+	}
+
+	// Settings changed by an admin on another PC take effect at the next check
+	a.loadDatabaseSettings()
+
+	if firstConnect {
+		a.registerComputer()
+		a.conn.markReady()
+		log.Println("Database ready")
+	}
+
+	state := DBConnected
+	if latency > time.Duration(policy.DegradedLatencyMS)*time.Millisecond {
+		state = DBDegraded
+	}
+	a.conn.set(state, nil, latency, offline)
+
+	if firstConnect {
+		// Close sessions left open by crashed or powered-off PCs
+		go a.runSessionReaper(ctx)
+		// Open and finalize each class day's attendance
+		go a.runAttendanceScheduler(ctx)
+	}
+	if offline {
+		a.syncOfflineQueue()
+	}
+	return a.conn.usable()
+}
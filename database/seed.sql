@@ -28,7 +28,7 @@ USE logbookdb;
 -- Admin Account #1: Primary System Administrator
 -- ----------------------------------------------------------------------------
 -- Username: 2211172
--- Password: admin123 (PLAIN TEXT - should be hashed in production)
+-- Password: admin123 (seeded as plain text; rehashed with argon2id on first login)
 -- Purpose: Primary system administrator account
 -- ----------------------------------------------------------------------------
 INSERT INTO users (id, username, password, user_type, is_active, created_at) VALUES 
@@ -6,6 +6,7 @@ import AdminDashboard from './pages/AdminDashboard';
 import TeacherDashboard from './pages/TeacherDashboard';
 import StudentDashboard from './pages/StudentDashboard';
 import WorkingStudentDashboard from './pages/WorkingStudentDashboard';
+import ForcePasswordChange from './components/ForcePasswordChange';
 import './style.css';
 
 // Inner routes component that uses auth context
@@ -13,7 +14,13 @@ function AppRoutes() {
   // Protected Route component - must be inside AuthProvider
   function ProtectedRoute({ children }: { children: React.ReactNode }) {
     const { user } = useAuth();
-    return user ? <>{children}</> : <Navigate to="/login" />;
+    if (!user) {
+      return <Navigate to="/login" />;
+    }
+    if (user.must_change_password) {
+      return <ForcePasswordChange />;
+    }
+    return <>{children}</>;
   }
 
   // Role-based route protection - must be inside AuthProvider
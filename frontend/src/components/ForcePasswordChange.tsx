@@ -0,0 +1,127 @@
+import React, { useState, useEffect } from 'react';
+import { useNavigate } from 'react-router-dom';
+import { Lock } from 'lucide-react';
+import { useAuth } from '../contexts/AuthContext';
+import { ChangePassword, GetPasswordPolicy } from '../../wailsjs/go/main/App';
+import { main } from '../../wailsjs/go/models';
+
+// Shown instead of the dashboard until the user replaces a temporary or expired password
+function ForcePasswordChange() {
+  const { user, logout, updateUser } = useAuth();
+  const navigate = useNavigate();
+  const [oldPassword, setOldPassword] = useState('');
+  const [newPassword, setNewPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [error, setError] = useState('');
+  const [saving, setSaving] = useState(false);
+  const [policy, setPolicy] = useState<main.PasswordPolicy | null>(null);
+
+  useEffect(() => {
+    GetPasswordPolicy().then(setPolicy).catch(() => setPolicy(null));
+  }, []);
+
+  if (!user) return null;
+
+  const rules: string[] = [];
+  if (policy) {
+    rules.push(`At least ${policy.min_length} characters`);
+    if (policy.require_uppercase) rules.push('An uppercase letter');
+    if (policy.require_lowercase) rules.push('A lowercase letter');
+    if (policy.require_digit) rules.push('A digit');
+    if (policy.require_symbol) rules.push('A symbol');
+    if (policy.disallow_student_code) rules.push('Not your username or student code');
+  }
+
+  const handleSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setError('');
+
+    if (!oldPassword || !newPassword || !confirmPassword) {
+      setError('All fields are required');
+      return;
+    }
+    if (newPassword !== confirmPassword) {
+      setError('New passwords do not match');
+      return;
+    }
+
+    setSaving(true);
+    try {
+      await ChangePassword(user.name, oldPassword, newPassword);
+      updateUser({ must_change_password: false, password_change_reason: '' });
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  const handleLogout = async () => {
+    await logout();
+    navigate('/login');
+  };
+
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gray-50 px-4">
+      <div className="w-full max-w-md bg-white rounded-lg shadow-lg p-8">
+        <div className="flex items-center mb-4">
+          <Lock className="h-6 w-6 text-primary-600 mr-2" />
+          <h2 className="text-xl font-semibold text-gray-900">Change your password</h2>
+        </div>
+        <p className="text-sm text-gray-600 mb-4">
+          {user.password_change_reason === 'expired'
+            ? 'Your password has expired. Choose a new password to continue.'
+            : 'You are signing in with a temporary password. Choose a new password to continue.'}
+        </p>
+
+        {rules.length > 0 && (
+          <ul className="text-xs text-gray-500 mb-4 list-disc list-inside">
+            {rules.map(rule => <li key={rule}>{rule}</li>)}
+          </ul>
+        )}
+
+        {error && (
+          <div className="mb-4 p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{error}</div>
+        )}
+
+        <form onSubmit={handleSubmit} className="space-y-4">
+          <input
+            type="password"
+            placeholder="Current password"
+            value={oldPassword}
+            onChange={e => setOldPassword(e.target.value)}
+            className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-primary-500"
+          />
+          <input
+            type="password"
+            placeholder="New password"
+            value={newPassword}
+            onChange={e => setNewPassword(e.target.value)}
+            className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-primary-500"
+          />
+          <input
+            type="password"
+            placeholder="Confirm new password"
+            value={confirmPassword}
+            onChange={e => setConfirmPassword(e.target.value)}
+            className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-primary-500"
+          />
+          <div className="flex justify-between items-center">
+            <button type="button" onClick={handleLogout} className="text-sm text-gray-600 hover:text-gray-900">
+              Sign out
+            </button>
+            <button
+              type="submit"
+              disabled={saving}
+              className="px-4 py-2 bg-primary-600 text-white rounded-md hover:bg-primary-700 disabled:opacity-50"
+            >
+              {saving ? 'Saving...' : 'Change Password'}
+            </button>
+          </div>
+        </form>
+      </div>
+    </div>
+  );
+}
+
+export default ForcePasswordChange;
@@ -9,9 +9,11 @@ import {
   LogOut,
   ChevronDown,
   Lock,
-  UserCircle
+  UserCircle,
+  Shield
 } from 'lucide-react';
 import LogoutFeedbackModal from './LogoutFeedbackModal';
+import TwoFactorSettings from './TwoFactorSettings';
 
 interface LayoutProps {
   children: React.ReactNode;
@@ -32,7 +34,7 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
   const [showAccountModal, setShowAccountModal] = useState(false);
   const [showLogoutConfirmModal, setShowLogoutConfirmModal] = useState(false);
   const [showFeedbackModal, setShowFeedbackModal] = useState(false);
-  const [activeTab, setActiveTab] = useState<'profile' | 'password'>('profile');
+  const [activeTab, setActiveTab] = useState<'profile' | 'password' | 'security'>('profile');
   const [photoFile, setPhotoFile] = useState<File | null>(null);
   const [photoPreview, setPhotoPreview] = useState<string>(user?.photo_url || '');
   
@@ -245,11 +247,6 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
       return;
     }
 
-    if (newPassword.length < 6) {
-      setPasswordError('New password must be at least 6 characters long');
-      return;
-    }
-
     if (!user) return;
 
     try {
@@ -266,7 +263,7 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
       }, 2000);
     } catch (error) {
       console.error('Failed to change password:', error);
-      setPasswordError('Failed to change password. Please check your old password.');
+      setPasswordError(String(error) || 'Failed to change password. Please check your old password.');
     }
   };
 
@@ -291,6 +288,9 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
     setProfileSuccess('');
   };
 
+  // Only admins may change names; everyone else edits their contact details
+  const canEditNames = user?.role === 'admin';
+
   const handleCancelEditProfile = () => {
     setEditingProfile(false);
     setProfileFormData({
@@ -323,7 +323,7 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
         profileFormData.lastName,
         '', // gender
         user.role || '',
-        '', // employeeID
+        user.employee_id || '', // employeeID
         user.student_id || user.name || '', // studentID
         '', // year - not editable
         '', // section - not editable
@@ -618,6 +618,21 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
                     <span>Change Password</span>
                   </div>
                 </button>
+                {(user?.role === 'admin' || user?.role === 'teacher') && (
+                  <button
+                    onClick={() => setActiveTab('security')}
+                    className={`py-4 px-1 border-b-2 font-medium text-sm transition-colors ${
+                      activeTab === 'security'
+                        ? 'border-blue-500 text-blue-600'
+                        : 'border-transparent text-gray-500 hover:text-gray-700 hover:border-gray-300'
+                    }`}
+                  >
+                    <div className="flex items-center space-x-2">
+                      <Shield className="h-5 w-5" />
+                      <span>Two-Factor</span>
+                    </div>
+                  </button>
+                )}
               </div>
             </div>
 
@@ -728,7 +743,8 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
                                   type="text"
                                   value={profileFormData.lastName}
                                   onChange={(e) => setProfileFormData({ ...profileFormData, lastName: e.target.value })}
-                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent w-56"
+                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent disabled:bg-gray-50 disabled:text-gray-600 w-56"
+                                  disabled={!canEditNames}
                                   required
                                 />
                               ) : (
@@ -748,7 +764,8 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
                                   type="text"
                                   value={profileFormData.firstName}
                                   onChange={(e) => setProfileFormData({ ...profileFormData, firstName: e.target.value })}
-                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent w-56"
+                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent disabled:bg-gray-50 disabled:text-gray-600 w-56"
+                                  disabled={!canEditNames}
                                   required
                                 />
                               ) : (
@@ -768,7 +785,8 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
                                   type="text"
                                   value={profileFormData.middleName}
                                   onChange={(e) => setProfileFormData({ ...profileFormData, middleName: e.target.value })}
-                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent w-56"
+                                  className="px-3 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent disabled:bg-gray-50 disabled:text-gray-600 w-56"
+                                  disabled={!canEditNames}
                                 />
                               ) : (
                                 <div className="px-3 py-2 bg-gray-50 rounded-md border border-gray-200 w-64">
@@ -1112,6 +1130,8 @@ function Layout({ children, navigationItems, title }: LayoutProps) {
                   </form>
                 </div>
               )}
+
+              {activeTab === 'security' && <TwoFactorSettings />}
             </div>
 
           </div>
@@ -0,0 +1,183 @@
+import React, { useState, useEffect } from 'react';
+import {
+  GetTwoFactorStatus,
+  BeginTwoFactorEnrollment,
+  ConfirmTwoFactorEnrollment,
+  DisableTwoFactor,
+  RegenerateRecoveryCodes
+} from '../../wailsjs/go/main/App';
+import { main } from '../../wailsjs/go/models';
+
+// Enrollment and management of authenticator-app sign-in for admins and teachers
+function TwoFactorSettings() {
+  const [status, setStatus] = useState<main.TwoFactorStatus | null>(null);
+  const [enrollment, setEnrollment] = useState<main.TwoFactorEnrollment | null>(null);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[]>([]);
+  const [code, setCode] = useState('');
+  const [error, setError] = useState('');
+  const [busy, setBusy] = useState(false);
+
+  const loadStatus = async () => {
+    try {
+      setStatus(await GetTwoFactorStatus());
+    } catch (err) {
+      setError(String(err));
+    }
+  };
+
+  useEffect(() => {
+    loadStatus();
+  }, []);
+
+  const run = async (action: () => Promise<void>) => {
+    setBusy(true);
+    setError('');
+    try {
+      await action();
+    } catch (err) {
+      setError(String(err));
+    } finally {
+      setBusy(false);
+      setCode('');
+    }
+  };
+
+  const handleBegin = () => run(async () => {
+    setRecoveryCodes([]);
+    setEnrollment(await BeginTwoFactorEnrollment());
+  });
+
+  const handleConfirm = (e: React.FormEvent) => {
+    e.preventDefault();
+    run(async () => {
+      setRecoveryCodes(await ConfirmTwoFactorEnrollment(code));
+      setEnrollment(null);
+      await loadStatus();
+    });
+  };
+
+  const handleRegenerate = (e: React.FormEvent) => {
+    e.preventDefault();
+    run(async () => {
+      setRecoveryCodes(await RegenerateRecoveryCodes(code));
+      await loadStatus();
+    });
+  };
+
+  const handleDisable = () => {
+    if (!window.confirm('Turn off two-factor authentication? Signing in will only require your password.')) return;
+    run(async () => {
+      await DisableTwoFactor(code);
+      setRecoveryCodes([]);
+      await loadStatus();
+    });
+  };
+
+  if (!status) return null;
+
+  if (!status.available) {
+    return <p className="text-sm text-gray-600">Two-factor authentication is only available to admins and teachers.</p>;
+  }
+
+  const codeInput = (
+    <input
+      type="text"
+      placeholder="6-digit code"
+      value={code}
+      onChange={(e) => setCode(e.target.value)}
+      autoComplete="one-time-code"
+      className="w-full px-4 py-2 border border-gray-300 rounded-md focus:ring-2 focus:ring-blue-500 focus:border-transparent bg-white tracking-widest"
+    />
+  );
+
+  return (
+    <div className="space-y-4">
+      <h4 className="text-2xl font-bold text-gray-900 mb-4">Two-Factor Authentication</h4>
+
+      {error && (
+        <div className="bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-md">{error}</div>
+      )}
+
+      {recoveryCodes.length > 0 && (
+        <div className="bg-yellow-50 border border-yellow-200 px-4 py-3 rounded-md">
+          <p className="text-sm font-medium text-yellow-800 mb-2">
+            Save these recovery codes somewhere safe. Each one can be used once if you lose your phone. They will not be shown again.
+          </p>
+          <div className="grid grid-cols-2 gap-1 font-mono text-sm text-gray-900">
+            {recoveryCodes.map(rc => <span key={rc}>{rc}</span>)}
+          </div>
+        </div>
+      )}
+
+      {status.enabled ? (
+        <>
+          <p className="text-sm text-green-700">
+            Two-factor authentication is on. {status.recovery_codes_remaining} recovery code(s) left.
+          </p>
+          <p className="text-sm text-gray-600">Enter a current code to get new recovery codes or to turn two-factor off.</p>
+          <form onSubmit={handleRegenerate} className="space-y-3">
+            {codeInput}
+            <div className="flex gap-2">
+              <button
+                type="submit"
+                disabled={busy || !code}
+                className="flex-1 px-4 py-2 bg-white border border-gray-300 text-gray-700 rounded-md hover:bg-gray-50 font-medium disabled:opacity-50"
+              >
+                New Recovery Codes
+              </button>
+              <button
+                type="button"
+                onClick={handleDisable}
+                disabled={busy || !code}
+                className="flex-1 px-4 py-2 bg-red-600 text-white rounded-md hover:bg-red-700 font-medium disabled:opacity-50"
+              >
+                Turn Off
+              </button>
+            </div>
+          </form>
+        </>
+      ) : enrollment ? (
+        <form onSubmit={handleConfirm} className="space-y-3">
+          <p className="text-sm text-gray-600">
+            Add this account to your authenticator app using the setup key below, then enter the code it shows.
+          </p>
+          <div>
+            <div className="text-xs font-medium text-gray-500 mb-1">Setup key</div>
+            <div className="px-3 py-2 bg-gray-50 rounded-md border border-gray-200 font-mono text-sm break-all select-all">
+              {enrollment.secret.match(/.{1,4}/g)?.join(' ')}
+            </div>
+          </div>
+          <div>
+            <div className="text-xs font-medium text-gray-500 mb-1">Setup link</div>
+            <div className="px-3 py-2 bg-gray-50 rounded-md border border-gray-200 font-mono text-xs text-gray-600 break-all select-all">
+              {enrollment.uri}
+            </div>
+          </div>
+          {codeInput}
+          <button
+            type="submit"
+            disabled={busy || !code}
+            className="w-full px-4 py-2 bg-blue-600 text-white rounded-md hover:bg-blue-700 font-medium disabled:opacity-50"
+          >
+            Verify and Turn On
+          </button>
+        </form>
+      ) : (
+        <>
+          <p className="text-sm text-gray-600">
+            Protect your account with a code from an authenticator app in addition to your password.
+          </p>
+          <button
+            onClick={handleBegin}
+            disabled={busy}
+            className="w-full px-4 py-2 bg-blue-600 text-white rounded-md hover:bg-blue-700 font-medium disabled:opacity-50"
+          >
+            Set Up Two-Factor Authentication
+          </button>
+        </>
+      )}
+    </div>
+  );
+}
+
+export default TwoFactorSettings;
@@ -1,5 +1,5 @@
 import React, { createContext, useContext, useState, useEffect } from 'react';
-import { Login, Logout } from '../../wailsjs/go/main/App';
+import { Login, Logout, ValidateSession, VerifyTwoFactorLogin } from '../../wailsjs/go/main/App';
 
 // Extend Window interface to include Wails runtime
 declare global {
@@ -28,11 +28,17 @@ interface User {
   contact_number?: string;
   photo_url?: string;
   created?: string;
+  session_token?: string;
+  must_change_password?: boolean;
+  password_change_reason?: string;
+  two_factor_required?: boolean;
+  two_factor_token?: string;
 }
 
 interface AuthContextType {
   user: User | null;
   login: (username: string, password: string) => Promise<User>;
+  verifyTwoFactor: (token: string, code: string) => Promise<User>;
   logout: () => Promise<void>;
   updateUser: (updatedUser: Partial<User>) => void;
   isAuthenticated: boolean;
@@ -50,8 +56,15 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     if (savedUser) {
       try {
         const parsedUser = JSON.parse(savedUser);
-        setUser(parsedUser);
-        setIsAuthenticated(true);
+        // The backend only knows the session while the app keeps running
+        ValidateSession(parsedUser.session_token || '')
+          .then(() => {
+            setUser(parsedUser);
+            setIsAuthenticated(true);
+          })
+          .catch(() => {
+            localStorage.removeItem('user');
+          });
       } catch (error) {
         console.error('Failed to parse saved user:', error);
         localStorage.removeItem('user');
@@ -59,6 +72,26 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
   }, []);
 
+  // Detect a session that was closed by a login on another PC or by the stale session reaper
+  useEffect(() => {
+    if (!user) return;
+
+    const SESSION_CHECK_INTERVAL = 60 * 1000; // 1 minute
+    const interval = setInterval(() => {
+      ValidateSession(user.session_token || '').catch((error) => {
+        const message = String(error);
+        if (message.startsWith('session_ended: ')) {
+          alert(`You were signed out: ${message.slice('session_ended: '.length)}.`);
+        }
+        setUser(null);
+        setIsAuthenticated(false);
+        localStorage.removeItem('user');
+      });
+    }, SESSION_CHECK_INTERVAL);
+
+    return () => clearInterval(interval);
+  }, [user]);
+
   // Handle automatic logout on window/app close, timeout, or inactivity
   useEffect(() => {
     if (!user) return;
@@ -160,6 +193,11 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
         throw new Error('Invalid credentials');
       }
 
+      // Two-factor accounts are not signed in until the code is verified
+      if (userData.two_factor_required) {
+        return userData;
+      }
+
       // Set user data
       setUser(userData);
       setIsAuthenticated(true);
@@ -178,6 +216,16 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     }
   };
 
+  const verifyTwoFactor = async (token: string, code: string): Promise<User> => {
+    const userData = await VerifyTwoFactorLogin(token, code);
+
+    setUser(userData);
+    setIsAuthenticated(true);
+    localStorage.setItem('user', JSON.stringify(userData));
+
+    return userData;
+  };
+
   const logout = async () => {
     try {
       // Call backend logout if user exists
@@ -210,6 +258,7 @@ export function AuthProvider({ children }: { children: React.ReactNode }) {
     <AuthContext.Provider value={{ 
       user, 
       login, 
+      verifyTwoFactor,
       logout, 
       updateUser,
       isAuthenticated 
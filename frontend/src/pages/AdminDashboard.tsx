@@ -23,7 +23,12 @@ import {
   FolderOpen,
   GraduationCap,
   BarChart3,
-  AlertCircle
+  AlertCircle,
+  Key,
+  History,
+  ShieldCheck,
+  ShieldOff,
+  Monitor
 } from 'lucide-react';
 import { 
   GetAdminDashboard, 
@@ -33,6 +38,8 @@ import {
   CreateUser, 
   UpdateUser, 
   DeleteUser,
+  AdminResetPassword,
+  AdminResetTwoFactor,
   GetAllLogs,
   GetFeedback,
   ExportLogsCSV,
@@ -42,7 +49,21 @@ import {
   GetDepartments,
   CreateDepartment,
   UpdateDepartment,
-  DeleteDepartment
+  DeleteDepartment,
+  GetAuditLog,
+  ExportAuditLogCSV,
+  ExportAuditLogPDF,
+  VerifyLogIntegrity,
+  GetComputers,
+  ApproveComputer,
+  UpdateComputer,
+  RevokeComputer,
+  GetAcademicTerms,
+  SaveAcademicTerm,
+  DeleteAcademicTerm,
+  GetCalendarEvents,
+  SaveCalendarEvent,
+  DeleteCalendarEvent
 } from '../../wailsjs/go/main/App';
 import { main } from '../../wailsjs/go/models';
 
@@ -81,6 +102,7 @@ interface LoginLog {
   pc_number?: string;
   login_time: string;
   logout_time?: string;
+  unregistered_pc: boolean;
 }
 
 // Use the generated Feedback model from main
@@ -314,7 +336,7 @@ function UserManagement() {
     if (ids.length === 0) return;
     if (!confirm(`Delete ${ids.length} selected user(s)? This cannot be undone.`)) return;
     try {
-      await Promise.all(ids.map((id) => DeleteUser(id)));
+      await Promise.all(ids.map((id) => DeleteUser(id, false)));
       setSelectedIds(new Set());
       showNotification('success', `${ids.length} user(s) deleted successfully!`);
       loadUsers();
@@ -351,16 +373,16 @@ function UserManagement() {
       // Use server-side filtering for better performance
       if (searchTerm && userTypeFilter) {
         // Search with user type filter
-        data = await SearchUsers(searchTerm, userTypeFilter);
+        data = await SearchUsers(searchTerm, userTypeFilter, '');
       } else if (searchTerm) {
         // Search all users
-        data = await SearchUsers(searchTerm, '');
+        data = await SearchUsers(searchTerm, '', '');
       } else if (userTypeFilter) {
         // Filter by user type only
         data = await GetUsersByType(userTypeFilter);
       } else {
         // Get all users
-        data = await GetUsers();
+        data = await GetUsers('');
       }
       
       // Ensure data is always an array, even if API returns null/undefined
@@ -497,7 +519,7 @@ function UserManagement() {
   const handleDelete = async (id: number) => {
     if (confirm('Are you sure you want to delete this user?')) {
       try {
-        await DeleteUser(id);
+        await DeleteUser(id, false);
         showNotification('success', 'User deleted successfully!');
         loadUsers();
       } catch (error) {
@@ -508,6 +530,30 @@ function UserManagement() {
     }
   };
 
+  const handleResetPassword = async (id: number, name: string) => {
+    if (confirm(`Reset the password for ${name}? They will have to choose a new one when they next sign in.`)) {
+      try {
+        const tempPassword = await AdminResetPassword(id);
+        alert(`Temporary password for ${name}: ${tempPassword}\n\nThis will not be shown again.`);
+      } catch (error) {
+        console.error('Failed to reset password:', error);
+        showNotification('error', String(error));
+      }
+    }
+  };
+
+  const handleResetTwoFactor = async (id: number, name: string) => {
+    if (confirm(`Remove two-factor authentication for ${name}? Use this when they have lost their phone. They can set it up again after signing in.`)) {
+      try {
+        await AdminResetTwoFactor(id);
+        showNotification('success', `Two-factor authentication reset for ${name}`);
+      } catch (error) {
+        console.error('Failed to reset two-factor authentication:', error);
+        showNotification('error', String(error));
+      }
+    }
+  };
+
   if (loading) {
     return (
       <div className="flex items-center justify-center h-64">
@@ -990,6 +1036,22 @@ function UserManagement() {
                         >
                           <Edit className="h-3 w-3" />
                         </button>
+                        <button
+                          onClick={() => handleResetPassword(user.id, user.name)}
+                          className="inline-flex items-center justify-center px-2 py-1 text-xs font-medium rounded text-white bg-yellow-600 hover:bg-yellow-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-yellow-500"
+                          title="Reset Password"
+                        >
+                          <Key className="h-3 w-3" />
+                        </button>
+                        {(user.role === 'admin' || user.role === 'teacher') && (
+                          <button
+                            onClick={() => handleResetTwoFactor(user.id, user.name)}
+                            className="inline-flex items-center justify-center px-2 py-1 text-xs font-medium rounded text-white bg-gray-600 hover:bg-gray-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-gray-500"
+                            title="Reset Two-Factor"
+                          >
+                            <ShieldOff className="h-3 w-3" />
+                          </button>
+                        )}
                         <button
                           onClick={() => handleDelete(user.id)}
                           className="inline-flex items-center justify-center px-2 py-1 text-xs font-medium rounded text-white bg-red-600 hover:bg-red-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-red-500"
@@ -1409,6 +1471,11 @@ function ViewLogs() {
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-700">
                         {log.pc_number || <span className="text-gray-400">N/A</span>}
+                        {log.unregistered_pc && (
+                          <span className="ml-2 inline-flex items-center px-2 py-0.5 rounded-full text-xs font-medium bg-yellow-100 text-yellow-800">
+                            Unregistered
+                          </span>
+                        )}
                       </td>
                       <td className="px-6 py-4 whitespace-nowrap text-sm text-gray-700">
                         {log.login_time ? new Date(log.login_time).toLocaleTimeString('en-US', { 
@@ -2413,6 +2480,689 @@ function DepartmentManagement() {
   );
 }
 
+function AuditLog() {
+  const [entries, setEntries] = useState<main.AuditEntry[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string>('');
+  const [expandedId, setExpandedId] = useState<number | null>(null);
+  const [integrity, setIntegrity] = useState<main.IntegrityReport | null>(null);
+  const [verifying, setVerifying] = useState(false);
+  const [filter, setFilter] = useState({
+    action: '',
+    entity_type: '',
+    entity_key: '',
+    date_from: '',
+    date_to: '',
+  });
+
+  const buildFilter = () => new main.AuditLogFilter({ ...filter, actor_user_id: 0, limit: 0 });
+
+  const loadEntries = async () => {
+    setLoading(true);
+    try {
+      const data = await GetAuditLog(buildFilter());
+      setEntries(data || []);
+      setError('');
+    } catch (error) {
+      console.error('Failed to load audit log:', error);
+      setError('Failed to load audit log. Please check your database connection.');
+      setEntries([]);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadEntries();
+  }, []);
+
+  const handleExport = async (format: 'csv' | 'pdf') => {
+    try {
+      const filename = format === 'csv'
+        ? await ExportAuditLogCSV(buildFilter())
+        : await ExportAuditLogPDF(buildFilter());
+      alert(`Audit log exported to ${filename}`);
+    } catch (error) {
+      console.error('Failed to export audit log:', error);
+      alert('Failed to export audit log');
+    }
+  };
+
+  const handleVerify = async () => {
+    setVerifying(true);
+    try {
+      setIntegrity(await VerifyLogIntegrity(filter.date_from, filter.date_to));
+    } catch (error) {
+      console.error('Failed to verify log integrity:', error);
+      alert('Failed to verify log integrity');
+    } finally {
+      setVerifying(false);
+    }
+  };
+
+  const inputClass = 'px-3 py-2 border border-gray-300 rounded-md text-sm focus:outline-none focus:ring-2 focus:ring-primary-500';
+
+  return (
+    <div>
+      <div className="flex justify-between items-center mb-4">
+        <h2 className="text-2xl font-bold text-gray-900">Audit Log</h2>
+        <div className="flex gap-2">
+          <button
+            onClick={handleVerify}
+            disabled={verifying}
+            className="inline-flex items-center px-4 py-2 border border-gray-300 rounded-md shadow-sm text-sm font-medium text-gray-700 bg-white hover:bg-gray-50 disabled:opacity-50"
+          >
+            <ShieldCheck className="h-4 w-4 mr-2" />
+            {verifying ? 'Verifying...' : 'Verify Integrity'}
+          </button>
+          <button
+            onClick={() => handleExport('csv')}
+            className="inline-flex items-center px-4 py-2 border border-gray-300 rounded-md shadow-sm text-sm font-medium text-gray-700 bg-white hover:bg-gray-50"
+          >
+            <Download className="h-4 w-4 mr-2" />
+            Export CSV
+          </button>
+          <button
+            onClick={() => handleExport('pdf')}
+            className="inline-flex items-center px-4 py-2 border border-gray-300 rounded-md shadow-sm text-sm font-medium text-white bg-red-600 hover:bg-red-700"
+          >
+            <Download className="h-4 w-4 mr-2" />
+            Export PDF
+          </button>
+        </div>
+      </div>
+
+      <div className="flex flex-wrap gap-2 mb-4">
+        <select value={filter.entity_type} onChange={(e) => setFilter({ ...filter, entity_type: e.target.value })} className={inputClass}>
+          <option value="">All entities</option>
+          <option value="user">Users</option>
+          <option value="department">Departments</option>
+          <option value="subject">Subjects</option>
+          <option value="class">Classes</option>
+          <option value="enrollment">Enrollment</option>
+          <option value="attendance">Attendance</option>
+          <option value="feedback">Feedback</option>
+          <option value="computer">Computers</option>
+        </select>
+        <input placeholder="Action" value={filter.action} onChange={(e) => setFilter({ ...filter, action: e.target.value })} className={inputClass} />
+        <input placeholder="Entity key" value={filter.entity_key} onChange={(e) => setFilter({ ...filter, entity_key: e.target.value })} className={inputClass} />
+        <input type="date" value={filter.date_from} onChange={(e) => setFilter({ ...filter, date_from: e.target.value })} className={inputClass} />
+        <input type="date" value={filter.date_to} onChange={(e) => setFilter({ ...filter, date_to: e.target.value })} className={inputClass} />
+        <button onClick={loadEntries} className="inline-flex items-center px-4 py-2 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">
+          <Search className="h-4 w-4 mr-2" />
+          Apply
+        </button>
+      </div>
+
+      {error && <div className="mb-4 p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{error}</div>}
+
+      {integrity && (
+        <div className={`mb-4 p-3 border text-sm rounded ${integrity.valid ? 'bg-green-50 border-green-200 text-green-700' : 'bg-red-50 border-red-200 text-red-700'}`}>
+          <div className="flex justify-between items-start">
+            <div>
+              <div className="font-medium">
+                {integrity.valid
+                  ? `Attendance and login logs verified: ${integrity.entries_checked} chain entries, ${integrity.rows_checked} rows`
+                  : `${integrity.issues.length} integrity issue(s) found`}
+              </div>
+              <div className="text-xs font-mono mt-1">Chain head #{integrity.head.seq} {integrity.head.hash}</div>
+            </div>
+            <button onClick={() => setIntegrity(null)}><X className="h-4 w-4" /></button>
+          </div>
+          {!integrity.valid && (
+            <ul className="mt-2 text-xs list-disc list-inside max-h-48 overflow-y-auto">
+              {integrity.issues.map((issue, i) => (
+                <li key={i}>{issue.kind} - {issue.entity_type} {issue.entity_key}: {issue.detail}</li>
+              ))}
+            </ul>
+          )}
+        </div>
+      )}
+
+      {loading ? (
+        <div className="flex items-center justify-center h-64">
+          <div className="animate-spin rounded-full h-32 w-32 border-b-2 border-primary-500"></div>
+        </div>
+      ) : (
+        <div className="bg-white shadow rounded-lg overflow-x-auto">
+          <table className="min-w-full divide-y divide-gray-200">
+            <thead className="bg-gray-50">
+              <tr>
+                {['Timestamp', 'Actor', 'Action', 'Entity', 'Key', 'PC'].map((heading) => (
+                  <th key={heading} className="px-4 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">{heading}</th>
+                ))}
+              </tr>
+            </thead>
+            <tbody className="bg-white divide-y divide-gray-200">
+              {entries.length === 0 ? (
+                <tr>
+                  <td colSpan={6} className="px-4 py-8 text-center text-sm text-gray-500">No audit entries found</td>
+                </tr>
+              ) : entries.map((entry) => (
+                <React.Fragment key={entry.id}>
+                  <tr className="hover:bg-gray-50 cursor-pointer" onClick={() => setExpandedId(expandedId === entry.id ? null : entry.id)}>
+                    <td className="px-4 py-2 text-sm text-gray-900 whitespace-nowrap">{entry.created_at}</td>
+                    <td className="px-4 py-2 text-sm text-gray-900">{entry.actor_username || '-'}</td>
+                    <td className="px-4 py-2 text-sm text-gray-900">{entry.action}</td>
+                    <td className="px-4 py-2 text-sm text-gray-900">{entry.entity_type}</td>
+                    <td className="px-4 py-2 text-sm text-gray-900">{entry.entity_key}</td>
+                    <td className="px-4 py-2 text-sm text-gray-500">{entry.pc_hostname || '-'}</td>
+                  </tr>
+                  {expandedId === entry.id && (
+                    <tr>
+                      <td colSpan={6} className="px-4 py-3 bg-gray-50">
+                        <div className="grid grid-cols-2 gap-4 text-xs">
+                          <div>
+                            <div className="font-medium text-gray-700 mb-1">Before</div>
+                            <pre className="whitespace-pre-wrap break-all text-gray-600">{entry.before ? JSON.stringify(JSON.parse(entry.before), null, 2) : '-'}</pre>
+                          </div>
+                          <div>
+                            <div className="font-medium text-gray-700 mb-1">After</div>
+                            <pre className="whitespace-pre-wrap break-all text-gray-600">{entry.after ? JSON.stringify(JSON.parse(entry.after), null, 2) : '-'}</pre>
+                          </div>
+                        </div>
+                      </td>
+                    </tr>
+                  )}
+                </React.Fragment>
+              ))}
+            </tbody>
+          </table>
+        </div>
+      )}
+    </div>
+  );
+}
+
+function ComputerManagement() {
+  const [computers, setComputers] = useState<main.Computer[]>([]);
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string>('');
+  const [editing, setEditing] = useState<main.Computer | null>(null);
+  const [form, setForm] = useState({ display_name: '', room: '', asset_tag: '' });
+  const [formError, setFormError] = useState('');
+
+  const loadComputers = async () => {
+    try {
+      const data = await GetComputers();
+      setComputers(data || []);
+      setError('');
+    } catch (error) {
+      console.error('Failed to load computers:', error);
+      setError('Failed to load computers. Please check your database connection.');
+      setComputers([]);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadComputers();
+  }, []);
+
+  const openEdit = (computer: main.Computer) => {
+    setEditing(computer);
+    setFormError('');
+    setForm({
+      display_name: computer.display_name || '',
+      room: computer.room || '',
+      asset_tag: computer.asset_tag || '',
+    });
+  };
+
+  const handleSave = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!editing) return;
+    try {
+      if (editing.status === 'approved') {
+        await UpdateComputer(editing.id, form.display_name, form.room, form.asset_tag);
+      } else {
+        await ApproveComputer(editing.id, form.display_name, form.room, form.asset_tag);
+      }
+      setEditing(null);
+      loadComputers();
+    } catch (error) {
+      setFormError(String(error));
+    }
+  };
+
+  const handleRevoke = async (computer: main.Computer) => {
+    if (!window.confirm(`Revoke ${computer.display_name || computer.hostname}? Logins from it will be treated as unregistered.`)) return;
+    try {
+      await RevokeComputer(computer.id);
+      loadComputers();
+    } catch (error) {
+      console.error('Failed to revoke computer:', error);
+      alert('Failed to revoke computer');
+    }
+  };
+
+  const statusClass: Record<string, string> = {
+    pending: 'bg-yellow-100 text-yellow-800',
+    approved: 'bg-green-100 text-green-800',
+    revoked: 'bg-red-100 text-red-800',
+  };
+  const inputClass = 'w-full px-3 py-2 border border-gray-300 rounded-md text-sm focus:outline-none focus:ring-2 focus:ring-primary-500';
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="animate-spin rounded-full h-32 w-32 border-b-2 border-primary-500"></div>
+      </div>
+    );
+  }
+
+  return (
+    <div>
+      <div className="mb-4">
+        <h2 className="text-2xl font-bold text-gray-900">Lab Computers</h2>
+        <p className="text-sm text-gray-600">Each PC requests registration when the app first starts. Approved PCs are logged under their display name.</p>
+      </div>
+
+      {error && <div className="mb-4 p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{error}</div>}
+
+      <div className="bg-white shadow rounded-lg overflow-x-auto">
+        <table className="min-w-full divide-y divide-gray-200">
+          <thead className="bg-gray-50">
+            <tr>
+              {['Display Name', 'Hostname', 'Room', 'Asset Tag', 'Status', 'Requested', 'Last Seen', ''].map((heading) => (
+                <th key={heading} className="px-4 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider">{heading}</th>
+              ))}
+            </tr>
+          </thead>
+          <tbody className="bg-white divide-y divide-gray-200">
+            {computers.length === 0 ? (
+              <tr>
+                <td colSpan={8} className="px-4 py-8 text-center text-sm text-gray-500">No computers have requested registration</td>
+              </tr>
+            ) : computers.map((computer) => (
+              <tr key={computer.id} className="hover:bg-gray-50">
+                <td className="px-4 py-2 text-sm font-medium text-gray-900">
+                  {computer.display_name || '-'}
+                  {computer.is_this_pc && <span className="ml-2 text-xs text-primary-600">(this PC)</span>}
+                </td>
+                <td className="px-4 py-2 text-sm text-gray-700" title={computer.mac_addresses}>{computer.hostname}</td>
+                <td className="px-4 py-2 text-sm text-gray-700">{computer.room || '-'}</td>
+                <td className="px-4 py-2 text-sm text-gray-700">{computer.asset_tag || '-'}</td>
+                <td className="px-4 py-2 text-sm">
+                  <span className={`inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium ${statusClass[computer.status]}`}>
+                    {computer.status.replace(/\b\w/g, l => l.toUpperCase())}
+                  </span>
+                </td>
+                <td className="px-4 py-2 text-sm text-gray-500 whitespace-nowrap">{computer.requested_at}</td>
+                <td className="px-4 py-2 text-sm text-gray-500 whitespace-nowrap">{computer.last_seen_at || '-'}</td>
+                <td className="px-4 py-2 text-sm text-right whitespace-nowrap">
+                  <button onClick={() => openEdit(computer)} className="text-primary-600 hover:text-primary-900 mr-3">
+                    {computer.status === 'approved' ? 'Edit' : 'Approve'}
+                  </button>
+                  {computer.status === 'approved' && (
+                    <button onClick={() => handleRevoke(computer)} className="text-red-600 hover:text-red-900">Revoke</button>
+                  )}
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+
+      {editing && (
+        <div className="fixed inset-0 bg-gray-600 bg-opacity-50 flex items-center justify-center z-50">
+          <form onSubmit={handleSave} className="bg-white rounded-lg shadow-xl w-full max-w-md p-6 space-y-4">
+            <div className="flex justify-between items-center">
+              <h3 className="text-lg font-semibold text-gray-900">
+                {editing.status === 'approved' ? 'Edit Computer' : 'Approve Computer'}
+              </h3>
+              <button type="button" onClick={() => setEditing(null)}><X className="h-5 w-5 text-gray-400" /></button>
+            </div>
+            <p className="text-sm text-gray-600">Hostname: {editing.hostname}</p>
+            {formError && <div className="p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{formError}</div>}
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">Display Name</label>
+              <input value={form.display_name} maxLength={20} placeholder="e.g. LAB1-PC05" onChange={(e) => setForm({ ...form, display_name: e.target.value })} className={inputClass} required />
+            </div>
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">Room</label>
+              <input value={form.room} onChange={(e) => setForm({ ...form, room: e.target.value })} className={inputClass} />
+            </div>
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">Asset Tag</label>
+              <input value={form.asset_tag} onChange={(e) => setForm({ ...form, asset_tag: e.target.value })} className={inputClass} />
+            </div>
+            <div className="flex justify-end gap-2">
+              <button type="button" onClick={() => setEditing(null)} className="px-4 py-2 border border-gray-300 rounded-md text-sm font-medium text-gray-700 bg-white hover:bg-gray-50">Cancel</button>
+              <button type="submit" className="px-4 py-2 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">
+                {editing.status === 'approved' ? 'Save' : 'Approve'}
+              </button>
+            </div>
+          </form>
+        </div>
+      )}
+    </div>
+  );
+}
+
+const weekdayNames = ['Sunday', 'Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday'];
+
+function CalendarManagement() {
+  const thisYear = new Date().getFullYear();
+  const [terms, setTerms] = useState<main.AcademicTerm[]>([]);
+  const [events, setEvents] = useState<main.CalendarEvent[]>([]);
+  const [departments, setDepartments] = useState<{ department_code: string; department_name: string }[]>([]);
+  const [range, setRange] = useState({ from: `${thisYear}-01-01`, to: `${thisYear}-12-31` });
+  const [loading, setLoading] = useState(true);
+  const [error, setError] = useState<string>('');
+  const [editingTerm, setEditingTerm] = useState<main.AcademicTerm | null>(null);
+  const [editingEvent, setEditingEvent] = useState<main.CalendarEvent | null>(null);
+  const [formError, setFormError] = useState('');
+
+  const loadCalendar = async () => {
+    try {
+      const [termData, eventData, departmentData] = await Promise.all([
+        GetAcademicTerms(),
+        GetCalendarEvents(range.from, range.to),
+        GetDepartments(),
+      ]);
+      setTerms(termData || []);
+      setEvents(eventData || []);
+      setDepartments(departmentData || []);
+      setError('');
+    } catch (error) {
+      console.error('Failed to load calendar:', error);
+      setError('Failed to load the academic calendar. Please check your database connection.');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  useEffect(() => {
+    loadCalendar();
+  }, [range.from, range.to]);
+
+  const openTerm = (term?: main.AcademicTerm) => {
+    setFormError('');
+    setEditingTerm(main.AcademicTerm.createFrom(term || { term_id: 0, school_year: '', semester: '', start_date: '', end_date: '' }));
+  };
+
+  const openEvent = (event?: main.CalendarEvent) => {
+    setFormError('');
+    setEditingEvent(main.CalendarEvent.createFrom(event || {
+      event_id: 0, event_type: 'holiday', scope: 'school', scope_value: '', start_date: '', end_date: '', description: '',
+    }));
+  };
+
+  const handleSaveTerm = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!editingTerm) return;
+    try {
+      await SaveAcademicTerm(editingTerm);
+      setEditingTerm(null);
+      loadCalendar();
+    } catch (error) {
+      setFormError(String(error));
+    }
+  };
+
+  const handleSaveEvent = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!editingEvent) return;
+    try {
+      await SaveCalendarEvent(editingEvent);
+      setEditingEvent(null);
+      loadCalendar();
+    } catch (error) {
+      setFormError(String(error));
+    }
+  };
+
+  const handleDeleteTerm = async (term: main.AcademicTerm) => {
+    if (!window.confirm(`Delete the ${term.semester} ${term.school_year} term? Its classes will no longer be limited to its dates.`)) return;
+    try {
+      await DeleteAcademicTerm(term.term_id);
+      loadCalendar();
+    } catch (error) {
+      console.error('Failed to delete term:', error);
+      alert('Failed to delete term');
+    }
+  };
+
+  const handleDeleteEvent = async (event: main.CalendarEvent) => {
+    if (!window.confirm(`Delete "${event.description}"?`)) return;
+    try {
+      await DeleteCalendarEvent(event.event_id);
+      loadCalendar();
+    } catch (error) {
+      console.error('Failed to delete event:', error);
+      alert('Failed to delete event');
+    }
+  };
+
+  const typeClass: Record<string, string> = {
+    holiday: 'bg-blue-100 text-blue-800',
+    suspension: 'bg-red-100 text-red-800',
+    makeup: 'bg-green-100 text-green-800',
+  };
+  const typeLabel: Record<string, string> = { holiday: 'Holiday', suspension: 'Suspension', makeup: 'Make-up Day' };
+  const inputClass = 'w-full px-3 py-2 border border-gray-300 rounded-md text-sm focus:outline-none focus:ring-2 focus:ring-primary-500';
+  const headingClass = 'px-4 py-3 text-left text-xs font-medium text-gray-500 uppercase tracking-wider';
+
+  const scopeText = (event: main.CalendarEvent) => {
+    if (event.scope === 'department') return `Department ${event.scope_value}`;
+    if (event.scope === 'room') return `Room ${event.scope_value}`;
+    return 'Whole school';
+  };
+
+  if (loading) {
+    return (
+      <div className="flex items-center justify-center h-64">
+        <div className="animate-spin rounded-full h-32 w-32 border-b-2 border-primary-500"></div>
+      </div>
+    );
+  }
+
+  return (
+    <div className="space-y-6">
+      <div>
+        <h2 className="text-2xl font-bold text-gray-900">Academic Calendar</h2>
+        <p className="text-sm text-gray-600">Attendance is not taken outside a class's term, on holidays or during suspensions. A make-up day holds the classes of another weekday.</p>
+      </div>
+
+      {error && <div className="p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{error}</div>}
+
+      <div className="bg-white shadow rounded-lg overflow-x-auto">
+        <div className="flex justify-between items-center px-4 py-3 border-b border-gray-200">
+          <h3 className="text-lg font-semibold text-gray-900">Terms</h3>
+          <button onClick={() => openTerm()} className="inline-flex items-center px-3 py-1.5 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">
+            <Plus className="h-4 w-4 mr-1" /> Add Term
+          </button>
+        </div>
+        <table className="min-w-full divide-y divide-gray-200">
+          <thead className="bg-gray-50">
+            <tr>
+              {['School Year', 'Semester', 'Start', 'End', ''].map((heading) => (
+                <th key={heading} className={headingClass}>{heading}</th>
+              ))}
+            </tr>
+          </thead>
+          <tbody className="bg-white divide-y divide-gray-200">
+            {terms.length === 0 ? (
+              <tr>
+                <td colSpan={5} className="px-4 py-8 text-center text-sm text-gray-500">No terms yet; classes are not limited to term dates</td>
+              </tr>
+            ) : terms.map((term) => (
+              <tr key={term.term_id} className="hover:bg-gray-50">
+                <td className="px-4 py-2 text-sm font-medium text-gray-900">{term.school_year}</td>
+                <td className="px-4 py-2 text-sm text-gray-700">{term.semester}</td>
+                <td className="px-4 py-2 text-sm text-gray-700 whitespace-nowrap">{term.start_date}</td>
+                <td className="px-4 py-2 text-sm text-gray-700 whitespace-nowrap">{term.end_date}</td>
+                <td className="px-4 py-2 text-sm text-right whitespace-nowrap">
+                  <button onClick={() => openTerm(term)} className="text-primary-600 hover:text-primary-900 mr-3">Edit</button>
+                  <button onClick={() => handleDeleteTerm(term)} className="text-red-600 hover:text-red-900">Delete</button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+
+      <div className="bg-white shadow rounded-lg overflow-x-auto">
+        <div className="flex flex-wrap gap-2 justify-between items-center px-4 py-3 border-b border-gray-200">
+          <h3 className="text-lg font-semibold text-gray-900">Holidays, Suspensions and Make-up Days</h3>
+          <div className="flex items-center gap-2">
+            <input type="date" value={range.from} onChange={(e) => setRange({ ...range, from: e.target.value })} className="px-2 py-1 border border-gray-300 rounded-md text-sm" />
+            <span className="text-sm text-gray-500">to</span>
+            <input type="date" value={range.to} onChange={(e) => setRange({ ...range, to: e.target.value })} className="px-2 py-1 border border-gray-300 rounded-md text-sm" />
+            <button onClick={() => openEvent()} className="inline-flex items-center px-3 py-1.5 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">
+              <Plus className="h-4 w-4 mr-1" /> Add Event
+            </button>
+          </div>
+        </div>
+        <table className="min-w-full divide-y divide-gray-200">
+          <thead className="bg-gray-50">
+            <tr>
+              {['Dates', 'Type', 'Description', 'Applies To', ''].map((heading) => (
+                <th key={heading} className={headingClass}>{heading}</th>
+              ))}
+            </tr>
+          </thead>
+          <tbody className="bg-white divide-y divide-gray-200">
+            {events.length === 0 ? (
+              <tr>
+                <td colSpan={5} className="px-4 py-8 text-center text-sm text-gray-500">No events in these dates</td>
+              </tr>
+            ) : events.map((event) => (
+              <tr key={event.event_id} className="hover:bg-gray-50">
+                <td className="px-4 py-2 text-sm text-gray-700 whitespace-nowrap">
+                  {event.start_date === event.end_date ? event.start_date : `${event.start_date} to ${event.end_date}`}
+                </td>
+                <td className="px-4 py-2 text-sm">
+                  <span className={`inline-flex items-center px-2.5 py-0.5 rounded-full text-xs font-medium ${typeClass[event.event_type]}`}>
+                    {typeLabel[event.event_type]}
+                  </span>
+                </td>
+                <td className="px-4 py-2 text-sm text-gray-900">
+                  {event.description}
+                  {event.event_type === 'makeup' && event.makeup_weekday !== undefined && (
+                    <span className="ml-2 text-xs text-gray-500">({weekdayNames[event.makeup_weekday]} classes)</span>
+                  )}
+                </td>
+                <td className="px-4 py-2 text-sm text-gray-700">{scopeText(event)}</td>
+                <td className="px-4 py-2 text-sm text-right whitespace-nowrap">
+                  <button onClick={() => openEvent(event)} className="text-primary-600 hover:text-primary-900 mr-3">Edit</button>
+                  <button onClick={() => handleDeleteEvent(event)} className="text-red-600 hover:text-red-900">Delete</button>
+                </td>
+              </tr>
+            ))}
+          </tbody>
+        </table>
+      </div>
+
+      {editingTerm && (
+        <div className="fixed inset-0 bg-gray-600 bg-opacity-50 flex items-center justify-center z-50">
+          <form onSubmit={handleSaveTerm} className="bg-white rounded-lg shadow-xl w-full max-w-md p-6 space-y-4">
+            <div className="flex justify-between items-center">
+              <h3 className="text-lg font-semibold text-gray-900">{editingTerm.term_id ? 'Edit Term' : 'Add Term'}</h3>
+              <button type="button" onClick={() => setEditingTerm(null)}><X className="h-5 w-5 text-gray-400" /></button>
+            </div>
+            <p className="text-sm text-gray-600">Classes with this school year and semester only meet between the start and end dates.</p>
+            {formError && <div className="p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{formError}</div>}
+            <div className="grid grid-cols-2 gap-3">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">School Year</label>
+                <input value={editingTerm.school_year} placeholder="e.g. 2024-2025" onChange={(e) => setEditingTerm(main.AcademicTerm.createFrom({ ...editingTerm, school_year: e.target.value }))} className={inputClass} required />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Semester</label>
+                <input value={editingTerm.semester} placeholder="e.g. 1st Semester" onChange={(e) => setEditingTerm(main.AcademicTerm.createFrom({ ...editingTerm, semester: e.target.value }))} className={inputClass} required />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Start Date</label>
+                <input type="date" value={editingTerm.start_date} onChange={(e) => setEditingTerm(main.AcademicTerm.createFrom({ ...editingTerm, start_date: e.target.value }))} className={inputClass} required />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">End Date</label>
+                <input type="date" value={editingTerm.end_date} onChange={(e) => setEditingTerm(main.AcademicTerm.createFrom({ ...editingTerm, end_date: e.target.value }))} className={inputClass} required />
+              </div>
+            </div>
+            <div className="flex justify-end gap-2">
+              <button type="button" onClick={() => setEditingTerm(null)} className="px-4 py-2 border border-gray-300 rounded-md text-sm font-medium text-gray-700 bg-white hover:bg-gray-50">Cancel</button>
+              <button type="submit" className="px-4 py-2 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">Save</button>
+            </div>
+          </form>
+        </div>
+      )}
+
+      {editingEvent && (
+        <div className="fixed inset-0 bg-gray-600 bg-opacity-50 flex items-center justify-center z-50">
+          <form onSubmit={handleSaveEvent} className="bg-white rounded-lg shadow-xl w-full max-w-md p-6 space-y-4">
+            <div className="flex justify-between items-center">
+              <h3 className="text-lg font-semibold text-gray-900">{editingEvent.event_id ? 'Edit Event' : 'Add Event'}</h3>
+              <button type="button" onClick={() => setEditingEvent(null)}><X className="h-5 w-5 text-gray-400" /></button>
+            </div>
+            {formError && <div className="p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{formError}</div>}
+            <div className="grid grid-cols-2 gap-3">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Type</label>
+                <select value={editingEvent.event_type} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, event_type: e.target.value, makeup_weekday: e.target.value === 'makeup' ? 1 : undefined }))} className={inputClass}>
+                  <option value="holiday">Holiday</option>
+                  <option value="suspension">Class Suspension</option>
+                  <option value="makeup">Make-up Day</option>
+                </select>
+              </div>
+              {editingEvent.event_type === 'makeup' ? (
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 mb-1">Holds Classes Of</label>
+                  <select value={editingEvent.makeup_weekday ?? 1} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, makeup_weekday: Number(e.target.value) }))} className={inputClass}>
+                    {weekdayNames.map((name, day) => <option key={name} value={day}>{name}</option>)}
+                  </select>
+                </div>
+              ) : <div />}
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Start Date</label>
+                <input type="date" value={editingEvent.start_date} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, start_date: e.target.value, end_date: editingEvent.end_date || e.target.value }))} className={inputClass} required />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">End Date</label>
+                <input type="date" value={editingEvent.end_date} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, end_date: e.target.value }))} className={inputClass} required />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">Applies To</label>
+                <select value={editingEvent.scope} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, scope: e.target.value, scope_value: '' }))} className={inputClass}>
+                  <option value="school">Whole school</option>
+                  <option value="department">Department</option>
+                  <option value="room">Room</option>
+                </select>
+              </div>
+              {editingEvent.scope === 'department' && (
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 mb-1">Department</label>
+                  <select value={editingEvent.scope_value} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, scope_value: e.target.value }))} className={inputClass} required>
+                    <option value="">Select...</option>
+                    {departments.map((dept) => <option key={dept.department_code} value={dept.department_code}>{dept.department_code} - {dept.department_name}</option>)}
+                  </select>
+                </div>
+              )}
+              {editingEvent.scope === 'room' && (
+                <div>
+                  <label className="block text-sm font-medium text-gray-700 mb-1">Room</label>
+                  <input value={editingEvent.scope_value} maxLength={50} onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, scope_value: e.target.value }))} className={inputClass} required />
+                </div>
+              )}
+            </div>
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">Description</label>
+              <input value={editingEvent.description} maxLength={255} placeholder="e.g. Typhoon signal no. 3" onChange={(e) => setEditingEvent(main.CalendarEvent.createFrom({ ...editingEvent, description: e.target.value }))} className={inputClass} required />
+            </div>
+            <div className="flex justify-end gap-2">
+              <button type="button" onClick={() => setEditingEvent(null)} className="px-4 py-2 border border-gray-300 rounded-md text-sm font-medium text-gray-700 bg-white hover:bg-gray-50">Cancel</button>
+              <button type="submit" className="px-4 py-2 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700">Save</button>
+            </div>
+          </form>
+        </div>
+      )}
+    </div>
+  );
+}
+
 function AdminDashboard() {
   const location = useLocation();
   
@@ -2422,6 +3172,9 @@ function AdminDashboard() {
     { name: 'Departments', href: '/admin/departments', icon: <GraduationCap className="h-5 w-5" />, current: location.pathname === '/admin/departments' },
     { name: 'View Logs', href: '/admin/logs', icon: <FolderOpen className="h-5 w-5" />, current: location.pathname === '/admin/logs' },
     { name: 'Reports', href: '/admin/reports', icon: <BarChart3 className="h-5 w-5" />, current: location.pathname === '/admin/reports' },
+    { name: 'Calendar', href: '/admin/calendar', icon: <Calendar className="h-5 w-5" />, current: location.pathname === '/admin/calendar' },
+    { name: 'Computers', href: '/admin/computers', icon: <Monitor className="h-5 w-5" />, current: location.pathname === '/admin/computers' },
+    { name: 'Audit Log', href: '/admin/audit', icon: <History className="h-5 w-5" />, current: location.pathname === '/admin/audit' },
   ];
 
   return (
@@ -2432,6 +3185,9 @@ function AdminDashboard() {
         <Route path="departments" element={<DepartmentManagement />} />
         <Route path="logs" element={<ViewLogs />} />
         <Route path="reports" element={<Reports />} />
+        <Route path="calendar" element={<CalendarManagement />} />
+        <Route path="computers" element={<ComputerManagement />} />
+        <Route path="audit" element={<AuditLog />} />
       </Routes>
     </Layout>
   );
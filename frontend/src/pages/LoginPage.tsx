@@ -1,7 +1,8 @@
-import React, { useState } from 'react';
+import React, { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import { useAuth } from '../contexts/AuthContext';
-import { User, Lock, Eye, EyeOff } from 'lucide-react';
+import { User, Lock, Eye, EyeOff, Shield } from 'lucide-react';
+import { CreateFirstAdmin, GetSetupStatus } from '../../wailsjs/go/main/App';
 import backgroundImage from '../../../assets/background/background.jpg';
 
 const roleRoutes: { [key: string]: string } = {
@@ -18,10 +19,48 @@ function LoginPage() {
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
   const [showPassword, setShowPassword] = useState(false);
+  const [twoFactorToken, setTwoFactorToken] = useState('');
+  const [twoFactorCode, setTwoFactorCode] = useState('');
+  const [needsAdmin, setNeedsAdmin] = useState(false);
+  const [adminForm, setAdminForm] = useState({ username: '', firstName: '', lastName: '', password: '', confirmPassword: '' });
+  const [notice, setNotice] = useState('');
   
-  const { login } = useAuth();
+  const { login, verifyTwoFactor } = useAuth();
   const navigate = useNavigate();
 
+  // A new database has no accounts; its first admin is created here
+  useEffect(() => {
+    GetSetupStatus()
+      .then((status) => setNeedsAdmin(status.needs_admin))
+      .catch((err) => console.error('Failed to load setup status:', err));
+  }, []);
+
+  const handleCreateFirstAdmin = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (adminForm.password !== adminForm.confirmPassword) {
+      setError('Passwords do not match');
+      return;
+    }
+
+    setLoading(true);
+    setError('');
+
+    try {
+      await CreateFirstAdmin(adminForm.username, adminForm.password, adminForm.firstName, adminForm.lastName);
+      setNeedsAdmin(false);
+      setSelectedRole('admin');
+      setUsername(adminForm.username.trim());
+      setPassword('');
+      setAdminForm({ username: '', firstName: '', lastName: '', password: '', confirmPassword: '' });
+      setNotice('Administrator account created. Sign in with it to continue.');
+    } catch (err) {
+      console.error('First admin error:', err);
+      setError(String(err));
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const handleLogin = async (e: React.FormEvent) => {
     e.preventDefault();
     if (!selectedRole || !username || !password) {
@@ -40,6 +79,12 @@ function LoginPage() {
           setError(`Please select the correct login type: ${userData.role}`);
           return;
         }
+
+        if (userData.two_factor_required && userData.two_factor_token) {
+          setTwoFactorToken(userData.two_factor_token);
+          setTwoFactorCode('');
+          return;
+        }
         
         navigate(roleRoutes[userData.role]);
       } else {
@@ -54,6 +99,41 @@ function LoginPage() {
     }
   };
 
+  const handleVerifyCode = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!twoFactorCode) {
+      setError('Please enter your authentication code');
+      return;
+    }
+
+    setLoading(true);
+    setError('');
+
+    try {
+      const userData = await verifyTwoFactor(twoFactorToken, twoFactorCode);
+      navigate(roleRoutes[userData.role]);
+    } catch (err) {
+      console.error('Two-factor verification error:', err);
+      const message = String(err);
+      setError(message);
+      setTwoFactorCode('');
+      // The challenge is discarded after too many attempts or when it expires
+      if (message.includes('password again') || message.includes('locked')) {
+        setTwoFactorToken('');
+        setPassword('');
+      }
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleCancelTwoFactor = () => {
+    setTwoFactorToken('');
+    setTwoFactorCode('');
+    setPassword('');
+    setError('');
+  };
+
   const getLabelText = () => {
     switch (selectedRole) {
       case 'student':
@@ -146,6 +226,95 @@ function LoginPage() {
             </p>
           </div>
 
+          {needsAdmin ? (
+          <form onSubmit={handleCreateFirstAdmin} className="space-y-5">
+            <p className="text-sm text-gray-600">
+              This database has no administrator yet. Create the first administrator account to set up the logbook.
+            </p>
+            {([
+              ['username', 'Admin ID', 'text'],
+              ['firstName', 'First Name', 'text'],
+              ['lastName', 'Last Name', 'text'],
+              ['password', 'Password', 'password'],
+              ['confirmPassword', 'Confirm Password', 'password'],
+            ] as const).map(([field, label, type]) => (
+              <div key={field}>
+                <label htmlFor={field} className="block text-sm font-semibold text-gray-800 mb-2.5">
+                  {label}
+                </label>
+                <input
+                  type={type}
+                  id={field}
+                  value={adminForm[field]}
+                  onChange={(e) => setAdminForm({ ...adminForm, [field]: e.target.value })}
+                  className="w-full px-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-teal-500 focus:border-transparent"
+                  required
+                />
+              </div>
+            ))}
+
+            {error && (
+              <div className="bg-red-50 border-l-4 border-red-500 text-red-700 px-4 py-3 rounded-r-lg text-sm font-medium">
+                {error}
+              </div>
+            )}
+
+            <button
+              type="submit"
+              disabled={loading}
+              className="w-full bg-teal-600 text-white py-3.5 px-4 rounded-lg hover:bg-teal-700 focus:outline-none focus:ring-2 focus:ring-teal-500 focus:ring-offset-2 disabled:opacity-50 disabled:cursor-not-allowed transition-all font-semibold text-base shadow-md hover:shadow-lg"
+            >
+              {loading ? 'Creating...' : 'Create Administrator'}
+            </button>
+          </form>
+          ) : twoFactorToken ? (
+          <form onSubmit={handleVerifyCode} className="space-y-5">
+            <div>
+              <label htmlFor="twoFactorCode" className="block text-sm font-semibold text-gray-800 mb-2.5">
+                Authentication Code
+              </label>
+              <div className="relative">
+                <input
+                  type="text"
+                  id="twoFactorCode"
+                  value={twoFactorCode}
+                  onChange={(e) => setTwoFactorCode(e.target.value)}
+                  autoComplete="one-time-code"
+                  autoFocus
+                  className="w-full pl-11 pr-4 py-3 border border-gray-300 rounded-lg focus:outline-none focus:ring-2 focus:ring-teal-500 focus:border-transparent tracking-widest"
+                  required
+                />
+                <Shield className="absolute left-4 top-1/2 transform -translate-y-1/2 w-5 h-5 text-gray-400" />
+              </div>
+              <p className="mt-2 text-xs text-gray-500">
+                Enter the 6-digit code from your authenticator app, or one of your recovery codes.
+              </p>
+            </div>
+
+            {error && (
+              <div className="bg-red-50 border-l-4 border-red-500 text-red-700 px-4 py-3 rounded-r-lg text-sm font-medium">
+                {error}
+              </div>
+            )}
+
+            <div className="flex gap-3">
+              <button
+                type="button"
+                onClick={handleCancelTwoFactor}
+                className="w-1/3 border border-gray-300 text-gray-700 py-3.5 px-4 rounded-lg hover:bg-gray-50 font-semibold text-base"
+              >
+                Back
+              </button>
+              <button
+                type="submit"
+                disabled={loading}
+                className="w-2/3 bg-teal-600 text-white py-3.5 px-4 rounded-lg hover:bg-teal-700 focus:outline-none focus:ring-2 focus:ring-teal-500 focus:ring-offset-2 disabled:opacity-50 disabled:cursor-not-allowed transition-all font-semibold text-base shadow-md hover:shadow-lg"
+              >
+                {loading ? 'Verifying...' : 'Verify'}
+              </button>
+            </div>
+          </form>
+          ) : (
           <form onSubmit={handleLogin} className="space-y-5">
             {/* Role Selection */}
             <div>
@@ -210,6 +379,12 @@ function LoginPage() {
               </div>
             </div>
 
+            {notice && !error && (
+              <div className="bg-teal-50 border-l-4 border-teal-500 text-teal-700 px-4 py-3 rounded-r-lg text-sm font-medium">
+                {notice}
+              </div>
+            )}
+
             {/* Error Message */}
             {error && (
               <div className="bg-red-50 border-l-4 border-red-500 text-red-700 px-4 py-3 rounded-r-lg text-sm font-medium">
@@ -250,6 +425,7 @@ function LoginPage() {
               ) : 'Sign In'}
             </button>
           </form>
+          )}
         </div>
       </div>
     </div>
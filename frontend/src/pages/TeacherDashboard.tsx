@@ -46,7 +46,11 @@ import {
   CreateSubject,
   GetSubjects,
   GetAllTeachers,
-  GenerateAttendanceFromLogs
+  GenerateAttendanceFromLogs,
+  GetSessionConflicts,
+  ParseClassSchedule,
+  GetClassAttendancePolicy,
+  SetClassAttendancePolicy
 } from '../../wailsjs/go/main/App';
 import { useAuth } from '../contexts/AuthContext';
 import { main } from '../../wailsjs/go/models';
@@ -712,44 +716,6 @@ function CreateClasslist() {
     return `${h.toString().padStart(2, '0')}:${minute}`;
   };
 
-  // Format time for display (12-hour format)
-  const formatTimeDisplay = (hour: string, minute: string, ampm: string): string => {
-    return `${hour}:${minute} ${ampm}`;
-  };
-
-  const formatSchedule = (days: string[], startHour: string, startMinute: string, startAmPm: string, endHour: string, endMinute: string, endAmPm: string): string => {
-    if (!days.length) return '';
-    
-    // Sort days in order
-    const dayOrder = ['Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat', 'Sun'];
-    const sortedDays = [...days].sort((a, b) => {
-      return dayOrder.indexOf(a) - dayOrder.indexOf(b);
-    });
-    
-    // Format days (Mon, Tue -> MW or Mon, Wed, Fri -> MWF)
-    const dayAbbrs: Record<string, string> = {
-      'Mon': 'M',
-      'Tue': 'T',
-      'Wed': 'W',
-      'Thu': 'TH',
-      'Fri': 'F',
-      'Sat': 'SAT',
-      'Sun': 'SUN'
-    };
-    
-    let dayString = '';
-    if (sortedDays.length === 2 && sortedDays.includes('Tue') && sortedDays.includes('Thu')) {
-      dayString = 'TTH';
-    } else {
-      dayString = sortedDays.map(d => dayAbbrs[d] || d).join('');
-    }
-    
-    const startTimeStr = formatTimeDisplay(startHour, startMinute, startAmPm);
-    const endTimeStr = formatTimeDisplay(endHour, endMinute, endAmPm);
-    
-    return `${dayString} ${startTimeStr}-${endTimeStr}`;
-  };
-
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
     setLoading(true);
@@ -775,16 +741,13 @@ function CreateClasslist() {
         return;
       }
 
-      // Format schedule from selected days and time
-      const formattedSchedule = formatSchedule(
-        formData.selectedDays,
-        formData.startHour,
-        formData.startMinute,
-        formData.startAmPm,
-        formData.endHour,
-        formData.endMinute,
-        formData.endAmPm
-      );
+      // One weekly meeting per selected day (0 = Sunday ... 6 = Saturday)
+      const weekdays: Record<string, number> = { 'Sun': 0, 'Mon': 1, 'Tue': 2, 'Wed': 3, 'Thu': 4, 'Fri': 5, 'Sat': 6 };
+      const meetings = formData.selectedDays.map(day => main.ClassMeeting.createFrom({
+        weekday: weekdays[day],
+        start_time: convertTo24Hour(formData.startHour, formData.startMinute, formData.startAmPm),
+        end_time: convertTo24Hour(formData.endHour, formData.endMinute, formData.endAmPm)
+      }));
 
       // Use the manually entered subject code
       const subjectCode = formData.subjectCode.toUpperCase().trim();
@@ -802,13 +765,12 @@ function CreateClasslist() {
         subjectCode,
         user?.id || 0,
         '', // Offering code removed - same as subject code
-        formattedSchedule,
+        meetings,
         formData.room,
         '',
         '',
         formData.semester,
-        formData.schoolYear,
-        user?.id || 0  // Teacher creates the class themselves
+        formData.schoolYear
       );
 
       setNotification({ type: 'success', message: 'Class created successfully!' });
@@ -1259,10 +1221,23 @@ function ClassManagementDetail() {
     if (!id || !classInfo) return;
 
     setSaving(true);
+    // Keep the meetings unless the schedule text was changed (or never converted)
+    let meetings: main.ClassMeeting[] = classInfo.meetings || [];
+    if (!editFormData.schedule.trim()) {
+      meetings = [];
+    } else if (editFormData.schedule !== (classInfo.schedule || '') || meetings.length === 0) {
+      try {
+        meetings = await ParseClassSchedule(editFormData.schedule);
+      } catch (error) {
+        alert(`Could not read the schedule: ${error}. Use a form like "MWF 1:00-2:00 PM; TTh 10:00-11:30 AM".`);
+        setSaving(false);
+        return;
+      }
+    }
     try {
       await UpdateClass(
         parseInt(id),
-        editFormData.schedule,
+        meetings,
         editFormData.room,
         editFormData.yearLevel,
         editFormData.section,
@@ -2305,6 +2280,82 @@ function StoredAttendance() {
   );
 }
 
+const attendanceRuleFields: { key: 'early_minutes' | 'late_minutes' | 'absent_minutes' | 'minimum_minutes'; label: string; help: string }[] = [
+  { key: 'early_minutes', label: 'Early window', help: 'Minutes before the start a login counts towards the class' },
+  { key: 'late_minutes', label: 'Late after', help: 'Minutes after the start before a login is marked late' },
+  { key: 'absent_minutes', label: 'Absent after', help: 'Minutes after the start before a login is marked absent (0 = never)' },
+  { key: 'minimum_minutes', label: 'Minimum stay', help: 'Minutes a student must stay in class (0 = no minimum)' },
+];
+
+function AttendanceRulesModal({ classId, onClose }: { classId: number; onClose: () => void }) {
+  const [policy, setPolicy] = useState<main.ClassAttendancePolicy | null>(null);
+  const [values, setValues] = useState<Record<string, string>>({});
+  const [error, setError] = useState('');
+  const [saving, setSaving] = useState(false);
+
+  useEffect(() => {
+    GetClassAttendancePolicy(classId)
+      .then((data) => {
+        setPolicy(data);
+        const initial: Record<string, string> = {};
+        attendanceRuleFields.forEach(({ key }) => {
+          const value = data.overrides[key];
+          initial[key] = value === undefined || value === null ? '' : String(value);
+        });
+        setValues(initial);
+      })
+      .catch((error) => setError(String(error)));
+  }, [classId]);
+
+  const handleSave = async (e: React.FormEvent) => {
+    e.preventDefault();
+    setSaving(true);
+    try {
+      const overrides: Record<string, number | undefined> = {};
+      attendanceRuleFields.forEach(({ key }) => {
+        overrides[key] = values[key] === '' ? undefined : Number(values[key]);
+      });
+      await SetClassAttendancePolicy(classId, main.AttendancePolicyOverride.createFrom(overrides));
+      onClose();
+    } catch (error) {
+      setError(String(error));
+    } finally {
+      setSaving(false);
+    }
+  };
+
+  return (
+    <div className="fixed inset-0 bg-gray-600 bg-opacity-50 flex items-center justify-center z-50">
+      <form onSubmit={handleSave} className="bg-white rounded-lg shadow-xl w-full max-w-md p-6 space-y-4">
+        <div className="flex justify-between items-center">
+          <h3 className="text-lg font-semibold text-gray-900">Attendance Rules</h3>
+          <button type="button" onClick={onClose}><X className="h-5 w-5 text-gray-400" /></button>
+        </div>
+        <p className="text-sm text-gray-600">Leave a field empty to follow the school default. The rules apply to logins and to attendance generated from the logs.</p>
+        {error && <div className="p-3 bg-red-50 border border-red-200 text-red-700 text-sm rounded">{error}</div>}
+        {policy && attendanceRuleFields.map(({ key, label, help }) => (
+          <div key={key}>
+            <label className="block text-sm font-medium text-gray-700 mb-1">{label} (minutes)</label>
+            <input
+              type="number"
+              min={0}
+              value={values[key] ?? ''}
+              placeholder={`School default: ${policy.defaults[key]}`}
+              onChange={(e) => setValues({ ...values, [key]: e.target.value })}
+              className="w-full px-3 py-2 border border-gray-300 rounded-md text-sm focus:outline-none focus:ring-2 focus:ring-primary-500"
+            />
+            <p className="mt-1 text-xs text-gray-500">{help}</p>
+          </div>
+        ))}
+        <div className="flex justify-end gap-2">
+          <button type="button" onClick={onClose} className="px-4 py-2 border border-gray-300 rounded-md text-sm font-medium text-gray-700 bg-white hover:bg-gray-50">Cancel</button>
+          <button type="submit" disabled={saving || !policy} className="px-4 py-2 rounded-md text-sm font-medium text-white bg-primary-600 hover:bg-primary-700 disabled:opacity-50">Save</button>
+        </div>
+      </form>
+    </div>
+  );
+}
+
 function AttendanceManagementDetail() {
   const navigate = useNavigate();
   const { id } = useParams<{ id: string }>();
@@ -2321,6 +2372,8 @@ function AttendanceManagementDetail() {
   const [pendingDate, setPendingDate] = useState<string>('');
   const [generating, setGenerating] = useState(false);
   const [isGenerated, setIsGenerated] = useState(false);
+  const [sessionConflicts, setSessionConflicts] = useState<main.SessionConflict[]>([]);
+  const [showRules, setShowRules] = useState(false);
 
   useEffect(() => {
     const loadClass = async () => {
@@ -2373,6 +2426,9 @@ function AttendanceManagementDetail() {
       const records = await GetClassAttendance(selectedClass.class_id, selectedDate);
       console.log('Loaded attendance records:', records?.length || 0);
       setAttendanceRecords(records || []);
+      GetSessionConflicts(selectedClass.class_id, selectedDate)
+        .then(conflicts => setSessionConflicts(conflicts || []))
+        .catch(() => setSessionConflicts([]));
       // If no records found but we have a class, it might mean no students are enrolled
       if ((!records || records.length === 0) && selectedClass) {
         console.log('No attendance records found. This might mean no students are enrolled.');
@@ -2507,16 +2563,25 @@ function AttendanceManagementDetail() {
       
       {/* Header */}
       <div className="mb-6">
-        <div className="flex items-center mb-4">
+        <div className="flex items-center justify-between mb-4">
           <button
             onClick={() => navigate('/teacher/attendance')}
             className="mr-4 p-2 hover:bg-gray-100 rounded-full transition-colors"
           >
             <ArrowLeft className="h-5 w-5 text-gray-600" />
           </button>
+          <button
+            onClick={() => setShowRules(true)}
+            className="inline-flex items-center px-4 py-2 text-sm font-medium text-gray-700 bg-white border border-gray-300 rounded-md hover:bg-gray-50"
+          >
+            <Clock className="h-4 w-4 mr-2" />
+            Attendance Rules
+          </button>
         </div>
       </div>
 
+      {showRules && <AttendanceRulesModal classId={selectedClass.class_id} onClose={() => setShowRules(false)} />}
+
       {error && (
         <div className="mb-6 bg-yellow-50 border border-yellow-200 text-yellow-700 px-4 py-3 rounded-md">
           {error}
@@ -2632,6 +2697,24 @@ function AttendanceManagementDetail() {
       
       
 
+      {/* Students who signed in while already signed in on another PC */}
+      {hasSelectedDate && sessionConflicts.length > 0 && (
+        <div className="mb-6 bg-yellow-50 border border-yellow-200 text-yellow-800 px-4 py-3 rounded-md">
+          <div className="flex items-center font-semibold mb-2">
+            <AlertCircle className="h-5 w-5 mr-2" />
+            Concurrent sign-ins detected
+          </div>
+          <ul className="text-sm space-y-1">
+            {sessionConflicts.map(conflict => (
+              <li key={conflict.id}>
+                {conflict.detected_at.slice(11, 16)} - {conflict.last_name}, {conflict.first_name} ({conflict.student_code}) signed in on {conflict.attempted_pc || 'unknown PC'} while signed in on {conflict.existing_pc || 'unknown PC'}
+                {conflict.policy === 'deny' ? ' (login refused)' : conflict.policy === 'kick' ? ' (older session closed)' : ''}
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       {/* Attendance List Section - Only show after date is selected */}
       {selectedClass && hasSelectedDate && (
         <div className="bg-white shadow rounded-lg overflow-hidden mb-6">
@@ -2669,6 +2752,9 @@ function AttendanceManagementDetail() {
                       <th className="border border-gray-400 px-4 py-3 text-left text-xs font-semibold text-gray-700 uppercase tracking-wider bg-gray-200">
                         Time Out
                       </th>
+                      <th className="border border-gray-400 px-4 py-3 text-left text-xs font-semibold text-gray-700 uppercase tracking-wider bg-gray-200">
+                        Minutes
+                      </th>
                       <th className="border border-gray-400 px-4 py-3 text-left text-xs font-semibold text-gray-700 uppercase tracking-wider bg-gray-200">
                         Status
                       </th>
@@ -2703,6 +2789,19 @@ function AttendanceManagementDetail() {
                             <span className="text-gray-400">-</span>
                           )}
                         </td>
+                        <td className="border border-gray-400 px-4 py-3 whitespace-nowrap text-sm text-gray-900 text-center">
+                          {record.minutes_present !== undefined && record.minutes_present !== null ? (
+                            record.short_stay ? (
+                              <span className="px-2 py-1 text-xs font-semibold rounded bg-orange-100 text-orange-800" title="Below the class's minimum stay">
+                                {record.minutes_present} min (short)
+                              </span>
+                            ) : (
+                              <span className="text-gray-700">{record.minutes_present} min</span>
+                            )
+                          ) : (
+                            <span className="text-gray-400">-</span>
+                          )}
+                        </td>
                         <td className="border border-gray-400 px-4 py-3 whitespace-nowrap text-sm text-center">
                           {record.status ? (
                             <span className={`px-2 py-1 text-xs font-semibold rounded ${getStatusColor(record.status)}`}>
@@ -394,6 +394,7 @@ function BulkRegisterStudentModal({ onClose, onSuccess }: BulkRegisterStudentMod
   const [loading, setLoading] = useState(false);
   const [notification, setNotification] = useState<{type: 'success' | 'error', message: string, details?: any} | null>(null);
   const [previewData, setPreviewData] = useState<string[][]>([]);
+  const [importMode, setImportMode] = useState<'per_row' | 'atomic'>('per_row');
 
   const processFile = (file: File) => {
     // Accept PDF, DOCX, CSV, TXT files
@@ -482,13 +483,20 @@ function BulkRegisterStudentModal({ onClose, onSuccess }: BulkRegisterStudentMod
     setNotification(null);
 
     try {
-      const result = await CreateUsersBulkFromFile(fileBase64, uploadedFile.name);
+      const result = await CreateUsersBulkFromFile(fileBase64, uploadedFile.name, importMode);
       
       const successCount = result.success_count as number || 0;
       const errorCount = result.error_count as number || 0;
       const errors = result.errors as string[] || [];
 
-      if (successCount > 0) {
+      if (!result.committed) {
+        setNotification({
+          type: 'error',
+          message: `No students were registered: ${errorCount} row(s) failed, so the whole file was rolled back.`,
+          details: errors
+        });
+        setTimeout(() => setNotification(null), 10000);
+      } else if (successCount > 0) {
         setNotification({
           type: 'success',
           message: `Successfully registered ${successCount} student(s)!${errorCount > 0 ? ` ${errorCount} failed.` : ''}`,
@@ -684,6 +692,21 @@ function BulkRegisterStudentModal({ onClose, onSuccess }: BulkRegisterStudentMod
                 </label>
               </div>
 
+              {/* Import Mode */}
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-2">
+                  If a row fails
+                </label>
+                <select
+                  value={importMode}
+                  onChange={(e) => setImportMode(e.target.value as 'per_row' | 'atomic')}
+                  className="w-full px-3 py-2 border border-gray-300 rounded-md text-sm focus:outline-none focus:ring-2 focus:ring-blue-500"
+                >
+                  <option value="per_row">Register the other rows and list the failed ones</option>
+                  <option value="atomic">Register nobody (all rows or none)</option>
+                </select>
+              </div>
+
               {/* Preview */}
               {previewData.length > 0 && (
                 <div>
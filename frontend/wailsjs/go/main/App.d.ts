@@ -2,25 +2,45 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AdminResetPassword(arg1:number):Promise<string>;
+
+export function AdminResetTwoFactor(arg1:number):Promise<void>;
+
+export function ApproveComputer(arg1:number,arg2:string,arg3:string,arg4:string):Promise<void>;
+
+export function BeginTwoFactorEnrollment():Promise<main.TwoFactorEnrollment>;
+
 export function ChangePassword(arg1:string,arg2:string,arg3:string):Promise<void>;
 
-export function CreateClass(arg1:string,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string,arg9:string,arg10:number):Promise<number>;
+export function ConfirmTwoFactorEnrollment(arg1:string):Promise<Array<string>>;
+
+export function CreateClass(arg1:string,arg2:number,arg3:string,arg4:Array<main.ClassMeeting>,arg5:string,arg6:string,arg7:string,arg8:string,arg9:string):Promise<number>;
 
 export function CreateDepartment(arg1:string,arg2:string,arg3:string):Promise<void>;
 
+export function CreateFirstAdmin(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+
 export function CreateSubject(arg1:string,arg2:string,arg3:number,arg4:string):Promise<void>;
 
 export function CreateUser(arg1:string,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string,arg9:string,arg10:string,arg11:string,arg12:string,arg13:string,arg14:string):Promise<void>;
 
 export function CreateUsersBulk(arg1:string):Promise<Record<string, any>>;
 
-export function CreateUsersBulkFromFile(arg1:string,arg2:string):Promise<Record<string, any>>;
+export function CreateUsersBulkFromFile(arg1:string,arg2:string,arg3:string):Promise<Record<string, any>>;
+
+export function DeactivateUser(arg1:number):Promise<void>;
+
+export function DeleteAcademicTerm(arg1:number):Promise<void>;
+
+export function DeleteCalendarEvent(arg1:number):Promise<void>;
 
 export function DeleteClass(arg1:number):Promise<void>;
 
 export function DeleteDepartment(arg1:string):Promise<void>;
 
-export function DeleteUser(arg1:number):Promise<void>;
+export function DeleteUser(arg1:number,arg2:boolean):Promise<void>;
+
+export function DisableTwoFactor(arg1:string):Promise<void>;
 
 export function EnrollMultipleStudents(arg1:Array<number>,arg2:number,arg3:number):Promise<void>;
 
@@ -28,6 +48,10 @@ export function EnrollStudentInClass(arg1:number,arg2:number,arg3:number):Promis
 
 export function ExportAttendanceCSV(arg1:number):Promise<string>;
 
+export function ExportAuditLogCSV(arg1:main.AuditLogFilter):Promise<string>;
+
+export function ExportAuditLogPDF(arg1:main.AuditLogFilter):Promise<string>;
+
 export function ExportFeedbackCSV():Promise<string>;
 
 export function ExportFeedbackPDF():Promise<string>;
@@ -42,6 +66,8 @@ export function ForwardMultipleFeedbackToAdmin(arg1:Array<number>,arg2:number,ar
 
 export function GenerateAttendanceFromLogs(arg1:number,arg2:string,arg3:number):Promise<void>;
 
+export function GetAcademicTerms():Promise<Array<main.AcademicTerm>>;
+
 export function GetAdminDashboard():Promise<main.AdminDashboard>;
 
 export function GetAllClasses():Promise<Array<main.CourseClass>>;
@@ -54,24 +80,46 @@ export function GetAllStudentsForEnrollment(arg1:number):Promise<Array<main.Clas
 
 export function GetAllTeachers():Promise<Array<main.User>>;
 
+export function GetAuditLog(arg1:main.AuditLogFilter):Promise<Array<main.AuditEntry>>;
+
 export function GetAvailableSections():Promise<Array<string>>;
 
 export function GetAvailableStudents(arg1:number):Promise<Array<main.ClassStudent>>;
 
+export function GetCalendarEvents(arg1:string,arg2:string):Promise<Array<main.CalendarEvent>>;
+
 export function GetClassAttendance(arg1:number,arg2:string):Promise<Array<main.Attendance>>;
 
+export function GetClassAttendancePolicy(arg1:number):Promise<main.ClassAttendancePolicy>;
+
+export function GetClassDay(arg1:number,arg2:string):Promise<main.ClassDay>;
+
 export function GetClassStudents(arg1:number):Promise<Array<main.ClasslistEntry>>;
 
 export function GetClassesByCreator(arg1:number):Promise<Array<main.CourseClass>>;
 
 export function GetClassesBySubjectCode(arg1:string):Promise<Array<main.CourseClass>>;
 
+export function GetClassesWithoutMeetings():Promise<Array<main.CourseClass>>;
+
+export function GetComputers():Promise<Array<main.Computer>>;
+
+export function GetConnectionStatus():Promise<main.ConnectionStatus>;
+
 export function GetDepartments():Promise<Array<main.Department>>;
 
 export function GetFeedback():Promise<Array<main.Feedback>>;
 
+export function GetPasswordPolicy():Promise<main.PasswordPolicy>;
+
 export function GetPendingFeedback():Promise<Array<main.Feedback>>;
 
+export function GetSessionConflicts(arg1:number,arg2:string):Promise<Array<main.SessionConflict>>;
+
+export function GetSettings():Promise<Array<main.Setting>>;
+
+export function GetSetupStatus():Promise<main.SetupStatus>;
+
 export function GetStudentClasses(arg1:number):Promise<Array<main.CourseClass>>;
 
 export function GetStudentDashboard(arg1:number):Promise<main.StudentDashboard>;
@@ -82,6 +130,8 @@ export function GetStudentLoginLogs(arg1:number):Promise<Array<main.LoginLog>>;
 
 export function GetSubjects():Promise<Array<main.Subject>>;
 
+export function GetSyncStatus():Promise<main.SyncStatus>;
+
 export function GetTeacherClasses(arg1:number):Promise<Array<main.CourseClass>>;
 
 export function GetTeacherClassesByUserID(arg1:number):Promise<Array<main.CourseClass>>;
@@ -92,7 +142,9 @@ export function GetTeacherDashboard(arg1:number):Promise<main.TeacherDashboard>;
 
 export function GetTeacherID(arg1:number):Promise<number>;
 
-export function GetUsers():Promise<Array<main.User>>;
+export function GetTwoFactorStatus():Promise<main.TwoFactorStatus>;
+
+export function GetUsers(arg1:string):Promise<Array<main.User>>;
 
 export function GetUsersByType(arg1:string):Promise<Array<main.User>>;
 
@@ -108,28 +160,62 @@ export function Login(arg1:string,arg2:string):Promise<main.User>;
 
 export function Logout(arg1:number):Promise<void>;
 
+export function ParseClassSchedule(arg1:string):Promise<Array<main.ClassMeeting>>;
+
+export function ReactivateUser(arg1:number):Promise<void>;
+
 export function RecordAttendance(arg1:number,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:number):Promise<void>;
 
-export function RecordStudentLogin(arg1:number,arg2:number,arg3:string):Promise<void>;
+export function RecordStudentLogin(arg1:number,arg2:number):Promise<void>;
 
 export function RecordTimeoutLogout(arg1:number):Promise<void>;
 
+export function RegenerateRecoveryCodes(arg1:string):Promise<Array<string>>;
+
+export function ResetClassPasswords(arg1:number):Promise<string>;
+
+export function ResetSectionPasswords(arg1:string,arg2:string):Promise<string>;
+
+export function RevokeComputer(arg1:number):Promise<void>;
+
+export function SaveAcademicTerm(arg1:main.AcademicTerm):Promise<number>;
+
+export function SaveCalendarEvent(arg1:main.CalendarEvent):Promise<number>;
+
 export function SaveEquipmentFeedback(arg1:number,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string,arg9:string,arg10:string,arg11:string):Promise<void>;
 
-export function SearchUsers(arg1:string,arg2:string):Promise<Array<main.User>>;
+export function SaveSetup(arg1:main.SetupConfig):Promise<void>;
+
+export function SearchUsers(arg1:string,arg2:string,arg3:string):Promise<Array<main.User>>;
+
+export function SetClassAttendancePolicy(arg1:number,arg2:main.AttendancePolicyOverride):Promise<void>;
+
+export function TestDatabaseConnection(arg1:main.SetupConfig):Promise<main.ConnectionTest>;
 
 export function UnenrollStudentFromClass(arg1:number):Promise<void>;
 
 export function UnenrollStudentFromClassByIDs(arg1:number,arg2:number):Promise<void>;
 
+export function UnlockUser(arg1:number):Promise<void>;
+
 export function UpdateAttendanceRecord(arg1:number,arg2:number,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string):Promise<void>;
 
 export function UpdateAttendanceTime(arg1:number,arg2:number,arg3:string,arg4:string,arg5:string):Promise<void>;
 
-export function UpdateClass(arg1:number,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:boolean):Promise<void>;
+export function UpdateClass(arg1:number,arg2:Array<main.ClassMeeting>,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:boolean):Promise<void>;
+
+export function UpdateComputer(arg1:number,arg2:string,arg3:string,arg4:string):Promise<void>;
 
 export function UpdateDepartment(arg1:string,arg2:string,arg3:string,arg4:string,arg5:boolean):Promise<void>;
 
+export function UpdateSettings(arg1:Record<string, string>):Promise<void>;
+
 export function UpdateUser(arg1:number,arg2:string,arg3:string,arg4:string,arg5:string,arg6:string,arg7:string,arg8:string,arg9:string,arg10:string,arg11:string,arg12:string,arg13:string,arg14:string):Promise<void>;
 
 export function UpdateUserPhoto(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function ValidateSession(arg1:string):Promise<void>;
+
+export function VerifyLogIntegrity(arg1:string,arg2:string):Promise<main.IntegrityReport>;
+
+export function VerifyTwoFactorLogin(arg1:string,arg2:string):Promise<main.User>;
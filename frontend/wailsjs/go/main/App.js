@@ -2,18 +2,42 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AdminResetPassword(arg1) {
+  return window['go']['main']['App']['AdminResetPassword'](arg1);
+}
+
+export function AdminResetTwoFactor(arg1) {
+  return window['go']['main']['App']['AdminResetTwoFactor'](arg1);
+}
+
+export function ApproveComputer(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ApproveComputer'](arg1, arg2, arg3, arg4);
+}
+
+export function BeginTwoFactorEnrollment() {
+  return window['go']['main']['App']['BeginTwoFactorEnrollment']();
+}
+
 export function ChangePassword(arg1, arg2, arg3) {
   return window['go']['main']['App']['ChangePassword'](arg1, arg2, arg3);
 }
 
-export function CreateClass(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10) {
-  return window['go']['main']['App']['CreateClass'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10);
+export function ConfirmTwoFactorEnrollment(arg1) {
+  return window['go']['main']['App']['ConfirmTwoFactorEnrollment'](arg1);
+}
+
+export function CreateClass(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9) {
+  return window['go']['main']['App']['CreateClass'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9);
 }
 
 export function CreateDepartment(arg1, arg2, arg3) {
   return window['go']['main']['App']['CreateDepartment'](arg1, arg2, arg3);
 }
 
+export function CreateFirstAdmin(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CreateFirstAdmin'](arg1, arg2, arg3, arg4);
+}
+
 export function CreateSubject(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['CreateSubject'](arg1, arg2, arg3, arg4);
 }
@@ -26,8 +50,20 @@ export function CreateUsersBulk(arg1) {
   return window['go']['main']['App']['CreateUsersBulk'](arg1);
 }
 
-export function CreateUsersBulkFromFile(arg1, arg2) {
-  return window['go']['main']['App']['CreateUsersBulkFromFile'](arg1, arg2);
+export function CreateUsersBulkFromFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateUsersBulkFromFile'](arg1, arg2, arg3);
+}
+
+export function DeactivateUser(arg1) {
+  return window['go']['main']['App']['DeactivateUser'](arg1);
+}
+
+export function DeleteAcademicTerm(arg1) {
+  return window['go']['main']['App']['DeleteAcademicTerm'](arg1);
+}
+
+export function DeleteCalendarEvent(arg1) {
+  return window['go']['main']['App']['DeleteCalendarEvent'](arg1);
 }
 
 export function DeleteClass(arg1) {
@@ -38,8 +74,12 @@ export function DeleteDepartment(arg1) {
   return window['go']['main']['App']['DeleteDepartment'](arg1);
 }
 
-export function DeleteUser(arg1) {
-  return window['go']['main']['App']['DeleteUser'](arg1);
+export function DeleteUser(arg1, arg2) {
+  return window['go']['main']['App']['DeleteUser'](arg1, arg2);
+}
+
+export function DisableTwoFactor(arg1) {
+  return window['go']['main']['App']['DisableTwoFactor'](arg1);
 }
 
 export function EnrollMultipleStudents(arg1, arg2, arg3) {
@@ -54,6 +94,14 @@ export function ExportAttendanceCSV(arg1) {
   return window['go']['main']['App']['ExportAttendanceCSV'](arg1);
 }
 
+export function ExportAuditLogCSV(arg1) {
+  return window['go']['main']['App']['ExportAuditLogCSV'](arg1);
+}
+
+export function ExportAuditLogPDF(arg1) {
+  return window['go']['main']['App']['ExportAuditLogPDF'](arg1);
+}
+
 export function ExportFeedbackCSV() {
   return window['go']['main']['App']['ExportFeedbackCSV']();
 }
@@ -82,6 +130,10 @@ export function GenerateAttendanceFromLogs(arg1, arg2, arg3) {
   return window['go']['main']['App']['GenerateAttendanceFromLogs'](arg1, arg2, arg3);
 }
 
+export function GetAcademicTerms() {
+  return window['go']['main']['App']['GetAcademicTerms']();
+}
+
 export function GetAdminDashboard() {
   return window['go']['main']['App']['GetAdminDashboard']();
 }
@@ -106,6 +158,10 @@ export function GetAllTeachers() {
   return window['go']['main']['App']['GetAllTeachers']();
 }
 
+export function GetAuditLog(arg1) {
+  return window['go']['main']['App']['GetAuditLog'](arg1);
+}
+
 export function GetAvailableSections() {
   return window['go']['main']['App']['GetAvailableSections']();
 }
@@ -114,10 +170,22 @@ export function GetAvailableStudents(arg1) {
   return window['go']['main']['App']['GetAvailableStudents'](arg1);
 }
 
+export function GetCalendarEvents(arg1, arg2) {
+  return window['go']['main']['App']['GetCalendarEvents'](arg1, arg2);
+}
+
 export function GetClassAttendance(arg1, arg2) {
   return window['go']['main']['App']['GetClassAttendance'](arg1, arg2);
 }
 
+export function GetClassAttendancePolicy(arg1) {
+  return window['go']['main']['App']['GetClassAttendancePolicy'](arg1);
+}
+
+export function GetClassDay(arg1, arg2) {
+  return window['go']['main']['App']['GetClassDay'](arg1, arg2);
+}
+
 export function GetClassStudents(arg1) {
   return window['go']['main']['App']['GetClassStudents'](arg1);
 }
@@ -130,6 +198,18 @@ export function GetClassesBySubjectCode(arg1) {
   return window['go']['main']['App']['GetClassesBySubjectCode'](arg1);
 }
 
+export function GetClassesWithoutMeetings() {
+  return window['go']['main']['App']['GetClassesWithoutMeetings']();
+}
+
+export function GetComputers() {
+  return window['go']['main']['App']['GetComputers']();
+}
+
+export function GetConnectionStatus() {
+  return window['go']['main']['App']['GetConnectionStatus']();
+}
+
 export function GetDepartments() {
   return window['go']['main']['App']['GetDepartments']();
 }
@@ -138,10 +218,26 @@ export function GetFeedback() {
   return window['go']['main']['App']['GetFeedback']();
 }
 
+export function GetPasswordPolicy() {
+  return window['go']['main']['App']['GetPasswordPolicy']();
+}
+
 export function GetPendingFeedback() {
   return window['go']['main']['App']['GetPendingFeedback']();
 }
 
+export function GetSessionConflicts(arg1, arg2) {
+  return window['go']['main']['App']['GetSessionConflicts'](arg1, arg2);
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetSetupStatus() {
+  return window['go']['main']['App']['GetSetupStatus']();
+}
+
 export function GetStudentClasses(arg1) {
   return window['go']['main']['App']['GetStudentClasses'](arg1);
 }
@@ -162,6 +258,10 @@ export function GetSubjects() {
   return window['go']['main']['App']['GetSubjects']();
 }
 
+export function GetSyncStatus() {
+  return window['go']['main']['App']['GetSyncStatus']();
+}
+
 export function GetTeacherClasses(arg1) {
   return window['go']['main']['App']['GetTeacherClasses'](arg1);
 }
@@ -182,8 +282,12 @@ export function GetTeacherID(arg1) {
   return window['go']['main']['App']['GetTeacherID'](arg1);
 }
 
-export function GetUsers() {
-  return window['go']['main']['App']['GetUsers']();
+export function GetTwoFactorStatus() {
+  return window['go']['main']['App']['GetTwoFactorStatus']();
+}
+
+export function GetUsers(arg1) {
+  return window['go']['main']['App']['GetUsers'](arg1);
 }
 
 export function GetUsersByType(arg1) {
@@ -214,24 +318,68 @@ export function Logout(arg1) {
   return window['go']['main']['App']['Logout'](arg1);
 }
 
+export function ParseClassSchedule(arg1) {
+  return window['go']['main']['App']['ParseClassSchedule'](arg1);
+}
+
+export function ReactivateUser(arg1) {
+  return window['go']['main']['App']['ReactivateUser'](arg1);
+}
+
 export function RecordAttendance(arg1, arg2, arg3, arg4, arg5, arg6, arg7) {
   return window['go']['main']['App']['RecordAttendance'](arg1, arg2, arg3, arg4, arg5, arg6, arg7);
 }
 
-export function RecordStudentLogin(arg1, arg2, arg3) {
-  return window['go']['main']['App']['RecordStudentLogin'](arg1, arg2, arg3);
+export function RecordStudentLogin(arg1, arg2) {
+  return window['go']['main']['App']['RecordStudentLogin'](arg1, arg2);
 }
 
 export function RecordTimeoutLogout(arg1) {
   return window['go']['main']['App']['RecordTimeoutLogout'](arg1);
 }
 
+export function RegenerateRecoveryCodes(arg1) {
+  return window['go']['main']['App']['RegenerateRecoveryCodes'](arg1);
+}
+
+export function ResetClassPasswords(arg1) {
+  return window['go']['main']['App']['ResetClassPasswords'](arg1);
+}
+
+export function ResetSectionPasswords(arg1, arg2) {
+  return window['go']['main']['App']['ResetSectionPasswords'](arg1, arg2);
+}
+
+export function RevokeComputer(arg1) {
+  return window['go']['main']['App']['RevokeComputer'](arg1);
+}
+
+export function SaveAcademicTerm(arg1) {
+  return window['go']['main']['App']['SaveAcademicTerm'](arg1);
+}
+
+export function SaveCalendarEvent(arg1) {
+  return window['go']['main']['App']['SaveCalendarEvent'](arg1);
+}
+
 export function SaveEquipmentFeedback(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11) {
   return window['go']['main']['App']['SaveEquipmentFeedback'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11);
 }
 
-export function SearchUsers(arg1, arg2) {
-  return window['go']['main']['App']['SearchUsers'](arg1, arg2);
+export function SaveSetup(arg1) {
+  return window['go']['main']['App']['SaveSetup'](arg1);
+}
+
+export function SearchUsers(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SearchUsers'](arg1, arg2, arg3);
+}
+
+export function SetClassAttendancePolicy(arg1, arg2) {
+  return window['go']['main']['App']['SetClassAttendancePolicy'](arg1, arg2);
+}
+
+export function TestDatabaseConnection(arg1) {
+  return window['go']['main']['App']['TestDatabaseConnection'](arg1);
 }
 
 export function UnenrollStudentFromClass(arg1) {
@@ -242,6 +390,10 @@ export function UnenrollStudentFromClassByIDs(arg1, arg2) {
   return window['go']['main']['App']['UnenrollStudentFromClassByIDs'](arg1, arg2);
 }
 
+export function UnlockUser(arg1) {
+  return window['go']['main']['App']['UnlockUser'](arg1);
+}
+
 export function UpdateAttendanceRecord(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
   return window['go']['main']['App']['UpdateAttendanceRecord'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
@@ -254,10 +406,18 @@ export function UpdateClass(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
   return window['go']['main']['App']['UpdateClass'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
+export function UpdateComputer(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['UpdateComputer'](arg1, arg2, arg3, arg4);
+}
+
 export function UpdateDepartment(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['UpdateDepartment'](arg1, arg2, arg3, arg4, arg5);
 }
 
+export function UpdateSettings(arg1) {
+  return window['go']['main']['App']['UpdateSettings'](arg1);
+}
+
 export function UpdateUser(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14) {
   return window['go']['main']['App']['UpdateUser'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9, arg10, arg11, arg12, arg13, arg14);
 }
@@ -265,3 +425,15 @@ export function UpdateUser(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8, arg9,
 export function UpdateUserPhoto(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateUserPhoto'](arg1, arg2, arg3);
 }
+
+export function ValidateSession(arg1) {
+  return window['go']['main']['App']['ValidateSession'](arg1);
+}
+
+export function VerifyLogIntegrity(arg1, arg2) {
+  return window['go']['main']['App']['VerifyLogIntegrity'](arg1, arg2);
+}
+
+export function VerifyTwoFactorLogin(arg1, arg2) {
+  return window['go']['main']['App']['VerifyTwoFactorLogin'](arg1, arg2);
+}
@@ -1,5 +1,25 @@
 export namespace main {
 	
+	export class AcademicTerm {
+	    term_id: number;
+	    school_year: string;
+	    semester: string;
+	    start_date: string;
+	    end_date: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AcademicTerm(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.term_id = source["term_id"];
+	        this.school_year = source["school_year"];
+	        this.semester = source["semester"];
+	        this.start_date = source["start_date"];
+	        this.end_date = source["end_date"];
+	    }
+	}
 	export class AdminDashboard {
 	    total_students: number;
 	    total_teachers: number;
@@ -34,6 +54,8 @@ export namespace main {
 	    status: string;
 	    remarks?: string;
 	    recorded_by?: number;
+	    minutes_present?: number;
+	    short_stay: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new Attendance(source);
@@ -56,8 +78,235 @@ export namespace main {
 	        this.status = source["status"];
 	        this.remarks = source["remarks"];
 	        this.recorded_by = source["recorded_by"];
+	        this.minutes_present = source["minutes_present"];
+	        this.short_stay = source["short_stay"];
+	    }
+	}
+	export class AttendancePolicy {
+	    early_minutes: number;
+	    late_minutes: number;
+	    absent_minutes: number;
+	    minimum_minutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttendancePolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.early_minutes = source["early_minutes"];
+	        this.late_minutes = source["late_minutes"];
+	        this.absent_minutes = source["absent_minutes"];
+	        this.minimum_minutes = source["minimum_minutes"];
+	    }
+	}
+	export class AttendancePolicyOverride {
+	    early_minutes?: number;
+	    late_minutes?: number;
+	    absent_minutes?: number;
+	    minimum_minutes?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttendancePolicyOverride(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.early_minutes = source["early_minutes"];
+	        this.late_minutes = source["late_minutes"];
+	        this.absent_minutes = source["absent_minutes"];
+	        this.minimum_minutes = source["minimum_minutes"];
+	    }
+	}
+	export class AuditEntry {
+	    id: number;
+	    actor_user_id?: number;
+	    actor_username?: string;
+	    action: string;
+	    entity_type: string;
+	    entity_key: string;
+	    before?: string;
+	    after?: string;
+	    pc_hostname?: string;
+	    created_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.actor_user_id = source["actor_user_id"];
+	        this.actor_username = source["actor_username"];
+	        this.action = source["action"];
+	        this.entity_type = source["entity_type"];
+	        this.entity_key = source["entity_key"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.pc_hostname = source["pc_hostname"];
+	        this.created_at = source["created_at"];
 	    }
 	}
+	export class AuditLogFilter {
+	    actor_user_id: number;
+	    action: string;
+	    entity_type: string;
+	    entity_key: string;
+	    date_from: string;
+	    date_to: string;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditLogFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.actor_user_id = source["actor_user_id"];
+	        this.action = source["action"];
+	        this.entity_type = source["entity_type"];
+	        this.entity_key = source["entity_key"];
+	        this.date_from = source["date_from"];
+	        this.date_to = source["date_to"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class CalendarEvent {
+	    event_id: number;
+	    event_type: string;
+	    scope: string;
+	    scope_value: string;
+	    start_date: string;
+	    end_date: string;
+	    makeup_weekday?: number;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CalendarEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.event_id = source["event_id"];
+	        this.event_type = source["event_type"];
+	        this.scope = source["scope"];
+	        this.scope_value = source["scope_value"];
+	        this.start_date = source["start_date"];
+	        this.end_date = source["end_date"];
+	        this.makeup_weekday = source["makeup_weekday"];
+	        this.description = source["description"];
+	    }
+	}
+	export class ChainHead {
+	    seq: number;
+	    hash: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ChainHead(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.hash = source["hash"];
+	    }
+	}
+	export class ClassAttendancePolicy {
+	    class_id: number;
+	    overrides: AttendancePolicyOverride;
+	    defaults: AttendancePolicy;
+	    effective: AttendancePolicy;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClassAttendancePolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.class_id = source["class_id"];
+	        this.overrides = this.convertValues(source["overrides"], AttendancePolicyOverride);
+	        this.defaults = this.convertValues(source["defaults"], AttendancePolicy);
+	        this.effective = this.convertValues(source["effective"], AttendancePolicy);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ClassMeeting {
+	    weekday: number;
+	    start_time: string;
+	    end_time: string;
+	    room?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClassMeeting(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.weekday = source["weekday"];
+	        this.start_time = source["start_time"];
+	        this.end_time = source["end_time"];
+	        this.room = source["room"];
+	    }
+	}
+	export class ClassDay {
+	    class_id: number;
+	    date: string;
+	    meets: boolean;
+	    cancelled: boolean;
+	    reason?: string;
+	    meetings: ClassMeeting[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ClassDay(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.class_id = source["class_id"];
+	        this.date = source["date"];
+	        this.meets = source["meets"];
+	        this.cancelled = source["cancelled"];
+	        this.reason = source["reason"];
+	        this.meetings = this.convertValues(source["meetings"], ClassMeeting);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
 	export class ClassStudent {
 	    id: number;
 	    student_id: string;
@@ -122,6 +371,76 @@ export namespace main {
 	        this.course = source["course"];
 	    }
 	}
+	export class Computer {
+	    id: number;
+	    asset_tag?: string;
+	    display_name?: string;
+	    room?: string;
+	    hostname: string;
+	    mac_addresses?: string;
+	    status: string;
+	    requested_at: string;
+	    approved_by?: string;
+	    approved_at?: string;
+	    last_seen_at?: string;
+	    is_this_pc: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Computer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.asset_tag = source["asset_tag"];
+	        this.display_name = source["display_name"];
+	        this.room = source["room"];
+	        this.hostname = source["hostname"];
+	        this.mac_addresses = source["mac_addresses"];
+	        this.status = source["status"];
+	        this.requested_at = source["requested_at"];
+	        this.approved_by = source["approved_by"];
+	        this.approved_at = source["approved_at"];
+	        this.last_seen_at = source["last_seen_at"];
+	        this.is_this_pc = source["is_this_pc"];
+	    }
+	}
+	export class ConnectionStatus {
+	    status: string;
+	    error?: string;
+	    latency_ms: number;
+	    offline: boolean;
+	    since: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.latency_ms = source["latency_ms"];
+	        this.offline = source["offline"];
+	        this.since = source["since"];
+	    }
+	}
+	export class ConnectionTest {
+	    server_version: string;
+	    tls_cipher: string;
+	    latency_ms: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnectionTest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.server_version = source["server_version"];
+	        this.tls_cipher = source["tls_cipher"];
+	        this.latency_ms = source["latency_ms"];
+	    }
+	}
 	export class CourseClass {
 	    class_id: number;
 	    subject_code: string;
@@ -131,6 +450,7 @@ export namespace main {
 	    teacher_code?: string;
 	    teacher_name: string;
 	    schedule?: string;
+	    meetings: ClassMeeting[];
 	    room?: string;
 	    year_level?: string;
 	    section?: string;
@@ -155,6 +475,7 @@ export namespace main {
 	        this.teacher_code = source["teacher_code"];
 	        this.teacher_name = source["teacher_name"];
 	        this.schedule = source["schedule"];
+	        this.meetings = this.convertValues(source["meetings"], ClassMeeting);
 	        this.room = source["room"];
 	        this.year_level = source["year_level"];
 	        this.section = source["section"];
@@ -165,6 +486,24 @@ export namespace main {
 	        this.created_by_user_id = source["created_by_user_id"];
 	        this.created_at = source["created_at"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class Department {
 	    department_code: string;
@@ -236,6 +575,68 @@ export namespace main {
 	        this.working_student_notes = source["working_student_notes"];
 	    }
 	}
+	export class IntegrityIssue {
+	    seq: number;
+	    entity_type: string;
+	    entity_key: string;
+	    kind: string;
+	    detail: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new IntegrityIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.entity_type = source["entity_type"];
+	        this.entity_key = source["entity_key"];
+	        this.kind = source["kind"];
+	        this.detail = source["detail"];
+	    }
+	}
+	export class IntegrityReport {
+	    from: string;
+	    to: string;
+	    entries_checked: number;
+	    rows_checked: number;
+	    head: ChainHead;
+	    valid: boolean;
+	    issues: IntegrityIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new IntegrityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.entries_checked = source["entries_checked"];
+	        this.rows_checked = source["rows_checked"];
+	        this.head = this.convertValues(source["head"], ChainHead);
+	        this.valid = source["valid"];
+	        this.issues = this.convertValues(source["issues"], IntegrityIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class LoginLog {
 	    id: number;
 	    user_id: number;
@@ -245,6 +646,8 @@ export namespace main {
 	    pc_number?: string;
 	    login_time: string;
 	    logout_time?: string;
+	    login_status: string;
+	    unregistered_pc: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new LoginLog(source);
@@ -260,8 +663,168 @@ export namespace main {
 	        this.pc_number = source["pc_number"];
 	        this.login_time = source["login_time"];
 	        this.logout_time = source["logout_time"];
+	        this.login_status = source["login_status"];
+	        this.unregistered_pc = source["unregistered_pc"];
 	    }
 	}
+	export class PasswordPolicy {
+	    min_length: number;
+	    require_uppercase: boolean;
+	    require_lowercase: boolean;
+	    require_digit: boolean;
+	    require_symbol: boolean;
+	    disallow_student_code: boolean;
+	    expiry_days: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PasswordPolicy(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.min_length = source["min_length"];
+	        this.require_uppercase = source["require_uppercase"];
+	        this.require_lowercase = source["require_lowercase"];
+	        this.require_digit = source["require_digit"];
+	        this.require_symbol = source["require_symbol"];
+	        this.disallow_student_code = source["disallow_student_code"];
+	        this.expiry_days = source["expiry_days"];
+	    }
+	}
+	export class SessionConflict {
+	    id: number;
+	    student_user_id: number;
+	    student_code: string;
+	    first_name: string;
+	    last_name: string;
+	    login_log_id?: number;
+	    existing_login_log_id: number;
+	    existing_pc?: string;
+	    attempted_pc?: string;
+	    policy: string;
+	    detected_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.student_user_id = source["student_user_id"];
+	        this.student_code = source["student_code"];
+	        this.first_name = source["first_name"];
+	        this.last_name = source["last_name"];
+	        this.login_log_id = source["login_log_id"];
+	        this.existing_login_log_id = source["existing_login_log_id"];
+	        this.existing_pc = source["existing_pc"];
+	        this.attempted_pc = source["attempted_pc"];
+	        this.policy = source["policy"];
+	        this.detected_at = source["detected_at"];
+	    }
+	}
+	export class Setting {
+	    key: string;
+	    group: string;
+	    type: string;
+	    description: string;
+	    value: string;
+	    default: string;
+	    source: string;
+	    min: number;
+	    options?: string[];
+	    editable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Setting(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.group = source["group"];
+	        this.type = source["type"];
+	        this.description = source["description"];
+	        this.value = source["value"];
+	        this.default = source["default"];
+	        this.source = source["source"];
+	        this.min = source["min"];
+	        this.options = source["options"];
+	        this.editable = source["editable"];
+	    }
+	}
+	export class SetupConfig {
+	    driver: string;
+	    sqlite_path: string;
+	    host: string;
+	    port: string;
+	    username: string;
+	    password: string;
+	    database: string;
+	    tls_mode: string;
+	    tls_ca: string;
+	    tls_cert: string;
+	    tls_key: string;
+	    tls_server_name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SetupConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.driver = source["driver"];
+	        this.sqlite_path = source["sqlite_path"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.database = source["database"];
+	        this.tls_mode = source["tls_mode"];
+	        this.tls_ca = source["tls_ca"];
+	        this.tls_cert = source["tls_cert"];
+	        this.tls_key = source["tls_key"];
+	        this.tls_server_name = source["tls_server_name"];
+	    }
+	}
+	export class SetupStatus {
+	    required: boolean;
+	    configured: boolean;
+	    needs_admin: boolean;
+	    config_path: string;
+	    config: SetupConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new SetupStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.required = source["required"];
+	        this.configured = source["configured"];
+	        this.needs_admin = source["needs_admin"];
+	        this.config_path = source["config_path"];
+	        this.config = this.convertValues(source["config"], SetupConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class StudentDashboard {
 	    attendance: Attendance[];
 	    today_log?: Attendance;
@@ -316,6 +879,24 @@ export namespace main {
 	        this.created_at = source["created_at"];
 	    }
 	}
+	export class SyncStatus {
+	    enabled: boolean;
+	    online: boolean;
+	    pending: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.online = source["online"];
+	        this.pending = source["pending"];
+	        this.failed = source["failed"];
+	    }
+	}
 	export class TeacherDashboard {
 	    classes: CourseClass[];
 	    attendance: Attendance[];
@@ -348,6 +929,36 @@ export namespace main {
 		    return a;
 		}
 	}
+	export class TwoFactorEnrollment {
+	    secret: string;
+	    uri: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TwoFactorEnrollment(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.secret = source["secret"];
+	        this.uri = source["uri"];
+	    }
+	}
+	export class TwoFactorStatus {
+	    available: boolean;
+	    enabled: boolean;
+	    recovery_codes_remaining: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TwoFactorStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.enabled = source["enabled"];
+	        this.recovery_codes_remaining = source["recovery_codes_remaining"];
+	    }
+	}
 	export class User {
 	    id: number;
 	    password: string;
@@ -365,8 +976,14 @@ export namespace main {
 	    contact_number?: string;
 	    photo_url?: string;
 	    department_code?: string;
+	    is_active: boolean;
 	    created: string;
 	    login_log_id: number;
+	    session_token?: string;
+	    must_change_password: boolean;
+	    password_change_reason?: string;
+	    two_factor_required?: boolean;
+	    two_factor_token?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new User(source);
@@ -390,8 +1007,14 @@ export namespace main {
 	        this.contact_number = source["contact_number"];
 	        this.photo_url = source["photo_url"];
 	        this.department_code = source["department_code"];
+	        this.is_active = source["is_active"];
 	        this.created = source["created"];
 	        this.login_log_id = source["login_log_id"];
+	        this.session_token = source["session_token"];
+	        this.must_change_password = source["must_change_password"];
+	        this.password_change_reason = source["password_change_reason"];
+	        this.two_factor_required = source["two_factor_required"];
+	        this.two_factor_token = source["two_factor_token"];
 	    }
 	}
 	export class WorkingStudentDashboard {
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// newTestApp opens a fresh SQLite database under t.TempDir(), migrates it and
+// returns an App connected to it. The attendance scheduler is switched off so
+// tests drive it themselves, and the offline queue is not used.
+func newTestApp(t *testing.T) *App {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("LOGBOOK_CONFIG", filepath.Join(dir, "config.json"))
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_SQLITE_PATH", filepath.Join(dir, "logbook.db"))
+	t.Setenv("OFFLINE_MODE", "false")
+	t.Setenv("ATTENDANCE_AUTO_SCHEDULE", "false")
+	// Tests seed sessions on fixed past dates; the reaper would time them out
+	t.Setenv("SESSION_MAX_MINUTES", "0")
+
+	cfg := GetDBConfig()
+	db, d, err := openDatabase(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	a := NewApp()
+	a.ctx, a.lifetime, a.stop = ctx, ctx, cancel
+	a.db, a.dialect = db, d
+	a.pool = newDBExecutor(ctx, db, cfg)
+	a.store = newStore(a.pool, d)
+	a.conn = newDBMonitor(nil)
+	if !a.checkDatabase(ctx) {
+		t.Fatal("database not ready")
+	}
+	return a
+}
+
+// mustExec runs a seeding statement
+func mustExec(t *testing.T, a *App, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := a.pool.Exec(query, args...); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+}
+
+// seedUser inserts an account with a plaintext password, which Login accepts and
+// upgrades, and the profile row of its role
+func seedUser(t *testing.T, a *App, id int, username, password, role string) {
+	t.Helper()
+	mustExec(t, a, `INSERT INTO users (id, username, password, user_type) VALUES (?, ?, ?, ?)`, id, username, password, role)
+	switch role {
+	case "admin":
+		mustExec(t, a, `INSERT INTO admins (user_id, first_name, last_name) VALUES (?, ?, 'Test')`, id, username)
+	case "teacher":
+		mustExec(t, a, `INSERT INTO teachers (user_id, first_name, last_name) VALUES (?, ?, 'Test')`, id, username)
+	case "student", "working_student":
+		mustExec(t, a, `INSERT INTO students (user_id, student_number, first_name, last_name, is_working_student) VALUES (?, ?, ?, 'Test', ?)`,
+			id, username, username, role == "working_student")
+	}
+}
+
+// seedClass inserts an active class of teacherID with the students enrolled
+func seedClass(t *testing.T, a *App, classID, teacherID int, students ...int) {
+	t.Helper()
+	mustExec(t, a, `INSERT OR IGNORE INTO subjects (subject_code, subject_name) VALUES ('IT101', 'Programming')`)
+	mustExec(t, a, `INSERT INTO classes (class_id, subject_code, teacher_user_id, section) VALUES (?, 'IT101', ?, 'A')`, classID, teacherID)
+	for _, studentID := range students {
+		mustExec(t, a, `INSERT INTO classlist (class_id, student_user_id) VALUES (?, ?)`, classID, studentID)
+	}
+}
+
+// seedMeeting adds a weekly meeting to a class
+func seedMeeting(t *testing.T, a *App, classID, weekday int, start, end string) {
+	t.Helper()
+	mustExec(t, a, `INSERT INTO class_meetings (class_id, weekday, start_time, end_time) VALUES (?, ?, ?, ?)`, classID, weekday, start, end)
+}
+
+// signInAs starts a session for a seeded user without going through Login
+func signInAs(t *testing.T, a *App, id int, username, role string) *Session {
+	t.Helper()
+	session, err := a.startSession(&User{ID: id, Name: username, Role: role})
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+	return session
+}
+
+// queryInt scans a single integer
+func queryInt(t *testing.T, a *App, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := a.pool.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// LOG INTEGRITY CHAIN
+// ==============================================================================
+//
+// Every change to an attendance row and every login_logs entry appends the
+// row's new content to integrity_chain. Each entry's hash covers its content
+// and the previous entry's hash, so rewriting, deleting or reordering history
+// breaks the chain. The live rows are compared with their last chained state,
+// which exposes edits made directly in the database.
+//
+// integrity_chain_head holds the latest sequence number and hash. Appends lock
+// that single row, which serialises writers across lab PCs. On SQLite every
+// transaction already holds the database's write lock. Its started_at marks
+// when the chain began: rows written before then have no entry and are not
+// reported as unchained.
+
+// genesisHash is the previous hash of the first chain entry
+var genesisHash = strings.Repeat("0", 64)
+
+const loginLogChainQuery = `SELECT id, user_id, pc_number, login_time, logout_time, login_status FROM login_logs WHERE id = ?`
+
+// chainEntryHash computes the hash of a chain entry
+func chainEntryHash(seq int64, prevHash, entityType, entityKey, payload string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		prevHash,
+		strconv.FormatInt(seq, 10),
+		entityType,
+		entityKey,
+		payload,
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// chainPayload encodes a row snapshot canonically. Values are stringified because the
+// MySQL driver returns integers as int64 or []byte depending on the query protocol,
+// and encoding/json sorts map keys.
+func chainPayload(row map[string]interface{}) (string, error) {
+	normalized := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		if value == nil {
+			normalized[column] = nil
+		} else {
+			normalized[column] = fmt.Sprint(value)
+		}
+	}
+	b, err := json.Marshal(normalized)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode chain payload: %w", err)
+	}
+	return string(b), nil
+}
+
+// appendChainEntry appends one row snapshot to the integrity chain inside tx
+func (a *App) appendChainEntry(tx sqlExecutor, entityType, entityKey string, row map[string]interface{}) error {
+	payload, err := chainPayload(row)
+	if err != nil {
+		return err
+	}
+
+	chain := a.txStore(tx).Integrity
+	headSeq, headHash, err := chain.LockHead()
+	if err != nil {
+		return err
+	}
+
+	seq := headSeq + 1
+	return chain.Append(chainEntry{
+		Seq:        seq,
+		EntityType: entityType,
+		EntityKey:  entityKey,
+		Payload:    payload,
+		PrevHash:   headHash,
+		Hash:       chainEntryHash(seq, headHash, entityType, entityKey, payload),
+	})
+}
+
+// chainLoginLog appends the current state of a login_logs row to the chain
+func (a *App) chainLoginLog(tx sqlExecutor, logID int64) error {
+	snapshot, err := auditSnapshot(tx, loginLogChainQuery, logID)
+	if err != nil {
+		return err
+	}
+	row, ok := snapshot.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("login log %d not found", logID)
+	}
+	return a.appendChainEntry(tx, "login_log", strconv.FormatInt(logID, 10), row)
+}
+
+// attendanceRowKey builds the chain key of an attendance snapshot row
+func attendanceRowKey(row map[string]interface{}) string {
+	date := fmt.Sprint(row["date"])
+	if len(date) > 10 {
+		date = date[:10]
+	}
+	return fmt.Sprintf("%v/%v/%s", row["class_id"], row["student_user_id"], date)
+}
+
+// snapshotRows normalises an auditSnapshot result to a list of rows
+func snapshotRows(snapshot interface{}) []map[string]interface{} {
+	switch v := snapshot.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []map[string]interface{}:
+		return v
+	default:
+		return nil
+	}
+}
+
+// auditAttendanceChange audits an attendance mutation like auditChange and
+// appends every attendance row whose content changed to the integrity chain
+func (a *App) auditAttendanceChange(tx sqlExecutor, action, entityKey, snapshotQuery string, snapshotArgs []interface{}, mutate func() error) error {
+	return a.auditAttendanceChangeAs(tx, a.currentSession(), action, entityKey, snapshotQuery, snapshotArgs, mutate)
+}
+
+// auditAttendanceChangeAs is auditAttendanceChange attributed to actor instead of the signed-in user
+func (a *App) auditAttendanceChangeAs(tx sqlExecutor, actor *Session, action, entityKey, snapshotQuery string, snapshotArgs []interface{}, mutate func() error) error {
+	before, err := auditSnapshot(tx, snapshotQuery, snapshotArgs...)
+	if err != nil {
+		return err
+	}
+	if err := mutate(); err != nil {
+		return err
+	}
+	after, err := auditSnapshot(tx, snapshotQuery, snapshotArgs...)
+	if err != nil {
+		return err
+	}
+	if err := recordAuditAs(tx, actor, action, "attendance", entityKey, before, after); err != nil {
+		return err
+	}
+
+	previous := make(map[string]string)
+	for _, row := range snapshotRows(before) {
+		payload, err := chainPayload(row)
+		if err != nil {
+			return err
+		}
+		previous[attendanceRowKey(row)] = payload
+	}
+	for _, row := range snapshotRows(after) {
+		key := attendanceRowKey(row)
+		payload, err := chainPayload(row)
+		if err != nil {
+			return err
+		}
+		if previous[key] == payload {
+			continue
+		}
+		if err := a.appendChainEntry(tx, "attendance", key, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChainHead identifies the integrity chain state at a point in time
+type ChainHead struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// chainHead returns the current head of the integrity chain
+func (a *App) chainHead() (ChainHead, error) {
+	return a.store.Integrity.Head()
+}
+
+// String formats the head for printing on exported reports
+func (h ChainHead) String() string {
+	return fmt.Sprintf("#%d %s", h.Seq, h.Hash)
+}
+
+// IntegrityIssue describes one break found by VerifyLogIntegrity
+type IntegrityIssue struct {
+	Seq        int64  `json:"seq"`
+	EntityType string `json:"entity_type"`
+	EntityKey  string `json:"entity_key"`
+	Kind       string `json:"kind"` // "missing_entry", "broken_link", "hash_mismatch", "row_modified", "row_deleted", "unchained_row", "head_mismatch"
+	Detail     string `json:"detail"`
+}
+
+// IntegrityReport is the result of VerifyLogIntegrity
+type IntegrityReport struct {
+	From           string           `json:"from"`
+	To             string           `json:"to"`
+	EntriesChecked int              `json:"entries_checked"`
+	RowsChecked    int              `json:"rows_checked"`
+	Head           ChainHead        `json:"head"`
+	Valid          bool             `json:"valid"`
+	Issues         []IntegrityIssue `json:"issues"`
+}
+
+// chainEntry is a stored integrity_chain row
+type chainEntry struct {
+	Seq        int64
+	EntityType string
+	EntityKey  string
+	Payload    string
+	PrevHash   string
+	Hash       string
+}
+
+// VerifyLogIntegrity re-computes the chain for entries created between from and to
+// (YYYY-MM-DD, inclusive; empty for no bound) and checks that the attendance and
+// login_logs rows in that range still match their last chained state.
+func (a *App) VerifyLogIntegrity(from, to string) (IntegrityReport, error) {
+	report := IntegrityReport{From: from, To: to}
+
+	if _, err := a.authorize(PermViewAuditLog); err != nil {
+		return report, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return report, err
+	}
+
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return report, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", d)
+		}
+	}
+
+	head, err := a.chainHead()
+	if err != nil {
+		return report, err
+	}
+	report.Head = head
+
+	if err := a.verifyChainEntries(&report); err != nil {
+		return report, err
+	}
+	if err := a.verifyLiveRows(&report); err != nil {
+		return report, err
+	}
+
+	report.Valid = len(report.Issues) == 0
+	if report.Valid {
+		log.Printf("✓ Log integrity verified: %d chain entries, %d rows", report.EntriesChecked, report.RowsChecked)
+	} else {
+		log.Printf("⚠ Log integrity check found %d issue(s)", len(report.Issues))
+	}
+	return report, nil
+}
+
+// dateRangeClause returns a SQL condition on column for the report's date range
+func dateRangeClause(d sqlDialect, column, from, to string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if from != "" {
+		conditions = append(conditions, column+" >= ?")
+		args = append(args, from)
+	}
+	if to != "" {
+		conditions = append(conditions, column+" < "+d.dateAdd("?", "1", "day"))
+		args = append(args, to)
+	}
+	if len(conditions) == 0 {
+		return "TRUE", nil
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// verifyChainEntries recomputes hashes and links for the chain entries in range
+func (a *App) verifyChainEntries(report *IntegrityReport) error {
+	entries, err := a.store.Integrity.Entries(report.From, report.To)
+	if err != nil {
+		return err
+	}
+	report.EntriesChecked = len(entries)
+
+	issue := func(e chainEntry, kind, detail string) {
+		report.Issues = append(report.Issues, IntegrityIssue{Seq: e.Seq, EntityType: e.EntityType, EntityKey: e.EntityKey, Kind: kind, Detail: detail})
+	}
+
+	var prevSeq int64
+	var prevHash string
+	for i, e := range entries {
+		if i == 0 {
+			// Anchor the range on the entry just before it
+			prevSeq = e.Seq - 1
+			prevHash = genesisHash
+			if prevSeq > 0 {
+				hash, err := a.store.Integrity.EntryHash(prevSeq)
+				if err == sql.ErrNoRows {
+					issue(e, "missing_entry", fmt.Sprintf("entry #%d before the range is missing", prevSeq))
+					hash = e.PrevHash
+				} else if err != nil {
+					return err
+				}
+				prevHash = hash
+			}
+		}
+
+		if e.Seq != prevSeq+1 {
+			issue(e, "missing_entry", fmt.Sprintf("entries #%d to #%d are missing", prevSeq+1, e.Seq-1))
+		}
+		if e.PrevHash != prevHash {
+			issue(e, "broken_link", "previous hash does not match the preceding entry")
+		}
+		if chainEntryHash(e.Seq, e.PrevHash, e.EntityType, e.EntityKey, e.Payload) != e.Hash {
+			issue(e, "hash_mismatch", "entry content does not match its hash")
+		}
+
+		prevSeq, prevHash = e.Seq, e.Hash
+	}
+
+	// The head must point at the last stored entry, otherwise entries were truncated
+	if report.To == "" && len(entries) > 0 {
+		last := entries[len(entries)-1]
+		if last.Seq != report.Head.Seq || last.Hash != report.Head.Hash {
+			issue(last, "head_mismatch", fmt.Sprintf("chain head is %s but the last entry is #%d", report.Head.String(), last.Seq))
+		}
+	}
+	return nil
+}
+
+// verifyLiveRows compares attendance and login_logs rows in range with their latest chain entry
+func (a *App) verifyLiveRows(report *IntegrityReport) error {
+	if err := a.verifyLiveTable(report, "attendance", attendanceRowKey); err != nil {
+		return err
+	}
+	loginKey := func(row map[string]interface{}) string { return fmt.Sprint(row["id"]) }
+	if err := a.verifyLiveTable(report, "login_log", loginKey); err != nil {
+		return err
+	}
+
+	// Chained entities in range whose row no longer exists
+	deleted, err := a.store.Integrity.DeletedEntities(report.From, report.To)
+	if err != nil {
+		return err
+	}
+	for _, e := range deleted {
+		report.Issues = append(report.Issues, IntegrityIssue{Seq: e.Seq, EntityType: e.EntityType, EntityKey: e.EntityKey, Kind: "row_deleted", Detail: "row was deleted after it was chained"})
+	}
+	return nil
+}
+
+// chainColumnsSince lists the columns added to an entity's snapshot after the
+// chain started. Entries chained before then lack them.
+var chainColumnsSince = map[string][]string{
+	"attendance": {"login_log_id", "minutes_present", "short_stay"},
+}
+
+// matchesEarlierPayload reports whether row equals a payload chained before some
+// of its columns were added, leaving out the columns the payload lacks
+func matchesEarlierPayload(entityType string, row map[string]interface{}, chained string) bool {
+	var earlier map[string]interface{}
+	if err := json.Unmarshal([]byte(chained), &earlier); err != nil {
+		return false
+	}
+	trimmed := make(map[string]interface{}, len(row))
+	for column, value := range row {
+		trimmed[column] = value
+	}
+	dropped := false
+	for _, column := range chainColumnsSince[entityType] {
+		if _, ok := earlier[column]; !ok {
+			delete(trimmed, column)
+			dropped = true
+		}
+	}
+	if !dropped {
+		return false
+	}
+	payload, err := chainPayload(trimmed)
+	return err == nil && payload == chained
+}
+
+// verifyLiveTable checks each row of an entity type in range against its latest
+// chained payload. Rows written before the chain started may have no entry.
+func (a *App) verifyLiveTable(report *IntegrityReport, entityType string, keyOf func(map[string]interface{}) string) error {
+	chain := a.store.Integrity
+	live, err := chain.LiveRows(entityType, report.From, report.To)
+	if err != nil {
+		return err
+	}
+	preChain, err := chain.PreChainKeys(entityType, report.From, report.To)
+	if err != nil {
+		return err
+	}
+	latest, err := chain.LatestStates(entityType)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range live {
+		report.RowsChecked++
+		key := keyOf(row)
+
+		payload, err := chainPayload(row)
+		if err != nil {
+			return err
+		}
+
+		state, ok := latest[key]
+		if !ok {
+			if !preChain[key] {
+				report.Issues = append(report.Issues, IntegrityIssue{EntityType: entityType, EntityKey: key, Kind: "unchained_row", Detail: "row has no chain entry (written outside the app)"})
+			}
+			continue
+		}
+		if state.Payload != payload && !matchesEarlierPayload(entityType, row, state.Payload) {
+			report.Issues = append(report.Issues, IntegrityIssue{Seq: state.Seq, EntityType: entityType, EntityKey: key, Kind: "row_modified", Detail: "row differs from its last chained state"})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"database/sql"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// integrityIssues runs VerifyLogIntegrity over every date as the admin and
+// returns the kinds of issue found per entity, such as "attendance 10/3/2026-03-02"
+func integrityIssues(t *testing.T, a *App) map[string][]string {
+	t.Helper()
+	signInAs(t, a, 1, "admin", "admin")
+	report, err := a.VerifyLogIntegrity("", "")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	issues := make(map[string][]string)
+	for _, issue := range report.Issues {
+		entity := issue.EntityType + " " + issue.EntityKey
+		issues[entity] = append(issues[entity], issue.Kind)
+	}
+	if report.Valid != (len(issues) == 0) {
+		t.Errorf("report valid = %v with %d issues", report.Valid, len(report.Issues))
+	}
+	return issues
+}
+
+// hasIssue reports whether kind was found for entity
+func hasIssue(issues map[string][]string, entity, kind string) bool {
+	for _, k := range issues[entity] {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// seedChainedRows signs the student in and records their attendance through the app
+func seedChainedRows(t *testing.T, a *App) {
+	t.Helper()
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3)
+
+	if _, err := a.Login("teacher1", "Teach#123"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	at := time.Date(2026, 3, 2, 8, 5, 0, 0, time.Local)
+	if err := a.mergeTap(nil, "auto_record", 10, 3, 0, at, "PC-01", "present", sql.NullString{}); err != nil {
+		t.Fatalf("record attendance: %v", err)
+	}
+}
+
+func TestVerifyLogIntegrityValid(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+
+	if issues := integrityIssues(t, a); len(issues) != 0 {
+		t.Errorf("issues on an untouched chain: %v", issues)
+	}
+}
+
+func TestVerifyLogIntegrityFindsEdits(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+	logID := queryInt(t, a, `SELECT id FROM login_logs WHERE user_id = 2`)
+
+	mustExec(t, a, `UPDATE attendance SET status = 'late' WHERE class_id = 10 AND student_user_id = 3`)
+	mustExec(t, a, `UPDATE login_logs SET pc_number = 'PC-99' WHERE id = ?`, logID)
+
+	issues := integrityIssues(t, a)
+	if !hasIssue(issues, "attendance 10/3/2026-03-02", "row_modified") {
+		t.Errorf("edited attendance not reported: %v", issues)
+	}
+	if !hasIssue(issues, "login_log "+strconv.Itoa(logID), "row_modified") {
+		t.Errorf("edited login log not reported: %v", issues)
+	}
+}
+
+func TestVerifyLogIntegrityFindsForgedEntries(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+
+	mustExec(t, a, `UPDATE integrity_chain SET payload = REPLACE(payload, 'present', 'late') WHERE entity_type = 'attendance'`)
+
+	if issues := integrityIssues(t, a); !hasIssue(issues, "attendance 10/3/2026-03-02", "hash_mismatch") {
+		t.Errorf("rewritten chain payload not reported: %v", issues)
+	}
+}
+
+func TestVerifyLogIntegrityUnchainedRows(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+
+	// Written before the chain started: no entry expected
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status, created_at) VALUES (10, 3, '2026-03-01', 'present', '2020-01-01 00:00:00')`)
+	// Written behind the app's back since
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status) VALUES (10, 3, '2026-03-03', 'present')`)
+
+	issues := integrityIssues(t, a)
+	if kinds, ok := issues["attendance 10/3/2026-03-01"]; ok {
+		t.Errorf("pre-chain row reported: %v", kinds)
+	}
+	if !hasIssue(issues, "attendance 10/3/2026-03-03", "unchained_row") {
+		t.Errorf("row written outside the app not reported: %v", issues)
+	}
+}
+
+func TestVerifyLogIntegrityEarlierPayloads(t *testing.T) {
+	a := newTestApp(t)
+	seedChainedRows(t, a)
+
+	// Chain the row again the way it was before the stay columns joined the snapshot
+	err := a.withTx(func(tx sqlExecutor) error {
+		snapshot, err := auditSnapshot(tx, `
+			SELECT class_id, student_user_id, date, time_in, time_out, pc_number, status, remarks
+			FROM attendance WHERE class_id = 10 AND student_user_id = 3`)
+		if err != nil {
+			return err
+		}
+		return a.appendChainEntry(tx, "attendance", "10/3/2026-03-02", snapshot.(map[string]interface{}))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := integrityIssues(t, a); len(issues) != 0 {
+		t.Errorf("issues with an earlier payload: %v", issues)
+	}
+
+	mustExec(t, a, `UPDATE attendance SET pc_number = 'PC-99' WHERE class_id = 10 AND student_user_id = 3`)
+	if issues := integrityIssues(t, a); !hasIssue(issues, "attendance 10/3/2026-03-02", "row_modified") {
+		t.Errorf("edited row with an earlier payload not reported: %v", issues)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// ==============================================================================
+// ACCOUNT LOCKOUT
+// ==============================================================================
+
+// AccountLockedError is returned by Login while an account is locked out.
+// Its message is shown as-is on the login screen.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked due to too many failed login attempts, try again after %s", e.Until.Format("3:04 PM"))
+}
+
+// checkAccountLock returns an AccountLockedError if the user is currently locked out.
+// An expired lock is cleared so the user starts again with a fresh failure count.
+func (a *App) checkAccountLock(userID int) error {
+	lockedUntil, isLocked, err := a.store.Users.LockState(userID)
+	if err != nil {
+		return err
+	}
+
+	if !lockedUntil.Valid {
+		return nil
+	}
+	if isLocked {
+		return &AccountLockedError{Until: lockedUntil.Time}
+	}
+
+	if err := a.store.Users.ClearExpiredLock(userID); err != nil {
+		log.Printf("⚠ Failed to clear expired lock for user %d: %v", userID, err)
+	}
+	return nil
+}
+
+// recordFailedLogin logs a failed attempt and locks the account once the policy threshold is reached.
+// Failures count while they are within the policy window of now and after the last
+// successful login, lock or unlock. It returns an AccountLockedError when this attempt caused the lock.
+func (a *App) recordFailedLogin(userID int, username string) error {
+	policy := GetLockoutPolicy()
+	hostname := a.currentComputer().pcNumber()
+
+	err := a.withTx(func(tx sqlExecutor) error {
+		logID, err := a.txStore(tx).Logs.RecordFailure(userID, hostname)
+		if err != nil {
+			return err
+		}
+		return a.chainLoginLog(tx, logID)
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to record failed login for user %d: %v", userID, err)
+	}
+
+	// Counted after the failure is logged, so it includes this attempt
+	failures, err := a.store.Users.CountFailedLogin(userID, policy.WindowMinutes)
+	if err != nil {
+		log.Printf("⚠ Failed to update failed attempts for user %d: %v", userID, err)
+	}
+
+	if failures == 0 {
+		return nil
+	}
+
+	if failures < policy.MaxFailedAttempts {
+		log.Printf("Failed login for %s (%d/%d)", username, failures, policy.MaxFailedAttempts)
+		return nil
+	}
+
+	if err := a.store.Users.Lock(userID, policy.LockoutMinutes); err != nil {
+		log.Printf("⚠ Failed to lock account for user %d: %v", userID, err)
+		return nil
+	}
+
+	log.Printf("🔒 Account locked: %s after %d failed attempts (for %d min)", username, failures, policy.LockoutMinutes)
+	return a.checkAccountLock(userID)
+}
+
+// resetFailedLogins clears the failure counter and lock after a successful login
+func (a *App) resetFailedLogins(userID int) {
+	if err := a.store.Users.RecordLogin(userID); err != nil {
+		log.Printf("⚠ Failed to reset failed attempts for user %d: %v", userID, err)
+	}
+}
+
+// UnlockUser clears a lockout so the user can sign in again (admin action)
+func (a *App) UnlockUser(userID int) error {
+	if _, err := a.authorize(PermManageUsers); err != nil {
+		return err
+	}
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	if exists, err := a.store.Users.Exists(userID); err != nil || !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	err := a.withTx(func(tx sqlExecutor) error {
+		return a.auditChange(tx, "unlock", "user", strconv.Itoa(userID), userAuditQuery, []interface{}{userID}, func() error {
+			return a.txStore(tx).Users.Unlock(userID)
+		})
+	})
+	if err != nil {
+		log.Printf("⚠ Failed to unlock user %d: %v", userID, err)
+		return err
+	}
+
+	log.Printf("✓ User %d unlocked", userID)
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// dbClock formats t the way SQLite stores its local timestamps
+func dbClock(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func TestLoginLocksAfterMaxFailures(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	limit := GetLockoutPolicy().MaxFailedAttempts
+
+	for i := 1; i < limit; i++ {
+		if _, err := a.Login("teacher1", "wrong"); err == nil || err.Error() != "invalid credentials" {
+			t.Fatalf("attempt %d: err = %v, want invalid credentials", i, err)
+		}
+	}
+	if n := queryInt(t, a, `SELECT failed_attempts FROM users WHERE id = 2`); n != limit-1 {
+		t.Fatalf("failed_attempts = %d, want %d", n, limit-1)
+	}
+
+	var locked *AccountLockedError
+	if _, err := a.Login("teacher1", "wrong"); !errors.As(err, &locked) {
+		t.Fatalf("attempt %d: err = %v, want AccountLockedError", limit, err)
+	}
+	// The failures that caused the lock are cleared with it
+	if n := queryInt(t, a, `SELECT failed_attempts FROM users WHERE id = 2`); n != 0 {
+		t.Errorf("failed_attempts after lock = %d, want 0", n)
+	}
+	if _, err := a.Login("teacher1", "Correct#1"); !errors.As(err, &locked) {
+		t.Errorf("correct password while locked: err = %v, want AccountLockedError", err)
+	}
+}
+
+func TestLoginResetsFailures(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+
+	a.Login("teacher1", "wrong")
+	a.Login("teacher1", "wrong")
+	user, err := a.Login("teacher1", "Correct#1")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.ID != 2 || user.Password != "" || user.SessionToken == "" {
+		t.Errorf("user = %+v, want id 2 with a session and no password", user)
+	}
+	if n := queryInt(t, a, `SELECT failed_attempts FROM users WHERE id = 2`); n != 0 {
+		t.Errorf("failed_attempts = %d, want 0", n)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM login_logs WHERE user_id = 2 AND login_status = 'success'`); n != 1 {
+		t.Errorf("%d successful login logs, want 1", n)
+	}
+	// The legacy plaintext password was upgraded
+	var stored string
+	a.pool.QueryRow(`SELECT password FROM users WHERE id = 2`).Scan(&stored)
+	if stored == "Correct#1" {
+		t.Error("plaintext password was not rehashed")
+	}
+
+	// Earlier failures no longer count towards a lock. Failures in the second
+	// of the reset never count, so the next attempt is made later.
+	past := dbClock(time.Now().Add(-time.Minute))
+	mustExec(t, a, `UPDATE login_logs SET login_time = ? WHERE user_id = 2`, past)
+	mustExec(t, a, `UPDATE users SET failures_reset_at = ? WHERE id = 2`, past)
+	a.Login("teacher1", "wrong")
+	if n := queryInt(t, a, `SELECT failed_attempts FROM users WHERE id = 2`); n != 1 {
+		t.Errorf("failed_attempts after a new failure = %d, want 1", n)
+	}
+}
+
+func TestExpiredLockStartsOver(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	limit := GetLockoutPolicy().MaxFailedAttempts
+	for i := 0; i < limit; i++ {
+		a.Login("teacher1", "wrong")
+	}
+
+	// Let the lock expire; the failures before it are still within the window
+	past := dbClock(time.Now().Add(-time.Minute))
+	mustExec(t, a, `UPDATE users SET account_locked_until = ?, failures_reset_at = ? WHERE id = 2`, past, past)
+	mustExec(t, a, `UPDATE login_logs SET login_time = ? WHERE user_id = 2`, past)
+
+	if _, err := a.Login("teacher1", "wrong"); err == nil || err.Error() != "invalid credentials" {
+		t.Fatalf("err = %v, want invalid credentials", err)
+	}
+	if n := queryInt(t, a, `SELECT failed_attempts FROM users WHERE id = 2`); n != 1 {
+		t.Errorf("failed_attempts = %d, want 1", n)
+	}
+	if _, err := a.Login("teacher1", "Correct#1"); err != nil {
+		t.Errorf("login after the lock expired: %v", err)
+	}
+}
+
+func TestCountFailedLoginWindow(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	policy := GetLockoutPolicy()
+	now := time.Now()
+
+	// Older than the window
+	old := dbClock(now.Add(-time.Duration(policy.WindowMinutes+1) * time.Minute))
+	for i := 0; i < policy.MaxFailedAttempts; i++ {
+		mustExec(t, a, `INSERT INTO login_logs (user_id, login_time, login_status) VALUES (2, ?, 'failed')`, old)
+	}
+	mustExec(t, a, `INSERT INTO login_logs (user_id, login_time, login_status) VALUES (2, ?, 'failed')`, dbClock(now.Add(-time.Minute)))
+
+	n, err := a.store.Users.CountFailedLogin(2, policy.WindowMinutes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("failures = %d, want only the one within the window", n)
+	}
+}
+
+func TestCountFailedLoginAfterReset(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	reset := time.Now().Add(-2 * time.Minute)
+
+	mustExec(t, a, `UPDATE users SET failures_reset_at = ? WHERE id = 2`, dbClock(reset))
+	for _, at := range []time.Time{reset.Add(-time.Second), reset, reset.Add(time.Second)} {
+		mustExec(t, a, `INSERT INTO login_logs (user_id, login_time, login_status) VALUES (2, ?, 'failed')`, dbClock(at))
+	}
+
+	// Only failures strictly after the reset count: the one logged in the same
+	// second is the failure that caused a lock, which must not count again
+	n, err := a.store.Users.CountFailedLogin(2, GetLockoutPolicy().WindowMinutes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("failures = %d, want 1", n)
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	if err := a.store.Users.Lock(2, 15); err != nil {
+		t.Fatal(err)
+	}
+
+	signInAs(t, a, 2, "teacher1", "teacher")
+	if err := a.UnlockUser(2); err == nil {
+		t.Fatal("a teacher unlocked an account")
+	}
+
+	signInAs(t, a, 1, "admin", "admin")
+	if err := a.UnlockUser(2); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := a.Login("teacher1", "Correct#1"); err != nil {
+		t.Errorf("login after unlock: %v", err)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'unlock' AND entity_key = '2'`); n != 1 {
+		t.Errorf("%d unlock audit entries, want 1", n)
+	}
+}
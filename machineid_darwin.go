@@ -0,0 +1,24 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+)
+
+var platformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID" = "([^"]+)"`)
+
+// machineID returns the hardware UUID reported by IOKit
+func machineID() (string, error) {
+	out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+	if err != nil {
+		return "", err
+	}
+	match := platformUUIDPattern.FindSubmatch(out)
+	if match == nil {
+		return "", fmt.Errorf("IOPlatformUUID not found")
+	}
+	return string(match[1]), nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows && !linux && !darwin
+
+package main
+
+import "fmt"
+
+// machineID is not available on this platform; the MAC addresses are used instead
+func machineID() (string, error) {
+	return "", fmt.Errorf("machine id not supported on this platform")
+}
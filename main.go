@@ -3,6 +3,7 @@ package main
 import (
 	"embed"
 	"log"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,6 +14,14 @@ import (
 var assets embed.FS
 
 func main() {
+	// -migrate, -rollback N and -migrate-status run without opening the window
+	if handled, err := runMigrationCommand(os.Args[1:], os.Stdout); handled {
+		if err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		return
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -33,6 +42,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 248, G: 250, B: 252, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ==============================================================================
+// SCHEMA MIGRATIONS
+// ==============================================================================
+//
+// The schema is built from the numbered migrations in migrations/<driver>/,
+// embedded in the binary so every lab PC running the same build expects the
+// same schema. The MySQL and SQLite directories carry the same versions, so a
+// version number means the same schema on either backend. Each version has an
+// up and a down script. Applied versions are recorded in
+// schema_migrations; a version is marked dirty while its script runs, because
+// MySQL commits DDL immediately and a failed script cannot be rolled back.
+// A version may also have a Go step for data SQL cannot convert, run after its
+// up script while the version is still dirty.
+//
+// On MySQL a named lock serialises migrations, so lab PCs starting at the same
+// time apply each version once. A database created from the old logbookschema.sql
+// script, which has tables but no schema_migrations, is adopted at version 1.
+
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+const migrationLockName = "logbook_schema_migrations"
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// migration is one numbered schema change
+type migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up script, to spot edits after release
+	Step     func(conn *sql.Conn) error
+}
+
+// migrationSteps are the Go steps of the versions that have one
+var migrationSteps = map[int]func(conn *sql.Conn) error{
+	9: convertClassSchedules,
+}
+
+// MigrationStatus describes one known migration and whether it has been applied
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	Dirty     bool
+	AppliedAt string
+}
+
+// loadMigrations reads the embedded migrations for a backend in version order
+func loadMigrations(d sqlDialect) ([]migration, error) {
+	dir := path.Join("migrations", d.name())
+	entries, err := fs.ReadDir(migrationFiles, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		b, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2], Step: migrationSteps[version]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(b)
+			sum := sha256.Sum256(b)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(b)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("migration versions must be numbered 1, 2, 3...; found %d at position %d", m.Version, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// splitSQLStatements splits a script on semicolons outside quotes and comments
+func splitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote byte
+
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				current.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			// Skip to the end of the line
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			flush()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+	return statements
+}
+
+// migrator applies migrations over a single connection holding the migration lock
+type migrator struct {
+	conn       *sql.Conn
+	dialect    sqlDialect
+	migrations []migration
+}
+
+// withMigrator runs fn while holding the migration lock
+func withMigrator(db *sql.DB, d sqlDialect, fn func(m *migrator) error) error {
+	migrations, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	unlock, err := d.lockMigrations(conn)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	m := &migrator{conn: conn, dialect: d, migrations: migrations}
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+	return fn(m)
+}
+
+// ensureTable creates schema_migrations and adopts a database created from logbookschema.sql
+func (m *migrator) ensureTable() error {
+	ctx := context.Background()
+	_, err := m.conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			dirty BOOLEAN NOT NULL DEFAULT FALSE,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			applied_from VARCHAR(255) NULL
+		) `+m.dialect.tableOptions())
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	var recorded int
+	if err := m.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&recorded); err != nil {
+		return err
+	}
+	if recorded > 0 {
+		return nil
+	}
+
+	existing, err := m.dialect.tableExists(m.conn, "users")
+	if err != nil {
+		return err
+	}
+	if existing {
+		baseline := m.migrations[0]
+		_, err := m.conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, applied_at, applied_from) VALUES (?, ?, ?, `+m.dialect.now()+`, ?)`,
+			baseline.Version, baseline.Name, baseline.Checksum, localHostname())
+		if err != nil {
+			return err
+		}
+		log.Printf("✓ Existing database adopted at schema version %d (%s)", baseline.Version, baseline.Name)
+	}
+	return nil
+}
+
+// applied returns the recorded migrations by version, refusing to continue past a dirty one
+func (m *migrator) applied() (map[int]MigrationStatus, error) {
+	rows, err := m.conn.QueryContext(context.Background(), `SELECT version, name, checksum, dirty, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	known := make(map[int]migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		known[mig.Version] = mig
+	}
+
+	applied := make(map[int]MigrationStatus)
+	for rows.Next() {
+		var s MigrationStatus
+		var checksum string
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&s.Version, &s.Name, &checksum, &s.Dirty, &appliedAt); err != nil {
+			return nil, err
+		}
+		s.Applied = true
+		if appliedAt.Valid {
+			s.AppliedAt = appliedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		if mig, ok := known[s.Version]; ok && mig.Checksum != checksum {
+			log.Printf("⚠ Migration %d_%s has changed since it was applied", s.Version, s.Name)
+		}
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, s := range applied {
+		if s.Dirty {
+			return nil, fmt.Errorf("migration %d_%s failed part-way; repair the schema by hand, then delete its schema_migrations row (or clear dirty if it finished)", s.Version, s.Name)
+		}
+		if s.Version > len(m.migrations) {
+			return nil, fmt.Errorf("database schema version %d is newer than this app knows (%d); update the app on this PC", s.Version, len(m.migrations))
+		}
+	}
+	return applied, nil
+}
+
+// run executes a migration script, marking the version dirty until it finishes
+func (m *migrator) run(mig migration, up bool) error {
+	ctx := context.Background()
+	script := mig.Down
+	if up {
+		script = mig.Up
+		_, err := m.conn.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, checksum, dirty, applied_from) VALUES (?, ?, ?, TRUE, ?)`,
+			mig.Version, mig.Name, mig.Checksum, localHostname())
+		if err != nil {
+			return err
+		}
+	} else if _, err := m.conn.ExecContext(ctx, `UPDATE schema_migrations SET dirty = TRUE WHERE version = ?`, mig.Version); err != nil {
+		return err
+	}
+
+	for i, statement := range splitSQLStatements(script) {
+		if _, err := m.conn.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("migration %d_%s statement %d: %w", mig.Version, mig.Name, i+1, err)
+		}
+	}
+	if up && mig.Step != nil {
+		if err := mig.Step(m.conn); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", mig.Version, mig.Name, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = m.conn.ExecContext(ctx, `UPDATE schema_migrations SET dirty = FALSE, applied_at = `+m.dialect.now()+` WHERE version = ?`, mig.Version)
+	} else {
+		_, err = m.conn.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, mig.Version)
+	}
+	return err
+}
+
+// migrateUp applies every pending migration and returns how many were applied
+func migrateUp(db *sql.DB, d sqlDialect) (int, error) {
+	count := 0
+	err := withMigrator(db, d, func(m *migrator) error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			log.Printf("Applying migration %d_%s", mig.Version, mig.Name)
+			if err := m.run(mig, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// migrateDown rolls back the last steps applied migrations
+func migrateDown(db *sql.DB, d sqlDialect, steps int) (int, error) {
+	count := 0
+	err := withMigrator(db, d, func(m *migrator) error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			log.Printf("Rolling back migration %d_%s", mig.Version, mig.Name)
+			if err := m.run(mig, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// migrationStatus lists every known migration and whether it has been applied
+func migrationStatus(db *sql.DB, d sqlDialect) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := withMigrator(db, d, func(m *migrator) error {
+		applied, err := m.applied()
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			s, ok := applied[mig.Version]
+			if !ok {
+				s = MigrationStatus{Version: mig.Version, Name: mig.Name}
+			}
+			statuses = append(statuses, s)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// prepareSchema brings the schema up to date at startup. With DB_AUTO_MIGRATE=false
+// it only checks that nothing is pending, leaving upgrades to the -migrate flag.
+func prepareSchema(db *sql.DB, d sqlDialect) error {
+	if GetDBConfig().AutoMigrate {
+		n, err := migrateUp(db, d)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("✓ Applied %d schema migration(s)", n)
+		}
+		return nil
+	}
+
+	statuses, err := migrationStatus(db, d)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if !s.Applied {
+			return fmt.Errorf("schema migration %d_%s is pending; run the app with -migrate", s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// runMigrationCommand handles the command-line migration flags. It reports whether
+// a flag was given, in which case the app exits instead of opening its window.
+func runMigrationCommand(args []string, out io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("logbook", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	migrate := flags.Bool("migrate", false, "apply pending schema migrations and exit")
+	rollback := flags.Int("rollback", 0, "roll back the last N schema migrations and exit")
+	status := flags.Bool("migrate-status", false, "list schema migrations and exit")
+	if err := flags.Parse(args); err != nil {
+		// Not our flags (e.g. passed by the Wails dev server)
+		return false, nil
+	}
+	if !*migrate && *rollback <= 0 && !*status {
+		return false, nil
+	}
+
+	db, dialect, err := InitDatabase()
+	if err != nil {
+		return true, err
+	}
+	defer db.Close()
+
+	switch {
+	case *rollback > 0:
+		n, err := migrateDown(db, dialect, *rollback)
+		fmt.Fprintf(out, "Rolled back %d migration(s)\n", n)
+		return true, err
+	case *migrate:
+		n, err := migrateUp(db, dialect)
+		fmt.Fprintf(out, "Applied %d migration(s)\n", n)
+		return true, err
+	default:
+		statuses, err := migrationStatus(db, dialect)
+		if err != nil {
+			return true, err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			fmt.Fprintf(out, "%04d %-24s %s\n", s.Version, s.Name, state)
+		}
+		return true, nil
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestMigrationsMatchAcrossBackends(t *testing.T) {
+	mysql, err := loadMigrations(mysqlDialect{})
+	if err != nil {
+		t.Fatalf("load mysql migrations: %v", err)
+	}
+	sqlite, err := loadMigrations(sqliteDialect{})
+	if err != nil {
+		t.Fatalf("load sqlite migrations: %v", err)
+	}
+	if len(mysql) != len(sqlite) {
+		t.Fatalf("mysql has %d migrations, sqlite %d", len(mysql), len(sqlite))
+	}
+	for i := range mysql {
+		if mysql[i].Version != sqlite[i].Version || mysql[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d: mysql %d_%s, sqlite %d_%s", i+1, mysql[i].Version, mysql[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	a := newTestApp(t)
+	migrations, err := loadMigrations(a.dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM schema_migrations WHERE dirty = FALSE`); n != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", n, len(migrations))
+	}
+
+	// Every down script undoes its up script, back to an empty database
+	n, err := migrateDown(a.db, a.dialect, len(migrations))
+	if err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if n != len(migrations) {
+		t.Fatalf("rolled back %d migrations, want %d", n, len(migrations))
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')`); n != 0 {
+		t.Errorf("%d tables left after rolling everything back", n)
+	}
+
+	n, err = migrateUp(a.db, a.dialect)
+	if err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+	if n != len(migrations) {
+		t.Fatalf("applied %d migrations, want %d", n, len(migrations))
+	}
+	if n, err := migrateUp(a.db, a.dialect); err != nil || n != 0 {
+		t.Errorf("second migrate up applied %d (err %v), want nothing", n, err)
+	}
+}
+
+func TestMigrateDownOneStep(t *testing.T) {
+	a := newTestApp(t)
+	migrations, err := loadMigrations(a.dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := migrations[len(migrations)-1]
+
+	if _, err := migrateDown(a.db, a.dialect, 1); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	statuses, err := migrationStatus(a.db, a.dialect)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range statuses {
+		if s.Applied != (s.Version != last.Version) {
+			t.Errorf("migration %d_%s applied = %v", s.Version, s.Name, s.Applied)
+		}
+	}
+
+	if n, err := migrateUp(a.db, a.dialect); err != nil || n != 1 {
+		t.Fatalf("migrate up applied %d (err %v), want 1", n, err)
+	}
+}
@@ -0,0 +1,16 @@
+DROP VIEW IF EXISTS v_classlist_complete;
+DROP VIEW IF EXISTS v_classes_complete;
+DROP VIEW IF EXISTS v_users_complete;
+DROP VIEW IF EXISTS v_login_logs_complete;
+
+DROP TABLE IF EXISTS attendance;
+DROP TABLE IF EXISTS classlist;
+DROP TABLE IF EXISTS classes;
+DROP TABLE IF EXISTS subjects;
+DROP TABLE IF EXISTS feedback;
+DROP TABLE IF EXISTS login_logs;
+DROP TABLE IF EXISTS students;
+DROP TABLE IF EXISTS teachers;
+DROP TABLE IF EXISTS admins;
+DROP TABLE IF EXISTS departments;
+DROP TABLE IF EXISTS users;
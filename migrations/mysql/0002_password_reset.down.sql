@@ -0,0 +1,5 @@
+ALTER TABLE users DROP FOREIGN KEY fk_users_password_reset_by;
+ALTER TABLE users
+    DROP COLUMN password_reset_at,
+    DROP COLUMN password_reset_by,
+    DROP COLUMN must_change_password;
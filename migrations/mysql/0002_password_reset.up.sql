@@ -0,0 +1,6 @@
+-- Forced password changes and admin password resets
+ALTER TABLE users
+    ADD COLUMN must_change_password BOOLEAN DEFAULT FALSE COMMENT 'User must choose a new password before using the app' AFTER is_active,
+    ADD COLUMN password_reset_by INT NULL COMMENT 'Foreign key to users.id - admin who last reset the password' AFTER password_changed_at,
+    ADD COLUMN password_reset_at DATETIME NULL COMMENT 'Timestamp of the last admin password reset' AFTER password_reset_by,
+    ADD CONSTRAINT fk_users_password_reset_by FOREIGN KEY (password_reset_by) REFERENCES users(id) ON DELETE SET NULL;
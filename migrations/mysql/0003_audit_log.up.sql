@@ -0,0 +1,20 @@
+-- Audit log: Append-only record of administrative changes, written in the same
+-- transaction as the change. actor_user_id deliberately has no foreign key so
+-- entries survive the deletion of the account that made them.
+CREATE TABLE audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_user_id INT NULL COMMENT 'users.id of the signed-in user who made the change',
+    actor_username VARCHAR(50) NULL COMMENT 'Username of the actor at the time of the change',
+    action VARCHAR(50) NOT NULL COMMENT 'Action performed (e.g., create, update, delete, reset_password)',
+    entity_type VARCHAR(50) NOT NULL COMMENT 'Kind of record changed (e.g., user, class, attendance)',
+    entity_key VARCHAR(100) NOT NULL COMMENT 'Key of the changed record (attendance: class_id/student_user_id/date)',
+    before_data JSON NULL COMMENT 'Snapshot of the record before the change',
+    after_data JSON NULL COMMENT 'Snapshot of the record after the change',
+    pc_hostname VARCHAR(100) NULL COMMENT 'Hostname of the computer the change was made from',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    INDEX idx_audit_created_at (created_at),
+    INDEX idx_audit_actor (actor_user_id, created_at),
+    INDEX idx_audit_entity (entity_type, entity_key),
+    INDEX idx_audit_action (action)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
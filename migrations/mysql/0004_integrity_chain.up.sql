@@ -0,0 +1,25 @@
+-- Integrity chain: Append-only hash chain over attendance changes and login log
+-- entries. Each hash covers the entry's payload and the previous entry's hash.
+-- payload is TEXT rather than JSON so the exact hashed bytes are preserved.
+CREATE TABLE integrity_chain (
+    seq BIGINT PRIMARY KEY COMMENT 'Position in the chain, starting at 1',
+    entity_type ENUM('attendance', 'login_log') NOT NULL,
+    entity_key VARCHAR(100) NOT NULL COMMENT 'attendance: class_id/student_user_id/date, login_log: login_logs.id',
+    payload MEDIUMTEXT NOT NULL COMMENT 'Canonical JSON of the row after the change',
+    prev_hash CHAR(64) NOT NULL COMMENT 'Hash of the previous entry (all zeros for the first entry)',
+    hash CHAR(64) NOT NULL COMMENT 'SHA-256 over prev_hash, seq, entity_type, entity_key and payload',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    INDEX idx_chain_entity (entity_type, entity_key, seq),
+    INDEX idx_chain_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Integrity chain head: Single row locked by every append so writers on
+-- different PCs extend the chain one at a time
+CREATE TABLE integrity_chain_head (
+    id TINYINT PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    hash CHAR(64) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO integrity_chain_head (id, seq, hash) VALUES (1, 0, REPEAT('0', 64));
@@ -0,0 +1,23 @@
+-- Sessions closed by a login on another PC or by the stale session reaper
+ALTER TABLE login_logs
+    MODIFY COLUMN login_status ENUM('success', 'failed', 'logout', 'kicked', 'timeout') DEFAULT 'success' COMMENT 'kicked: closed by a login on another PC, timeout: closed as stale';
+
+-- Session conflicts: Logins of a student who was still signed in on another PC,
+-- flagged for the teacher whatever the concurrent session policy decided
+CREATE TABLE session_conflicts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    login_log_id INT NULL COMMENT 'The new session (NULL when the login was denied)',
+    existing_login_log_id INT NOT NULL COMMENT 'The session that was already open',
+    existing_pc VARCHAR(50) NULL,
+    attempted_pc VARCHAR(50) NULL,
+    policy ENUM('allow', 'deny', 'kick') NOT NULL COMMENT 'Concurrent session policy applied',
+    detected_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (login_log_id) REFERENCES login_logs(id) ON DELETE SET NULL,
+    FOREIGN KEY (existing_login_log_id) REFERENCES login_logs(id) ON DELETE CASCADE,
+    
+    INDEX idx_session_conflicts_user_time (user_id, detected_at),
+    INDEX idx_session_conflicts_detected_at (detected_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS totp_recovery_codes;
+ALTER TABLE users
+    DROP COLUMN totp_last_step,
+    DROP COLUMN totp_enabled,
+    DROP COLUMN totp_secret;
@@ -0,0 +1,18 @@
+-- Optional TOTP sign-in for admins and teachers
+ALTER TABLE users
+    ADD COLUMN totp_secret VARCHAR(64) NULL COMMENT 'Base32 TOTP secret (set during enrollment, admins and teachers only)' AFTER account_locked_until,
+    ADD COLUMN totp_enabled BOOLEAN DEFAULT FALSE COMMENT 'Login requires a TOTP or recovery code' AFTER totp_secret,
+    ADD COLUMN totp_last_step BIGINT NULL COMMENT 'Last accepted TOTP time step, prevents code replay' AFTER totp_enabled;
+
+-- TOTP recovery codes: Single-use codes for signing in without the authenticator app
+CREATE TABLE totp_recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the normalized code',
+    used_at DATETIME NULL COMMENT 'NULL until the code is used',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    
+    UNIQUE KEY uq_recovery_code (user_id, code_hash)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
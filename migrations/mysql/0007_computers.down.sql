@@ -0,0 +1,5 @@
+ALTER TABLE login_logs DROP FOREIGN KEY fk_login_logs_computer;
+ALTER TABLE login_logs
+    DROP COLUMN unregistered_pc,
+    DROP COLUMN computer_id;
+DROP TABLE IF EXISTS computers;
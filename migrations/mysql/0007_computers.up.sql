@@ -0,0 +1,27 @@
+-- Computers table: Registry of lab PCs allowed to record logins
+-- Each app install requests registration on first start; an admin approves it and names the PC.
+-- The enrollment secret stays on the PC, only its hash is stored here.
+CREATE TABLE computers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    asset_tag VARCHAR(50) NULL COMMENT 'School property / inventory tag',
+    display_name VARCHAR(20) NULL UNIQUE COMMENT 'Name recorded as the PC number once approved',
+    room VARCHAR(50) NULL,
+    hostname VARCHAR(255) NOT NULL COMMENT 'Hostname reported by the PC at its last start',
+    machine_fingerprint CHAR(64) NOT NULL UNIQUE COMMENT 'SHA-256 of the OS machine ID, or of the MAC addresses',
+    mac_addresses VARCHAR(500) NULL,
+    enrollment_secret_hash CHAR(64) NOT NULL COMMENT 'SHA-256 of the secret kept by the app install',
+    status ENUM('pending', 'approved', 'revoked') NOT NULL DEFAULT 'pending',
+    requested_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    approved_by INT NULL,
+    approved_at DATETIME NULL,
+    last_seen_at DATETIME NULL,
+
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL,
+
+    INDEX idx_computers_status (status)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE login_logs
+    ADD COLUMN computer_id INT NULL COMMENT 'Registered computer the login came from' AFTER login_status,
+    ADD COLUMN unregistered_pc BOOLEAN DEFAULT FALSE COMMENT 'Login allowed from a PC not approved in the registry' AFTER computer_id,
+    ADD CONSTRAINT fk_login_logs_computer FOREIGN KEY (computer_id) REFERENCES computers(id) ON DELETE SET NULL;
@@ -0,0 +1,18 @@
+-- Class meetings table: The weekly meetings of a class, replacing the free-text classes.schedule
+-- classes.schedule is kept as a display summary written from the meetings.
+-- Existing schedules are converted by the app after this script runs.
+CREATE TABLE class_meetings (
+    meeting_id INT AUTO_INCREMENT PRIMARY KEY,
+    class_id INT NOT NULL,
+    weekday TINYINT NOT NULL COMMENT '0 = Sunday ... 6 = Saturday',
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    room VARCHAR(50) NULL COMMENT 'NULL uses the class room',
+
+    FOREIGN KEY (class_id) REFERENCES classes(class_id) ON DELETE CASCADE,
+    CHECK (weekday BETWEEN 0 AND 6),
+    CHECK (end_time > start_time),
+
+    INDEX idx_class_meetings_class (class_id),
+    INDEX idx_class_meetings_weekday (weekday, start_time)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
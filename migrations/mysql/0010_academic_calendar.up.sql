@@ -0,0 +1,40 @@
+-- Academic terms table: The first and last class day of each semester
+-- A class belongs to the term with its school year and semester; it has no class days outside it.
+CREATE TABLE academic_terms (
+    term_id INT AUTO_INCREMENT PRIMARY KEY,
+    school_year VARCHAR(20) NOT NULL COMMENT 'Matches classes.school_year (e.g., 2024-2025)',
+    semester VARCHAR(20) NOT NULL COMMENT 'Matches classes.semester (e.g., 1st Semester)',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE KEY uq_academic_terms (school_year, semester),
+    CHECK (end_date >= start_date),
+
+    INDEX idx_academic_terms_dates (start_date, end_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- Calendar events table: Holidays, class suspensions and make-up days
+-- A holiday or suspension cancels classes in its scope; a make-up day holds the
+-- meetings of makeup_weekday instead of the date's own.
+CREATE TABLE calendar_events (
+    event_id INT AUTO_INCREMENT PRIMARY KEY,
+    event_type ENUM('holiday', 'suspension', 'makeup') NOT NULL,
+    scope ENUM('school', 'department', 'room') NOT NULL DEFAULT 'school',
+    scope_value VARCHAR(50) NULL COMMENT 'Department code or room; NULL for the whole school',
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    makeup_weekday TINYINT NULL COMMENT 'Make-up days only: 0 = Sunday ... 6 = Saturday',
+    description VARCHAR(255) NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (end_date >= start_date),
+    CHECK (makeup_weekday BETWEEN 0 AND 6),
+
+    INDEX idx_calendar_events_dates (start_date, end_date)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,21 @@
+-- Class attendance policies table: A class's own attendance rules
+-- A NULL column uses the school default from the ATTENDANCE_* setting named after it.
+CREATE TABLE class_attendance_policies (
+    class_id INT PRIMARY KEY,
+    early_minutes INT NULL COMMENT 'Minutes before the start a login counts towards the class',
+    late_minutes INT NULL COMMENT 'Minutes after the start before a login is marked late',
+    absent_minutes INT NULL COMMENT 'Minutes after the start before a login is marked absent; 0 never',
+    minimum_minutes INT NULL COMMENT 'Minutes a student must stay; 0 no minimum',
+    updated_by INT NULL,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    FOREIGN KEY (class_id) REFERENCES classes(class_id) ON DELETE CASCADE,
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (early_minutes >= 0),
+    CHECK (late_minutes >= 0),
+    CHECK (absent_minutes >= 0),
+    CHECK (minimum_minutes >= 0)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+-- The schedule grace period now only applies before the class starts
+UPDATE settings SET setting_key = 'ATTENDANCE_EARLY_MINUTES' WHERE setting_key = 'ATTENDANCE_SCHEDULE_GRACE_MINUTES';
@@ -0,0 +1,5 @@
+ALTER TABLE attendance DROP FOREIGN KEY fk_attendance_login_log;
+ALTER TABLE attendance
+    DROP COLUMN short_stay,
+    DROP COLUMN minutes_present,
+    DROP COLUMN login_log_id;
@@ -0,0 +1,7 @@
+-- Attendance time out and duration: the session a row was recorded from is
+-- remembered, so its logout fills in time_out and the minutes present
+ALTER TABLE attendance
+    ADD COLUMN login_log_id INT NULL COMMENT 'Login session that recorded the time in' AFTER pc_number,
+    ADD COLUMN minutes_present INT NULL COMMENT 'Minutes between time in and out within the class meeting' AFTER login_log_id,
+    ADD COLUMN short_stay BOOLEAN NOT NULL DEFAULT FALSE COMMENT 'Minutes present below the class minimum' AFTER minutes_present,
+    ADD CONSTRAINT fk_attendance_login_log FOREIGN KEY (login_log_id) REFERENCES login_logs(id) ON DELETE SET NULL;
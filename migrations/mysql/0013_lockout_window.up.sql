@@ -0,0 +1,4 @@
+-- Failed sign-ins are counted from the lockout window, the last successful
+-- sign-in, lock or unlock, whichever is latest
+ALTER TABLE users
+    ADD COLUMN failures_reset_at DATETIME NULL COMMENT 'Failed sign-ins before this time no longer count towards a lockout' AFTER account_locked_until;
@@ -0,0 +1 @@
+ALTER TABLE integrity_chain_head DROP COLUMN started_at;
@@ -0,0 +1,9 @@
+-- Integrity chain start: rows written before the chain existed have no entry
+-- and are not reported as unchained. Anchored on the first entry, or on now
+-- when nothing has been chained yet.
+ALTER TABLE integrity_chain_head
+    ADD COLUMN started_at DATETIME NULL COMMENT 'When the chain started; older rows were written before it' AFTER hash;
+
+UPDATE integrity_chain_head
+SET started_at = COALESCE((SELECT MIN(created_at) FROM integrity_chain), CURRENT_TIMESTAMP)
+WHERE id = 1;
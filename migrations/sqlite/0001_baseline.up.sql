@@ -0,0 +1,355 @@
+-- ============================================================================
+-- 0001 BASELINE (SQLite)
+-- ============================================================================
+-- The same schema as migrations/mysql/0001_baseline.up.sql for a standalone
+-- lab PC. Differences from the MySQL schema:
+-- - Role and status columns are TEXT; the application validates their values.
+--   A CHECK constraint cannot be changed later without rebuilding the table.
+-- - Timestamps default to local time, matching MySQL's NOW().
+-- - SQLite has no ON UPDATE clause; updated_at only changes where a statement
+--   sets it explicitly.
+-- - Codes and usernames compare case-insensitively, as under utf8mb4_unicode_ci.
+-- - Index names are prefixed with their table, since SQLite index names are
+--   shared by the whole database.
+
+-- ============================================================================
+-- CORE USER MANAGEMENT
+-- ============================================================================
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) NOT NULL UNIQUE COLLATE NOCASE,
+    password VARCHAR(255) NOT NULL,
+    user_type TEXT NOT NULL, -- admin, teacher, student, working_student
+    is_active BOOLEAN DEFAULT TRUE,
+    password_changed_at DATETIME NULL,
+    last_login_at DATETIME NULL,
+    failed_attempts INT DEFAULT 0,
+    account_locked_until DATETIME NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE INDEX idx_users_user_type ON users (user_type);
+CREATE INDEX idx_users_is_active ON users (is_active);
+CREATE INDEX idx_users_account_locked ON users (account_locked_until);
+
+-- ============================================================================
+-- ORGANIZATIONAL STRUCTURE
+-- ============================================================================
+CREATE TABLE departments (
+    department_code VARCHAR(20) PRIMARY KEY COLLATE NOCASE,
+    department_name VARCHAR(200) NOT NULL,
+    description TEXT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE INDEX idx_departments_name ON departments (department_name);
+CREATE INDEX idx_departments_is_active ON departments (is_active);
+
+CREATE TABLE admins (
+    user_id INTEGER PRIMARY KEY,
+    employee_number VARCHAR(50) UNIQUE COLLATE NOCASE,
+    first_name VARCHAR(100) NOT NULL,
+    middle_name VARCHAR(100) NULL,
+    last_name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NULL,
+    contact_number VARCHAR(20) NULL,
+    profile_photo TEXT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_admins_email ON admins (email);
+CREATE INDEX idx_admins_name ON admins (last_name, first_name);
+
+CREATE TABLE teachers (
+    user_id INTEGER PRIMARY KEY,
+    employee_number VARCHAR(50) UNIQUE COLLATE NOCASE,
+    first_name VARCHAR(100) NOT NULL,
+    middle_name VARCHAR(100) NULL,
+    last_name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NULL,
+    contact_number VARCHAR(20) NULL,
+    department_code VARCHAR(20) NULL COLLATE NOCASE,
+    profile_photo TEXT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (department_code) REFERENCES departments(department_code) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_teachers_email ON teachers (email);
+CREATE INDEX idx_teachers_name ON teachers (last_name, first_name);
+CREATE INDEX idx_teachers_department_code ON teachers (department_code);
+
+CREATE TABLE students (
+    user_id INTEGER PRIMARY KEY,
+    student_number VARCHAR(50) UNIQUE COLLATE NOCASE,
+    first_name VARCHAR(100) NOT NULL,
+    middle_name VARCHAR(100) NULL,
+    last_name VARCHAR(100) NOT NULL,
+    email VARCHAR(255) NULL,
+    contact_number VARCHAR(20) NULL,
+    is_working_student BOOLEAN DEFAULT FALSE,
+    profile_photo TEXT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_students_email ON students (email);
+CREATE INDEX idx_students_name ON students (last_name, first_name);
+CREATE INDEX idx_students_is_working_student ON students (is_working_student);
+
+-- ============================================================================
+-- ACADEMIC MANAGEMENT
+-- ============================================================================
+CREATE TABLE subjects (
+    subject_code VARCHAR(20) PRIMARY KEY COLLATE NOCASE,
+    subject_name VARCHAR(200) NOT NULL,
+    description TEXT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE INDEX idx_subjects_name ON subjects (subject_name);
+
+CREATE TABLE classes (
+    class_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    subject_code VARCHAR(20) NOT NULL COLLATE NOCASE,
+    teacher_user_id INT NOT NULL,
+    offering_code VARCHAR(50) NULL,
+    schedule VARCHAR(100) NULL,
+    room VARCHAR(50) NULL,
+    year_level VARCHAR(20) NULL,
+    section VARCHAR(50) NULL,
+    semester VARCHAR(20) NULL,
+    school_year VARCHAR(20) NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by_user_id INT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (subject_code) REFERENCES subjects(subject_code) ON DELETE CASCADE,
+    FOREIGN KEY (teacher_user_id) REFERENCES teachers(user_id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_classes_subject_code ON classes (subject_code);
+CREATE INDEX idx_classes_year_section ON classes (year_level, section);
+CREATE INDEX idx_classes_is_active ON classes (is_active);
+CREATE INDEX idx_classes_semester_year ON classes (semester, school_year);
+CREATE INDEX idx_classes_teacher_active ON classes (teacher_user_id, is_active);
+CREATE INDEX idx_classes_offering_code ON classes (offering_code);
+CREATE INDEX idx_classes_created_by_user_id ON classes (created_by_user_id);
+
+CREATE TABLE classlist (
+    class_id INT NOT NULL,
+    student_user_id INT NOT NULL,
+    enrollment_date DATE DEFAULT (date('now', 'localtime')),
+    status TEXT DEFAULT 'active', -- active, dropped, completed
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    PRIMARY KEY (class_id, student_user_id),
+    FOREIGN KEY (class_id) REFERENCES classes(class_id) ON DELETE CASCADE,
+    FOREIGN KEY (student_user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_classlist_enrollment_date ON classlist (enrollment_date);
+CREATE INDEX idx_classlist_class_status ON classlist (class_id, status);
+CREATE INDEX idx_classlist_student_status ON classlist (student_user_id, status);
+
+-- ============================================================================
+-- ATTENDANCE TRACKING
+-- ============================================================================
+CREATE TABLE attendance (
+    class_id INT NOT NULL,
+    student_user_id INT NOT NULL,
+    date DATE NOT NULL,
+    time_in TIME NULL,
+    time_out TIME NULL,
+    pc_number VARCHAR(20) NULL,
+    status TEXT NOT NULL DEFAULT 'present', -- present, absent, late, excused
+    remarks TEXT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    PRIMARY KEY (class_id, student_user_id, date),
+    FOREIGN KEY (class_id, student_user_id) REFERENCES classlist(class_id, student_user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_attendance_date_classlist ON attendance (date DESC, class_id, student_user_id);
+CREATE INDEX idx_attendance_pc_number ON attendance (pc_number);
+CREATE INDEX idx_attendance_status_date ON attendance (status, date DESC);
+CREATE INDEX idx_attendance_student_date ON attendance (student_user_id, date DESC);
+
+CREATE TABLE login_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    pc_number VARCHAR(50) NULL,
+    login_time DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    logout_time DATETIME NULL,
+    login_status TEXT DEFAULT 'success', -- success, failed, logout
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_login_logs_login_time ON login_logs (login_time);
+CREATE INDEX idx_login_logs_pc_number ON login_logs (pc_number);
+CREATE INDEX idx_login_logs_user_time ON login_logs (user_id, login_time DESC);
+CREATE INDEX idx_login_logs_status_time ON login_logs (login_status, login_time DESC);
+
+-- ============================================================================
+-- FEEDBACK MANAGEMENT
+-- ============================================================================
+CREATE TABLE feedback (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    student_user_id INT NOT NULL,
+    pc_number VARCHAR(50) NOT NULL,
+    equipment_condition TEXT NOT NULL DEFAULT 'Good', -- Good, Minor Issue, Not Working
+    monitor_condition TEXT NOT NULL DEFAULT 'Good',
+    keyboard_condition TEXT NOT NULL DEFAULT 'Good',
+    mouse_condition TEXT NOT NULL DEFAULT 'Good',
+    comments TEXT NULL,
+    working_student_notes TEXT NULL,
+    status TEXT DEFAULT 'pending', -- pending, forwarded, resolved
+    forwarded_by_user_id INT NULL,
+    forwarded_at DATETIME NULL,
+    reviewed_by_user_id INT NULL,
+    date_submitted DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (student_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (forwarded_by_user_id) REFERENCES users(id) ON DELETE SET NULL,
+    FOREIGN KEY (reviewed_by_user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_feedback_student_user_id ON feedback (student_user_id);
+CREATE INDEX idx_feedback_date ON feedback (date_submitted DESC);
+CREATE INDEX idx_feedback_equipment_condition ON feedback (equipment_condition);
+CREATE INDEX idx_feedback_forwarded_by_user_id ON feedback (forwarded_by_user_id);
+CREATE INDEX idx_feedback_forwarded_at ON feedback (forwarded_at);
+CREATE INDEX idx_feedback_status_date ON feedback (status, date_submitted DESC);
+CREATE INDEX idx_feedback_pc_date ON feedback (pc_number, date_submitted DESC);
+
+CREATE VIEW v_users_complete AS
+SELECT 
+    u.id,
+    u.username,
+    u.user_type,
+    u.is_active,
+    u.created_at,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.first_name
+        WHEN u.user_type = 'teacher' THEN t.first_name
+        WHEN u.user_type IN ('student', 'working_student') THEN s.first_name
+    END AS first_name,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.middle_name
+        WHEN u.user_type = 'teacher' THEN t.middle_name
+        WHEN u.user_type IN ('student', 'working_student') THEN s.middle_name
+    END AS middle_name,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.last_name
+        WHEN u.user_type = 'teacher' THEN t.last_name
+        WHEN u.user_type IN ('student', 'working_student') THEN s.last_name
+    END AS last_name,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.email
+        WHEN u.user_type = 'teacher' THEN t.email
+        WHEN u.user_type IN ('student', 'working_student') THEN s.email
+    END AS email,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.contact_number
+        WHEN u.user_type = 'teacher' THEN t.contact_number
+        WHEN u.user_type IN ('student', 'working_student') THEN s.contact_number
+    END AS contact_number,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.employee_number
+        WHEN u.user_type = 'teacher' THEN t.employee_number
+    END AS employee_number,
+    CASE 
+        WHEN u.user_type IN ('student', 'working_student') THEN s.student_number
+    END AS student_number,
+    CASE 
+        WHEN u.user_type = 'teacher' THEN t.department_code
+    END AS department_code,
+    CASE 
+        WHEN u.user_type = 'admin' THEN a.profile_photo
+        WHEN u.user_type = 'teacher' THEN t.profile_photo
+        WHEN u.user_type IN ('student', 'working_student') THEN s.profile_photo
+    END AS profile_photo,
+    CASE 
+        WHEN u.user_type IN ('student', 'working_student') THEN s.is_working_student
+        ELSE FALSE
+    END AS is_working_student
+FROM users u
+LEFT JOIN admins a ON u.id = a.user_id AND u.user_type = 'admin'
+LEFT JOIN teachers t ON u.id = t.user_id AND u.user_type = 'teacher'
+LEFT JOIN students s ON u.id = s.user_id AND u.user_type IN ('student', 'working_student');
+
+CREATE VIEW v_login_logs_complete AS
+SELECT 
+    ll.id,
+    ll.user_id,
+    vu.user_type,
+    ll.pc_number,
+    ll.login_time,
+    ll.logout_time,
+    ll.login_status,
+    vu.first_name,
+    vu.middle_name,
+    vu.last_name,
+    vu.last_name || ', ' || vu.first_name ||
+        CASE WHEN vu.middle_name IS NOT NULL THEN ' ' || vu.middle_name ELSE '' END AS full_name
+FROM login_logs ll
+JOIN v_users_complete vu ON ll.user_id = vu.id;
+
+CREATE VIEW v_classes_complete AS
+SELECT 
+    c.class_id,
+    c.subject_code,
+    s.subject_name,
+    c.offering_code,
+    c.teacher_user_id,
+    t.employee_number AS teacher_employee_number,
+    t.last_name || ', ' || t.first_name ||
+        CASE WHEN t.middle_name IS NOT NULL THEN ' ' || t.middle_name ELSE '' END AS teacher_name,
+    c.schedule,
+    c.room,
+    c.year_level,
+    c.section,
+    c.semester,
+    c.school_year,
+    c.is_active,
+    c.created_at
+FROM classes c
+JOIN subjects s ON c.subject_code = s.subject_code
+JOIN teachers t ON c.teacher_user_id = t.user_id;
+
+CREATE VIEW v_classlist_complete AS
+SELECT 
+    cl.class_id,
+    cl.student_user_id,
+    cl.enrollment_date,
+    cl.status AS enrollment_status,
+    u.username,
+    u.user_type,
+    s.student_number,
+    s.first_name,
+    s.middle_name,
+    s.last_name,
+    s.is_working_student
+FROM classlist cl
+JOIN users u ON cl.student_user_id = u.id
+LEFT JOIN students s ON u.id = s.user_id AND u.user_type IN ('student', 'working_student');
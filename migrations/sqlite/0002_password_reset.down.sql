@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN password_reset_at;
+ALTER TABLE users DROP COLUMN password_reset_by;
+ALTER TABLE users DROP COLUMN must_change_password;
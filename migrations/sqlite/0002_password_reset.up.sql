@@ -0,0 +1,6 @@
+-- Forced password changes and admin password resets.
+-- password_reset_by refers to users.id; it has no FOREIGN KEY clause so the
+-- down migration can drop it, which SQLite refuses for constrained columns.
+ALTER TABLE users ADD COLUMN must_change_password BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN password_reset_by INT NULL;
+ALTER TABLE users ADD COLUMN password_reset_at DATETIME NULL;
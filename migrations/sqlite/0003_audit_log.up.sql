@@ -0,0 +1,21 @@
+-- Audit log: Append-only record of administrative changes, written in the same
+-- transaction as the change. actor_user_id deliberately has no foreign key so
+-- entries survive the deletion of the account that made them.
+-- before_data and after_data hold JSON text.
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_user_id INT NULL,
+    actor_username VARCHAR(50) NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_key VARCHAR(100) NOT NULL,
+    before_data TEXT NULL,
+    after_data TEXT NULL,
+    pc_hostname VARCHAR(100) NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE INDEX idx_audit_created_at ON audit_log (created_at);
+CREATE INDEX idx_audit_actor ON audit_log (actor_user_id, created_at);
+CREATE INDEX idx_audit_entity ON audit_log (entity_type, entity_key);
+CREATE INDEX idx_audit_action ON audit_log (action);
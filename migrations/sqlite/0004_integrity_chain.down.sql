@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS integrity_chain_head;
+DROP TABLE IF EXISTS integrity_chain;
@@ -0,0 +1,23 @@
+-- Integrity chain: Append-only hash chain over attendance changes and login log
+-- entries. Each hash covers the entry's payload and the previous entry's hash.
+CREATE TABLE integrity_chain (
+    seq BIGINT PRIMARY KEY,
+    entity_type TEXT NOT NULL, -- attendance, login_log
+    entity_key VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    prev_hash CHAR(64) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime'))
+);
+
+CREATE INDEX idx_chain_entity ON integrity_chain (entity_type, entity_key, seq);
+CREATE INDEX idx_chain_created_at ON integrity_chain (created_at);
+
+-- Integrity chain head: Single row updated by every append
+CREATE TABLE integrity_chain_head (
+    id TINYINT PRIMARY KEY,
+    seq BIGINT NOT NULL,
+    hash CHAR(64) NOT NULL
+);
+
+INSERT INTO integrity_chain_head (id, seq, hash) VALUES (1, 0, '0000000000000000000000000000000000000000000000000000000000000000');
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS session_conflicts;
+
+-- Kicked and timed out sessions were closed normally as far as the old schema is concerned
+UPDATE login_logs SET login_status = 'success' WHERE login_status IN ('kicked', 'timeout');
@@ -0,0 +1,22 @@
+-- Sessions closed by a login on another PC or by the stale session reaper are
+-- recorded with login_status 'kicked' or 'timeout'; the column is plain TEXT.
+
+-- Session conflicts: Logins of a student who was still signed in on another PC,
+-- flagged for the teacher whatever the concurrent session policy decided
+CREATE TABLE session_conflicts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    login_log_id INT NULL,
+    existing_login_log_id INT NOT NULL,
+    existing_pc VARCHAR(50) NULL,
+    attempted_pc VARCHAR(50) NULL,
+    policy TEXT NOT NULL, -- allow, deny, kick
+    detected_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (login_log_id) REFERENCES login_logs(id) ON DELETE SET NULL,
+    FOREIGN KEY (existing_login_log_id) REFERENCES login_logs(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_session_conflicts_user_time ON session_conflicts (user_id, detected_at);
+CREATE INDEX idx_session_conflicts_detected_at ON session_conflicts (detected_at);
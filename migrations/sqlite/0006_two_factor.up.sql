@@ -0,0 +1,16 @@
+-- Optional TOTP sign-in for admins and teachers
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64) NULL;
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NULL;
+
+-- TOTP recovery codes: Single-use codes for signing in without the authenticator app
+CREATE TABLE totp_recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at DATETIME NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE login_logs DROP COLUMN unregistered_pc;
+ALTER TABLE login_logs DROP COLUMN computer_id;
+DROP TABLE IF EXISTS computers;
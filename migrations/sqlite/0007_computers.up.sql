@@ -0,0 +1,27 @@
+-- Computers table: Registry of lab PCs allowed to record logins
+-- Each app install requests registration on first start; an admin approves it and names the PC.
+-- The enrollment secret stays on the PC, only its hash is stored here.
+CREATE TABLE computers (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    asset_tag VARCHAR(50) NULL,
+    display_name VARCHAR(20) NULL UNIQUE,
+    room VARCHAR(50) NULL,
+    hostname VARCHAR(255) NOT NULL,
+    machine_fingerprint CHAR(64) NOT NULL UNIQUE,
+    mac_addresses VARCHAR(500) NULL,
+    enrollment_secret_hash CHAR(64) NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, approved, revoked
+    requested_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    approved_by INT NULL,
+    approved_at DATETIME NULL,
+    last_seen_at DATETIME NULL,
+
+    FOREIGN KEY (approved_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_computers_status ON computers (status);
+
+-- computer_id refers to computers.id; like users.password_reset_by it has no
+-- FOREIGN KEY clause so the down migration can drop it
+ALTER TABLE login_logs ADD COLUMN computer_id INT NULL;
+ALTER TABLE login_logs ADD COLUMN unregistered_pc BOOLEAN DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- Settings table: Values changed by admins from the settings screen
+-- They override the config file and environment variables of every lab PC.
+CREATE TABLE settings (
+    setting_key VARCHAR(100) PRIMARY KEY, -- Named like the environment variable it overrides
+    setting_value VARCHAR(500) NOT NULL,
+    updated_by INT NULL,
+    updated_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (updated_by) REFERENCES users(id) ON DELETE SET NULL
+);
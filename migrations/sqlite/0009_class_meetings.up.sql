@@ -0,0 +1,17 @@
+-- Class meetings table: The weekly meetings of a class, replacing the free-text classes.schedule
+-- classes.schedule is kept as a display summary written from the meetings.
+-- Existing schedules are converted by the app after this script runs.
+CREATE TABLE class_meetings (
+    meeting_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    class_id INT NOT NULL,
+    weekday INT NOT NULL CHECK (weekday BETWEEN 0 AND 6), -- 0 = Sunday ... 6 = Saturday
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    room VARCHAR(50) NULL, -- NULL uses the class room
+
+    FOREIGN KEY (class_id) REFERENCES classes(class_id) ON DELETE CASCADE,
+    CHECK (end_time > start_time)
+);
+
+CREATE INDEX idx_class_meetings_class ON class_meetings (class_id);
+CREATE INDEX idx_class_meetings_weekday ON class_meetings (weekday, start_time);
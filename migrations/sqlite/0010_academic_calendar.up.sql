@@ -0,0 +1,39 @@
+-- Academic terms table: The first and last class day of each semester
+-- A class belongs to the term with its school year and semester; it has no class days outside it.
+CREATE TABLE academic_terms (
+    term_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    school_year VARCHAR(20) NOT NULL COLLATE NOCASE, -- Matches classes.school_year
+    semester VARCHAR(20) NOT NULL COLLATE NOCASE, -- Matches classes.semester
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+    updated_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    UNIQUE (school_year, semester),
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_academic_terms_dates ON academic_terms (start_date, end_date);
+
+-- Calendar events table: Holidays, class suspensions and make-up days
+-- A holiday or suspension cancels classes in its scope; a make-up day holds the
+-- meetings of makeup_weekday instead of the date's own.
+CREATE TABLE calendar_events (
+    event_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_type TEXT NOT NULL CHECK (event_type IN ('holiday', 'suspension', 'makeup')),
+    scope TEXT NOT NULL DEFAULT 'school' CHECK (scope IN ('school', 'department', 'room')),
+    scope_value VARCHAR(50) NULL, -- Department code or room; NULL for the whole school
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,
+    makeup_weekday INT NULL CHECK (makeup_weekday BETWEEN 0 AND 6), -- Make-up days only: 0 = Sunday ... 6 = Saturday
+    description VARCHAR(255) NOT NULL,
+    created_by INT NULL,
+    created_at TIMESTAMP DEFAULT (datetime('now', 'localtime')),
+
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+    CHECK (end_date >= start_date)
+);
+
+CREATE INDEX idx_calendar_events_dates ON calendar_events (start_date, end_date);
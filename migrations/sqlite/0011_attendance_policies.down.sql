@@ -0,0 +1,3 @@
+UPDATE settings SET setting_key = 'ATTENDANCE_SCHEDULE_GRACE_MINUTES' WHERE setting_key = 'ATTENDANCE_EARLY_MINUTES';
+DELETE FROM settings WHERE setting_key IN ('ATTENDANCE_ABSENT_MINUTES', 'ATTENDANCE_MINIMUM_MINUTES');
+DROP TABLE IF EXISTS class_attendance_policies;
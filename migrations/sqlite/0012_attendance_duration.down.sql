@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_attendance_login_log;
+ALTER TABLE attendance DROP COLUMN short_stay;
+ALTER TABLE attendance DROP COLUMN minutes_present;
+ALTER TABLE attendance DROP COLUMN login_log_id;
@@ -0,0 +1,10 @@
+-- Attendance time out and duration: the session a row was recorded from is
+-- remembered, so its logout fills in time_out and the minutes present
+
+-- login_log_id refers to login_logs.id; like login_logs.computer_id it has no
+-- FOREIGN KEY clause so the down migration can drop it
+ALTER TABLE attendance ADD COLUMN login_log_id INT NULL;
+ALTER TABLE attendance ADD COLUMN minutes_present INT NULL;
+ALTER TABLE attendance ADD COLUMN short_stay BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_attendance_login_log ON attendance (login_log_id);
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN failures_reset_at;
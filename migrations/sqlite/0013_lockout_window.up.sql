@@ -0,0 +1,3 @@
+-- Failed sign-ins are counted from the lockout window, the last successful
+-- sign-in, lock or unlock, whichever is latest
+ALTER TABLE users ADD COLUMN failures_reset_at DATETIME NULL;
@@ -0,0 +1,728 @@
+package main
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// ==============================================================================
+// OFFLINE MODE
+// ==============================================================================
+//
+// A lab PC on the shared MySQL server keeps working through a network outage.
+// While the server is unreachable, users who signed in on this PC before are
+// checked against their cached credentials, and sign-ins, sign-outs, attendance
+// taps and equipment feedback are queued in a local SQLite file. The queue is
+// replayed in order each time the connection monitor reaches the server, using
+// the local time at which each operation happened.
+//
+// Replayed attendance is merged with the row on the server, keyed by class,
+// student and date: the earliest login keeps its time in and PC, and only a
+// placeholder absent row takes the login's status (see MergeTap). Anything a teacher
+// changed on the server in the meantime is kept.
+//
+// Cached credentials are dropped when the password is changed or reset on this
+// PC and expire after OFFLINE_CREDENTIAL_DAYS; a password changed on another PC
+// keeps working here offline until then or until the user next signs in online.
+// Accounts with two-factor or a pending password change never sign in offline.
+
+// Kinds of queued operations
+const (
+	syncLogin      = "login"
+	syncLogout     = "logout"
+	syncAttendance = "attendance"
+	syncFeedback   = "feedback"
+)
+
+// Synced operations are kept this long for troubleshooting
+const syncedRetention = 30 * 24 * time.Hour
+
+const offlineSchema = `
+	CREATE TABLE IF NOT EXISTS offline_accounts (
+		user_id INTEGER PRIMARY KEY,
+		username TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		profile TEXT NOT NULL,
+		failed_attempts INTEGER NOT NULL DEFAULT 0,
+		last_failed_at DATETIME NULL,
+		locked_until DATETIME NULL,
+		cached_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS offline_state (
+		name TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS sync_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		queued_at DATETIME NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NULL,
+		server_id INTEGER NULL,
+		synced_at DATETIME NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_sync_queue_pending ON sync_queue (synced_at, id);
+`
+
+// offlineQueue is the local SQLite file holding cached credentials and the
+// operations recorded while the database server was unreachable
+type offlineQueue struct {
+	db sqlExecutor // Local queue file
+
+	mu       sync.Mutex
+	computer *labComputer // Last registry lookup of this PC
+}
+
+// queuedOp is a row of sync_queue
+type queuedOp struct {
+	ID       int64
+	Kind     string
+	Payload  string
+	Attempts int
+}
+
+// queuedLogin is the payload of a sign-in made offline
+type queuedLogin struct {
+	UserID         int       `json:"user_id"`
+	Username       string    `json:"username"`
+	Role           string    `json:"role"`
+	PCNumber       string    `json:"pc_number"`
+	ComputerID     int       `json:"computer_id,omitempty"`
+	UnregisteredPC bool      `json:"unregistered_pc,omitempty"`
+	LoginTime      time.Time `json:"login_time"`
+}
+
+// queuedLogout closes either a queued sign-in or one already on the server
+type queuedLogout struct {
+	UserID       int       `json:"user_id"`
+	LoginQueueID int64     `json:"login_queue_id,omitempty"`
+	LoginLogID   int64     `json:"login_log_id,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	LogoutTime   time.Time `json:"logout_time"`
+}
+
+// queuedTap is the payload of an attendance tap recorded offline
+type queuedTap struct {
+	ClassID        int       `json:"class_id"`
+	StudentUserID  int       `json:"student_user_id"`
+	PCNumber       string    `json:"pc_number"`
+	TappedAt       time.Time `json:"tapped_at"`
+	UnregisteredPC bool      `json:"unregistered_pc"`
+	ActorID        int       `json:"actor_id"`
+	ActorName      string    `json:"actor_name"`
+}
+
+// offlineAccount is a cached credential of a user who signed in on this PC
+type offlineAccount struct {
+	User           User
+	PasswordHash   string
+	FailedAttempts int
+	LastFailedAt   sql.NullTime // First failure of the current window
+	LockedUntil    sql.NullTime
+	CachedAt       time.Time
+}
+
+// SyncStatus reports whether this PC is working offline and what is waiting to sync
+type SyncStatus struct {
+	Enabled bool `json:"enabled"`
+	Online  bool `json:"online"`
+	Pending int  `json:"pending"`
+	Failed  int  `json:"failed"` // Pending operations the server rejected at least once
+}
+
+// defaultOfflinePath keeps the offline queue next to this install's computer identity
+func defaultOfflinePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "offline.db"
+	}
+	return filepath.Join(dir, "DigitalLogbook", "offline.db")
+}
+
+// isConnectionError reports whether err means the database server could not be reached
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn)
+}
+
+// openOfflineQueue opens the offline queue of a lab PC on the shared MySQL server.
+// It reports whether offline mode is available.
+func (a *App) openOfflineQueue() bool {
+	policy := GetOfflinePolicy()
+	if !policy.Enabled || GetDBConfig().Driver != DriverMySQL {
+		return false
+	}
+
+	db, err := openSQLite(DBConfig{Path: policy.Path})
+	if err == nil {
+		_, err = db.Exec(offlineSchema)
+		if err != nil {
+			db.Close()
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Failed to open offline queue %s: %v", policy.Path, err)
+		return false
+	}
+
+	q := &offlineQueue{db: newDBExecutor(a.lifetimeContext(), db, GetDBConfig())}
+
+	var saved string
+	if err := q.db.QueryRow(`SELECT value FROM offline_state WHERE name = 'computer'`).Scan(&saved); err == nil {
+		var pc labComputer
+		if json.Unmarshal([]byte(saved), &pc) == nil {
+			q.computer = &pc
+		}
+	}
+
+	a.offline = q
+	log.Printf("✓ Offline queue ready (%s)", policy.Path)
+	return true
+}
+
+// workingOffline reports whether operations are queued instead of sent to the server
+func (a *App) workingOffline() bool {
+	return a.offline != nil && !a.conn.usable()
+}
+
+// lastComputer returns the last registry lookup of this PC, or fallback if there is none
+func (q *offlineQueue) lastComputer(fallback labComputer) labComputer {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.computer == nil {
+		return fallback
+	}
+	return *q.computer
+}
+
+// rememberComputer keeps the registry lookup of this PC for use while offline
+func (q *offlineQueue) rememberComputer(pc labComputer) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.computer != nil && *q.computer == pc {
+		return
+	}
+	q.computer = &pc
+
+	saved, err := json.Marshal(pc)
+	if err == nil {
+		_, err = q.db.Exec(`INSERT INTO offline_state (name, value) VALUES ('computer', ?)
+			ON CONFLICT (name) DO UPDATE SET value = excluded.value`, string(saved))
+	}
+	if err != nil {
+		log.Printf("⚠ Failed to save this computer for offline use: %v", err)
+	}
+}
+
+// ==============================================================================
+// CACHED CREDENTIALS
+// ==============================================================================
+
+// rememberOfflineAccount caches the credentials of a user who just signed in online.
+// Accounts that still have to change their password are not cached.
+func (a *App) rememberOfflineAccount(user *User, stored, password string, needsRehash bool) {
+	if a.offline == nil {
+		return
+	}
+	if user.MustChangePassword {
+		a.offline.forget(user.ID)
+		return
+	}
+
+	// Never cache a legacy plaintext password
+	hash := stored
+	if needsRehash {
+		var err error
+		if hash, err = HashPassword(password); err != nil {
+			log.Printf("⚠ Failed to hash password for offline use: %v", err)
+			return
+		}
+	}
+
+	profile := *user
+	profile.Password = ""
+	profile.SessionToken = ""
+	profile.LoginLogID = 0
+	profile.PasswordChangeReason = ""
+	encoded, err := json.Marshal(profile)
+	if err == nil {
+		_, err = a.offline.db.Exec(`
+			INSERT INTO offline_accounts (user_id, username, password_hash, profile, cached_at)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (user_id) DO UPDATE SET
+				username = excluded.username,
+				password_hash = excluded.password_hash,
+				profile = excluded.profile,
+				failed_attempts = 0,
+				last_failed_at = NULL,
+				locked_until = NULL,
+				cached_at = excluded.cached_at
+		`, user.ID, user.Name, hash, string(encoded), time.Now())
+	}
+	if err != nil {
+		log.Printf("⚠ Failed to cache credentials of user %d for offline use: %v", user.ID, err)
+	}
+}
+
+// forget drops a user's cached credentials
+func (q *offlineQueue) forget(userID int) {
+	if q == nil {
+		return
+	}
+	if _, err := q.db.Exec(`DELETE FROM offline_accounts WHERE user_id = ?`, userID); err != nil {
+		log.Printf("⚠ Failed to drop cached credentials of user %d: %v", userID, err)
+	}
+}
+
+// account returns the cached credentials of a username
+func (q *offlineQueue) account(username string) (*offlineAccount, error) {
+	var account offlineAccount
+	var profile string
+	err := q.db.QueryRow(`
+		SELECT password_hash, profile, failed_attempts, last_failed_at, locked_until, cached_at
+		FROM offline_accounts WHERE username = ?
+	`, username).Scan(&account.PasswordHash, &profile, &account.FailedAttempts,
+		&account.LastFailedAt, &account.LockedUntil, &account.CachedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(profile), &account.User); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// recordFailure counts a failed offline attempt under the lockout policy and
+// returns the lock expiry when this attempt locked the account
+func (q *offlineQueue) recordFailure(account *offlineAccount, policy LockoutPolicy) (time.Time, bool) {
+	// Only the failures since the first one of the window count; without a log
+	// of each attempt the window runs from that first failure
+	now := time.Now()
+	failures := account.FailedAttempts
+	windowStart := account.LastFailedAt.Time
+	window := time.Duration(policy.WindowMinutes) * time.Minute
+	if !account.LastFailedAt.Valid || now.Sub(windowStart) > window {
+		failures, windowStart = 0, now
+	}
+	failures++
+
+	var lockedUntil sql.NullTime
+	if failures >= policy.MaxFailedAttempts {
+		lockedUntil = sql.NullTime{Time: now.Add(time.Duration(policy.LockoutMinutes) * time.Minute), Valid: true}
+		failures = 0
+	}
+
+	_, err := q.db.Exec(`UPDATE offline_accounts SET failed_attempts = ?, last_failed_at = ?, locked_until = ? WHERE user_id = ?`,
+		failures, windowStart, lockedUntil, account.User.ID)
+	if err != nil {
+		log.Printf("⚠ Failed to update offline failed attempts for user %d: %v", account.User.ID, err)
+	}
+	return lockedUntil.Time, lockedUntil.Valid
+}
+
+// resetFailures clears the offline failure count after a successful sign-in
+func (q *offlineQueue) resetFailures(userID int) {
+	_, err := q.db.Exec(`UPDATE offline_accounts SET failed_attempts = 0, last_failed_at = NULL, locked_until = NULL WHERE user_id = ?`, userID)
+	if err != nil {
+		log.Printf("⚠ Failed to reset offline failed attempts for user %d: %v", userID, err)
+	}
+}
+
+// offlineLogin signs a user in from the credential cache while the server is unreachable
+func (a *App) offlineLogin(username, password string) (*User, error) {
+	account, err := a.offline.account(username)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	maxAge := time.Duration(GetOfflinePolicy().CredentialDays) * 24 * time.Hour
+	if err == sql.ErrNoRows || time.Since(account.CachedAt) > maxAge {
+		return nil, fmt.Errorf("the server is unreachable and this account has not signed in on this PC recently, please try again later")
+	}
+
+	if account.LockedUntil.Valid && time.Now().Before(account.LockedUntil.Time) {
+		return nil, &AccountLockedError{Until: account.LockedUntil.Time}
+	}
+	if ok, _ := VerifyPassword(account.PasswordHash, password); !ok {
+		if until, locked := a.offline.recordFailure(account, GetLockoutPolicy()); locked {
+			log.Printf("🔒 Account %s locked offline until %s", username, until.Format("15:04"))
+			return nil, &AccountLockedError{Until: until}
+		}
+		return nil, fmt.Errorf("invalid credentials")
+	}
+	a.offline.resetFailures(account.User.ID)
+
+	user := account.User
+	if !user.IsActive {
+		log.Printf("⚠ Offline login rejected for deactivated account: %s", username)
+		return nil, fmt.Errorf("this account has been deactivated, please contact the administrator")
+	}
+	pc := a.currentComputer()
+	unregisteredPC, err := applyComputerPolicy(&user, pc)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.queueLogin(&user, pc, unregisteredPC); err != nil {
+		return nil, fmt.Errorf("failed to record sign-in: %w", err)
+	}
+
+	session, err := a.startSession(&user)
+	if err != nil {
+		return nil, err
+	}
+	user.SessionToken = session.Token
+
+	log.Printf("User login successful offline: %s (role: %s, pc: %s)", user.Name, user.Role, pc.pcNumber())
+	return &user, nil
+}
+
+// ==============================================================================
+// QUEUE
+// ==============================================================================
+
+// enqueue appends an operation to the queue and returns its ID
+func (q *offlineQueue) enqueue(kind string, payload interface{}) (int64, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	result, err := q.db.Exec(`INSERT INTO sync_queue (kind, payload, queued_at) VALUES (?, ?, ?)`, kind, string(encoded), time.Now())
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// pending returns the operations still waiting to sync, oldest first
+func (q *offlineQueue) pending() ([]queuedOp, error) {
+	rows, err := q.db.Query(`SELECT id, kind, payload, attempts FROM sync_queue WHERE synced_at IS NULL ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ops []queuedOp
+	for rows.Next() {
+		var op queuedOp
+		if err := rows.Scan(&op.ID, &op.Kind, &op.Payload, &op.Attempts); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
+
+// markSynced records that an operation reached the server. serverID is the
+// login_logs ID of a replayed sign-in; note explains an operation that was skipped.
+func (q *offlineQueue) markSynced(id, serverID int64, note string) {
+	var server sql.NullInt64
+	if serverID > 0 {
+		server = sql.NullInt64{Int64: serverID, Valid: true}
+	}
+	_, err := q.db.Exec(`UPDATE sync_queue SET synced_at = ?, server_id = ?, last_error = ?, attempts = attempts + 1 WHERE id = ?`,
+		time.Now(), server, nullString(note), id)
+	if err != nil {
+		log.Printf("⚠ Failed to mark queued operation %d as synced: %v", id, err)
+	}
+}
+
+// markFailed records why the server rejected an operation; it is retried on the next sync
+func (q *offlineQueue) markFailed(id int64, cause error) {
+	_, err := q.db.Exec(`UPDATE sync_queue SET attempts = attempts + 1, last_error = ? WHERE id = ?`, cause.Error(), id)
+	if err != nil {
+		log.Printf("⚠ Failed to record sync failure of queued operation %d: %v", id, err)
+	}
+}
+
+// loginLogID returns the server login_logs ID of a queued sign-in once it has synced
+func (q *offlineQueue) loginLogID(queueID int64) (int64, error) {
+	var serverID sql.NullInt64
+	err := q.db.QueryRow(`SELECT server_id FROM sync_queue WHERE id = ? AND kind = ?`, queueID, syncLogin).Scan(&serverID)
+	if err != nil {
+		return 0, err
+	}
+	if !serverID.Valid {
+		return 0, fmt.Errorf("sign-in %d has not synced yet", queueID)
+	}
+	return serverID.Int64, nil
+}
+
+// queueLogin queues the login log of a sign-in made offline. The session refers to
+// it by the negated queue ID until the sign-in reaches the server.
+func (a *App) queueLogin(user *User, pc labComputer, unregisteredPC bool) error {
+	id, err := a.offline.enqueue(syncLogin, queuedLogin{
+		UserID:         user.ID,
+		Username:       user.Name,
+		Role:           user.Role,
+		PCNumber:       pc.pcNumber(),
+		ComputerID:     pc.ID,
+		UnregisteredPC: unregisteredPC,
+		LoginTime:      time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	user.LoginLogID = int(-id)
+	log.Printf("📥 Login queued offline - queue ID: %d, User: %s (ID: %d)", id, user.Name, user.ID)
+	return nil
+}
+
+// queueLogout queues the sign-out of a session and ends it
+func (a *App) queueLogout(session *Session, status string) error {
+	logout := queuedLogout{UserID: session.UserID, Status: status, LogoutTime: time.Now()}
+	if session.LoginLogID < 0 {
+		logout.LoginQueueID = int64(-session.LoginLogID)
+	} else {
+		logout.LoginLogID = int64(session.LoginLogID)
+	}
+	if _, err := a.offline.enqueue(syncLogout, logout); err != nil {
+		log.Printf("Failed to queue logout for user %d: %v", session.UserID, err)
+		return err
+	}
+
+	log.Printf("User logout queued offline: user_id=%d", session.UserID)
+	a.endSession(session.UserID)
+	// A session started offline may end after the server is back; send it right away
+	a.conn.requestCheck()
+	return nil
+}
+
+// queueAttendanceTap queues a student's attendance tap on a class for today
+func (a *App) queueAttendanceTap(classID, studentID int, pcNumber string, unregisteredPC bool) error {
+	tap := queuedTap{ClassID: classID, StudentUserID: studentID, PCNumber: pcNumber, TappedAt: time.Now(), UnregisteredPC: unregisteredPC}
+	if session := a.currentSession(); session != nil {
+		tap.ActorID = session.UserID
+		tap.ActorName = session.Username
+	}
+	if _, err := a.offline.enqueue(syncAttendance, tap); err != nil {
+		log.Printf("⚠ Failed to queue student login: %v", err)
+		return err
+	}
+	log.Printf("📥 Student login queued offline: student=%d, class=%d, pc=%s", studentID, classID, pcNumber)
+	return nil
+}
+
+// queueFeedback queues an equipment report, keeping the time it was submitted
+func (a *App) queueFeedback(fb Feedback) error {
+	fb.DateSubmitted = time.Now().Format("2006-01-02 15:04:05")
+	if _, err := a.offline.enqueue(syncFeedback, fb); err != nil {
+		log.Printf("Failed to queue equipment feedback: %v", err)
+		return fmt.Errorf("failed to save feedback: %w", err)
+	}
+	log.Printf("📥 Equipment feedback queued offline for user %d", fb.StudentUserID)
+	return nil
+}
+
+// GetSyncStatus reports whether this PC is working offline and how much is waiting to sync
+func (a *App) GetSyncStatus() (SyncStatus, error) {
+	if _, err := a.authorizeAuthenticated(); err != nil {
+		return SyncStatus{}, err
+	}
+
+	status := SyncStatus{Online: a.requireDB() == nil}
+	if a.offline == nil {
+		return status, nil
+	}
+	status.Enabled = true
+	err := a.offline.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN attempts > 0 THEN 1 ELSE 0 END), 0)
+		FROM sync_queue WHERE synced_at IS NULL
+	`).Scan(&status.Pending, &status.Failed)
+	return status, err
+}
+
+// ==============================================================================
+// SYNC
+// ==============================================================================
+
+// syncOfflineQueue replays the queue after the connection monitor reached the server
+func (a *App) syncOfflineQueue() {
+	synced, err := a.replayOfflineQueue()
+	if synced > 0 {
+		log.Printf("✓ Synced %d queued operation(s) to the server", synced)
+	}
+	if a.lostConnection(err) {
+		return
+	}
+
+	if _, err := a.offline.db.Exec(`DELETE FROM sync_queue WHERE synced_at < ?`, time.Now().Add(-syncedRetention)); err != nil {
+		log.Printf("⚠ Failed to prune synced operations: %v", err)
+	}
+}
+
+// replayOfflineQueue sends pending operations to the server in the order they
+// were made. An operation the server rejects is kept and retried on the next sync;
+// losing the connection again stops the replay and returns the error.
+func (a *App) replayOfflineQueue() (int, error) {
+	ops, err := a.offline.pending()
+	if err != nil {
+		log.Printf("⚠ Failed to read offline queue: %v", err)
+		return 0, nil
+	}
+
+	synced := 0
+	for _, op := range ops {
+		serverID, note, err := a.replayOp(op)
+		if err != nil {
+			if isConnectionError(err) {
+				return synced, err
+			}
+			log.Printf("⚠ Failed to sync queued %s %d (attempt %d): %v", op.Kind, op.ID, op.Attempts+1, err)
+			a.offline.markFailed(op.ID, err)
+			continue
+		}
+		if note != "" {
+			log.Printf("⚠ Queued %s %d skipped: %s", op.Kind, op.ID, note)
+		}
+		a.offline.markSynced(op.ID, serverID, note)
+		synced++
+	}
+	return synced, nil
+}
+
+// replayOp sends one queued operation to the server
+func (a *App) replayOp(op queuedOp) (serverID int64, note string, err error) {
+	switch op.Kind {
+	case syncLogin:
+		var login queuedLogin
+		if err := json.Unmarshal([]byte(op.Payload), &login); err != nil {
+			return 0, "", err
+		}
+		return a.replayLogin(login)
+	case syncLogout:
+		var logout queuedLogout
+		if err := json.Unmarshal([]byte(op.Payload), &logout); err != nil {
+			return 0, "", err
+		}
+		note, err := a.replayLogout(logout)
+		return 0, note, err
+	case syncAttendance:
+		var tap queuedTap
+		if err := json.Unmarshal([]byte(op.Payload), &tap); err != nil {
+			return 0, "", err
+		}
+		note, err := a.replayTap(tap)
+		return 0, note, err
+	case syncFeedback:
+		var fb Feedback
+		if err := json.Unmarshal([]byte(op.Payload), &fb); err != nil {
+			return 0, "", err
+		}
+		return 0, "", a.store.Feedback.Create(fb)
+	default:
+		return 0, "", fmt.Errorf("unknown queued operation %q", op.Kind)
+	}
+}
+
+// replayLogin inserts and chains the login log of an offline sign-in, then records
+// a student's attendance in the classes that were in session at the time
+func (a *App) replayLogin(login queuedLogin) (int64, string, error) {
+	var computerID sql.NullInt64
+	if login.ComputerID > 0 {
+		computerID = sql.NullInt64{Int64: int64(login.ComputerID), Valid: true}
+	}
+
+	var logID int64
+	err := a.withTx(func(tx sqlExecutor) error {
+		id, err := a.txStore(tx).Logs.OpenAt(login.UserID, login.PCNumber, computerID, login.UnregisteredPC, login.LoginTime)
+		if err != nil {
+			return err
+		}
+		logID = id
+		return a.chainLoginLog(tx, logID)
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	if login.Role == "student" || login.Role == "working_student" {
+		// The login log is in; attendance failures are logged rather than retried
+		actor := &Session{UserID: login.UserID, Username: login.Username}
+		date := login.LoginTime.Format("2006-01-02")
+		var classes []classSession
+		classIDs, err := a.store.Attendance.InitializedClasses(login.UserID, date)
+		if err == nil {
+			classes, err = a.classesInSession(classIDs, login.LoginTime)
+		}
+		if err != nil {
+			log.Printf("Failed to query enrolled classes for queued login %d: %v", logID, err)
+		}
+		for _, class := range classes {
+			status, remark := class.status(login.LoginTime, login.UnregisteredPC)
+			if err := a.mergeTap(actor, "auto_record", class.ClassID, login.UserID, int(logID), login.LoginTime, login.PCNumber, status, remark); err != nil {
+				log.Printf("Failed to auto-record queued attendance for student %d, class %d: %v", login.UserID, class.ClassID, err)
+			}
+		}
+	}
+	return logID, "", nil
+}
+
+// replayLogout closes the login log of a session that ended offline
+func (a *App) replayLogout(logout queuedLogout) (string, error) {
+	logID := logout.LoginLogID
+	if logout.LoginQueueID > 0 {
+		var err error
+		if logID, err = a.offline.loginLogID(logout.LoginQueueID); err != nil {
+			return "", err
+		}
+	}
+
+	err := a.closeLoginLog(logID, logout.UserID, logout.Status, logout.LogoutTime)
+	if err == sql.ErrNoRows {
+		return fmt.Sprintf("login log %d was already closed", logID), nil
+	}
+	return "", err
+}
+
+// replayTap merges an attendance tap made offline into the server's row, with
+// the status the class's rules gave it when it was made
+func (a *App) replayTap(tap queuedTap) (string, error) {
+	enrolled, err := a.store.Classes.IsEnrolled(tap.ClassID, tap.StudentUserID)
+	if err != nil {
+		return "", err
+	}
+	if !enrolled {
+		return fmt.Sprintf("student %d is not enrolled in class %d", tap.StudentUserID, tap.ClassID), nil
+	}
+
+	var actor *Session
+	if tap.ActorID > 0 {
+		actor = &Session{UserID: tap.ActorID, Username: tap.ActorName}
+	}
+
+	classes, err := a.classesInSession([]int{tap.ClassID}, tap.TappedAt)
+	if err != nil {
+		return "", err
+	}
+	if len(classes) == 0 {
+		return fmt.Sprintf("class %d was not in session at %s", tap.ClassID, tap.TappedAt.Format("2006-01-02 15:04")), nil
+	}
+	status, remark := classes[0].status(tap.TappedAt, tap.UnregisteredPC)
+	return "", a.mergeTap(actor, "record_login", tap.ClassID, tap.StudentUserID, 0, tap.TappedAt, tap.PCNumber, status, remark)
+}
+
+// mergeTap merges a login at loginTime into a student's attendance, audited as actor.
+// loginLogID is the session the login opened, or 0 for a tap.
+func (a *App) mergeTap(actor *Session, action string, classID, studentID, loginLogID int, loginTime time.Time, pcNumber, status string, remark sql.NullString) error {
+	date := loginTime.Format("2006-01-02")
+	return a.withTx(func(tx sqlExecutor) error {
+		key := attendanceKey(classID, studentID, date)
+		return a.auditAttendanceChangeAs(tx, actor, action, key, attendanceAuditQuery, []interface{}{classID, studentID, date}, func() error {
+			return a.txStore(tx).Attendance.MergeTap(classID, studentID, loginLogID, date, loginTime.Format("15:04:05"), pcNumber, status, remark)
+		})
+	})
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// withOfflineQueue gives a an offline queue in a temporary SQLite file, as a lab
+// PC on the MySQL server has
+func withOfflineQueue(t *testing.T, a *App) {
+	t.Helper()
+	db, err := openSQLite(DBConfig{Path: filepath.Join(t.TempDir(), "offline.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(offlineSchema); err != nil {
+		t.Fatal(err)
+	}
+	a.offline = &offlineQueue{db: newDBExecutor(a.ctx, db, GetDBConfig())}
+}
+
+// setOnline marks the server as reachable or not
+func setOnline(a *App, online bool) {
+	if online {
+		a.conn.set(DBConnected, nil, 0, true)
+	} else {
+		a.conn.set(DBDisconnected, errors.New("connection refused"), 0, true)
+	}
+}
+
+// queueTap queues an attendance tap made at tappedAt
+func queueTap(t *testing.T, a *App, classID, studentID int, pcNumber string, tappedAt time.Time) {
+	t.Helper()
+	tap := queuedTap{ClassID: classID, StudentUserID: studentID, PCNumber: pcNumber, TappedAt: tappedAt}
+	if _, err := a.offline.enqueue(syncAttendance, tap); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// replay sends the queue to the server and fails on operations it kept
+func replay(t *testing.T, a *App) {
+	t.Helper()
+	setOnline(a, true)
+	if _, err := a.replayOfflineQueue(); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	ops, err := a.offline.pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) > 0 {
+		t.Fatalf("%d operation(s) left unsynced", len(ops))
+	}
+}
+
+// attendanceRow reads the time in, PC, status and remarks of a row
+func attendanceRow(t *testing.T, a *App, classID, studentID int, date string) (timeIn, pcNumber, status, remarks string) {
+	t.Helper()
+	var in, pc, rem sql.NullString
+	err := a.pool.QueryRow(`SELECT time_in, pc_number, status, remarks FROM attendance WHERE class_id = ? AND student_user_id = ? AND date = ?`,
+		classID, studentID, date).Scan(&in, &pc, &status, &rem)
+	if err != nil {
+		t.Fatalf("attendance %d/%d/%s: %v", classID, studentID, date, err)
+	}
+	return in.String, pc.String, status, rem.String
+}
+
+func TestOfflineLoginReplaysLoginLog(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+
+	// Signing in online caches the credentials on this PC
+	user, err := a.Login("teacher1", "Teach#123")
+	if err != nil {
+		t.Fatalf("online login: %v", err)
+	}
+	if err := a.Logout(user.ID); err != nil {
+		t.Fatalf("online logout: %v", err)
+	}
+
+	setOnline(a, false)
+	if _, err := a.Login("admin", "Admin#123"); err == nil {
+		t.Error("an account never signed in on this PC signed in offline")
+	}
+	if _, err := a.Login("teacher1", "wrong"); err == nil || err.Error() != "invalid credentials" {
+		t.Errorf("wrong password offline: err = %v, want invalid credentials", err)
+	}
+	user, err = a.Login("teacher1", "Teach#123")
+	if err != nil {
+		t.Fatalf("offline login: %v", err)
+	}
+	if user.LoginLogID >= 0 {
+		t.Errorf("offline login log ID = %d, want a queued one", user.LoginLogID)
+	}
+	if err := a.Logout(user.ID); err != nil {
+		t.Fatalf("offline logout: %v", err)
+	}
+
+	replay(t, a)
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM login_logs WHERE user_id = 2 AND login_status = 'success' AND logout_time IS NOT NULL`); n != 2 {
+		t.Errorf("%d closed login logs, want the online and the replayed one", n)
+	}
+	if issues := integrityIssues(t, a); len(issues) != 0 {
+		t.Errorf("replayed login logs are not chained: %v", issues)
+	}
+}
+
+func TestOfflineLockout(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	if _, err := a.Login("teacher1", "Teach#123"); err != nil {
+		t.Fatal(err)
+	}
+	setOnline(a, false)
+	policy := GetLockoutPolicy()
+
+	// Failures from before the window started over
+	for i := 1; i < policy.MaxFailedAttempts; i++ {
+		a.Login("teacher1", "wrong")
+	}
+	old := time.Now().Add(-time.Duration(policy.WindowMinutes+1) * time.Minute)
+	if _, err := a.offline.db.Exec(`UPDATE offline_accounts SET last_failed_at = ? WHERE user_id = 2`, old); err != nil {
+		t.Fatal(err)
+	}
+	var locked *AccountLockedError
+	if _, err := a.Login("teacher1", "wrong"); errors.As(err, &locked) {
+		t.Fatal("failures outside the window locked the account")
+	}
+
+	for i := 1; i < policy.MaxFailedAttempts-1; i++ {
+		a.Login("teacher1", "wrong")
+	}
+	if _, err := a.Login("teacher1", "wrong"); !errors.As(err, &locked) {
+		t.Fatalf("err = %v, want AccountLockedError", err)
+	}
+	if _, err := a.Login("teacher1", "Teach#123"); !errors.As(err, &locked) {
+		t.Errorf("correct password while locked offline: err = %v, want AccountLockedError", err)
+	}
+}
+
+func TestReplayedTapKeepsEarliestLogin(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+	if err := a.mergeTap(nil, "auto_record", 10, 3, 0, day.Add(8*time.Hour+5*time.Minute), "PC-05", "present", sql.NullString{}); err != nil {
+		t.Fatal(err)
+	}
+	queueTap(t, a, 10, 3, "PC-02", day.Add(8*time.Hour+2*time.Minute))
+	queueTap(t, a, 10, 3, "PC-10", day.Add(8*time.Hour+10*time.Minute))
+	replay(t, a)
+
+	timeIn, pc, status, _ := attendanceRow(t, a, 10, 3, "2026-03-02")
+	if timeIn != "08:02:00" || pc != "PC-02" || status != "present" {
+		t.Errorf("row = %s %s %s, want the earliest tap 08:02:00 PC-02 present", timeIn, pc, status)
+	}
+}
+
+func TestReplayedTapKeepsTeacherEntries(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status, remarks) VALUES (10, 3, '2026-03-02', 'excused', 'Medical')`)
+	mustExec(t, a, `INSERT INTO attendance (class_id, student_user_id, date, status, remarks) VALUES (10, 4, '2026-03-02', 'absent', 'Not yet logged in')`)
+
+	tapped := time.Date(2026, 3, 2, 8, 3, 0, 0, time.Local)
+	queueTap(t, a, 10, 3, "PC-03", tapped)
+	queueTap(t, a, 10, 4, "PC-04", tapped)
+	// Not enrolled: skipped with a note instead of retried
+	queueTap(t, a, 10, 2, "PC-09", tapped)
+	replay(t, a)
+
+	if _, _, status, remarks := attendanceRow(t, a, 10, 3, "2026-03-02"); status != "excused" || remarks != "Medical" {
+		t.Errorf("excused row = %s %q, want it kept", status, remarks)
+	}
+	if timeIn, _, status, remarks := attendanceRow(t, a, 10, 4, "2026-03-02"); timeIn != "08:03:00" || status != "present" || remarks != "" {
+		t.Errorf("placeholder row = %s %s %q, want present from 08:03:00", timeIn, status, remarks)
+	}
+}
+
+func TestReplayedTapFollowsClassPolicy(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "student1", "Study#123", "student")
+	seedUser(t, a, 4, "student2", "Study#123", "student")
+	seedUser(t, a, 5, "student3", "Study#123", "student")
+	seedClass(t, a, 10, 2, 3, 4, 5)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	mustExec(t, a, `INSERT INTO class_attendance_policies (class_id, late_minutes, absent_minutes) VALUES (10, 10, 30)`)
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+	tap := queuedTap{ClassID: 10, StudentUserID: 4, PCNumber: "PC-04", TappedAt: day.Add(8*time.Hour + 45*time.Minute), UnregisteredPC: true}
+	if _, err := a.offline.enqueue(syncAttendance, tap); err != nil {
+		t.Fatal(err)
+	}
+	queueTap(t, a, 10, 3, "PC-03", day.Add(8*time.Hour+20*time.Minute))
+	// After the class: nothing to record
+	queueTap(t, a, 10, 5, "PC-05", day.Add(10*time.Hour))
+	replay(t, a)
+
+	if _, _, status, _ := attendanceRow(t, a, 10, 3, "2026-03-02"); status != "late" {
+		t.Errorf("tap 20 minutes after the start = %s, want late", status)
+	}
+	if _, _, status, remarks := attendanceRow(t, a, 10, 4, "2026-03-02"); status != "absent" || remarks != "Arrived 45 minutes after the start; Signed in from an unregistered PC" {
+		t.Errorf("tap 45 minutes after the start = %s %q, want absent with the arrival and PC noted", status, remarks)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM attendance WHERE student_user_id = 5`); n != 0 {
+		t.Error("a tap after the class was recorded")
+	}
+}
+
+func TestOfflineLoginRejectsDeactivatedAccount(t *testing.T) {
+	a := newTestApp(t)
+	withOfflineQueue(t, a)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedUser(t, a, 3, "teacher2", "Teach#123", "teacher")
+	for _, username := range []string{"teacher1", "teacher2"} {
+		user, err := a.Login(username, "Teach#123")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Logout(user.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Teacher 1 was deactivated on another PC; the cache here still holds them
+	if _, err := a.offline.db.Exec(`UPDATE offline_accounts SET profile = REPLACE(profile, '"is_active":true', '"is_active":false') WHERE user_id = 2`); err != nil {
+		t.Fatal(err)
+	}
+	// Teacher 2 tried to sign in here after being deactivated
+	mustExec(t, a, `UPDATE users SET is_active = FALSE WHERE id = 3`)
+	if _, err := a.Login("teacher2", "Teach#123"); err == nil {
+		t.Fatal("a deactivated account signed in")
+	}
+
+	setOnline(a, false)
+	for _, username := range []string{"teacher1", "teacher2"} {
+		if _, err := a.Login(username, "Teach#123"); err == nil {
+			t.Errorf("deactivated %s signed in offline", username)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ==============================================================================
+// PASSWORD HASHING
+// ==============================================================================
+//
+// Stored passwords carry a versioned prefix so the scheme can be identified:
+//   - "$argon2id$v=19$m=...,t=...,p=...$<salt>$<hash>" (current scheme, PHC format)
+//   - "$2a$" / "$2b$" / "$2y$" bcrypt hashes (accepted for verification)
+//   - anything else is treated as a legacy plaintext row and upgraded on login
+
+const argon2idPrefix = "$argon2id$"
+
+// argon2Params holds the argon2id cost parameters used for new hashes
+type argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// currentArgon2Params are the parameters applied to every newly hashed password.
+// Raising these causes existing hashes to be upgraded the next time the user logs in.
+var currentArgon2Params = argon2Params{
+	Memory:      64 * 1024,
+	Iterations:  1,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// HashPassword hashes a plaintext password using argon2id
+func HashPassword(password string) (string, error) {
+	p := currentArgon2Params
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPassword checks a plaintext password against a stored value.
+// needsRehash is true when the stored value should be replaced with a fresh
+// HashPassword result (legacy plaintext, bcrypt, or outdated argon2 parameters).
+func VerifyPassword(stored, password string) (ok bool, needsRehash bool) {
+	switch {
+	case strings.HasPrefix(stored, argon2idPrefix):
+		params, salt, key, err := decodeArgon2Hash(stored)
+		if err != nil {
+			return false, false
+		}
+		computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(computed, key) != 1 {
+			return false, false
+		}
+		return true, params != currentArgon2Params
+
+	case isBcryptHash(stored):
+		if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+			return false, false
+		}
+		return true, true
+
+	default:
+		// Legacy plaintext row
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(password)) != 1 {
+			return false, false
+		}
+		return true, true
+	}
+}
+
+// isHashedPassword reports whether a stored value uses a recognised hash scheme
+func isHashedPassword(stored string) bool {
+	return strings.HasPrefix(stored, argon2idPrefix) || isBcryptHash(stored)
+}
+
+// isBcryptHash reports whether a stored value is a bcrypt hash
+func isBcryptHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// decodeArgon2Hash parses a PHC-formatted argon2id hash
+func decodeArgon2Hash(encoded string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id version: %w", err)
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// isIssuedPassword reports whether password is the user's username or student
+// number, which accounts used to be given as their first password
+func isIssuedPassword(user *User, password string) bool {
+	identifiers := []string{user.Name}
+	if user.StudentID != nil {
+		identifiers = append(identifiers, *user.StudentID)
+	}
+	for _, id := range identifiers {
+		if id != "" && strings.EqualFold(strings.TrimSpace(password), strings.TrimSpace(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidatePassword checks a new password against the policy.
+// identifiers are values the password must not equal, such as the username and student code.
+func ValidatePassword(policy PasswordPolicy, password string, identifiers ...string) error {
+	var problems []string
+
+	if len([]rune(password)) < policy.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", policy.MinLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUppercase && !hasUpper {
+		problems = append(problems, "an uppercase letter")
+	}
+	if policy.RequireLowercase && !hasLower {
+		problems = append(problems, "a lowercase letter")
+	}
+	if policy.RequireDigit && !hasDigit {
+		problems = append(problems, "a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		problems = append(problems, "a symbol")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("password must contain %s", strings.Join(problems, ", "))
+	}
+
+	if policy.DisallowStudentCode {
+		for _, id := range identifiers {
+			if id != "" && strings.EqualFold(strings.TrimSpace(password), strings.TrimSpace(id)) {
+				return fmt.Errorf("password must not be your username or student code")
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	stored, err := HashPassword("Correct#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stored, "$argon2id$v=19$m=65536,t=1,p=2$") {
+		t.Fatalf("hash = %q, want argon2id with the current parameters", stored)
+	}
+	if ok, needsRehash := VerifyPassword(stored, "Correct#1"); !ok || needsRehash {
+		t.Errorf("correct password = %v, rehash %v; want accepted as is", ok, needsRehash)
+	}
+	for _, wrong := range []string{"correct#1", "Correct#1 ", "", stored} {
+		if ok, _ := VerifyPassword(stored, wrong); ok {
+			t.Errorf("%q was accepted", wrong)
+		}
+	}
+
+	// Every hash gets its own salt
+	again, err := HashPassword("Correct#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == stored {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestVerifyPasswordUpgradesOldFormats(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("Correct#1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A hash made before the argon2 cost was raised
+	current := currentArgon2Params
+	currentArgon2Params.Memory = 8 * 1024
+	outdated, err := HashPassword("Correct#1")
+	currentArgon2Params = current
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{"bcrypt", string(bcryptHash)},
+		{"bcrypt $2y$", "$2y$" + strings.TrimPrefix(string(bcryptHash), "$2a$")},
+		{"plaintext", "Correct#1"},
+		{"outdated argon2id", outdated},
+	}
+	for _, tt := range tests {
+		if ok, needsRehash := VerifyPassword(tt.stored, "Correct#1"); !ok || !needsRehash {
+			t.Errorf("%s: correct password = %v, rehash %v; want accepted and rehashed", tt.name, ok, needsRehash)
+		}
+		if ok, needsRehash := VerifyPassword(tt.stored, "Wrong#1"); ok || needsRehash {
+			t.Errorf("%s: wrong password = %v, rehash %v; want rejected", tt.name, ok, needsRehash)
+		}
+	}
+}
+
+func TestLoginUpgradesBcryptHash(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Correct#1", "teacher")
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("Correct#1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustExec(t, a, `UPDATE users SET password = ? WHERE id = 2`, string(bcryptHash))
+
+	if _, err := a.Login("teacher1", "Correct#1"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	var stored string
+	a.pool.QueryRow(`SELECT password FROM users WHERE id = 2`).Scan(&stored)
+	if ok, needsRehash := VerifyPassword(stored, "Correct#1"); !strings.HasPrefix(stored, argon2idPrefix) || !ok || needsRehash {
+		t.Errorf("stored password after login = %q, want a current argon2id hash", stored)
+	}
+}
+
+func TestVerifyPasswordRejectsMalformedHashes(t *testing.T) {
+	stored, err := HashPassword("Correct#1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(stored, "$")
+	// with returns the valid hash with part i replaced
+	with := func(i int, part string) string {
+		changed := append([]string(nil), parts...)
+		changed[i] = part
+		return strings.Join(changed, "$")
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{"missing key", strings.Join(parts[:5], "$")},
+		{"extra part", stored + "$AAAA"},
+		{"unsupported version", with(2, "v=16")},
+		{"version not a number", with(2, "v=x")},
+		{"missing parameters", with(3, "m=65536,t=1")},
+		{"parameters not numbers", with(3, "m=a,t=b,p=c")},
+		{"salt not base64", with(4, "!!!!")},
+		{"key not base64", with(5, "!!!!")},
+		{"padded key", with(5, parts[5]+"==")},
+	}
+	for _, tt := range tests {
+		if _, _, _, err := decodeArgon2Hash(tt.stored); err == nil {
+			t.Errorf("%s: %q was decoded", tt.name, tt.stored)
+		}
+		// A malformed hash is never compared as plaintext
+		for _, password := range []string{"Correct#1", tt.stored} {
+			if ok, needsRehash := VerifyPassword(tt.stored, password); ok || needsRehash {
+				t.Errorf("%s: password %q = %v, rehash %v; want rejected", tt.name, password, ok, needsRehash)
+			}
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ==============================================================================
+// ADMIN PASSWORD RESET
+// ==============================================================================
+
+// temporaryPasswordAlphabet leaves out characters that are easy to misread on a printed slip (0/O, 1/l/I)
+const temporaryPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+
+const temporaryPasswordLength = 10
+
+// credentialSlip is one user's temporary credentials for a printed slip
+type credentialSlip struct {
+	UserID       int
+	Username     string
+	FullName     string
+	TempPassword string
+	hash         string
+}
+
+// generateTemporaryPassword returns a random one-time password with upper, lower and digit characters
+func generateTemporaryPassword() (string, error) {
+	max := big.NewInt(int64(len(temporaryPasswordAlphabet)))
+	for {
+		b := make([]byte, temporaryPasswordLength)
+		for i := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			b[i] = temporaryPasswordAlphabet[n.Int64()]
+		}
+		password := string(b)
+		if strings.ContainsAny(password, "ABCDEFGHJKLMNPQRSTUVWXYZ") &&
+			strings.ContainsAny(password, "abcdefghijkmnpqrstuvwxyz") &&
+			strings.ContainsAny(password, "23456789") {
+			return password, nil
+		}
+	}
+}
+
+// AdminResetPassword replaces a user's password with a random temporary one and
+// forces a change on their next login. The temporary password is returned once
+// so the admin can hand it over; it is not stored in plain text.
+func (a *App) AdminResetPassword(userID int) (string, error) {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return "", err
+	}
+	if session.UserID == userID {
+		return "", fmt.Errorf("use Change Password to change your own password")
+	}
+
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+
+	slip := credentialSlip{UserID: userID}
+	if slip.Username, err = a.store.Users.Username(userID); err != nil {
+		return "", fmt.Errorf("user not found")
+	}
+
+	if err := prepareCredentialSlip(&slip); err != nil {
+		return "", err
+	}
+	if err := a.applyPasswordResets(session.UserID, []credentialSlip{slip}); err != nil {
+		return "", err
+	}
+
+	log.Printf("🔑 Password reset for %s by admin %d", slip.Username, session.UserID)
+	return slip.TempPassword, nil
+}
+
+// ResetClassPasswords resets every student enrolled in a class and returns
+// the path of a PDF with one credential slip per student, saved where the admin chooses
+func (a *App) ResetClassPasswords(classID int) (string, error) {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+
+	subjectCode, section, err := a.store.Classes.SubjectAndSection(classID)
+	if err != nil {
+		return "", fmt.Errorf("class not found")
+	}
+
+	title := subjectCode
+	if section != "" {
+		title += " - Section " + section
+	}
+
+	students, err := a.store.Users.ClassStudentAccounts(classID)
+	if err != nil {
+		return "", err
+	}
+	return a.resetStudentPasswords(session.UserID, title, students)
+}
+
+// ResetSectionPasswords resets every student enrolled in an active class of the
+// given section (and year level, if provided) and returns the path of the credential slip PDF,
+// saved where the admin chooses
+func (a *App) ResetSectionPasswords(yearLevel, section string) (string, error) {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return "", err
+	}
+	if section == "" {
+		return "", fmt.Errorf("section is required")
+	}
+
+	title := "Section " + section
+	if yearLevel != "" {
+		title = yearLevel + " - " + title
+	}
+
+	students, err := a.store.Users.SectionStudentAccounts(yearLevel, section)
+	if err != nil {
+		return "", err
+	}
+	return a.resetStudentPasswords(session.UserID, title, students)
+}
+
+// resetStudentPasswords resets the students, except the admin doing it, and writes
+// their credential slips. The PDF is written before any password changes so a
+// failed export never leaves students with passwords nobody has seen.
+// The slips hold plain text passwords, so they are never saved to EXPORT_DIR: the admin
+// picks the file each time and nothing is reset when the dialog is cancelled.
+func (a *App) resetStudentPasswords(adminID int, title string, students []credentialSlip) (string, error) {
+	var slips []credentialSlip
+	for _, slip := range students {
+		if slip.UserID != adminID {
+			slips = append(slips, slip)
+		}
+	}
+	if len(slips) == 0 {
+		return "", fmt.Errorf("no enrolled students found")
+	}
+
+	filename, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Save credential slips",
+		DefaultFilename: fmt.Sprintf("credential_slips_%s.pdf", time.Now().Format("20060102_150405")),
+		Filters:         []runtime.FileFilter{{DisplayName: "PDF files (*.pdf)", Pattern: "*.pdf"}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to choose where to save the credential slips: %w", err)
+	}
+	if filename == "" {
+		return "", fmt.Errorf("credential slips were not saved, no passwords were reset")
+	}
+
+	for i := range slips {
+		if err := prepareCredentialSlip(&slips[i]); err != nil {
+			return "", err
+		}
+	}
+
+	if err := writeCredentialSlipsPDF(filename, title, slips); err != nil {
+		return "", fmt.Errorf("failed to write credential slips: %w", err)
+	}
+
+	if err := a.applyPasswordResets(adminID, slips); err != nil {
+		os.Remove(filename)
+		return "", err
+	}
+
+	log.Printf("🔑 Reset %d passwords for %s by admin %d; delete %s once the slips are printed", len(slips), title, adminID, filename)
+	return filename, nil
+}
+
+// prepareCredentialSlip fills in a new temporary password and its hash
+func prepareCredentialSlip(slip *credentialSlip) error {
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		return fmt.Errorf("failed to generate temporary password: %w", err)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	slip.TempPassword = password
+	slip.hash = hash
+	return nil
+}
+
+// applyPasswordResets stores the prepared passwords in a single transaction,
+// forcing a change on next login and clearing any lockout
+func (a *App) applyPasswordResets(adminID int, slips []credentialSlip) error {
+	err := a.withTx(func(tx sqlExecutor) error {
+		users := a.txStore(tx).Users
+		for _, slip := range slips {
+			if err := users.ResetPassword(slip.UserID, slip.hash, adminID); err != nil {
+				log.Printf("❌ Failed to reset password for user %d: %v", slip.UserID, err)
+				return fmt.Errorf("failed to reset password for %s: %w", slip.Username, err)
+			}
+			if err := a.recordAudit(tx, "reset_password", "user", strconv.Itoa(slip.UserID), nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, slip := range slips {
+		a.offline.forget(slip.UserID)
+	}
+	return nil
+}
+
+// writeCredentialSlipsPDF lays out credential slips two per row, ready to print and cut,
+// in a file only the current user can read
+func writeCredentialSlipsPDF(filename, title string, slips []credentialSlip) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 10)
+
+	const (
+		slipWidth  = 90.0
+		slipHeight = 45.0
+		marginX    = 12.0
+		marginY    = 20.0
+		gap        = 6.0
+		perPage    = 10
+	)
+
+	issued := time.Now().Format("2006-01-02 15:04")
+	for i, slip := range slips {
+		if i%perPage == 0 {
+			pdf.AddPage()
+			pdf.SetFont("Arial", "B", 12)
+			pdf.SetXY(marginX, 8)
+			pdf.Cell(0, 8, "Temporary Credentials - "+title)
+		}
+
+		pos := i % perPage
+		x := marginX + float64(pos%2)*(slipWidth+gap)
+		y := marginY + float64(pos/2)*(slipHeight+gap)
+
+		pdf.SetDashPattern([]float64{1, 1}, 0)
+		pdf.Rect(x, y, slipWidth, slipHeight, "D")
+		pdf.SetDashPattern([]float64{}, 0)
+
+		pdf.SetXY(x+4, y+4)
+		pdf.SetFont("Arial", "B", 11)
+		pdf.Cell(slipWidth-8, 6, slip.FullName)
+
+		pdf.SetXY(x+4, y+13)
+		pdf.SetFont("Arial", "", 10)
+		pdf.Cell(25, 6, "Username:")
+		pdf.SetFont("Courier", "B", 11)
+		pdf.Cell(slipWidth-33, 6, slip.Username)
+
+		pdf.SetXY(x+4, y+20)
+		pdf.SetFont("Arial", "", 10)
+		pdf.Cell(25, 6, "Password:")
+		pdf.SetFont("Courier", "B", 11)
+		pdf.Cell(slipWidth-33, 6, slip.TempPassword)
+
+		pdf.SetXY(x+4, y+30)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.MultiCell(slipWidth-8, 4, "You will be asked to choose a new password when you first sign in. Issued "+issued+".", "", "L", false)
+	}
+
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := pdf.Output(file); err != nil {
+		file.Close()
+		os.Remove(filename)
+		return err
+	}
+	return file.Close()
+}
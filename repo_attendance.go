@@ -0,0 +1,493 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+)
+
+// sqlAttendanceRepository implements AttendanceRepository for both backends
+type sqlAttendanceRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// placeholderRemarks are the remarks of an absent row no one has entered anything in,
+// before and after the scheduler finalizes it
+const placeholderRemarks = `('Not yet logged in', '` + finalizedAbsentRemark + `')`
+
+// TodayForTeacher returns today's attendance across a teacher's classes, latest time in first
+func (r *sqlAttendanceRepository) TodayForTeacher(teacherUserID int) ([]Attendance, error) {
+	query := `
+		SELECT
+			a.class_id, a.student_user_id, a.date, a.time_in, a.time_out, a.minutes_present, a.short_stay, a.status, a.remarks,
+			vcl.student_number, vcl.first_name, vcl.middle_name, vcl.last_name,
+			vc.subject_code, vc.subject_name
+		FROM attendance a
+		JOIN v_classlist_complete vcl ON a.class_id = vcl.class_id AND a.student_user_id = vcl.student_user_id
+		JOIN v_classes_complete vc ON a.class_id = vc.class_id
+		WHERE vc.teacher_user_id = ? AND a.date = ` + r.d.today() + `
+		ORDER BY a.time_in DESC
+		LIMIT 100
+	`
+	return r.list(query, teacherUserID)
+}
+
+// ForExport returns every attendance record of a class, newest date first
+func (r *sqlAttendanceRepository) ForExport(classID int) ([]Attendance, error) {
+	query := `
+		SELECT
+			a.class_id, a.student_user_id, a.date, a.time_in, a.time_out, a.minutes_present, a.short_stay, a.status, a.remarks,
+			vcl.student_number, vcl.first_name, vcl.middle_name, vcl.last_name,
+			vc.subject_code, vc.subject_name
+		FROM attendance a
+		JOIN v_classlist_complete vcl ON a.class_id = vcl.class_id AND a.student_user_id = vcl.student_user_id
+		JOIN v_classes_complete vc ON a.class_id = vc.class_id
+		WHERE a.class_id = ?
+		ORDER BY a.date DESC, vcl.last_name, vcl.first_name
+	`
+	return r.list(query, classID)
+}
+
+// list scans attendance rows joined with the student and subject names
+func (r *sqlAttendanceRepository) list(query string, args ...interface{}) ([]Attendance, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attendances []Attendance
+	for rows.Next() {
+		var att Attendance
+		var timeIn, timeOut, remarks, middleName sql.NullString
+		var minutesPresent sql.NullInt64
+		err := rows.Scan(
+			&att.ClassID, &att.StudentUserID, &att.Date, &timeIn, &timeOut, &minutesPresent, &att.ShortStay, &att.Status, &remarks,
+			&att.StudentCode, &att.FirstName, &middleName, &att.LastName,
+			&att.SubjectCode, &att.SubjectName,
+		)
+		if err != nil {
+			continue
+		}
+
+		att.TimeIn = nullStringPtr(timeIn)
+		att.TimeOut = nullStringPtr(timeOut)
+		att.MinutesPresent = nullIntPtr(minutesPresent)
+		att.Remarks = nullStringPtr(remarks)
+		att.MiddleName = nullStringPtr(middleName)
+
+		attendances = append(attendances, att)
+	}
+
+	return attendances, nil
+}
+
+// ForClass returns a row for every enrolled student of a class on a date,
+// with an empty status where no attendance was recorded yet
+func (r *sqlAttendanceRepository) ForClass(classID int, date string) ([]Attendance, error) {
+	query := `
+		SELECT
+			cl.class_id,
+			cl.student_user_id,
+			COALESCE(a.date, ?) as date,
+			vcl.student_number,
+			vcl.first_name,
+			vcl.middle_name,
+			vcl.last_name,
+			s.subject_code,
+			s.subject_name,
+			a.time_in,
+			a.time_out,
+			a.pc_number,
+			a.minutes_present,
+			COALESCE(a.short_stay, FALSE),
+			a.status,
+			a.remarks
+		FROM classlist cl
+		JOIN v_classlist_complete vcl ON cl.class_id = vcl.class_id AND cl.student_user_id = vcl.student_user_id
+		JOIN classes c ON cl.class_id = c.class_id
+		JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN attendance a ON cl.class_id = a.class_id AND cl.student_user_id = a.student_user_id AND a.date = ?
+		WHERE cl.class_id = ? AND cl.status = 'active'
+		ORDER BY vcl.last_name, vcl.first_name
+	`
+
+	rows, err := r.q.Query(query, date, date, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attendances []Attendance
+	for rows.Next() {
+		var att Attendance
+		var middleName, timeIn, timeOut, pcNumber, remarks, status sql.NullString
+		var minutesPresent sql.NullInt64
+
+		err := rows.Scan(
+			&att.ClassID, &att.StudentUserID, &att.Date,
+			&att.StudentCode, &att.FirstName, &middleName, &att.LastName,
+			&att.SubjectCode, &att.SubjectName,
+			&timeIn, &timeOut, &pcNumber, &minutesPresent, &att.ShortStay, &status, &remarks,
+		)
+		if err != nil {
+			log.Printf("⚠ Failed to scan attendance row: %v", err)
+			continue
+		}
+
+		att.MiddleName = nullStringPtr(middleName)
+		att.TimeIn = nullStringPtr(timeIn)
+		att.TimeOut = nullStringPtr(timeOut)
+		att.PCNumber = nullStringPtr(pcNumber)
+		att.MinutesPresent = nullIntPtr(minutesPresent)
+		att.Remarks = nullStringPtr(remarks)
+		att.Status = status.String // Empty string when no status is set yet
+
+		attendances = append(attendances, att)
+	}
+
+	return attendances, nil
+}
+
+// ForStudent returns a student's newest attendance records
+func (r *sqlAttendanceRepository) ForStudent(studentUserID, limit int) ([]Attendance, error) {
+	query := `
+		SELECT
+			a.class_id,
+			a.student_user_id,
+			a.date,
+			a.time_in,
+			a.time_out,
+			a.status
+		FROM attendance a
+		WHERE a.student_user_id = ?
+		ORDER BY a.date DESC
+		LIMIT ?`
+	rows, err := r.q.Query(query, studentUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attendances []Attendance
+	for rows.Next() {
+		var att Attendance
+		var timeIn, timeOut sql.NullString
+		err := rows.Scan(&att.ClassID, &att.StudentUserID, &att.Date, &timeIn, &timeOut, &att.Status)
+		if err != nil {
+			continue
+		}
+
+		att.TimeIn = nullStringPtr(timeIn)
+		att.TimeOut = nullStringPtr(timeOut)
+
+		attendances = append(attendances, att)
+	}
+
+	return attendances, nil
+}
+
+// InitializedClasses returns the IDs of the student's active classes with an attendance row on date
+func (r *sqlAttendanceRepository) InitializedClasses(studentUserID int, date string) ([]int, error) {
+	query := `
+		SELECT cl.class_id
+		FROM classlist cl
+		JOIN classes c ON cl.class_id = c.class_id
+		LEFT JOIN attendance a ON cl.class_id = a.class_id AND cl.student_user_id = a.student_user_id AND a.date = ?
+		WHERE cl.student_user_id = ?
+			AND cl.status = 'active'
+			AND c.is_active = TRUE
+			AND a.class_id IS NOT NULL
+	`
+	rows, err := r.q.Query(query, date, studentUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classIDs []int
+	for rows.Next() {
+		var classID int
+		if err := rows.Scan(&classID); err != nil {
+			continue
+		}
+		classIDs = append(classIDs, classID)
+	}
+	return classIDs, rows.Err()
+}
+
+// Record inserts or overwrites the status and remarks of a student's attendance,
+// keeping the existing times where none are given
+func (r *sqlAttendanceRepository) Record(classID, studentUserID int, date, timeIn, timeOut, status, remarks string) error {
+	d := r.d
+	_, err := r.q.Exec(`
+		INSERT INTO attendance (class_id, student_user_id, date, time_in, time_out, status, remarks)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		`+d.upsert()+`
+			time_in = COALESCE(`+d.excluded("time_in")+`, time_in),
+			time_out = COALESCE(`+d.excluded("time_out")+`, time_out),
+			status = `+d.excluded("status")+`,
+			remarks = `+d.excluded("remarks")+`,
+			updated_at = `+d.now()+`
+	`, classID, studentUserID, date, nullString(timeIn), nullString(timeOut), status, nullString(remarks))
+	return err
+}
+
+// UpdateTimes sets the time in and out of an attendance row, keeping whichever is empty
+func (r *sqlAttendanceRepository) UpdateTimes(classID, studentUserID int, date, timeIn, timeOut string) error {
+	_, err := r.q.Exec(`
+		UPDATE attendance
+		SET time_in = COALESCE(?, time_in),
+		    time_out = COALESCE(?, time_out),
+		    updated_at = `+r.d.now()+`
+		WHERE class_id = ? AND student_user_id = ? AND date = ?
+	`, nullString(timeIn), nullString(timeOut), classID, studentUserID, date)
+	return err
+}
+
+// Update overwrites every editable field of an attendance row
+func (r *sqlAttendanceRepository) Update(classID, studentUserID int, date, timeIn, timeOut, pcNumber, status, remarks string) error {
+	_, err := r.q.Exec(`
+		UPDATE attendance
+		SET time_in = ?,
+		    time_out = ?,
+		    pc_number = ?,
+		    status = ?,
+		    remarks = ?,
+		    updated_at = `+r.d.now()+`
+		WHERE class_id = ? AND student_user_id = ? AND date = ?
+	`, nullString(timeIn), nullString(timeOut), nullString(pcNumber), status, nullString(remarks), classID, studentUserID, date)
+	return err
+}
+
+// Initialize creates an absent row for every enrolled student of a class on date,
+// leaving existing rows as they are apart from a missing "Not yet logged in" remark
+func (r *sqlAttendanceRepository) Initialize(classID int, date string) error {
+	_, err := r.q.Exec(`
+		INSERT INTO attendance (class_id, student_user_id, date, status, remarks, created_at)
+		SELECT
+			cl.class_id,
+			cl.student_user_id,
+			?,
+			'absent',
+			'Not yet logged in',
+			`+r.d.now()+`
+		FROM classlist cl
+		WHERE cl.class_id = ? AND cl.status = 'active'
+		`+r.d.upsert()+`
+			remarks = CASE
+				WHEN time_in IS NULL AND (remarks IS NULL OR remarks = '') THEN 'Not yet logged in'
+				ELSE remarks
+			END,
+			class_id = class_id
+	`, date, classID)
+	return err
+}
+
+// Merge writes the attendance generated from a student's login logs. Times and
+// PC are only filled in, and a login replaces a placeholder remark.
+func (r *sqlAttendanceRepository) Merge(classID, studentUserID, loginLogID int, date, timeIn, timeOut, pcNumber, status, remarks string) error {
+	d := r.d
+	_, err := r.q.Exec(`
+		INSERT INTO attendance (class_id, student_user_id, date, time_in, time_out, pc_number, login_log_id, status, remarks, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, `+d.now()+`)
+		`+d.upsert()+`
+			time_in = COALESCE(`+d.excluded("time_in")+`, time_in),
+			time_out = COALESCE(`+d.excluded("time_out")+`, time_out),
+			pc_number = COALESCE(`+d.excluded("pc_number")+`, pc_number),
+			login_log_id = COALESCE(`+d.excluded("login_log_id")+`, login_log_id),
+			status = `+d.excluded("status")+`,
+			remarks = CASE
+				WHEN `+d.excluded("time_in")+` IS NOT NULL AND (remarks IN `+placeholderRemarks+` OR remarks IS NULL OR remarks = '') THEN `+d.excluded("remarks")+`
+				WHEN `+d.excluded("time_in")+` IS NOT NULL THEN remarks
+				WHEN `+d.excluded("remarks")+` IS NOT NULL AND `+d.excluded("remarks")+` != '' THEN `+d.excluded("remarks")+`
+				WHEN remarks IS NULL OR remarks = '' THEN `+d.excluded("remarks")+`
+				ELSE remarks
+			END,
+			updated_at = `+d.now()+`
+	`, classID, studentUserID, date,
+		nullString(timeIn), nullString(timeOut), nullString(pcNumber), nullInt(loginLogID), status, nullString(remarks))
+	return err
+}
+
+// MergeTap merges a login made at timeIn, live or replayed from the offline queue,
+// into whatever the server already has. The earliest login keeps its time in, PC
+// and session; only a placeholder row (absent, no time in, no teacher remark, even
+// once finalized) takes the login's status, and other edits made on the server are kept. A login
+// after the row's time out reopens the stay: the new session takes over, so its
+// logout records the time out again, and the minutes are added up per session.
+func (r *sqlAttendanceRepository) MergeTap(classID, studentUserID, loginLogID int, date, timeIn, pcNumber, status string, remark sql.NullString) error {
+	d := r.d
+	placeholder := `(time_in IS NULL AND status = 'absent' AND (remarks IS NULL OR remarks IN ` + placeholderRemarks + `))`
+	earlier := `(` + placeholder + ` OR (status <> 'absent' AND (time_in IS NULL OR ` + d.excluded("time_in") + ` < time_in)))`
+	reopen := `(time_in IS NOT NULL AND time_out IS NOT NULL AND ` + d.excluded("time_in") + ` >= time_out)`
+
+	// MySQL evaluates each assignment against the row as updated so far and SQLite
+	// against the old row; in this order both give the same result. A placeholder
+	// given a status other than absent still counts as earlier through its status,
+	// so remarks, which the placeholder test reads, are changed after it, and
+	// time_out, which the reopen test reads, is changed last.
+	_, err := r.q.Exec(`
+		INSERT INTO attendance (class_id, student_user_id, date, time_in, pc_number, login_log_id, status, remarks)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`+d.upsert()+`
+			status = CASE WHEN `+placeholder+` THEN `+d.excluded("status")+` ELSE status END,
+			pc_number = CASE WHEN `+earlier+` OR `+reopen+` THEN `+d.excluded("pc_number")+` ELSE pc_number END,
+			login_log_id = CASE WHEN `+earlier+` OR `+reopen+` THEN `+d.excluded("login_log_id")+` ELSE login_log_id END,
+			time_in = CASE WHEN `+earlier+` THEN `+d.excluded("time_in")+` ELSE time_in END,
+			remarks = CASE
+				WHEN remarks IS NULL OR remarks IN `+placeholderRemarks+` THEN `+d.excluded("remarks")+`
+				ELSE remarks
+			END,
+			minutes_present = CASE WHEN `+reopen+` THEN NULL ELSE minutes_present END,
+			short_stay = CASE WHEN `+reopen+` THEN FALSE ELSE short_stay END,
+			time_out = CASE WHEN `+reopen+` THEN NULL ELSE time_out END,
+			updated_at = `+d.now()+`
+	`, classID, studentUserID, date, timeIn, pcNumber, nullInt(loginLogID), status, remark)
+	return err
+}
+
+// SessionStays returns the attendance rows a login session recorded a time in
+// for and that have no time out yet, with the session's logout time
+func (r *sqlAttendanceRepository) SessionStays(loginLogID int) ([]attendanceStay, error) {
+	rows, err := r.q.Query(`
+		SELECT a.class_id, a.student_user_id, a.date, a.time_in, ll.logout_time
+		FROM attendance a
+		JOIN login_logs ll ON a.login_log_id = ll.id
+		WHERE a.login_log_id = ? AND a.time_in IS NOT NULL AND a.time_out IS NULL AND ll.logout_time IS NOT NULL
+	`, loginLogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stays []attendanceStay
+	for rows.Next() {
+		var s attendanceStay
+		var date, logout dbTime
+		if err := rows.Scan(&s.ClassID, &s.StudentUserID, &date, &s.TimeIn, &logout); err != nil {
+			return nil, err
+		}
+		s.Date = date.Format("2006-01-02")
+		s.LogoutTime = logout.Time
+		stays = append(stays, s)
+	}
+	return stays, rows.Err()
+}
+
+// Stays returns the times in and out of a class's attendance rows on date
+func (r *sqlAttendanceRepository) Stays(classID int, date string) ([]attendanceStay, error) {
+	rows, err := r.q.Query(`
+		SELECT class_id, student_user_id, time_in, time_out
+		FROM attendance
+		WHERE class_id = ? AND date = ?
+	`, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stays []attendanceStay
+	for rows.Next() {
+		s := attendanceStay{Date: date}
+		var timeIn, timeOut sql.NullString
+		if err := rows.Scan(&s.ClassID, &s.StudentUserID, &timeIn, &timeOut); err != nil {
+			return nil, err
+		}
+		s.TimeIn = timeIn.String
+		s.TimeOut = timeOut.String
+		stays = append(stays, s)
+	}
+	return stays, rows.Err()
+}
+
+// SetStay records the minutes a student was present and whether that was too
+// short. A timeOut is only filled in where the row has none.
+func (r *sqlAttendanceRepository) SetStay(classID, studentUserID int, date, timeOut string, minutes sql.NullInt64, short bool) error {
+	_, err := r.q.Exec(`
+		UPDATE attendance
+		SET time_out = COALESCE(time_out, ?),
+			minutes_present = ?,
+			short_stay = ?,
+			updated_at = `+r.d.now()+`
+		WHERE class_id = ? AND student_user_id = ? AND date = ?
+	`, nullString(timeOut), minutes, short, classID, studentUserID, date)
+	return err
+}
+
+// Uninitialized counts the active students of a class without an attendance row on date
+func (r *sqlAttendanceRepository) Uninitialized(classID int, date string) (int, error) {
+	var n int
+	err := r.q.QueryRow(`
+		SELECT COUNT(*)
+		FROM classlist cl
+		LEFT JOIN attendance a ON cl.class_id = a.class_id AND cl.student_user_id = a.student_user_id AND a.date = ?
+		WHERE cl.class_id = ? AND cl.status = 'active' AND a.class_id IS NULL
+	`, date, classID).Scan(&n)
+	return n, err
+}
+
+// Placeholders returns the students of a class whose row on date is still an
+// absent placeholder, finalized or not
+func (r *sqlAttendanceRepository) Placeholders(classID int, date string) ([]int, error) {
+	rows, err := r.q.Query(`
+		SELECT student_user_id
+		FROM attendance
+		WHERE class_id = ? AND date = ? AND time_in IS NULL AND status = 'absent' AND remarks IN `+placeholderRemarks+`
+	`, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		students = append(students, id)
+	}
+	return students, rows.Err()
+}
+
+// UnfinalizedDays returns the classes and dates from from to to that still have
+// "Not yet logged in" placeholders
+func (r *sqlAttendanceRepository) UnfinalizedDays(from, to string) ([]ClassDay, error) {
+	rows, err := r.q.Query(`
+		SELECT DISTINCT class_id, date
+		FROM attendance
+		WHERE date >= ? AND date <= ? AND time_in IS NULL AND status = 'absent' AND remarks = 'Not yet logged in'
+		ORDER BY date, class_id
+	`, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []ClassDay
+	for rows.Next() {
+		var day ClassDay
+		var date dbTime
+		if err := rows.Scan(&day.ClassID, &date); err != nil {
+			return nil, err
+		}
+		day.Date = date.Format("2006-01-02")
+		days = append(days, day)
+	}
+	return days, rows.Err()
+}
+
+// FinalizeAbsent replaces the remark of the placeholder rows of a class on date,
+// which stay absent, and returns how many there were
+func (r *sqlAttendanceRepository) FinalizeAbsent(classID int, date, remark string) (int64, error) {
+	result, err := r.q.Exec(`
+		UPDATE attendance
+		SET remarks = ?, updated_at = `+r.d.now()+`
+		WHERE class_id = ? AND date = ? AND time_in IS NULL AND status = 'absent' AND remarks = 'Not yet logged in'
+	`, remark, classID, date)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// sqlCalendarRepository implements CalendarRepository for both backends
+type sqlCalendarRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// classScope is what the calendar needs to know about a class to decide its class days
+type classScope struct {
+	SchoolYear string
+	Semester   string
+	Room       string
+	Department string // The teacher's department
+}
+
+// Terms returns every academic term, latest first
+func (r *sqlCalendarRepository) Terms() ([]AcademicTerm, error) {
+	rows, err := r.q.Query(`
+		SELECT term_id, school_year, semester, start_date, end_date
+		FROM academic_terms
+		ORDER BY start_date DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var terms []AcademicTerm
+	for rows.Next() {
+		var term AcademicTerm
+		var start, end dbTime
+		if err := rows.Scan(&term.TermID, &term.SchoolYear, &term.Semester, &start, &end); err != nil {
+			return nil, err
+		}
+		term.StartDate = start.Format("2006-01-02")
+		term.EndDate = end.Format("2006-01-02")
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// CreateTerm adds an academic term and returns its ID
+func (r *sqlCalendarRepository) CreateTerm(term AcademicTerm, createdBy int) (int64, error) {
+	result, err := r.q.Exec(`
+		INSERT INTO academic_terms (school_year, semester, start_date, end_date, created_by)
+		VALUES (?, ?, ?, ?, ?)
+	`, term.SchoolYear, term.Semester, term.StartDate, term.EndDate, nullInt(createdBy))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateTerm changes an academic term, reporting whether it exists
+func (r *sqlCalendarRepository) UpdateTerm(term AcademicTerm) (bool, error) {
+	result, err := r.q.Exec(`
+		UPDATE academic_terms
+		SET school_year = ?, semester = ?, start_date = ?, end_date = ?, updated_at = `+r.d.now()+`
+		WHERE term_id = ?
+	`, term.SchoolYear, term.Semester, term.StartDate, term.EndDate, term.TermID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteTerm removes an academic term
+func (r *sqlCalendarRepository) DeleteTerm(termID int) error {
+	_, err := r.q.Exec(`DELETE FROM academic_terms WHERE term_id = ?`, termID)
+	return err
+}
+
+// TermExists reports whether another term than termID covers the semester of the school year
+func (r *sqlCalendarRepository) TermExists(schoolYear, semester string, termID int) (bool, error) {
+	var existing int
+	err := r.q.QueryRow(`SELECT term_id FROM academic_terms WHERE school_year = ? AND semester = ? AND term_id <> ?`,
+		schoolYear, semester, termID).Scan(&existing)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Events returns the calendar events overlapping the dates from to to, inclusive
+func (r *sqlCalendarRepository) Events(from, to string) ([]CalendarEvent, error) {
+	rows, err := r.q.Query(`
+		SELECT event_id, event_type, scope, scope_value, start_date, end_date, makeup_weekday, description
+		FROM calendar_events
+		WHERE start_date <= ? AND end_date >= ?
+		ORDER BY start_date, event_id
+	`, to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []CalendarEvent
+	for rows.Next() {
+		var ev CalendarEvent
+		var scopeValue sql.NullString
+		var start, end dbTime
+		var weekday sql.NullInt64
+		err := rows.Scan(&ev.EventID, &ev.EventType, &ev.Scope, &scopeValue, &start, &end, &weekday, &ev.Description)
+		if err != nil {
+			return nil, err
+		}
+		ev.ScopeValue = scopeValue.String
+		ev.StartDate = start.Format("2006-01-02")
+		ev.EndDate = end.Format("2006-01-02")
+		if weekday.Valid {
+			day := int(weekday.Int64)
+			ev.MakeupWeekday = &day
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// CreateEvent adds a calendar event and returns its ID
+func (r *sqlCalendarRepository) CreateEvent(ev CalendarEvent, createdBy int) (int64, error) {
+	result, err := r.q.Exec(`
+		INSERT INTO calendar_events (event_type, scope, scope_value, start_date, end_date, makeup_weekday, description, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, ev.EventType, ev.Scope, nullString(ev.ScopeValue), ev.StartDate, ev.EndDate, makeupWeekday(ev), ev.Description, nullInt(createdBy))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateEvent changes a calendar event, reporting whether it exists
+func (r *sqlCalendarRepository) UpdateEvent(ev CalendarEvent) (bool, error) {
+	result, err := r.q.Exec(`
+		UPDATE calendar_events
+		SET event_type = ?, scope = ?, scope_value = ?, start_date = ?, end_date = ?, makeup_weekday = ?, description = ?
+		WHERE event_id = ?
+	`, ev.EventType, ev.Scope, nullString(ev.ScopeValue), ev.StartDate, ev.EndDate, makeupWeekday(ev), ev.Description, ev.EventID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteEvent removes a calendar event
+func (r *sqlCalendarRepository) DeleteEvent(eventID int) error {
+	_, err := r.q.Exec(`DELETE FROM calendar_events WHERE event_id = ?`, eventID)
+	return err
+}
+
+// ClassScopes returns the term, room and department of each class
+func (r *sqlCalendarRepository) ClassScopes(classIDs ...int) (map[int]classScope, error) {
+	scopes := make(map[int]classScope)
+	if len(classIDs) == 0 {
+		return scopes, nil
+	}
+
+	args := make([]interface{}, len(classIDs))
+	for i, id := range classIDs {
+		args[i] = id
+	}
+	rows, err := r.q.Query(`
+		SELECT c.class_id, c.school_year, c.semester, c.room, t.department_code
+		FROM classes c
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		WHERE c.class_id IN (?`+strings.Repeat(", ?", len(classIDs)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var classID int
+		var schoolYear, semester, room, department sql.NullString
+		if err := rows.Scan(&classID, &schoolYear, &semester, &room, &department); err != nil {
+			return nil, err
+		}
+		scopes[classID] = classScope{
+			SchoolYear: schoolYear.String,
+			Semester:   semester.String,
+			Room:       room.String,
+			Department: department.String,
+		}
+	}
+	return scopes, rows.Err()
+}
+
+// makeupWeekday is the makeup_weekday column value of an event
+func makeupWeekday(ev CalendarEvent) sql.NullInt64 {
+	if ev.EventType != EventMakeup || ev.MakeupWeekday == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*ev.MakeupWeekday), Valid: true}
+}
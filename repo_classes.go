@@ -0,0 +1,610 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// sqlClassRepository implements ClassRepository for both backends
+type sqlClassRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// classFields are the columns of a class set when creating or editing it
+type classFields struct {
+	SubjectCode   string
+	TeacherUserID int
+	OfferingCode  string
+	Schedule      string // Summary written from the meetings
+	Room          string
+	YearLevel     string
+	Section       string
+	Semester      string
+	SchoolYear    string
+	CreatedBy     int // Working student who created the class, 0 if none
+	IsActive      bool
+}
+
+// classColumns are the CourseClass columns read by scanClasses. Queries using it
+// join subjects s, teachers t and an enrollment_count subquery.
+const classColumns = `
+	c.class_id, c.subject_code, s.subject_name, c.offering_code,
+	c.teacher_user_id, CONCAT(t.last_name, ', ', t.first_name) as teacher_name,
+	c.schedule, c.room, c.year_level, c.section, c.semester, c.school_year,
+	COALESCE(enrollment_count.count, 0) as enrolled_count,
+	c.is_active, c.created_by_user_id, c.created_at`
+
+// Enrollment counts of every enrollment, including dropped students
+const allEnrollmentCounts = `
+	LEFT JOIN (
+		SELECT class_id, COUNT(*) as count
+		FROM classlist
+		GROUP BY class_id
+	) enrollment_count ON c.class_id = enrollment_count.class_id`
+
+// Enrollment counts of active students only
+const activeEnrollmentCounts = `
+	LEFT JOIN (
+		SELECT class_id, COUNT(*) as count
+		FROM classlist
+		WHERE status = 'active'
+		GROUP BY class_id
+	) enrollment_count ON c.class_id = enrollment_count.class_id`
+
+// Subjects returns every subject with its teacher's name
+func (r *sqlClassRepository) Subjects() ([]Subject, error) {
+	query := `
+		SELECT
+			s.subject_code, s.subject_name, s.teacher_user_id, s.created_at,
+			CONCAT(t.last_name, ', ', t.first_name,
+			       CASE WHEN t.middle_name IS NOT NULL THEN CONCAT(' ', t.middle_name) ELSE '' END) AS teacher_name,
+			s.description
+		FROM subjects s
+		JOIN teachers t ON s.teacher_user_id = t.user_id
+		ORDER BY s.subject_code
+	`
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subjects []Subject
+	for rows.Next() {
+		var subj Subject
+		var teacherName, description sql.NullString
+		var createdAt time.Time
+		err := rows.Scan(&subj.Code, &subj.Name, &subj.TeacherUserID, &createdAt, &teacherName, &description)
+		if err != nil {
+			continue
+		}
+		subj.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		subj.TeacherName = nullStringPtr(teacherName)
+		subj.Description = nullStringPtr(description)
+		subjects = append(subjects, subj)
+	}
+
+	return subjects, nil
+}
+
+// SaveSubject creates a subject, or renames and redescribes it if the code exists
+func (r *sqlClassRepository) SaveSubject(code, name, description string) error {
+	_, err := r.q.Exec(`
+		INSERT INTO subjects (subject_code, subject_name, description)
+		VALUES (?, ?, ?)
+		`+r.d.upsert()+`
+			subject_name = `+r.d.excluded("subject_name")+`,
+			description = `+r.d.excluded("description")+`
+	`, code, name, nullString(description))
+	return err
+}
+
+// ByTeacher returns a teacher's active classes
+func (r *sqlClassRepository) ByTeacher(teacherUserID int) ([]CourseClass, error) {
+	return r.list(`
+		SELECT `+classColumns+`
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		`+allEnrollmentCounts+`
+		WHERE c.teacher_user_id = ? AND c.is_active = TRUE
+		ORDER BY s.subject_code, c.year_level, c.section
+	`, teacherUserID)
+}
+
+// CreatedForTeacher returns a teacher's active classes that working students created, newest first
+func (r *sqlClassRepository) CreatedForTeacher(teacherUserID int) ([]CourseClass, error) {
+	return r.list(`
+		SELECT `+classColumns+`
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		`+activeEnrollmentCounts+`
+		WHERE c.teacher_user_id = ? AND c.is_active = TRUE AND c.created_by_user_id IS NOT NULL
+		ORDER BY c.created_at DESC, s.subject_code, c.year_level, c.section
+	`, teacherUserID)
+}
+
+// Active returns every active class
+func (r *sqlClassRepository) Active() ([]CourseClass, error) {
+	return r.list(`
+		SELECT ` + classColumns + `
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		` + allEnrollmentCounts + `
+		WHERE c.is_active = TRUE
+		ORDER BY s.subject_code, c.year_level, c.section
+	`)
+}
+
+// ByCreator returns the active classes a working student created
+func (r *sqlClassRepository) ByCreator(createdBy int) ([]CourseClass, error) {
+	return r.list(`
+		SELECT `+classColumns+`
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		`+allEnrollmentCounts+`
+		WHERE c.is_active = TRUE AND c.created_by_user_id = ?
+		ORDER BY s.subject_code, c.year_level, c.section
+	`, createdBy)
+}
+
+// BySubjectCode returns the active classes of a subject, newest first
+func (r *sqlClassRepository) BySubjectCode(subjectCode string) ([]CourseClass, error) {
+	return r.list(`
+		SELECT `+classColumns+`
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		`+activeEnrollmentCounts+`
+		WHERE c.subject_code = ? AND c.is_active = TRUE
+		ORDER BY c.created_at DESC, c.year_level, c.section
+	`, subjectCode)
+}
+
+// ForStudent returns the active classes a student is enrolled in
+func (r *sqlClassRepository) ForStudent(studentUserID int) ([]CourseClass, error) {
+	return r.list(`
+		SELECT `+classColumns+`
+		FROM classlist cl
+		JOIN classes c ON cl.class_id = c.class_id
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		`+activeEnrollmentCounts+`
+		WHERE cl.student_user_id = ? AND cl.status = 'active' AND c.is_active = TRUE
+		ORDER BY s.subject_code, c.year_level, c.section
+	`, studentUserID)
+}
+
+// list runs a query selecting classColumns and scans its rows
+func (r *sqlClassRepository) list(query string, args ...interface{}) ([]CourseClass, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var classes []CourseClass
+	for rows.Next() {
+		var class CourseClass
+		var offeringCode, schedule, room, yearLevel, section, semester, schoolYear sql.NullString
+		var createdBy sql.NullInt64
+		var createdAt time.Time
+		err := rows.Scan(
+			&class.ClassID, &class.SubjectCode, &class.SubjectName, &offeringCode,
+			&class.TeacherUserID, &class.TeacherName,
+			&schedule, &room, &yearLevel, &section, &semester, &schoolYear,
+			&class.EnrolledCount, &class.IsActive, &createdBy, &createdAt,
+		)
+		if err != nil {
+			continue
+		}
+		class.OfferingCode = nullStringPtr(offeringCode)
+		class.Schedule = nullStringPtr(schedule)
+		class.Room = nullStringPtr(room)
+		class.YearLevel = nullStringPtr(yearLevel)
+		class.Section = nullStringPtr(section)
+		class.Semester = nullStringPtr(semester)
+		class.SchoolYear = nullStringPtr(schoolYear)
+		if createdBy.Valid {
+			createdByInt := int(createdBy.Int64)
+			class.CreatedByUserID = &createdByInt
+		}
+		class.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		classes = append(classes, class)
+	}
+
+	ids := make([]int, len(classes))
+	for i, class := range classes {
+		ids[i] = class.ClassID
+	}
+	meetings, err := r.Meetings(ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i := range classes {
+		classes[i].Meetings = meetings[classes[i].ClassID]
+	}
+
+	return classes, nil
+}
+
+// WithoutMeetings returns the active classes with a typed schedule but no meetings
+func (r *sqlClassRepository) WithoutMeetings() ([]CourseClass, error) {
+	return r.list(`
+		SELECT ` + classColumns + `
+		FROM classes c
+		LEFT JOIN subjects s ON c.subject_code = s.subject_code
+		LEFT JOIN teachers t ON c.teacher_user_id = t.user_id
+		` + activeEnrollmentCounts + `
+		WHERE c.is_active = TRUE AND c.schedule IS NOT NULL AND c.schedule <> ''
+			AND NOT EXISTS (SELECT 1 FROM class_meetings m WHERE m.class_id = c.class_id)
+		ORDER BY s.subject_code, c.year_level, c.section
+	`)
+}
+
+// Meetings returns the weekly meetings of the given classes by class ID, Monday first
+func (r *sqlClassRepository) Meetings(classIDs ...int) (map[int][]ClassMeeting, error) {
+	meetings := make(map[int][]ClassMeeting)
+	if len(classIDs) == 0 {
+		return meetings, nil
+	}
+
+	args := make([]interface{}, len(classIDs))
+	for i, id := range classIDs {
+		args[i] = id
+	}
+	rows, err := r.q.Query(`
+		SELECT class_id, weekday, start_time, end_time, room
+		FROM class_meetings
+		WHERE class_id IN (?`+strings.Repeat(", ?", len(classIDs)-1)+`)
+		ORDER BY class_id, (weekday + 6) % 7, start_time
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var classID int
+		var m ClassMeeting
+		var room sql.NullString
+		if err := rows.Scan(&classID, &m.Weekday, &m.StartTime, &m.EndTime, &room); err != nil {
+			return nil, err
+		}
+		m.StartTime = m.StartTime[:5]
+		m.EndTime = m.EndTime[:5]
+		m.Room = room.String
+		meetings[classID] = append(meetings[classID], m)
+	}
+	return meetings, rows.Err()
+}
+
+// SetMeetings replaces the weekly meetings of a class
+func (r *sqlClassRepository) SetMeetings(classID int, meetings []ClassMeeting) error {
+	if _, err := r.q.Exec(`DELETE FROM class_meetings WHERE class_id = ?`, classID); err != nil {
+		return err
+	}
+	for _, m := range meetings {
+		_, err := r.q.Exec(`
+			INSERT INTO class_meetings (class_id, weekday, start_time, end_time, room)
+			VALUES (?, ?, ?, ?, ?)
+		`, classID, m.Weekday, m.StartTime+":00", m.EndTime+":00", nullString(m.Room))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttendancePolicies returns the attendance rules set by each of the classes
+// that override the school defaults
+func (r *sqlClassRepository) AttendancePolicies(classIDs ...int) (map[int]AttendancePolicyOverride, error) {
+	overrides := make(map[int]AttendancePolicyOverride)
+	if len(classIDs) == 0 {
+		return overrides, nil
+	}
+
+	args := make([]interface{}, len(classIDs))
+	for i, id := range classIDs {
+		args[i] = id
+	}
+	rows, err := r.q.Query(`
+		SELECT class_id, early_minutes, late_minutes, absent_minutes, minimum_minutes
+		FROM class_attendance_policies
+		WHERE class_id IN (?`+strings.Repeat(", ?", len(classIDs)-1)+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var classID int
+		var early, late, absent, minimum sql.NullInt64
+		if err := rows.Scan(&classID, &early, &late, &absent, &minimum); err != nil {
+			return nil, err
+		}
+		overrides[classID] = AttendancePolicyOverride{
+			EarlyMinutes:   nullIntPtr(early),
+			LateMinutes:    nullIntPtr(late),
+			AbsentMinutes:  nullIntPtr(absent),
+			MinimumMinutes: nullIntPtr(minimum),
+		}
+	}
+	return overrides, rows.Err()
+}
+
+// SetAttendancePolicy replaces the attendance rules a class overrides; with
+// none left the class follows the school defaults
+func (r *sqlClassRepository) SetAttendancePolicy(classID int, o AttendancePolicyOverride, updatedBy int) error {
+	if o.empty() {
+		_, err := r.q.Exec(`DELETE FROM class_attendance_policies WHERE class_id = ?`, classID)
+		return err
+	}
+
+	d := r.d
+	_, err := r.q.Exec(`
+		INSERT INTO class_attendance_policies (class_id, early_minutes, late_minutes, absent_minutes, minimum_minutes, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, `+d.now()+`)
+		`+d.upsert()+`
+			early_minutes = `+d.excluded("early_minutes")+`,
+			late_minutes = `+d.excluded("late_minutes")+`,
+			absent_minutes = `+d.excluded("absent_minutes")+`,
+			minimum_minutes = `+d.excluded("minimum_minutes")+`,
+			updated_by = `+d.excluded("updated_by")+`,
+			updated_at = `+d.now()+`
+	`, classID, intPtrNull(o.EarlyMinutes), intPtrNull(o.LateMinutes), intPtrNull(o.AbsentMinutes), intPtrNull(o.MinimumMinutes), nullInt(updatedBy))
+	return err
+}
+
+// Create inserts an active class and returns its ID
+func (r *sqlClassRepository) Create(class classFields) (int64, error) {
+	result, err := r.q.Exec(`
+		INSERT INTO classes (subject_code, teacher_user_id, offering_code, schedule, room, year_level, section, semester, school_year, created_by_user_id, is_active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, TRUE)
+	`,
+		class.SubjectCode, class.TeacherUserID,
+		nullString(class.OfferingCode),
+		nullString(class.Schedule), nullString(class.Room),
+		nullString(class.YearLevel), nullString(class.Section),
+		nullString(class.Semester), nullString(class.SchoolYear),
+		nullInt(class.CreatedBy),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Update sets the schedule, room, section, term and active flag of a class
+func (r *sqlClassRepository) Update(classID int, class classFields) error {
+	_, err := r.q.Exec(`
+		UPDATE classes
+		SET schedule = ?, room = ?, year_level = ?, section = ?, semester = ?, school_year = ?, is_active = ?
+		WHERE class_id = ?
+	`,
+		nullString(class.Schedule), nullString(class.Room),
+		nullString(class.YearLevel), nullString(class.Section),
+		nullString(class.Semester), nullString(class.SchoolYear),
+		class.IsActive, classID,
+	)
+	return err
+}
+
+// Deactivate soft-deletes a class
+func (r *sqlClassRepository) Deactivate(classID int) error {
+	_, err := r.q.Exec(`UPDATE classes SET is_active = FALSE WHERE class_id = ?`, classID)
+	return err
+}
+
+// SubjectAndSection returns the subject code and section of a class
+func (r *sqlClassRepository) SubjectAndSection(classID int) (string, string, error) {
+	var subjectCode string
+	var section sql.NullString
+	err := r.q.QueryRow(`SELECT subject_code, section FROM classes WHERE class_id = ?`, classID).Scan(&subjectCode, &section)
+	return subjectCode, section.String, err
+}
+
+// EnrollmentCount returns the number of enrollments in every class
+func (r *sqlClassRepository) EnrollmentCount() (int, error) {
+	var count int
+	err := r.q.QueryRow(`SELECT COUNT(*) FROM classlist`).Scan(&count)
+	return count, err
+}
+
+// Owners returns the teacher of a class and the user who created it, 0 if none
+func (r *sqlClassRepository) Owners(classID int) (int, int, error) {
+	var teacherUserID int
+	var createdBy sql.NullInt64
+	err := r.q.QueryRow(`SELECT teacher_user_id, created_by_user_id FROM classes WHERE class_id = ?`, classID).Scan(&teacherUserID, &createdBy)
+	return teacherUserID, int(createdBy.Int64), err
+}
+
+// Students returns the students actively enrolled in a class
+func (r *sqlClassRepository) Students(classID int) ([]ClasslistEntry, error) {
+	query := `
+		SELECT
+			vcl.class_id, vcl.student_user_id, vcl.student_number,
+			vcl.first_name, vcl.middle_name, vcl.last_name,
+			vcl.enrollment_status,
+			cl.enrollment_date,
+			s.email,
+			s.contact_number,
+			sub.subject_name as course
+		FROM v_classlist_complete vcl
+		JOIN classlist cl ON vcl.class_id = cl.class_id AND vcl.student_user_id = cl.student_user_id
+		JOIN classes c ON cl.class_id = c.class_id
+		JOIN subjects sub ON c.subject_code = sub.subject_code
+		LEFT JOIN students s ON vcl.student_user_id = s.user_id
+		WHERE vcl.class_id = ? AND vcl.enrollment_status = 'active'
+		ORDER BY vcl.last_name, vcl.first_name
+	`
+
+	rows, err := r.q.Query(query, classID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []ClasslistEntry
+	for rows.Next() {
+		var student ClasslistEntry
+		var middleName, email, contactNumber, course sql.NullString
+		var enrollmentDate time.Time
+		err := rows.Scan(
+			&student.ClassID, &student.StudentUserID, &student.StudentCode,
+			&student.FirstName, &middleName, &student.LastName,
+			&student.Status,
+			&enrollmentDate, &email, &contactNumber, &course,
+		)
+		if err != nil {
+			continue
+		}
+		student.MiddleName = nullStringPtr(middleName)
+		student.Email = nullStringPtr(email)
+		student.ContactNumber = nullStringPtr(contactNumber)
+		student.Course = nullStringPtr(course)
+		student.EnrollmentDate = enrollmentDate.Format("2006-01-02")
+		students = append(students, student)
+	}
+
+	return students, nil
+}
+
+// Candidates returns students with whether each is actively enrolled in the class.
+// With availableOnly, enrolled students are left out.
+func (r *sqlClassRepository) Candidates(classID int, availableOnly bool) ([]ClassStudent, error) {
+	query := `
+		SELECT
+			s.user_id as id, s.student_number, s.first_name, s.middle_name, s.last_name,
+			EXISTS(
+				SELECT 1 FROM classlist cl
+				WHERE cl.student_user_id = s.user_id AND cl.class_id = ? AND cl.status = 'active'
+			) as is_enrolled
+		FROM students s
+	`
+	args := []interface{}{classID}
+	if availableOnly {
+		query += `
+		WHERE NOT EXISTS (
+			SELECT 1 FROM classlist cl
+			WHERE cl.student_user_id = s.user_id AND cl.class_id = ? AND cl.status = 'active'
+		)
+		`
+		args = append(args, classID)
+	}
+	query += `ORDER BY last_name, first_name`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []ClassStudent
+	for rows.Next() {
+		var student ClassStudent
+		var middleName sql.NullString
+		err := rows.Scan(&student.ID, &student.StudentID, &student.FirstName, &middleName,
+			&student.LastName, &student.IsEnrolled)
+		if err != nil {
+			continue
+		}
+		student.MiddleName = nullStringPtr(middleName)
+		students = append(students, student)
+	}
+
+	return students, nil
+}
+
+// RegisteredStudents returns every student with their contact details
+func (r *sqlClassRepository) RegisteredStudents() ([]ClassStudent, error) {
+	query := `
+		SELECT
+			s.user_id as id, s.student_number, s.first_name, s.middle_name, s.last_name,
+			s.email, s.contact_number, s.profile_photo
+		FROM students s
+		ORDER BY s.last_name, s.first_name
+	`
+
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var students []ClassStudent
+	for rows.Next() {
+		var student ClassStudent
+		var middleName, email, contactNumber, profilePhoto sql.NullString
+		err := rows.Scan(&student.ID, &student.StudentID, &student.FirstName, &middleName,
+			&student.LastName, &email, &contactNumber, &profilePhoto)
+		if err != nil {
+			continue
+		}
+		student.MiddleName = nullStringPtr(middleName)
+		student.Email = nullStringPtr(email)
+		student.ContactNumber = nullStringPtr(contactNumber)
+		student.ProfilePhoto = nullStringPtr(profilePhoto)
+		students = append(students, student)
+	}
+
+	return students, nil
+}
+
+// Enroll adds a student to a class, or reactivates a dropped enrollment
+func (r *sqlClassRepository) Enroll(classID, studentUserID int) error {
+	_, err := r.q.Exec(`
+		INSERT INTO classlist (class_id, student_user_id, status)
+		VALUES (?, ?, 'active')
+		`+r.d.upsert()+` status = 'active', updated_at = `+r.d.now()+`
+	`, classID, studentUserID)
+	return err
+}
+
+// IsEnrolled reports whether a student is actively enrolled in a class
+func (r *sqlClassRepository) IsEnrolled(classID, studentUserID int) (bool, error) {
+	var exists int
+	err := r.q.QueryRow(
+		`SELECT 1 FROM classlist WHERE class_id = ? AND student_user_id = ? AND status = 'active' LIMIT 1`,
+		classID, studentUserID,
+	).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Drop marks one student's enrollment in a class as dropped
+func (r *sqlClassRepository) Drop(classID, studentUserID int) error {
+	_, err := r.q.Exec(`UPDATE classlist SET status = 'dropped' WHERE student_user_id = ? AND class_id = ?`, studentUserID, classID)
+	return err
+}
+
+// DropAll marks every enrollment in a class as dropped
+func (r *sqlClassRepository) DropAll(classID int) error {
+	_, err := r.q.Exec(`UPDATE classlist SET status = 'dropped' WHERE class_id = ?`, classID)
+	return err
+}
+
+// nullIntPtr converts a nullable integer column to a pointer, nil when NULL
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	i := int(n.Int64)
+	return &i
+}
+
+// intPtrNull converts an optional integer, where 0 is a value, to a nullable column
+func intPtrNull(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// sqlComputerRepository implements ComputerRepository for both backends
+type sqlComputerRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// Fingerprint returns the machine fingerprint of the registration the enrollment secret belongs to
+func (r *sqlComputerRepository) Fingerprint(id int, secretHash string) (string, error) {
+	var stored string
+	err := r.q.QueryRow(`SELECT machine_fingerprint FROM computers WHERE id = ? AND enrollment_secret_hash = ?`,
+		id, secretHash).Scan(&stored)
+	return stored, err
+}
+
+// Seen records that a registered computer started, under its current hostname
+func (r *sqlComputerRepository) Seen(id int, hostname, macs string) error {
+	_, err := r.q.Exec(`UPDATE computers SET hostname = ?, mac_addresses = ?, last_seen_at = `+r.d.now()+` WHERE id = ?`,
+		hostname, nullString(macs), id)
+	return err
+}
+
+// Request files a pending registration and returns its ID. A machine that
+// registered before (e.g. reinstalled) keeps its row but must be approved again.
+func (r *sqlComputerRepository) Request(hostname, fingerprint, macs, secretHash string) (int64, error) {
+	d := r.d
+	_, err := r.q.Exec(`
+		INSERT INTO computers (hostname, machine_fingerprint, mac_addresses, enrollment_secret_hash, status, requested_at)
+		VALUES (?, ?, ?, ?, 'pending', `+d.now()+`)
+		`+d.upsert()+`
+			hostname = `+d.excluded("hostname")+`,
+			mac_addresses = `+d.excluded("mac_addresses")+`,
+			enrollment_secret_hash = `+d.excluded("enrollment_secret_hash")+`,
+			status = 'pending',
+			requested_at = `+d.now()+`,
+			approved_by = NULL,
+			approved_at = NULL
+	`, hostname, fingerprint, nullString(macs), secretHash)
+	if err != nil {
+		return 0, err
+	}
+	// Read the ID back, since an update does not report it as the insert ID on every backend
+	var id int64
+	err = r.q.QueryRow(`SELECT id FROM computers WHERE machine_fingerprint = ?`, fingerprint).Scan(&id)
+	return id, err
+}
+
+// Lookup returns the display name and status of a registration whose secret and fingerprint match
+func (r *sqlComputerRepository) Lookup(id int, secretHash, fingerprint string) (sql.NullString, string, error) {
+	var displayName sql.NullString
+	var status string
+	err := r.q.QueryRow(`
+		SELECT display_name, status FROM computers
+		WHERE id = ? AND enrollment_secret_hash = ? AND machine_fingerprint = ?
+	`, id, secretHash, fingerprint).Scan(&displayName, &status)
+	return displayName, status, err
+}
+
+// List returns the computers registry, pending requests first
+func (r *sqlComputerRepository) List() ([]Computer, error) {
+	query := `
+		SELECT c.id, c.asset_tag, c.display_name, c.room, c.hostname, c.mac_addresses, c.status,
+			c.requested_at, u.username, c.approved_at, c.last_seen_at
+		FROM computers c
+		LEFT JOIN users u ON u.id = c.approved_by
+		ORDER BY c.status = 'pending' DESC, c.display_name, c.hostname
+	`
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var computers []Computer
+	for rows.Next() {
+		var c Computer
+		var assetTag, displayName, room, macs, approvedBy sql.NullString
+		var requestedAt time.Time
+		var approvedAt, lastSeenAt sql.NullTime
+		err := rows.Scan(&c.ID, &assetTag, &displayName, &room, &c.Hostname, &macs, &c.Status,
+			&requestedAt, &approvedBy, &approvedAt, &lastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		if assetTag.Valid {
+			c.AssetTag = &assetTag.String
+		}
+		if displayName.Valid {
+			c.DisplayName = &displayName.String
+		}
+		if room.Valid {
+			c.Room = &room.String
+		}
+		if macs.Valid {
+			c.MACAddresses = &macs.String
+		}
+		if approvedBy.Valid {
+			c.ApprovedBy = &approvedBy.String
+		}
+		c.RequestedAt = requestedAt.Format("2006-01-02 15:04:05")
+		if approvedAt.Valid {
+			formatted := approvedAt.Time.Format("2006-01-02 15:04:05")
+			c.ApprovedAt = &formatted
+		}
+		if lastSeenAt.Valid {
+			formatted := lastSeenAt.Time.Format("2006-01-02 15:04:05")
+			c.LastSeenAt = &formatted
+		}
+		computers = append(computers, c)
+	}
+	return computers, rows.Err()
+}
+
+// Exists reports whether a computer is in the registry
+func (r *sqlComputerRepository) Exists(id int) (bool, error) {
+	var exists int
+	err := r.q.QueryRow(`SELECT 1 FROM computers WHERE id = ?`, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NameTaken reports whether a computer other than id already uses the display name
+func (r *sqlComputerRepository) NameTaken(displayName string, id int) (bool, error) {
+	var exists int
+	err := r.q.QueryRow(`SELECT 1 FROM computers WHERE display_name = ? AND id <> ?`, displayName, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Approve approves a registration and names the computer. It reports whether the computer exists.
+func (r *sqlComputerRepository) Approve(id int, displayName, room, assetTag string, approvedBy int) (bool, error) {
+	result, err := r.q.Exec(`
+		UPDATE computers
+		SET display_name = ?, room = ?, asset_tag = ?, status = 'approved', approved_by = ?, approved_at = `+r.d.now()+`
+		WHERE id = ?
+	`, displayName, nullString(room), nullString(assetTag), approvedBy, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// UpdateDetails changes the display name, room and asset tag of a computer
+func (r *sqlComputerRepository) UpdateDetails(id int, displayName, room, assetTag string) error {
+	_, err := r.q.Exec(`UPDATE computers SET display_name = ?, room = ?, asset_tag = ? WHERE id = ?`,
+		displayName, nullString(room), nullString(assetTag), id)
+	return err
+}
+
+// Revoke withdraws a computer's approval
+func (r *sqlComputerRepository) Revoke(id int) error {
+	_, err := r.q.Exec(`UPDATE computers SET status = 'revoked' WHERE id = ?`, id)
+	return err
+}
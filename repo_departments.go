@@ -0,0 +1,77 @@
+package main
+
+import (
+	"database/sql"
+	"time"
+)
+
+// sqlDepartmentRepository implements DepartmentRepository for both backends
+type sqlDepartmentRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// List returns every department ordered by code
+func (r *sqlDepartmentRepository) List() ([]Department, error) {
+	query := `
+		SELECT department_code, department_name, description, is_active, created_at, updated_at
+		FROM departments
+		ORDER BY department_code
+	`
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var departments []Department
+	for rows.Next() {
+		var dept Department
+		var description sql.NullString
+		var createdAt, updatedAt time.Time
+
+		err := rows.Scan(&dept.DepartmentCode, &dept.DepartmentName, &description, &dept.IsActive, &createdAt, &updatedAt)
+		if err != nil {
+			continue
+		}
+
+		if description.Valid {
+			dept.Description = &description.String
+		}
+		dept.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+		dept.UpdatedAt = updatedAt.Format("2006-01-02 15:04:05")
+
+		departments = append(departments, dept)
+	}
+	return departments, nil
+}
+
+// Exists reports whether a department with the code exists
+func (r *sqlDepartmentRepository) Exists(code string) (bool, error) {
+	var exists int
+	err := r.q.QueryRow(`SELECT 1 FROM departments WHERE department_code = ?`, code).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Create inserts a department
+func (r *sqlDepartmentRepository) Create(code, name, description string) error {
+	query := `INSERT INTO departments (department_code, department_name, description) VALUES (?, ?, ?)`
+	_, err := r.q.Exec(query, code, name, nullString(description))
+	return err
+}
+
+// Update changes a department, including its code
+func (r *sqlDepartmentRepository) Update(oldCode, code, name, description string, isActive bool) error {
+	query := `UPDATE departments SET department_code = ?, department_name = ?, description = ?, is_active = ? WHERE department_code = ?`
+	_, err := r.q.Exec(query, code, name, nullString(description), isActive, oldCode)
+	return err
+}
+
+// Delete removes a department
+func (r *sqlDepartmentRepository) Delete(code string) error {
+	_, err := r.q.Exec(`DELETE FROM departments WHERE department_code = ?`, code)
+	return err
+}
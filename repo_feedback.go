@@ -0,0 +1,238 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlFeedbackRepository implements FeedbackRepository for both backends
+type sqlFeedbackRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// Forwarded returns feedback forwarded to the admins, newest first
+func (r *sqlFeedbackRepository) Forwarded() ([]Feedback, error) {
+	query := `
+		SELECT
+			f.id,
+			f.student_user_id,
+			COALESCE(s.student_number, 'N/A') as student_id_str,
+			s.first_name,
+			s.middle_name,
+			s.last_name,
+			f.pc_number,
+			f.equipment_condition,
+			f.monitor_condition,
+			f.keyboard_condition,
+			f.mouse_condition,
+			f.comments,
+			f.date_submitted,
+			f.status,
+			f.forwarded_by_user_id,
+			f.forwarded_at,
+			f.working_student_notes,
+			CONCAT(
+				COALESCE(s_fwd.last_name, t_fwd.last_name, a_fwd.last_name, ''),
+				CASE WHEN COALESCE(s_fwd.last_name, t_fwd.last_name, a_fwd.last_name) IS NOT NULL THEN ', ' ELSE '' END,
+				COALESCE(s_fwd.first_name, t_fwd.first_name, a_fwd.first_name, ''),
+				CASE WHEN COALESCE(s_fwd.middle_name, t_fwd.middle_name, a_fwd.middle_name) IS NOT NULL
+					THEN CONCAT(' ', COALESCE(s_fwd.middle_name, t_fwd.middle_name, a_fwd.middle_name))
+					ELSE '' END
+			) as forwarded_by_name
+		FROM feedback f
+		LEFT JOIN students s ON f.student_user_id = s.user_id
+		LEFT JOIN users u_fwd ON f.forwarded_by_user_id = u_fwd.id
+		LEFT JOIN students s_fwd ON u_fwd.id = s_fwd.user_id AND u_fwd.user_type IN ('student', 'working_student')
+		LEFT JOIN teachers t_fwd ON u_fwd.id = t_fwd.user_id AND u_fwd.user_type = 'teacher'
+		LEFT JOIN admins a_fwd ON u_fwd.id = a_fwd.user_id AND u_fwd.user_type = 'admin'
+		WHERE f.status = 'forwarded'
+		ORDER BY f.date_submitted DESC
+		LIMIT 1000`
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedbacks []Feedback
+	for rows.Next() {
+		var fb Feedback
+		var middleName, comments, studentIDStr, forwardedByName, workingStudentNotes sql.NullString
+		var dateSubmitted time.Time
+		var forwardedBy sql.NullInt64
+		var forwardedAt sql.NullTime
+
+		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
+			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
+			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted, &fb.Status,
+			&forwardedBy, &forwardedAt, &workingStudentNotes, &forwardedByName)
+		if err != nil {
+			continue
+		}
+
+		fillFeedbackStudent(&fb, studentIDStr, middleName, comments, dateSubmitted)
+		if forwardedBy.Valid {
+			forwardedByInt := int(forwardedBy.Int64)
+			fb.ForwardedByUserID = &forwardedByInt
+		}
+		if forwardedAt.Valid {
+			forwardedAtStr := forwardedAt.Time.Format("2006-01-02 15:04:05")
+			fb.ForwardedAt = &forwardedAtStr
+		}
+		if forwardedByName.Valid && forwardedByName.String != "" {
+			fb.ForwardedByName = &forwardedByName.String
+		}
+		fb.WorkingStudentNotes = nullStringPtr(workingStudentNotes)
+
+		feedbacks = append(feedbacks, fb)
+	}
+
+	return feedbacks, nil
+}
+
+// Pending returns feedback still waiting for a working student, newest first
+func (r *sqlFeedbackRepository) Pending() ([]Feedback, error) {
+	query := `
+		SELECT
+			f.id,
+			f.student_user_id,
+			COALESCE(s.student_number, 'N/A') as student_id_str,
+			s.first_name,
+			s.middle_name,
+			s.last_name,
+			f.pc_number,
+			f.equipment_condition,
+			f.monitor_condition,
+			f.keyboard_condition,
+			f.mouse_condition,
+			f.comments,
+			f.date_submitted,
+			f.status
+		FROM feedback f
+		LEFT JOIN students s ON f.student_user_id = s.user_id
+		WHERE f.status = 'pending'
+		ORDER BY f.date_submitted DESC
+		LIMIT 1000`
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedbacks []Feedback
+	for rows.Next() {
+		var fb Feedback
+		var middleName, comments, studentIDStr sql.NullString
+		var dateSubmitted time.Time
+
+		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
+			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
+			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted, &fb.Status)
+		if err != nil {
+			continue
+		}
+
+		fillFeedbackStudent(&fb, studentIDStr, middleName, comments, dateSubmitted)
+		feedbacks = append(feedbacks, fb)
+	}
+
+	return feedbacks, nil
+}
+
+// ForStudent returns the feedback history of one student, newest first
+func (r *sqlFeedbackRepository) ForStudent(studentUserID int) ([]Feedback, error) {
+	query := `
+		SELECT
+			f.id,
+			f.student_user_id,
+			COALESCE(s.student_number, 'N/A') as student_id_str,
+			s.first_name,
+			s.middle_name,
+			s.last_name,
+			f.pc_number,
+			f.equipment_condition,
+			f.monitor_condition,
+			f.keyboard_condition,
+			f.mouse_condition,
+			f.comments,
+			f.date_submitted
+		FROM feedback f
+		LEFT JOIN students s ON f.student_user_id = s.user_id
+		WHERE f.student_user_id = ?
+		ORDER BY f.date_submitted DESC`
+	rows, err := r.q.Query(query, studentUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var feedbacks []Feedback
+	for rows.Next() {
+		var fb Feedback
+		var middleName, comments, studentIDStr sql.NullString
+		var dateSubmitted time.Time
+
+		err := rows.Scan(&fb.ID, &fb.StudentUserID, &studentIDStr, &fb.FirstName, &middleName, &fb.LastName,
+			&fb.PCNumber, &fb.EquipmentCondition, &fb.MonitorCondition,
+			&fb.KeyboardCondition, &fb.MouseCondition, &comments, &dateSubmitted)
+		if err != nil {
+			continue
+		}
+
+		fillFeedbackStudent(&fb, studentIDStr, middleName, comments, dateSubmitted)
+		feedbacks = append(feedbacks, fb)
+	}
+
+	return feedbacks, nil
+}
+
+// Create saves a student's equipment report, submitted at fb.DateSubmitted or now
+func (r *sqlFeedbackRepository) Create(fb Feedback) error {
+	query := `INSERT INTO feedback (student_user_id, pc_number,
+			  equipment_condition, monitor_condition, keyboard_condition, mouse_condition,
+			  comments, date_submitted)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, COALESCE(?, ` + r.d.now() + `))`
+
+	var comments sql.NullString
+	if fb.Comments != nil {
+		comments = nullString(*fb.Comments)
+	}
+	_, err := r.q.Exec(query, fb.StudentUserID, fb.PCNumber,
+		fb.EquipmentCondition, fb.MonitorCondition, fb.KeyboardCondition, fb.MouseCondition, comments, nullString(fb.DateSubmitted))
+	return err
+}
+
+// Forward marks a pending item as forwarded to the admins.
+// It returns false when the item does not exist or was already forwarded.
+func (r *sqlFeedbackRepository) Forward(feedbackID, workingStudentID int, notes string) (bool, error) {
+	result, err := r.q.Exec(`UPDATE feedback
+			  SET status = 'forwarded',
+			      forwarded_by_user_id = ?,
+			      forwarded_at = `+r.d.now()+`,
+			      working_student_notes = ?
+			  WHERE id = ? AND status = 'pending'`, workingStudentID, nullString(notes), feedbackID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// fillFeedbackStudent sets the student and submission fields shared by every feedback listing
+func fillFeedbackStudent(fb *Feedback, studentIDStr, middleName, comments sql.NullString, dateSubmitted time.Time) {
+	if studentIDStr.Valid {
+		fb.StudentIDStr = studentIDStr.String
+	} else {
+		fb.StudentIDStr = "N/A"
+	}
+	fb.MiddleName = nullStringPtr(middleName)
+	fb.Comments = nullStringPtr(comments)
+
+	fb.StudentName = fmt.Sprintf("%s, %s", fb.LastName, fb.FirstName)
+	if middleName.Valid {
+		fb.StudentName += " " + middleName.String
+	}
+	fb.DateSubmitted = dateSubmitted.Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// sqlIntegrityRepository implements IntegrityRepository for both backends
+type sqlIntegrityRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// chainedState is the latest chain entry of an entity
+type chainedState struct {
+	Seq     int64
+	Payload string
+}
+
+// LockHead returns the head of the chain, locked until the transaction ends.
+// The head is created at the genesis hash when the chain has not started yet.
+func (r *sqlIntegrityRepository) LockHead() (int64, string, error) {
+	var seq int64
+	var hash string
+	err := r.q.QueryRow(`SELECT seq, hash FROM integrity_chain_head WHERE id = 1 `+r.d.forUpdate()).Scan(&seq, &hash)
+	if err == sql.ErrNoRows {
+		if _, err := r.q.Exec(`INSERT INTO integrity_chain_head (id, seq, hash, started_at) VALUES (1, 0, ?, `+r.d.now()+`)`, genesisHash); err != nil {
+			return 0, "", fmt.Errorf("failed to initialise integrity chain: %w", err)
+		}
+		return 0, genesisHash, nil
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read integrity chain head: %w", err)
+	}
+	return seq, hash, nil
+}
+
+// Append stores a chain entry and advances the head to it
+func (r *sqlIntegrityRepository) Append(e chainEntry) error {
+	_, err := r.q.Exec(`
+		INSERT INTO integrity_chain (seq, entity_type, entity_key, payload, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.Seq, e.EntityType, e.EntityKey, e.Payload, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to append integrity chain entry: %w", err)
+	}
+	if _, err := r.q.Exec(`UPDATE integrity_chain_head SET seq = ?, hash = ? WHERE id = 1`, e.Seq, e.Hash); err != nil {
+		return fmt.Errorf("failed to advance integrity chain head: %w", err)
+	}
+	return nil
+}
+
+// Head returns the current head of the chain, or the genesis hash before it started
+func (r *sqlIntegrityRepository) Head() (ChainHead, error) {
+	var head ChainHead
+	err := r.q.QueryRow(`SELECT seq, hash FROM integrity_chain_head WHERE id = 1`).Scan(&head.Seq, &head.Hash)
+	if err == sql.ErrNoRows {
+		return ChainHead{Seq: 0, Hash: genesisHash}, nil
+	}
+	return head, err
+}
+
+// Entries returns the chain entries created between from and to, in order
+func (r *sqlIntegrityRepository) Entries(from, to string) ([]chainEntry, error) {
+	where, args := dateRangeClause(r.d, "created_at", from, to)
+	rows, err := r.q.Query(`
+		SELECT seq, entity_type, entity_key, payload, prev_hash, hash
+		FROM integrity_chain WHERE `+where+` ORDER BY seq`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read integrity chain: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []chainEntry
+	for rows.Next() {
+		var e chainEntry
+		if err := rows.Scan(&e.Seq, &e.EntityType, &e.EntityKey, &e.Payload, &e.PrevHash, &e.Hash); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// EntryHash returns the hash of the entry with the sequence number
+func (r *sqlIntegrityRepository) EntryHash(seq int64) (string, error) {
+	var hash string
+	err := r.q.QueryRow(`SELECT hash FROM integrity_chain WHERE seq = ?`, seq).Scan(&hash)
+	return hash, err
+}
+
+// liveRowsQuery returns the snapshot query of an entity type's rows and the column its date range applies to
+func liveRowsQuery(entityType string) (string, string, error) {
+	switch entityType {
+	case "attendance":
+		return `SELECT ` + attendanceSnapshotColumns + ` FROM attendance`, "date", nil
+	case "login_log":
+		return `SELECT id, user_id, pc_number, login_time, logout_time, login_status FROM login_logs`, "login_time", nil
+	}
+	return "", "", fmt.Errorf("unknown chain entity type %q", entityType)
+}
+
+// LiveRows returns the current rows of an entity type dated between from and to
+func (r *sqlIntegrityRepository) LiveRows(entityType, from, to string) ([]map[string]interface{}, error) {
+	query, column, err := liveRowsQuery(entityType)
+	if err != nil {
+		return nil, err
+	}
+	where, args := dateRangeClause(r.d, column, from, to)
+	snapshot, err := auditSnapshot(r.q, query+` WHERE `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotRows(snapshot), nil
+}
+
+// PreChainKeys returns the keys of an entity type's rows dated between from and
+// to that were written before the chain started
+func (r *sqlIntegrityRepository) PreChainKeys(entityType, from, to string) (map[string]bool, error) {
+	var query string
+	var where string
+	var args []interface{}
+	switch entityType {
+	case "attendance":
+		where, args = dateRangeClause(r.d, "date", from, to)
+		query = `
+			SELECT CONCAT(class_id, '/', student_user_id, '/', DATE(date)) FROM attendance
+			WHERE created_at < (SELECT started_at FROM integrity_chain_head WHERE id = 1) AND ` + where
+	case "login_log":
+		where, args = dateRangeClause(r.d, "login_time", from, to)
+		query = `
+			SELECT id FROM login_logs
+			WHERE login_time < (SELECT started_at FROM integrity_chain_head WHERE id = 1) AND ` + where
+	default:
+		return nil, fmt.Errorf("unknown chain entity type %q", entityType)
+	}
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find rows older than the chain: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
+	}
+	return keys, rows.Err()
+}
+
+// LatestStates returns the latest chain entry of each entity of a type
+func (r *sqlIntegrityRepository) LatestStates(entityType string) (map[string]chainedState, error) {
+	rows, err := r.q.Query(`
+		SELECT ic.entity_key, ic.seq, ic.payload
+		FROM integrity_chain ic
+		JOIN (SELECT MAX(seq) AS seq FROM integrity_chain WHERE entity_type = ? GROUP BY entity_key) latest
+			ON latest.seq = ic.seq
+	`, entityType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chained states: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[string]chainedState)
+	for rows.Next() {
+		var key string
+		var state chainedState
+		if err := rows.Scan(&key, &state.Seq, &state.Payload); err != nil {
+			return nil, err
+		}
+		latest[key] = state
+	}
+	return latest, rows.Err()
+}
+
+// DeletedEntities returns the latest chain entries created between from and to
+// whose row no longer exists
+func (r *sqlIntegrityRepository) DeletedEntities(from, to string) ([]chainEntry, error) {
+	where, args := dateRangeClause(r.d, "ic.created_at", from, to)
+	rows, err := r.q.Query(`
+		SELECT ic.seq, ic.entity_type, ic.entity_key
+		FROM integrity_chain ic
+		JOIN (SELECT entity_type, entity_key, MAX(seq) AS seq FROM integrity_chain GROUP BY entity_type, entity_key) latest
+			ON latest.seq = ic.seq
+		WHERE `+where+`
+			AND ((ic.entity_type = 'login_log' AND NOT EXISTS (SELECT 1 FROM login_logs l WHERE l.id = ic.entity_key))
+			  OR (ic.entity_type = 'attendance' AND NOT EXISTS (
+					SELECT 1 FROM attendance at
+					WHERE CONCAT(at.class_id, '/', at.student_user_id, '/', DATE(at.date)) = ic.entity_key)))
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for deleted rows: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []chainEntry
+	for rows.Next() {
+		var e chainEntry
+		if err := rows.Scan(&e.Seq, &e.EntityType, &e.EntityKey); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
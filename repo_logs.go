@@ -0,0 +1,312 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"time"
+)
+
+// sqlLogRepository implements LogRepository for both backends
+type sqlLogRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// List returns the newest login logs with user details, of one user or of everyone when userID is 0.
+// Logs are read from login_logs directly so they are returned even if the user's profile is missing.
+func (r *sqlLogRepository) List(userID, limit int) ([]LoginLog, error) {
+	query := `
+		SELECT
+			ll.id,
+			ll.user_id,
+			COALESCE(u.user_type, 'unknown') as user_type,
+			ll.pc_number,
+			ll.login_time,
+			ll.logout_time,
+			COALESCE(ll.login_status, 'success') as login_status,
+			COALESCE(
+				CASE WHEN s.last_name IS NOT NULL AND s.first_name IS NOT NULL
+					THEN CONCAT(s.last_name, ', ', s.first_name,
+						CASE WHEN s.middle_name IS NOT NULL THEN CONCAT(' ', s.middle_name) ELSE '' END)
+					ELSE NULL END,
+				CASE WHEN t.last_name IS NOT NULL AND t.first_name IS NOT NULL
+					THEN CONCAT(t.last_name, ', ', t.first_name,
+						CASE WHEN t.middle_name IS NOT NULL THEN CONCAT(' ', t.middle_name) ELSE '' END)
+					ELSE NULL END,
+				CASE WHEN a.last_name IS NOT NULL AND a.first_name IS NOT NULL
+					THEN CONCAT(a.last_name, ', ', a.first_name,
+						CASE WHEN a.middle_name IS NOT NULL THEN CONCAT(' ', a.middle_name) ELSE '' END)
+					ELSE NULL END,
+				u.username
+			) as full_name,
+			COALESCE(
+				s.student_number,
+				t.employee_number,
+				a.employee_number,
+				u.username
+			) as user_id_number,
+			COALESCE(ll.unregistered_pc, FALSE)
+		FROM login_logs ll
+		JOIN users u ON ll.user_id = u.id
+		LEFT JOIN students s ON u.id = s.user_id AND u.user_type IN ('student', 'working_student')
+		LEFT JOIN teachers t ON u.id = t.user_id AND u.user_type = 'teacher'
+		LEFT JOIN admins a ON u.id = a.user_id AND u.user_type = 'admin'
+		WHERE (? = 0 OR ll.user_id = ?)
+		ORDER BY ll.login_time DESC
+		LIMIT ?
+	`
+	rows, err := r.q.Query(query, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var logs []LoginLog
+	for rows.Next() {
+		var logEntry LoginLog
+		var pcNumber sql.NullString
+		var loginTime time.Time
+		var logoutTime sql.NullTime
+		var userIDNumber sql.NullString
+
+		err := rows.Scan(&logEntry.ID, &logEntry.UserID, &logEntry.UserType, &pcNumber, &loginTime, &logoutTime, &logEntry.LoginStatus, &logEntry.UserName, &userIDNumber, &logEntry.UnregisteredPC)
+		if err != nil {
+			log.Printf("Error scanning login log row: %v", err)
+			continue
+		}
+
+		logEntry.LoginTime = loginTime.Format("2006-01-02 15:04:05")
+		logEntry.PCNumber = nullStringPtr(pcNumber)
+		if logoutTime.Valid {
+			formattedLogoutTime := logoutTime.Time.Format("2006-01-02 15:04:05")
+			logEntry.LogoutTime = &formattedLogoutTime
+		}
+		if userIDNumber.Valid {
+			logEntry.UserIDNumber = userIDNumber.String
+		} else {
+			logEntry.UserIDNumber = logEntry.UserName // Fallback to username if ID number not found
+		}
+
+		logs = append(logs, logEntry)
+	}
+
+	return logs, rows.Err()
+}
+
+// RecentLoginCount counts logins that were not failures within the last hours
+func (r *sqlLogRepository) RecentLoginCount(hours int) (int, error) {
+	var count int
+	err := r.q.QueryRow(`
+		SELECT COUNT(*)
+		FROM login_logs
+		WHERE login_time >= `+r.d.dateSub(r.d.now(), "?", "hour")+` AND login_status <> 'failed'
+	`, hours).Scan(&count)
+	return count, err
+}
+
+// FirstLogin returns the earliest login of a user from from until until that was not a failed attempt
+func (r *sqlLogRepository) FirstLogin(userID int, from, until time.Time) (logID int, loginTime, logoutTime sql.NullTime, pcNumber sql.NullString, err error) {
+	err = r.q.QueryRow(`
+		SELECT id, login_time, logout_time, pc_number
+		FROM login_logs
+		WHERE user_id = ?
+		AND login_time BETWEEN ? AND ?
+		AND login_status <> 'failed'
+		ORDER BY login_time ASC
+		LIMIT 1
+	`, userID, from.Format("2006-01-02 15:04:05"), until.Format("2006-01-02 15:04:05")).Scan(&logID, &loginTime, &logoutTime, &pcNumber)
+	return
+}
+
+// Sessions returns the login and logout times of a user's ended sessions that
+// overlap from until until, by login time
+func (r *sqlLogRepository) Sessions(userID int, from, until time.Time) ([]loginSpan, error) {
+	rows, err := r.q.Query(`
+		SELECT login_time, logout_time
+		FROM login_logs
+		WHERE user_id = ?
+		AND login_status <> 'failed'
+		AND logout_time IS NOT NULL
+		AND login_time < ? AND logout_time > ?
+		ORDER BY login_time ASC
+	`, userID, until.Format("2006-01-02 15:04:05"), from.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []loginSpan
+	for rows.Next() {
+		var login, logout dbTime
+		if err := rows.Scan(&login, &logout); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, loginSpan{Login: login.Time, Logout: logout.Time})
+	}
+	return sessions, rows.Err()
+}
+
+// Open inserts the login_logs row of a successful login and returns its ID
+func (r *sqlLogRepository) Open(userID int, pcNumber string, computerID sql.NullInt64, unregisteredPC bool) (int64, error) {
+	result, err := r.q.Exec(`INSERT INTO login_logs (user_id, pc_number, computer_id, unregistered_pc, login_time, login_status)
+		VALUES (?, ?, ?, ?, `+r.d.now()+`, 'success')`, userID, pcNumber, computerID, unregisteredPC)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// OpenAt inserts the login_logs row of a successful login made at loginTime,
+// such as one replayed from the offline queue, and returns its ID
+func (r *sqlLogRepository) OpenAt(userID int, pcNumber string, computerID sql.NullInt64, unregisteredPC bool, loginTime time.Time) (int64, error) {
+	result, err := r.q.Exec(`INSERT INTO login_logs (user_id, pc_number, computer_id, unregistered_pc, login_time, login_status)
+		VALUES (?, ?, ?, ?, ?, 'success')`, userID, pcNumber, computerID, unregisteredPC, loginTime.Format("2006-01-02 15:04:05"))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// RecordFailure inserts the login_logs row of a failed password attempt and returns its ID
+func (r *sqlLogRepository) RecordFailure(userID int, pcNumber string) (int64, error) {
+	result, err := r.q.Exec(`INSERT INTO login_logs (user_id, pc_number, login_time, login_status) VALUES (?, ?, `+r.d.now()+`, 'failed')`, userID, pcNumber)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Close closes an open login_logs row. An empty status keeps the current
+// login_status; a zero logoutTime records the current time.
+// It returns sql.ErrNoRows when the row was already closed.
+func (r *sqlLogRepository) Close(logID int64, userID int, status string, logoutTime time.Time) error {
+	var end sql.NullString
+	if !logoutTime.IsZero() {
+		end = nullString(logoutTime.Format("2006-01-02 15:04:05"))
+	}
+	result, err := r.q.Exec(`
+		UPDATE login_logs
+		SET logout_time = COALESCE(?, `+r.d.now()+`), login_status = COALESCE(?, login_status)
+		WHERE id = ? AND user_id = ? AND logout_time IS NULL
+	`, end, nullString(status), logID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Kick closes a session ended by the same user signing in on another PC
+func (r *sqlLogRepository) Kick(logID int64) error {
+	_, err := r.q.Exec(`UPDATE login_logs SET logout_time = `+r.d.now()+`, login_status = 'kicked' WHERE id = ?`, logID)
+	return err
+}
+
+// OpenElsewhere locks and returns the user's open sessions on PCs other than pcNumber
+func (r *sqlLogRepository) OpenElsewhere(userID int, pcNumber string) ([]openLoginSession, error) {
+	rows, err := r.q.Query(`
+		SELECT id, user_id, COALESCE(pc_number, ''), login_time
+		FROM login_logs
+		WHERE user_id = ? AND logout_time IS NULL AND login_status = 'success'
+			AND COALESCE(pc_number, '') <> ?
+		ORDER BY login_time
+		`+r.d.forUpdate(), userID, pcNumber)
+	if err != nil {
+		return nil, err
+	}
+	return scanOpenSessions(rows)
+}
+
+// OpenSessions returns the open sessions of one user, or of everyone when userID is 0,
+// together with the database's current time to measure them against
+func (r *sqlLogRepository) OpenSessions(userID int) ([]openLoginSession, time.Time, error) {
+	var now dbTime
+	if err := r.q.QueryRow(`SELECT ` + r.d.now()).Scan(&now); err != nil {
+		return nil, time.Time{}, err
+	}
+
+	rows, err := r.q.Query(`
+		SELECT id, user_id, COALESCE(pc_number, ''), login_time
+		FROM login_logs
+		WHERE logout_time IS NULL AND login_status = 'success' AND (? = 0 OR user_id = ?)
+	`, userID, userID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	sessions, err := scanOpenSessions(rows)
+	return sessions, now.Time, err
+}
+
+// RecordConflict flags a login that found the existing session still open.
+// newLogID is 0 when the login was denied.
+func (r *sqlLogRepository) RecordConflict(userID int, newLogID int64, existing openLoginSession, attemptedPC, policy string) error {
+	_, err := r.q.Exec(`
+		INSERT INTO session_conflicts (user_id, login_log_id, existing_login_log_id, existing_pc, attempted_pc, policy)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, userID, nullInt(int(newLogID)), existing.ID, nullString(existing.PCNumber), nullString(attemptedPC), policy)
+	return err
+}
+
+// Conflicts returns the flagged logins of students enrolled in a class on a date
+func (r *sqlLogRepository) Conflicts(classID int, date string) ([]SessionConflict, error) {
+	query := `
+		SELECT sc.id, sc.user_id, s.student_number, s.first_name, s.last_name,
+			sc.login_log_id, sc.existing_login_log_id, sc.existing_pc, sc.attempted_pc,
+			sc.policy, sc.detected_at
+		FROM session_conflicts sc
+		JOIN classlist cl ON cl.student_user_id = sc.user_id AND cl.class_id = ? AND cl.status = 'active'
+		JOIN students s ON s.user_id = sc.user_id
+		WHERE DATE(sc.detected_at) = ?
+		ORDER BY sc.detected_at
+	`
+	rows, err := r.q.Query(query, classID, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []SessionConflict
+	for rows.Next() {
+		var c SessionConflict
+		var loginLogID sql.NullInt64
+		var existingPC, attemptedPC sql.NullString
+		var detectedAt time.Time
+		err := rows.Scan(&c.ID, &c.StudentUserID, &c.StudentCode, &c.FirstName, &c.LastName,
+			&loginLogID, &c.ExistingLoginLogID, &existingPC, &attemptedPC, &c.Policy, &detectedAt)
+		if err != nil {
+			return nil, err
+		}
+		c.LoginLogID = nullIntPtr(loginLogID)
+		c.ExistingPC = nullStringPtr(existingPC)
+		c.AttemptedPC = nullStringPtr(attemptedPC)
+		c.DetectedAt = detectedAt.Format("2006-01-02 15:04:05")
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// scanOpenSessions reads and closes rows of id, user_id, pc_number, login_time
+func scanOpenSessions(rows *dbRows) ([]openLoginSession, error) {
+	defer rows.Close()
+
+	var sessions []openLoginSession
+	for rows.Next() {
+		var s openLoginSession
+		if err := rows.Scan(&s.ID, &s.UserID, &s.PCNumber, &s.LoginTime); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// Status returns the login status of a login log
+func (r *sqlLogRepository) Status(logID int) (string, error) {
+	var status string
+	err := r.q.QueryRow(`SELECT login_status FROM login_logs WHERE id = ?`, logID).Scan(&status)
+	return status, err
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// sqlUserRepository implements UserRepository for both backends
+type sqlUserRepository struct {
+	q sqlExecutor
+	d sqlDialect
+}
+
+// loginAccount is a user row as checked by Login
+type loginAccount struct {
+	User
+	PasswordAgeDays int
+	TOTPEnabled     bool
+}
+
+// userFilter narrows a user listing; empty fields do not filter
+type userFilter struct {
+	Role   string
+	Status string // "active", "inactive", or "" for all
+	Search string // Matched against names, ID numbers and the creation date
+}
+
+// userAccount holds the fields written when creating or updating a user and its role profile
+type userAccount struct {
+	Username       string
+	Password       string // Already hashed
+	Role           string
+	FirstName      string
+	MiddleName     string
+	LastName       string
+	EmployeeID     string
+	StudentID      string
+	Email          string
+	ContactNumber  string
+	DepartmentCode string
+}
+
+// FindForLogin returns the account signing in with username
+func (r *sqlUserRepository) FindForLogin(username string) (*loginAccount, error) {
+	var account loginAccount
+	query := `SELECT id, username, password, user_type, COALESCE(is_active, TRUE), COALESCE(must_change_password, FALSE),
+		` + r.d.dateDiff(r.d.now(), "COALESCE(password_changed_at, created_at)") + `, COALESCE(totp_enabled, FALSE), created_at FROM users WHERE username = ?`
+
+	var createdAt time.Time
+	err := r.q.QueryRow(query, username).Scan(&account.ID, &account.Name, &account.Password, &account.Role, &account.IsActive,
+		&account.MustChangePassword, &account.PasswordAgeDays, &account.TOTPEnabled, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	account.Created = createdAt.Format("2006-01-02 15:04:05")
+	return &account, nil
+}
+
+// LoadProfile fills in the name, ID number and contact details from the user's role table
+func (r *sqlUserRepository) LoadProfile(user *User) error {
+	var firstName, middleName, lastName sql.NullString
+	var idNumber, email, contactNumber, photoURL sql.NullString
+
+	var err error
+	switch user.Role {
+	case "admin":
+		query := `SELECT first_name, middle_name, last_name, employee_number, email, profile_photo FROM admins WHERE user_id = ?`
+		err = r.q.QueryRow(query, user.ID).Scan(&firstName, &middleName, &lastName, &idNumber, &email, &photoURL)
+	case "teacher":
+		query := `SELECT first_name, middle_name, last_name, employee_number, email, contact_number, profile_photo FROM teachers WHERE user_id = ?`
+		err = r.q.QueryRow(query, user.ID).Scan(&firstName, &middleName, &lastName, &idNumber, &email, &contactNumber, &photoURL)
+	case "student":
+		query := `SELECT first_name, middle_name, last_name, student_number, email, contact_number, profile_photo FROM students WHERE user_id = ? AND is_working_student = FALSE`
+		err = r.q.QueryRow(query, user.ID).Scan(&firstName, &middleName, &lastName, &idNumber, &email, &contactNumber, &photoURL)
+	case "working_student":
+		query := `SELECT first_name, middle_name, last_name, student_number, email, contact_number, profile_photo FROM students WHERE user_id = ? AND is_working_student = TRUE`
+		err = r.q.QueryRow(query, user.ID).Scan(&firstName, &middleName, &lastName, &idNumber, &email, &contactNumber, &photoURL)
+	default:
+		return fmt.Errorf("unknown role %q", user.Role)
+	}
+	if err != nil {
+		return err
+	}
+
+	user.FirstName = nullStringPtr(firstName)
+	user.MiddleName = nullStringPtr(middleName)
+	user.LastName = nullStringPtr(lastName)
+	if user.Role == "admin" || user.Role == "teacher" {
+		user.EmployeeID = nullStringPtr(idNumber)
+	} else {
+		user.StudentID = nullStringPtr(idNumber)
+	}
+	user.Email = nullStringPtr(email)
+	user.ContactNumber = nullStringPtr(contactNumber)
+	user.PhotoURL = nullStringPtr(photoURL)
+	return nil
+}
+
+// List returns users with complete details, newest first
+func (r *sqlUserRepository) List(filter userFilter) ([]User, error) {
+	query := `
+		SELECT
+			id, username, user_type, COALESCE(is_active, TRUE), created_at,
+			first_name, middle_name, last_name,
+			employee_number, student_number,
+			email, contact_number, department_code
+		FROM v_users_complete
+		WHERE 1 = 1
+	`
+	var args []interface{}
+	if filter.Search != "" {
+		query += ` AND (
+			username LIKE ? OR
+			first_name LIKE ? OR
+			last_name LIKE ? OR
+			middle_name LIKE ? OR
+			employee_number LIKE ? OR
+			student_number LIKE ? OR
+			DATE(created_at) LIKE ?
+		)`
+		searchPattern := "%" + filter.Search + "%"
+		for i := 0; i < 7; i++ {
+			args = append(args, searchPattern)
+		}
+	}
+	if filter.Role != "" {
+		query += ` AND user_type = ?`
+		args = append(args, filter.Role)
+	}
+	if clause, arg, ok := userStatusFilter(filter.Status); ok {
+		query += ` AND ` + clause
+		args = append(args, arg)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var user User
+		var createdAt time.Time
+		var firstName, middleName, lastName sql.NullString
+		var employeeID, studentID sql.NullString
+		var email, contactNumber, departmentCode sql.NullString
+
+		err := rows.Scan(&user.ID, &user.Name, &user.Role, &user.IsActive, &createdAt,
+			&firstName, &middleName, &lastName,
+			&employeeID, &studentID,
+			&email, &contactNumber, &departmentCode)
+		if err != nil {
+			continue
+		}
+
+		user.Created = createdAt.Format("2006-01-02 15:04:05")
+		user.FirstName = nullStringPtr(firstName)
+		user.MiddleName = nullStringPtr(middleName)
+		user.LastName = nullStringPtr(lastName)
+		user.EmployeeID = nullStringPtr(employeeID)
+		user.StudentID = nullStringPtr(studentID)
+		user.Email = nullStringPtr(email)
+		user.ContactNumber = nullStringPtr(contactNumber)
+		user.DepartmentCode = nullStringPtr(departmentCode)
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// Teachers returns every teacher ordered by name
+func (r *sqlUserRepository) Teachers() ([]User, error) {
+	query := `
+		SELECT
+			t.user_id, t.employee_number, t.first_name, t.middle_name, t.last_name
+		FROM teachers t
+		ORDER BY t.last_name, t.first_name
+	`
+
+	rows, err := r.q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var teachers []User
+	for rows.Next() {
+		var teacher User
+		var middleName, employeeID sql.NullString
+		err := rows.Scan(&teacher.ID, &employeeID, &teacher.FirstName, &middleName, &teacher.LastName)
+		if err != nil {
+			continue
+		}
+		teacher.MiddleName = nullStringPtr(middleName)
+		teacher.EmployeeID = nullStringPtr(employeeID)
+		teacher.Role = "teacher"
+		teachers = append(teachers, teacher)
+	}
+
+	return teachers, nil
+}
+
+// Create inserts a user and its role profile and returns the new user ID.
+// The initial password was chosen by someone else (often the student code),
+// so the user must replace it on first login.
+func (r *sqlUserRepository) Create(account userAccount) (int64, error) {
+	switch account.Role {
+	case "admin", "teacher", "student", "working_student":
+	default:
+		return 0, fmt.Errorf("invalid user role %q", account.Role)
+	}
+
+	query := `INSERT INTO users (username, password, user_type, must_change_password) VALUES (?, ?, ?, TRUE)`
+	result, err := r.q.Exec(query, account.Username, account.Password, account.Role)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user account: %w", err)
+	}
+	userID, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user account: %w", err)
+	}
+
+	switch account.Role {
+	case "admin":
+		query = `INSERT INTO admins (user_id, employee_number, first_name, middle_name, last_name, email) VALUES (?, ?, ?, ?, ?, ?)`
+		_, err = r.q.Exec(query, userID, nullString(account.EmployeeID), account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.Email))
+	case "teacher":
+		query = `INSERT INTO teachers (user_id, employee_number, first_name, middle_name, last_name, email, contact_number, department_code) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = r.q.Exec(query, userID, nullString(account.EmployeeID), account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.Email), nullString(account.ContactNumber), nullString(account.DepartmentCode))
+	case "student", "working_student":
+		query = `INSERT INTO students (user_id, student_number, first_name, middle_name, last_name, email, contact_number, is_working_student) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err = r.q.Exec(query, userID, nullString(account.StudentID), account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.Email), nullString(account.ContactNumber), account.Role == "working_student")
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s profile: %w", account.Role, err)
+	}
+	return userID, nil
+}
+
+// UpdateProfile updates the role table row of a user
+func (r *sqlUserRepository) UpdateProfile(id int, account userAccount) error {
+	var query string
+	var err error
+	switch account.Role {
+	case "admin":
+		query = `UPDATE admins SET first_name = ?, middle_name = ?, last_name = ?, employee_number = ?, email = ? WHERE user_id = ?`
+		_, err = r.q.Exec(query, account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.EmployeeID), nullString(account.Email), id)
+	case "teacher":
+		query = `UPDATE teachers SET first_name = ?, middle_name = ?, last_name = ?, employee_number = ?, email = ?, contact_number = ?, department_code = ? WHERE user_id = ?`
+		_, err = r.q.Exec(query, account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.EmployeeID), nullString(account.Email), nullString(account.ContactNumber), nullString(account.DepartmentCode), id)
+	case "student":
+		query = `UPDATE students SET first_name = ?, middle_name = ?, last_name = ?, student_number = ?, email = ?, contact_number = ? WHERE user_id = ? AND is_working_student = FALSE`
+		_, err = r.q.Exec(query, account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.StudentID), nullString(account.Email), nullString(account.ContactNumber), id)
+	case "working_student":
+		query = `UPDATE students SET first_name = ?, middle_name = ?, last_name = ?, student_number = ?, email = ?, contact_number = ? WHERE user_id = ? AND is_working_student = TRUE`
+		_, err = r.q.Exec(query, account.FirstName, nullString(account.MiddleName), account.LastName, nullString(account.StudentID), nullString(account.Email), nullString(account.ContactNumber), id)
+	}
+	return err
+}
+
+// UpdateContact updates only the email and contact number in the user's role table
+func (r *sqlUserRepository) UpdateContact(id int, role, email, contactNumber string) error {
+	var query string
+	var err error
+	switch role {
+	case "admin":
+		query = `UPDATE admins SET email = ? WHERE user_id = ?`
+		_, err = r.q.Exec(query, nullString(email), id)
+	case "teacher":
+		query = `UPDATE teachers SET email = ?, contact_number = ? WHERE user_id = ?`
+		_, err = r.q.Exec(query, nullString(email), nullString(contactNumber), id)
+	case "student", "working_student":
+		query = `UPDATE students SET email = ?, contact_number = ? WHERE user_id = ?`
+		_, err = r.q.Exec(query, nullString(email), nullString(contactNumber), id)
+	default:
+		return fmt.Errorf("invalid user role")
+	}
+	return err
+}
+
+// UpdatePhoto replaces the profile photo in the user's role table
+func (r *sqlUserRepository) UpdatePhoto(id int, role, photoURL string) error {
+	var query string
+	switch role {
+	case "admin":
+		query = `UPDATE admins SET profile_photo = ? WHERE user_id = ?`
+	case "teacher":
+		query = `UPDATE teachers SET profile_photo = ? WHERE user_id = ?`
+	case "student":
+		query = `UPDATE students SET profile_photo = ? WHERE user_id = ?`
+	case "working_student":
+		query = `UPDATE students SET profile_photo = ? WHERE user_id = ? AND is_working_student = TRUE`
+	default:
+		return fmt.Errorf("invalid user role")
+	}
+	_, err := r.q.Exec(query, photoURL, id)
+	return err
+}
+
+// Delete removes a user; the database cascades the deletion to its history
+func (r *sqlUserRepository) Delete(id int) error {
+	_, err := r.q.Exec(`DELETE FROM users WHERE id = ?`, id)
+	return err
+}
+
+// Exists reports whether a user with the ID exists
+func (r *sqlUserRepository) Exists(id int) (bool, error) {
+	var exists int
+	err := r.q.QueryRow(`SELECT 1 FROM users WHERE id = ?`, id).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// HasRole returns sql.ErrNoRows unless the user has a profile in the role's table
+func (r *sqlUserRepository) HasRole(id int, role string) error {
+	var query string
+	switch role {
+	case "teacher":
+		query = `SELECT 1 FROM teachers WHERE user_id = ?`
+	case "working_student":
+		query = `SELECT 1 FROM students WHERE user_id = ? AND is_working_student = TRUE`
+	default:
+		return fmt.Errorf("unknown role %q", role)
+	}
+	var exists int
+	return r.q.QueryRow(query, id).Scan(&exists)
+}
+
+// SetActive updates the is_active flag of a user
+func (r *sqlUserRepository) SetActive(id int, active bool) error {
+	_, err := r.q.Exec(`UPDATE users SET is_active = ? WHERE id = ?`, active, id)
+	return err
+}
+
+// HistoryCount counts the records that would be cascaded away by deleting a user
+func (r *sqlUserRepository) HistoryCount(id int) (int, error) {
+	var count int
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM attendance WHERE student_user_id = ?) +
+			(SELECT COUNT(*) FROM classlist WHERE student_user_id = ?) +
+			(SELECT COUNT(*) FROM feedback WHERE student_user_id = ?) +
+			(SELECT COUNT(*) FROM classes WHERE teacher_user_id = ?) +
+			(SELECT COUNT(*) FROM login_logs WHERE user_id = ?) +
+			(SELECT COUNT(*) FROM audit_log WHERE actor_user_id = ?)
+	`
+	err := r.q.QueryRow(query, id, id, id, id, id, id).Scan(&count)
+	return count, err
+}
+
+// ChainedCount counts the login logs and attendance rows in the integrity chain
+// that deleting a user would cascade away, including the attendance of the
+// classes they teach
+func (r *sqlUserRepository) ChainedCount(id int) (int, error) {
+	var count int
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM login_logs l
+			 WHERE l.user_id = ?
+				AND EXISTS (SELECT 1 FROM integrity_chain ic WHERE ic.entity_type = 'login_log' AND ic.entity_key = l.id)) +
+			(SELECT COUNT(*) FROM attendance at
+			 WHERE (at.student_user_id = ? OR at.class_id IN (SELECT class_id FROM classes WHERE teacher_user_id = ?))
+				AND EXISTS (
+					SELECT 1 FROM integrity_chain ic
+					WHERE ic.entity_type = 'attendance'
+						AND ic.entity_key = CONCAT(at.class_id, '/', at.student_user_id, '/', DATE(at.date))))
+	`
+	err := r.q.QueryRow(query, id, id, id).Scan(&count)
+	return count, err
+}
+
+// PasswordForChange returns the stored password and, for students, the student number
+func (r *sqlUserRepository) PasswordForChange(username string) (string, string, error) {
+	var currentPassword string
+	var studentNumber sql.NullString
+	query := `SELECT u.password, s.student_number FROM users u LEFT JOIN students s ON s.user_id = u.id WHERE u.username = ?`
+	err := r.q.QueryRow(query, username).Scan(&currentPassword, &studentNumber)
+	return currentPassword, studentNumber.String, err
+}
+
+// SetPassword stores a password the user chose themselves
+func (r *sqlUserRepository) SetPassword(username, hashed string) error {
+	query := `UPDATE users SET password = ?, password_changed_at = ` + r.d.now() + `, must_change_password = FALSE WHERE username = ?`
+	_, err := r.q.Exec(query, hashed, username)
+	return err
+}
+
+// ReplacePassword swaps the stored password only if it still equals oldStored,
+// so a concurrent password change is never overwritten
+func (r *sqlUserRepository) ReplacePassword(id int, oldStored, hashed string) error {
+	_, err := r.q.Exec(`UPDATE users SET password = ? WHERE id = ? AND password = ?`, hashed, id, oldStored)
+	return err
+}
+
+// ResetPassword stores a temporary password issued by an admin, forcing a change
+// on next login and clearing any lockout
+func (r *sqlUserRepository) ResetPassword(id int, hashed string, resetBy int) error {
+	query := `
+		UPDATE users
+		SET password = ?, must_change_password = TRUE, password_changed_at = ` + r.d.now() + `,
+			password_reset_by = ?, password_reset_at = ` + r.d.now() + `,
+			failed_attempts = 0, account_locked_until = NULL, failures_reset_at = ` + r.d.now() + `
+		WHERE id = ?
+	`
+	_, err := r.q.Exec(query, hashed, resetBy, id)
+	return err
+}
+
+// Username returns the username of a user
+func (r *sqlUserRepository) Username(id int) (string, error) {
+	var username string
+	err := r.q.QueryRow(`SELECT username FROM users WHERE id = ?`, id).Scan(&username)
+	return username, err
+}
+
+// ClassStudentAccounts returns the accounts of the students actively enrolled in a class, by name
+func (r *sqlUserRepository) ClassStudentAccounts(classID int) ([]credentialSlip, error) {
+	query := `
+		SELECT u.id, u.username, CONCAT(s.first_name, ' ', s.last_name)
+		FROM classlist cl
+		JOIN users u ON u.id = cl.student_user_id
+		JOIN students s ON s.user_id = u.id
+		WHERE cl.class_id = ? AND cl.status = 'active'
+		ORDER BY s.last_name, s.first_name
+	`
+	return r.studentAccounts(query, classID)
+}
+
+// SectionStudentAccounts returns the accounts of the students actively enrolled in
+// a class of the section, and of the year level unless it is empty, by name
+func (r *sqlUserRepository) SectionStudentAccounts(yearLevel, section string) ([]credentialSlip, error) {
+	query := `
+		SELECT u.id, u.username, CONCAT(s.first_name, ' ', s.last_name)
+		FROM users u
+		JOIN students s ON s.user_id = u.id
+		WHERE u.id IN (
+			SELECT cl.student_user_id
+			FROM classes c
+			JOIN classlist cl ON cl.class_id = c.class_id AND cl.status = 'active'
+			WHERE c.is_active = TRUE AND c.section = ? AND (? = '' OR c.year_level = ?)
+		)
+		ORDER BY s.last_name, s.first_name
+	`
+	return r.studentAccounts(query, section, yearLevel, yearLevel)
+}
+
+// studentAccounts scans the id, username and full name rows of query
+func (r *sqlUserRepository) studentAccounts(query string, args ...interface{}) ([]credentialSlip, error) {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []credentialSlip
+	for rows.Next() {
+		var account credentialSlip
+		if err := rows.Scan(&account.UserID, &account.Username, &account.FullName); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// TOTPSecret returns a user's TOTP secret and last used time step, and the database's
+// current Unix time. With enabled false it reads a secret still being enrolled.
+func (r *sqlUserRepository) TOTPSecret(id int, enabled bool) (secret sql.NullString, lastStep sql.NullInt64, now int64, err error) {
+	err = r.q.QueryRow(`
+		SELECT totp_secret, totp_last_step, `+r.d.unixTimestamp()+`
+		FROM users WHERE id = ? AND COALESCE(totp_enabled, FALSE) = ?
+	`, id, enabled).Scan(&secret, &lastStep, &now)
+	return secret, lastStep, now, err
+}
+
+// ClaimTOTPStep records a time step as used unless it or a later one already was.
+// The conditional update keeps two PCs racing with the same code from both succeeding.
+func (r *sqlUserRepository) ClaimTOTPStep(id int, step int64) (bool, error) {
+	result, err := r.q.Exec(`UPDATE users SET totp_last_step = ? WHERE id = ? AND (totp_last_step IS NULL OR totp_last_step < ?)`,
+		step, id, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode marks an unused recovery code as used and reports whether there was one
+func (r *sqlUserRepository) UseRecoveryCode(id int, codeHash string) (bool, error) {
+	result, err := r.q.Exec(`
+		UPDATE totp_recovery_codes SET used_at = `+r.d.now()+`
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, id, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// TwoFactorStatus returns whether two-factor is enabled and how many recovery codes are unused
+func (r *sqlUserRepository) TwoFactorStatus(id int) (bool, int, error) {
+	var enabled bool
+	var remaining int
+	err := r.q.QueryRow(`
+		SELECT COALESCE(u.totp_enabled, FALSE),
+			(SELECT COUNT(*) FROM totp_recovery_codes rc WHERE rc.user_id = u.id AND rc.used_at IS NULL)
+		FROM users u WHERE u.id = ?
+	`, id).Scan(&enabled, &remaining)
+	return enabled, remaining, err
+}
+
+// SetTOTPSecret stores a new secret that stays disabled until enrollment is confirmed
+func (r *sqlUserRepository) SetTOTPSecret(id int, secret string) error {
+	_, err := r.q.Exec(`UPDATE users SET totp_secret = ?, totp_enabled = FALSE, totp_last_step = NULL WHERE id = ?`, secret, id)
+	return err
+}
+
+// EnableTwoFactor turns on the enrolled secret
+func (r *sqlUserRepository) EnableTwoFactor(id int) error {
+	_, err := r.q.Exec(`UPDATE users SET totp_enabled = TRUE WHERE id = ?`, id)
+	return err
+}
+
+// ClearTwoFactor removes a user's secret and recovery codes
+func (r *sqlUserRepository) ClearTwoFactor(id int) error {
+	if _, err := r.q.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled = FALSE, totp_last_step = NULL WHERE id = ?`, id); err != nil {
+		return err
+	}
+	_, err := r.q.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = ?`, id)
+	return err
+}
+
+// ReplaceRecoveryCodes discards a user's recovery codes and stores the new hashes
+func (r *sqlUserRepository) ReplaceRecoveryCodes(id int, codeHashes []string) error {
+	if _, err := r.q.Exec(`DELETE FROM totp_recovery_codes WHERE user_id = ?`, id); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := r.q.Exec(`INSERT INTO totp_recovery_codes (user_id, code_hash) VALUES (?, ?)`, id, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LockState returns the lockout time and whether it is still in the future
+func (r *sqlUserRepository) LockState(id int) (sql.NullTime, bool, error) {
+	var lockedUntil sql.NullTime
+	var isLocked bool
+	query := `SELECT account_locked_until, COALESCE(account_locked_until > ` + r.d.now() + `, FALSE) FROM users WHERE id = ?`
+	err := r.q.QueryRow(query, id).Scan(&lockedUntil, &isLocked)
+	return lockedUntil, isLocked, err
+}
+
+// CountFailedLogin stores and returns the number of failed logins within the
+// window that came after the last successful login, lock or unlock
+func (r *sqlUserRepository) CountFailedLogin(id, windowMinutes int) (int, error) {
+	_, err := r.q.Exec(`
+		UPDATE users
+		SET failed_attempts = (
+			SELECT COUNT(*) FROM login_logs
+			WHERE login_logs.user_id = users.id AND login_logs.login_status = 'failed'
+				AND login_logs.login_time >= `+r.d.dateSub(r.d.now(), "?", "minute")+`
+				AND (users.failures_reset_at IS NULL OR login_logs.login_time > users.failures_reset_at)
+		)
+		WHERE id = ?
+	`, windowMinutes, id)
+	if err != nil {
+		return 0, err
+	}
+
+	var failures int
+	err = r.q.QueryRow(`SELECT COALESCE(failed_attempts, 0) FROM users WHERE id = ?`, id).Scan(&failures)
+	return failures, err
+}
+
+// Lock locks the account for the given number of minutes from now. The failures
+// that caused it no longer count once it expires.
+func (r *sqlUserRepository) Lock(id, minutes int) error {
+	_, err := r.q.Exec(`
+		UPDATE users
+		SET account_locked_until = `+r.d.dateAdd(r.d.now(), "?", "minute")+`,
+			failed_attempts = 0, failures_reset_at = `+r.d.now()+`
+		WHERE id = ?
+	`, minutes, id)
+	return err
+}
+
+// Unlock clears the failure count and any lock
+func (r *sqlUserRepository) Unlock(id int) error {
+	_, err := r.q.Exec(`UPDATE users SET failed_attempts = 0, account_locked_until = NULL, failures_reset_at = `+r.d.now()+` WHERE id = ?`, id)
+	return err
+}
+
+// ClearExpiredLock clears a lock that has run out. The failures before it already
+// stopped counting when it was set, so the reset time is kept.
+func (r *sqlUserRepository) ClearExpiredLock(id int) error {
+	_, err := r.q.Exec(`UPDATE users SET failed_attempts = 0, account_locked_until = NULL WHERE id = ?`, id)
+	return err
+}
+
+// RecordLogin clears the failure count and lock and stamps the last login
+func (r *sqlUserRepository) RecordLogin(id int) error {
+	_, err := r.q.Exec(`
+		UPDATE users
+		SET failed_attempts = 0, account_locked_until = NULL, failures_reset_at = `+r.d.now()+`, last_login_at = `+r.d.now()+`
+		WHERE id = ?
+	`, id)
+	return err
+}
+
+// CountByRole counts students, teachers and working students for the admin dashboard
+func (r *sqlUserRepository) CountByRole() (AdminDashboard, error) {
+	var dashboard AdminDashboard
+	err := r.q.QueryRow(`
+		SELECT
+			(SELECT COUNT(*) FROM students),
+			(SELECT COUNT(*) FROM teachers),
+			(SELECT COUNT(*) FROM students WHERE is_working_student = TRUE)
+	`).Scan(&dashboard.TotalStudents, &dashboard.TotalTeachers, &dashboard.WorkingStudents)
+	return dashboard, err
+}
+
+// AdminCount counts the admin accounts, active or not
+func (r *sqlUserRepository) AdminCount() (int, error) {
+	var count int
+	err := r.q.QueryRow(`SELECT COUNT(*) FROM users WHERE user_type = 'admin'`).Scan(&count)
+	return count, err
+}
+
+// StudentCount returns the number of student accounts, working students included
+func (r *sqlUserRepository) StudentCount() (int, error) {
+	var count int
+	err := r.q.QueryRow(`SELECT COUNT(*) FROM students`).Scan(&count)
+	return count, err
+}
+
+// userStatusFilter returns the WHERE clause for an account status filter
+func userStatusFilter(status string) (string, interface{}, bool) {
+	switch status {
+	case "active":
+		return `COALESCE(is_active, TRUE) = ?`, true, true
+	case "inactive":
+		return `COALESCE(is_active, TRUE) = ?`, false, true
+	default:
+		return "", nil, false
+	}
+}
+
+// nullStringPtr converts a scanned sql.NullString to the *string used by the JSON structs
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// CLASS SCHEDULES
+// ==============================================================================
+//
+// A class meets at fixed weekly times kept in class_meetings, one row per
+// weekday and time range. classes.schedule only holds a summary such as
+// "MWF 1:00 PM-2:00 PM" for display, written from the meetings.
+//
+// parseSchedule reads the free-text schedules entered before meetings existed.
+// It is used once by migration 9 to convert them, and by ParseClassSchedule so
+// the class forms can still take typed schedules. A schedule it cannot read is
+// left without meetings; GetClassesWithoutMeetings lists those classes.
+//
+// classesInSession is the one place that decides which class a login at a given
+// time belongs to. It only considers the meetings the academic calendar holds
+// that day.
+
+// ClassMeeting is one weekly meeting of a class
+type ClassMeeting struct {
+	Weekday   int    `json:"weekday"`        // 0 = Sunday ... 6 = Saturday
+	StartTime string `json:"start_time"`     // HH:MM, 24-hour
+	EndTime   string `json:"end_time"`       // HH:MM, 24-hour
+	Room      string `json:"room,omitempty"` // Empty uses the class room
+}
+
+// dayTokens are the day names accepted in typed schedules, longest first so
+// "TH" is read before "T"
+var dayTokens = []struct {
+	token   string
+	weekday time.Weekday
+}{
+	{"WEDNESDAY", time.Wednesday}, {"THURSDAY", time.Thursday}, {"SATURDAY", time.Saturday},
+	{"TUESDAY", time.Tuesday}, {"MONDAY", time.Monday}, {"FRIDAY", time.Friday}, {"SUNDAY", time.Sunday},
+	{"THURS", time.Thursday}, {"THUR", time.Thursday}, {"TUES", time.Tuesday},
+	{"MON", time.Monday}, {"TUE", time.Tuesday}, {"WED", time.Wednesday}, {"THU", time.Thursday},
+	{"FRI", time.Friday}, {"SAT", time.Saturday}, {"SUN", time.Sunday},
+	{"TH", time.Thursday}, {"TU", time.Tuesday}, {"SA", time.Saturday}, {"SU", time.Sunday},
+	{"M", time.Monday}, {"T", time.Tuesday}, {"W", time.Wednesday}, {"F", time.Friday},
+}
+
+// dayCodes are the day abbreviations written in schedule summaries
+var dayCodes = map[int]string{0: "Su", 1: "M", 2: "T", 3: "W", 4: "Th", 5: "F", 6: "Sa"}
+
+// scheduleTimePattern matches a time range such as "1:00-2:30 PM", "7:30 AM - 9 AM" or "13:00-14:00"
+var scheduleTimePattern = regexp.MustCompile(`(\d{1,2})(?::(\d{2}))?\s*(AM|PM)?\s*(?:-|TO)\s*(\d{1,2})(?::(\d{2}))?\s*(AM|PM)?`)
+
+// parseSchedule reads a typed schedule such as "MWF 1:00-2:00 PM; TTh 10:00-11:30 AM"
+func parseSchedule(schedule string) ([]ClassMeeting, error) {
+	text := strings.ToUpper(strings.TrimSpace(schedule))
+	text = strings.NewReplacer("–", "-", "—", "-", ".", "").Replace(text)
+	if text == "" {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+
+	// Parts without a time, as in "Mon, Wed, Fri 8:00-9:00 AM", add their days to the next part
+	var meetings []ClassMeeting
+	var pending string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ';' || r == ',' }) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		loc := scheduleTimePattern.FindStringSubmatchIndex(part)
+		if loc == nil {
+			pending += part + " "
+			continue
+		}
+		match := make([]string, len(loc)/2)
+		for i := range match {
+			if loc[2*i] >= 0 {
+				match[i] = part[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		start, end, err := parseTimeRange(match)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+		days, err := parseDays(pending + part[:loc[0]] + " " + part[loc[1]:])
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", part, err)
+		}
+		pending = ""
+		for _, day := range days {
+			meetings = append(meetings, ClassMeeting{Weekday: int(day), StartTime: start, EndTime: end})
+		}
+	}
+	if pending != "" {
+		return nil, fmt.Errorf("no time range in %q", strings.TrimSpace(pending))
+	}
+	if len(meetings) == 0 {
+		return nil, fmt.Errorf("schedule is empty")
+	}
+	return normalizeMeetings(meetings)
+}
+
+// parseTimeRange converts a scheduleTimePattern match to HH:MM start and end
+// times. A missing AM/PM is taken from the other time; with neither, hours 1
+// to 6 are read as afternoon, since no class starts that early.
+func parseTimeRange(match []string) (string, string, error) {
+	startHour, _ := strconv.Atoi(match[1])
+	startMin, _ := strconv.Atoi(match[2])
+	startPeriod := match[3]
+	endHour, _ := strconv.Atoi(match[4])
+	endMin, _ := strconv.Atoi(match[5])
+	endPeriod := match[6]
+
+	clock := func(hour, min int, period string) (int, error) {
+		if min > 59 {
+			return 0, fmt.Errorf("invalid minutes %d", min)
+		}
+		switch period {
+		case "AM", "PM":
+			if hour < 1 || hour > 12 {
+				return 0, fmt.Errorf("invalid hour %d %s", hour, period)
+			}
+			hour %= 12
+			if period == "PM" {
+				hour += 12
+			}
+		default:
+			if hour > 23 {
+				return 0, fmt.Errorf("invalid hour %d", hour)
+			}
+			if hour >= 1 && hour <= 6 {
+				hour += 12
+			}
+		}
+		return hour*60 + min, nil
+	}
+
+	// Minutes past twelve, to tell whether a range crosses noon
+	pastTwelve := func(hour, min int) int { return hour%12*60 + min }
+	if startPeriod == "" && endPeriod != "" {
+		startPeriod = endPeriod
+		if endPeriod == "PM" && pastTwelve(startHour, startMin) > pastTwelve(endHour, endMin) {
+			startPeriod = "AM" // 11:00-1:00 PM
+		}
+	} else if endPeriod == "" && startPeriod != "" {
+		endPeriod = startPeriod
+		if startPeriod == "AM" && pastTwelve(endHour, endMin) <= pastTwelve(startHour, startMin) {
+			endPeriod = "PM" // 11:00 AM-1:00
+		}
+	}
+
+	start, err := clock(startHour, startMin, startPeriod)
+	if err != nil {
+		return "", "", err
+	}
+	end, err := clock(endHour, endMin, endPeriod)
+	if err != nil {
+		return "", "", err
+	}
+	if end <= start {
+		return "", "", fmt.Errorf("class ends before it starts")
+	}
+	return minutesClock(start), minutesClock(end), nil
+}
+
+// parseDays reads the days of a schedule part, such as "MWF", "TTh", "Mon-Wed-Fri"
+// or "M-F". A dash between two days more than two apart is a range; otherwise
+// dashes separate days, as in "Tu-Th". A second bare "T" means Thursday ("MTWTF").
+func parseDays(text string) ([]time.Weekday, error) {
+	text = strings.NewReplacer(" ", "", "/", "", "&", "").Replace(text)
+	if text == "" {
+		return nil, fmt.Errorf("no days given")
+	}
+
+	var days []time.Weekday
+	pieces := strings.Split(text, "-")
+	for _, piece := range pieces {
+		for rest := piece; rest != ""; {
+			found := false
+			for _, d := range dayTokens {
+				if !strings.HasPrefix(rest, d.token) {
+					continue
+				}
+				day := d.weekday
+				if d.token == "T" && containsWeekday(days, time.Tuesday) {
+					day = time.Thursday
+				}
+				days = append(days, day)
+				rest = rest[len(d.token):]
+				found = true
+				break
+			}
+			if !found {
+				return nil, fmt.Errorf("unrecognised days %q", text)
+			}
+		}
+	}
+
+	if len(pieces) == 2 && len(days) == 2 {
+		if span := (int(days[1]) - int(days[0]) + 7) % 7; span > 2 {
+			days = days[:1]
+			for i := 1; i <= span; i++ {
+				days = append(days, time.Weekday((int(days[0])+i)%7))
+			}
+		}
+	}
+	return days, nil
+}
+
+// containsWeekday reports whether days includes day
+func containsWeekday(days []time.Weekday, day time.Weekday) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// clockMinutes parses an HH:MM or HH:MM:SS time into minutes after midnight
+func clockMinutes(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		if t, err = time.Parse("15:04:05", clock); err != nil {
+			return 0, fmt.Errorf("invalid time %q", clock)
+		}
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// minutesClock formats minutes after midnight as HH:MM
+func minutesClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// normalizeMeetings validates meetings, writes their times as HH:MM and sorts
+// them from Monday to Sunday. Meetings on the same day may not overlap.
+func normalizeMeetings(meetings []ClassMeeting) ([]ClassMeeting, error) {
+	normalized := make([]ClassMeeting, 0, len(meetings))
+	for _, m := range meetings {
+		if m.Weekday < 0 || m.Weekday > 6 {
+			return nil, fmt.Errorf("invalid weekday %d", m.Weekday)
+		}
+		start, err := clockMinutes(strings.TrimSpace(m.StartTime))
+		if err != nil {
+			return nil, err
+		}
+		end, err := clockMinutes(strings.TrimSpace(m.EndTime))
+		if err != nil {
+			return nil, err
+		}
+		if end <= start {
+			return nil, fmt.Errorf("%s meeting ends before it starts", time.Weekday(m.Weekday))
+		}
+		normalized = append(normalized, ClassMeeting{
+			Weekday:   m.Weekday,
+			StartTime: minutesClock(start),
+			EndTime:   minutesClock(end),
+			Room:      strings.TrimSpace(m.Room),
+		})
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		a, b := (normalized[i].Weekday+6)%7, (normalized[j].Weekday+6)%7
+		if a != b {
+			return a < b
+		}
+		return normalized[i].StartTime < normalized[j].StartTime
+	})
+	for i := 1; i < len(normalized); i++ {
+		prev, m := normalized[i-1], normalized[i]
+		if prev.Weekday == m.Weekday && m.StartTime < prev.EndTime {
+			return nil, fmt.Errorf("%s meetings %s-%s and %s-%s overlap", time.Weekday(m.Weekday), prev.StartTime, prev.EndTime, m.StartTime, m.EndTime)
+		}
+	}
+	return normalized, nil
+}
+
+// formatMeetings writes the display summary of normalized meetings, grouping
+// days that meet at the same time: "MWF 1:00 PM-2:00 PM; TTh 10:00 AM-11:30 AM"
+func formatMeetings(meetings []ClassMeeting) string {
+	var order []string
+	days := make(map[string]string)
+	for _, m := range meetings {
+		start, _ := time.Parse("15:04", m.StartTime)
+		end, _ := time.Parse("15:04", m.EndTime)
+		times := start.Format("3:04 PM") + "-" + end.Format("3:04 PM")
+		if _, ok := days[times]; !ok {
+			order = append(order, times)
+		}
+		days[times] += dayCodes[m.Weekday]
+	}
+
+	parts := make([]string, len(order))
+	for i, times := range order {
+		parts[i] = days[times] + " " + times
+	}
+	summary := strings.Join(parts, "; ")
+	if len(summary) > 100 {
+		summary = summary[:97] + "..."
+	}
+	return summary
+}
+
+// meetingInSession returns the meeting a login at t counts towards under policy
+func meetingInSession(meetings []ClassMeeting, t time.Time, policy AttendancePolicy) (ClassMeeting, bool) {
+	now := t.Hour()*60 + t.Minute()
+	for _, m := range meetings {
+		if m.Weekday != int(t.Weekday()) {
+			continue
+		}
+		from, until, err := policy.window(m)
+		if err != nil {
+			continue
+		}
+		if now >= from && now <= until {
+			return m, true
+		}
+	}
+	return ClassMeeting{}, false
+}
+
+// classSession is a class meeting a login counts towards, under the class's rules
+type classSession struct {
+	ClassID int
+	Meeting ClassMeeting
+	Policy  AttendancePolicy
+}
+
+// status returns the attendance status of a login at t, and its remark
+func (s classSession) status(t time.Time, unregisteredPC bool) (string, sql.NullString) {
+	status, minutesLate := s.Policy.arrival(s.Meeting, t)
+	return status, arrivalRemark(status, minutesLate, unregisteredPC)
+}
+
+// classesInSession returns the classes among classIDs with a meeting a login at t counts towards
+func (a *App) classesInSession(classIDs []int, t time.Time) ([]classSession, error) {
+	if len(classIDs) == 0 {
+		return nil, nil
+	}
+	calendar, err := a.loadCalendar(classIDs, t, t)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := a.classPolicies(classIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var inSession []classSession
+	for _, classID := range classIDs {
+		if m, ok := meetingInSession(calendar.day(classID, t).Meetings, t, policies[classID]); ok {
+			inSession = append(inSession, classSession{ClassID: classID, Meeting: m, Policy: policies[classID]})
+		}
+	}
+	return inSession, nil
+}
+
+// convertClassSchedules is the step of migration 9. It parses every class
+// schedule into meetings and logs the ones it cannot read.
+func convertClassSchedules(conn *sql.Conn) error {
+	ctx := context.Background()
+	rows, err := conn.QueryContext(ctx, `SELECT class_id, schedule FROM classes WHERE schedule IS NOT NULL AND schedule <> ''`)
+	if err != nil {
+		return err
+	}
+	type classSchedule struct {
+		id       int
+		schedule string
+	}
+	var schedules []classSchedule
+	for rows.Next() {
+		var s classSchedule
+		if err := rows.Scan(&s.id, &s.schedule); err != nil {
+			rows.Close()
+			return err
+		}
+		schedules = append(schedules, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	converted := 0
+	for _, s := range schedules {
+		meetings, err := parseSchedule(s.schedule)
+		if err != nil {
+			log.Printf("⚠ Class %d schedule %q was not converted: %v", s.id, s.schedule, err)
+			continue
+		}
+		for _, m := range meetings {
+			_, err := conn.ExecContext(ctx, `INSERT INTO class_meetings (class_id, weekday, start_time, end_time) VALUES (?, ?, ?, ?)`,
+				s.id, m.Weekday, m.StartTime+":00", m.EndTime+":00")
+			if err != nil {
+				return fmt.Errorf("failed to convert schedule of class %d: %w", s.id, err)
+			}
+		}
+		converted++
+	}
+	if converted < len(schedules) {
+		log.Printf("⚠ %d of %d class schedules could not be converted; set their meetings in the class form", len(schedules)-converted, len(schedules))
+	} else if converted > 0 {
+		log.Printf("✓ Converted %d class schedules to meetings", converted)
+	}
+	return nil
+}
+
+// ParseClassSchedule reads a typed schedule such as "MWF 1:00-2:00 PM" into meetings
+func (a *App) ParseClassSchedule(schedule string) ([]ClassMeeting, error) {
+	if _, err := a.authorize(PermManageClasses); err != nil {
+		return nil, err
+	}
+	return parseSchedule(schedule)
+}
+
+// GetClassesWithoutMeetings lists active classes whose typed schedule could not
+// be converted to meetings
+func (a *App) GetClassesWithoutMeetings() ([]CourseClass, error) {
+	if _, err := a.authorize(PermManageClasses); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	return a.store.Classes.WithoutMeetings()
+}
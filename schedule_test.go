@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// monday is a school day used across the attendance tests, 2 March 2026
+var monday = time.Date(2026, 3, 2, 0, 0, 0, 0, time.Local)
+
+// at returns the time hh:mm on day
+func at(day time.Time, hour, minute int) time.Time {
+	return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		schedule string
+		want     []ClassMeeting
+	}{
+		{"MWF 1:00-2:00 PM", []ClassMeeting{{1, "13:00", "14:00", ""}, {3, "13:00", "14:00", ""}, {5, "13:00", "14:00", ""}}},
+		{"TTh 10:00-11:30 AM", []ClassMeeting{{2, "10:00", "11:30", ""}, {4, "10:00", "11:30", ""}}},
+		{"Tu-Th 7:30 AM - 9 AM", []ClassMeeting{{2, "07:30", "09:00", ""}, {4, "07:30", "09:00", ""}}},
+		// Days two apart are a list, further apart a range
+		{"M-W 13:00-14:00", []ClassMeeting{{1, "13:00", "14:00", ""}, {3, "13:00", "14:00", ""}}},
+		{"M-Th 13:00-14:00", []ClassMeeting{{1, "13:00", "14:00", ""}, {2, "13:00", "14:00", ""}, {3, "13:00", "14:00", ""}, {4, "13:00", "14:00", ""}}},
+		{"Sat 11:30-1:00 PM; M 8-9 AM", []ClassMeeting{{1, "08:00", "09:00", ""}, {6, "11:30", "13:00", ""}}},
+	}
+	for _, tt := range tests {
+		got, err := parseSchedule(tt.schedule)
+		if err != nil {
+			t.Errorf("%q: %v", tt.schedule, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%q = %v, want %v", tt.schedule, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%q = %v, want %v", tt.schedule, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, schedule := range []string{"TBA", "MWF", "10:00-11:00"} {
+		if meetings, err := parseSchedule(schedule); err == nil {
+			t.Errorf("%q read as %v", schedule, meetings)
+		}
+	}
+}
+
+func TestClockMinutes(t *testing.T) {
+	for clock, want := range map[string]int{"00:00": 0, "08:30": 510, "08:30:59": 510, "23:59": 1439} {
+		if got, err := clockMinutes(clock); err != nil || got != want {
+			t.Errorf("clockMinutes(%q) = %d, %v; want %d", clock, got, err, want)
+		}
+	}
+	for _, clock := range []string{"", "8.30", "24:00", "noon"} {
+		if got, err := clockMinutes(clock); err == nil {
+			t.Errorf("clockMinutes(%q) = %d, want an error", clock, got)
+		}
+	}
+}
+
+func TestNormalizeMeetings(t *testing.T) {
+	got, err := normalizeMeetings([]ClassMeeting{
+		{Weekday: 0, StartTime: "9:00", EndTime: "10:00"},
+		{Weekday: 3, StartTime: "13:00:00", EndTime: "14:30:00", Room: " Lab 2 "},
+		{Weekday: 1, StartTime: "08:00", EndTime: "09:00"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ClassMeeting{{1, "08:00", "09:00", ""}, {3, "13:00", "14:30", "Lab 2"}, {0, "09:00", "10:00", ""}}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("normalized = %v, want %v", got, want)
+		}
+	}
+	if summary := formatMeetings(want); summary != "M 8:00 AM-9:00 AM; W 1:00 PM-2:30 PM; Su 9:00 AM-10:00 AM" {
+		t.Errorf("summary = %q", summary)
+	}
+
+	bad := [][]ClassMeeting{
+		{{Weekday: 7, StartTime: "08:00", EndTime: "09:00"}},
+		{{Weekday: 1, StartTime: "09:00", EndTime: "08:00"}},
+		{{Weekday: 1, StartTime: "08:00", EndTime: "09:00"}, {Weekday: 1, StartTime: "08:30", EndTime: "10:00"}},
+	}
+	for _, meetings := range bad {
+		if _, err := normalizeMeetings(meetings); err == nil {
+			t.Errorf("%v accepted", meetings)
+		}
+	}
+}
+
+func TestClassesInSession(t *testing.T) {
+	a := newTestApp(t)
+	seedUser(t, a, 2, "teacher1", "Teach#123", "teacher")
+	seedClass(t, a, 10, 2)
+	seedClass(t, a, 11, 2)
+	seedMeeting(t, a, 10, 1, "08:00", "09:00")
+	seedMeeting(t, a, 11, 1, "09:30", "11:00")
+	seedMeeting(t, a, 11, 3, "08:00", "09:00")
+	early := GetAttendancePolicy().EarlyMinutes
+
+	tests := []struct {
+		at   time.Time
+		want []int
+	}{
+		{at(monday, 8, -early-1), nil},
+		{at(monday, 8, -early), []int{10}},
+		{at(monday, 8, 45), []int{10}},
+		// The early window of the next meeting overlaps the end of the first
+		{at(monday, 9, 0), []int{10, 11}},
+		{at(monday, 9, 1), []int{11}},
+		{at(monday, 11, 1), nil},
+		{at(monday.AddDate(0, 0, 1), 8, 30), nil},
+		{at(monday.AddDate(0, 0, 2), 8, 30), []int{11}},
+	}
+	for _, tt := range tests {
+		classes, err := a.classesInSession([]int{10, 11}, tt.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []int
+		for _, c := range classes {
+			got = append(got, c.ClassID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("classes in session on %s = %v, want %v", tt.at.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
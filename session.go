@@ -0,0 +1,327 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ==============================================================================
+// SESSIONS & AUTHORIZATION
+// ==============================================================================
+//
+// Each running app instance serves a single lab seat, so the signed-in user is
+// kept server-side in the App struct. Bound methods never trust the user IDs the
+// frontend passes in; they check the active session against the permission
+// matrix below before touching the database.
+
+// Session represents the signed-in user of this app instance
+type Session struct {
+	Token      string
+	UserID     int
+	Username   string
+	Role       string
+	LoginLogID int
+	IssuedAt   time.Time
+
+	// MustChangePassword blocks every bound method except ChangePassword and
+	// Logout until the user has replaced a temporary or expired password
+	MustChangePassword bool
+}
+
+// Permission names an action guarded by the role permission matrix
+type Permission string
+
+const (
+	PermManageUsers          Permission = "manage_users"           // Create/update/delete any account, view user lists
+	PermRegisterStudents     Permission = "register_students"      // Create student accounts (single and bulk)
+	PermManageDepartments    Permission = "manage_departments"     // Create/update/delete departments
+	PermViewAllLogs          Permission = "view_all_logs"          // View and export everyone's login logs
+	PermViewRecords          Permission = "view_records"           // View another user's logs, feedback, classes and dashboard
+	PermReviewFeedback       Permission = "review_feedback"        // View and export forwarded feedback
+	PermTriageFeedback       Permission = "triage_feedback"        // View pending feedback and forward it to admins
+	PermManageClasses        Permission = "manage_classes"         // Subjects, classes and enrollment
+	PermManageAttendance     Permission = "manage_attendance"      // Record, edit, generate and export attendance
+	PermViewAdminDashboard   Permission = "view_admin_dashboard"   // Admin statistics
+	PermViewWorkingDashboard Permission = "view_working_dashboard" // Working student statistics
+	PermViewAuditLog         Permission = "view_audit_log"         // View and export the audit trail
+	PermManageComputers      Permission = "manage_computers"       // Approve and name lab PCs
+	PermManageSettings       Permission = "manage_settings"        // View and change application settings
+	PermManageCalendar       Permission = "manage_calendar"        // Academic terms, holidays, suspensions and make-up days
+)
+
+// rolePermissions is the role permission matrix
+var rolePermissions = map[Permission][]string{
+	PermManageUsers:          {"admin"},
+	PermRegisterStudents:     {"admin", "working_student"},
+	PermManageDepartments:    {"admin"},
+	PermViewAllLogs:          {"admin"},
+	PermViewRecords:          {"admin", "teacher", "working_student"},
+	PermReviewFeedback:       {"admin"},
+	PermTriageFeedback:       {"admin", "working_student"},
+	PermManageClasses:        {"admin", "teacher", "working_student"},
+	PermManageAttendance:     {"admin", "teacher"},
+	PermViewAdminDashboard:   {"admin"},
+	PermViewWorkingDashboard: {"admin", "working_student"},
+	PermViewAuditLog:         {"admin"},
+	PermManageComputers:      {"admin"},
+	PermManageSettings:       {"admin"},
+	PermManageCalendar:       {"admin"},
+}
+
+// AuthError is returned when a bound method is called without a valid session
+// or by a role that lacks the required permission
+type AuthError struct {
+	Code    string `json:"code"` // "unauthenticated", "forbidden", "password_change_required", "already_signed_in" or "session_ended"
+	Message string `json:"message"`
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func errUnauthenticated() error {
+	return &AuthError{Code: "unauthenticated", Message: "please sign in again"}
+}
+
+func errForbidden(message string) error {
+	return &AuthError{Code: "forbidden", Message: message}
+}
+
+func errPasswordChangeRequired() error {
+	return &AuthError{Code: "password_change_required", Message: "you must change your password before continuing"}
+}
+
+func errAlreadySignedIn(pcs string) error {
+	return &AuthError{Code: "already_signed_in", Message: fmt.Sprintf("this account is already signed in on %s, sign out there first", pcs)}
+}
+
+func errSessionEnded(message string) error {
+	return &AuthError{Code: "session_ended", Message: message}
+}
+
+// hasPermission reports whether a role is granted a permission
+func hasPermission(role string, perm Permission) bool {
+	for _, r := range rolePermissions[perm] {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// newSessionToken returns a random opaque session token
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// startSession replaces the active session with one for the given user
+func (a *App) startSession(user *User) (*Session, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session := &Session{
+		Token:      token,
+		UserID:     user.ID,
+		Username:   user.Name,
+		Role:       user.Role,
+		LoginLogID: user.LoginLogID,
+		IssuedAt:   time.Now(),
+
+		MustChangePassword: user.MustChangePassword,
+	}
+
+	a.sessionMu.Lock()
+	previous := a.session
+	a.session = session
+	a.sessionMu.Unlock()
+
+	if previous != nil && previous.UserID != user.ID {
+		log.Printf("Session for user %d replaced by new login of user %d", previous.UserID, user.ID)
+	}
+	return session, nil
+}
+
+// endSession clears the active session if it belongs to the given user
+func (a *App) endSession(userID int) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.session != nil && a.session.UserID == userID {
+		a.session = nil
+	}
+}
+
+// currentSession returns the active session or nil
+func (a *App) currentSession() *Session {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	return a.session
+}
+
+// clearPasswordChangeRequirement lifts the password change block on the active session
+func (a *App) clearPasswordChangeRequirement(userID int) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.session != nil && a.session.UserID == userID {
+		a.session.MustChangePassword = false
+	}
+}
+
+// requireSession returns the active session. Unless allowPasswordChange is set,
+// a session that still has to change its password is refused.
+func (a *App) requireSession(allowPasswordChange bool) (*Session, error) {
+	session := a.currentSession()
+	if session == nil {
+		return nil, errUnauthenticated()
+	}
+	if session.MustChangePassword && !allowPasswordChange {
+		return nil, errPasswordChangeRequired()
+	}
+	return session, nil
+}
+
+// authorize requires an active session whose role has the given permission
+func (a *App) authorize(perm Permission) (*Session, error) {
+	session, err := a.requireSession(false)
+	if err != nil {
+		return nil, err
+	}
+	if !hasPermission(session.Role, perm) {
+		log.Printf("⚠ Denied %s for user %d (role: %s)", perm, session.UserID, session.Role)
+		return nil, errForbidden(fmt.Sprintf("role %s is not allowed to %s", session.Role, perm))
+	}
+	return session, nil
+}
+
+// authorizeSelf requires the active session to belong to the given user
+func (a *App) authorizeSelf(userID int) (*Session, error) {
+	session, err := a.requireSession(false)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		log.Printf("⚠ Denied access to user %d's data for user %d", userID, session.UserID)
+		return nil, errForbidden("you can only act on your own account")
+	}
+	return session, nil
+}
+
+// authorizeSelfOr allows the given user themselves, or any role with the permission
+func (a *App) authorizeSelfOr(userID int, perm Permission) (*Session, error) {
+	session, err := a.requireSession(false)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID == userID {
+		return session, nil
+	}
+	return a.authorize(perm)
+}
+
+// authorizeAuthenticated only requires an active session
+func (a *App) authorizeAuthenticated() (*Session, error) {
+	return a.requireSession(false)
+}
+
+// authorizeSignOut requires the active session to belong to the given user,
+// even while a password change is pending
+func (a *App) authorizeSignOut(userID int) (*Session, error) {
+	session, err := a.requireSession(true)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		log.Printf("⚠ Denied sign out of user %d for user %d", userID, session.UserID)
+		return nil, errForbidden("you can only sign out your own account")
+	}
+	return session, nil
+}
+
+// authorizeClassAttendance requires attendance permission, and for teachers,
+// that the class is one of their own
+func (a *App) authorizeClassAttendance(classID int) (*Session, error) {
+	session, err := a.authorize(PermManageAttendance)
+	if err != nil {
+		return nil, err
+	}
+	if session.Role != "teacher" || a.requireDB() != nil {
+		return session, nil
+	}
+
+	teacherUserID, _, err := a.store.Classes.Owners(classID)
+	if err != nil {
+		return nil, fmt.Errorf("class not found")
+	}
+	if teacherUserID != session.UserID {
+		log.Printf("⚠ Denied attendance access to class %d for teacher %d", classID, session.UserID)
+		return nil, errForbidden("you can only manage attendance for your own classes")
+	}
+	return session, nil
+}
+
+// authorizeClass requires class management permission, and for teachers and
+// working students, that the class is assigned to or was created by them
+func (a *App) authorizeClass(classID int) (*Session, error) {
+	session, err := a.authorize(PermManageClasses)
+	if err != nil {
+		return nil, err
+	}
+	if session.Role == "admin" || a.requireDB() != nil {
+		return session, nil
+	}
+
+	teacherUserID, createdBy, err := a.store.Classes.Owners(classID)
+	if err != nil {
+		return nil, fmt.Errorf("class not found")
+	}
+	if teacherUserID != session.UserID && createdBy != session.UserID {
+		log.Printf("⚠ Denied access to class %d for user %d (role: %s)", classID, session.UserID, session.Role)
+		return nil, errForbidden("you can only manage your own classes")
+	}
+	return session, nil
+}
+
+// authorizeTeacher allows the given teacher themselves, or an admin
+func (a *App) authorizeTeacher(teacherUserID int) (*Session, error) {
+	session, err := a.requireSession(false)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != teacherUserID && session.Role != "admin" {
+		log.Printf("⚠ Denied access to teacher %d's classes for user %d", teacherUserID, session.UserID)
+		return nil, errForbidden("you can only view your own classes")
+	}
+	return session, nil
+}
+
+// ValidateSession reports whether the token belongs to the active session.
+// The frontend calls this on reload to detect that the app was restarted.
+func (a *App) ValidateSession(token string) error {
+	session := a.currentSession()
+	if session == nil || subtle.ConstantTimeCompare([]byte(session.Token), []byte(token)) != 1 {
+		return errUnauthenticated()
+	}
+
+	// A newer login on another PC or the stale session reaper may have closed this session's login log
+	if a.requireDB() == nil && session.LoginLogID > 0 {
+		status, err := a.store.Logs.Status(session.LoginLogID)
+		if err == nil && (status == "kicked" || status == "timeout") {
+			log.Printf("🔒 Session of user %d ended (%s)", session.UserID, status)
+			a.endSession(session.UserID)
+			if status == "kicked" {
+				return errSessionEnded("this account was signed in on another PC")
+			}
+			return errSessionEnded("the session exceeded the lab's time limit")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// authErrorCode returns the code of an AuthError, or "" for any other result
+func authErrorCode(err error) string {
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return authErr.Code
+	}
+	return ""
+}
+
+func TestAuthorize(t *testing.T) {
+	roles := []string{"admin", "teacher", "student", "working_student"}
+	tests := []struct {
+		perm    Permission
+		allowed []string
+	}{
+		{PermManageUsers, []string{"admin"}},
+		{PermRegisterStudents, []string{"admin", "working_student"}},
+		{PermManageDepartments, []string{"admin"}},
+		{PermViewAllLogs, []string{"admin"}},
+		{PermViewRecords, []string{"admin", "teacher", "working_student"}},
+		{PermReviewFeedback, []string{"admin"}},
+		{PermTriageFeedback, []string{"admin", "working_student"}},
+		{PermManageClasses, []string{"admin", "teacher", "working_student"}},
+		{PermManageAttendance, []string{"admin", "teacher"}},
+		{PermViewAdminDashboard, []string{"admin"}},
+		{PermViewWorkingDashboard, []string{"admin", "working_student"}},
+		{PermViewAuditLog, []string{"admin"}},
+		{PermManageComputers, []string{"admin"}},
+		{PermManageSettings, []string{"admin"}},
+		{PermManageCalendar, []string{"admin"}},
+	}
+	if len(tests) != len(rolePermissions) {
+		t.Fatalf("%d permissions tested, the matrix has %d", len(tests), len(rolePermissions))
+	}
+
+	a := NewApp()
+	for _, tt := range tests {
+		if _, err := a.authorize(tt.perm); authErrorCode(err) != "unauthenticated" {
+			t.Errorf("%s without a session: err = %v, want unauthenticated", tt.perm, err)
+		}
+
+		allowed := map[string]bool{}
+		for _, role := range tt.allowed {
+			allowed[role] = true
+		}
+		for i, role := range roles {
+			signInAs(t, a, i+1, role, role)
+			session, err := a.authorize(tt.perm)
+			switch {
+			case allowed[role] && (err != nil || session.UserID != i+1):
+				t.Errorf("%s for %s = %+v, %v; want allowed", tt.perm, role, session, err)
+			case !allowed[role] && authErrorCode(err) != "forbidden":
+				t.Errorf("%s for %s: err = %v, want forbidden", tt.perm, role, err)
+			}
+
+			// Nothing is allowed before a required password change
+			a.session.MustChangePassword = true
+			if _, err := a.authorize(tt.perm); authErrorCode(err) != "password_change_required" {
+				t.Errorf("%s for %s before changing the password: err = %v", tt.perm, role, err)
+			}
+			a.endSession(i + 1)
+		}
+	}
+
+	// Roles outside the matrix get nothing
+	signInAs(t, a, 9, "guest", "guest")
+	for _, tt := range tests {
+		if _, err := a.authorize(tt.perm); authErrorCode(err) != "forbidden" {
+			t.Errorf("%s for an unknown role: err = %v, want forbidden", tt.perm, err)
+		}
+	}
+}
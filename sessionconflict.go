@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// CONCURRENT SESSIONS
+// ==============================================================================
+//
+// A student who is still signed in on another PC when they log in again is
+// handled by the configured SessionPolicy. Every such login is written to
+// session_conflicts so the teacher sees it next to the class attendance, even
+// when the policy allowed it.
+
+// openLoginSession is an open login_logs row
+type openLoginSession struct {
+	ID        int64
+	UserID    int
+	PCNumber  string
+	LoginTime time.Time
+}
+
+// SessionConflict is a flagged login of a student who was already signed in elsewhere
+type SessionConflict struct {
+	ID                 int     `json:"id"`
+	StudentUserID      int     `json:"student_user_id"`
+	StudentCode        string  `json:"student_code"`
+	FirstName          string  `json:"first_name"`
+	LastName           string  `json:"last_name"`
+	LoginLogID         *int    `json:"login_log_id,omitempty"` // nil when the login was denied
+	ExistingLoginLogID int     `json:"existing_login_log_id"`
+	ExistingPC         *string `json:"existing_pc,omitempty"`
+	AttemptedPC        *string `json:"attempted_pc,omitempty"`
+	Policy             string  `json:"policy"`
+	DetectedAt         string  `json:"detected_at"`
+}
+
+// concurrentSessionRole reports whether the concurrent session policy applies to a role.
+// Staff routinely keep the app open on more than one PC, so only students are checked.
+func concurrentSessionRole(role string) bool {
+	return role == "student" || role == "working_student"
+}
+
+// recordSessionConflicts flags a login that found other open sessions.
+// newLogID is 0 when the login was denied.
+func recordSessionConflicts(logs LogRepository, userID int, newLogID int64, hostname, policy string, open []openLoginSession) error {
+	for _, s := range open {
+		if err := logs.RecordConflict(userID, newLogID, s, hostname, policy); err != nil {
+			return fmt.Errorf("failed to record session conflict: %w", err)
+		}
+	}
+	return nil
+}
+
+// openLoginLog creates and chains the login_logs row for a successful login,
+// applying the concurrent session policy first. When the policy refuses the
+// login the conflict is still recorded and an "already_signed_in" AuthError is returned.
+// Logins from a PC that is not approved in the registry are marked unregistered_pc.
+func (a *App) openLoginLog(user *User, pc labComputer, unregisteredPC bool) (int64, error) {
+	policy := GetSessionPolicy().Concurrent
+	hostname := pc.pcNumber()
+	var computerID sql.NullInt64
+	if pc.ID > 0 {
+		computerID = sql.NullInt64{Int64: int64(pc.ID), Valid: true}
+	}
+
+	var logID int64
+	var kicked []openLoginSession
+	var denied error
+	err := a.withTx(func(tx sqlExecutor) error {
+		logs := a.txStore(tx).Logs
+		var open []openLoginSession
+		if concurrentSessionRole(user.Role) {
+			var err error
+			if open, err = logs.OpenElsewhere(user.ID, hostname); err != nil {
+				return err
+			}
+		}
+
+		if len(open) > 0 && policy == ConcurrentSessionDeny {
+			pcs := make([]string, 0, len(open))
+			for _, s := range open {
+				pcs = append(pcs, s.PCNumber)
+			}
+			denied = errAlreadySignedIn(strings.Join(pcs, ", "))
+			return recordSessionConflicts(logs, user.ID, 0, hostname, policy, open)
+		}
+
+		var err error
+		if logID, err = logs.Open(user.ID, hostname, computerID, unregisteredPC); err != nil {
+			return err
+		}
+		if err := a.chainLoginLog(tx, logID); err != nil {
+			return err
+		}
+
+		if policy == ConcurrentSessionKick {
+			for _, s := range open {
+				if err := logs.Kick(s.ID); err != nil {
+					return err
+				}
+				if err := a.chainLoginLog(tx, s.ID); err != nil {
+					return err
+				}
+			}
+			kicked = open
+		}
+		return recordSessionConflicts(logs, user.ID, logID, hostname, policy, open)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if denied != nil {
+		log.Printf("⚠ Login of %s on %s denied: already signed in elsewhere", user.Name, hostname)
+		return 0, denied
+	}
+	// Sessions closed by this login end their attendance here
+	for _, s := range kicked {
+		a.recordTimeOut(s.ID)
+	}
+	return logID, nil
+}
+
+// GetSessionConflicts returns the flagged logins of students enrolled in a class on a date
+func (a *App) GetSessionConflicts(classID int, date string) ([]SessionConflict, error) {
+	if _, err := a.authorizeClassAttendance(classID); err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	return a.store.Logs.Conflicts(classID, date)
+}
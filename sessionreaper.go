@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// ==============================================================================
+// STALE SESSION REAPER
+// ==============================================================================
+//
+// A crashed app or a PC that was switched off never records a logout, leaving
+// its login_logs row open. The reaper closes such rows with status 'timeout'
+// once they exceed the maximum session length or the lab closing time. It runs
+// in the background of every app instance and again whenever a user logs in.
+//
+// Login times are compared with the database's current time rather than the local
+// clock, so lab PCs with a drifting clock do not close sessions early.
+
+// closeLoginLog closes an open login_logs row and chains it in one transaction,
+// then records the time out of the attendance the session recorded.
+// An empty status keeps the current login_status; a zero logoutTime records the current time.
+// It returns sql.ErrNoRows when the row was already closed.
+func (a *App) closeLoginLog(logID int64, userID int, status string, logoutTime time.Time) error {
+	err := a.withTx(func(tx sqlExecutor) error {
+		if err := a.txStore(tx).Logs.Close(logID, userID, status, logoutTime); err != nil {
+			return err
+		}
+		return a.chainLoginLog(tx, logID)
+	})
+	if err != nil {
+		return err
+	}
+	a.recordTimeOut(logID)
+	return nil
+}
+
+// staleSessionEnd reports whether a session that started at loginTime is stale at now,
+// and the time it should be recorded as ended
+func staleSessionEnd(policy SessionPolicy, loginTime, now time.Time) (time.Time, bool) {
+	var end time.Time
+	stale := false
+
+	if policy.MaxSessionMinutes > 0 {
+		limit := loginTime.Add(time.Duration(policy.MaxSessionMinutes) * time.Minute)
+		if now.After(limit) {
+			end, stale = limit, true
+		}
+	}
+
+	if policy.LabClosingTime != "" {
+		closing, err := time.Parse("15:04", policy.LabClosingTime)
+		if err == nil {
+			closingAt := time.Date(loginTime.Year(), loginTime.Month(), loginTime.Day(),
+				closing.Hour(), closing.Minute(), 0, 0, loginTime.Location())
+			if loginTime.Before(closingAt) && now.After(closingAt) && (!stale || closingAt.Before(end)) {
+				end, stale = closingAt, true
+			}
+		}
+	}
+
+	return end, stale
+}
+
+// reapStaleSessions closes stale open sessions and returns how many were closed.
+// With a userID it only looks at that user's sessions and also closes any left
+// open on hostname, since a new login on this PC means the old app instance is gone.
+func (a *App) reapStaleSessions(userID int, hostname string) (int, error) {
+	if a.requireDB() != nil {
+		return 0, nil
+	}
+
+	open, now, err := a.store.Logs.OpenSessions(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	policy := GetSessionPolicy()
+	var stale []openLoginSession
+	var ends []time.Time
+	for _, s := range open {
+		end, ok := staleSessionEnd(policy, s.LoginTime, now)
+		if !ok && userID > 0 && s.PCNumber == hostname {
+			end, ok = now, true
+		}
+		if ok {
+			stale = append(stale, s)
+			ends = append(ends, end)
+		}
+	}
+
+	closed := 0
+	for i, s := range stale {
+		err := a.closeLoginLog(s.ID, s.UserID, "timeout", ends[i])
+		if err == sql.ErrNoRows {
+			// Closed in the meantime by a logout or another PC's reaper
+			continue
+		} else if err != nil {
+			return closed, err
+		}
+		closed++
+	}
+	return closed, nil
+}
+
+// runSessionReaper periodically closes stale sessions until ctx is done
+func (a *App) runSessionReaper(ctx context.Context) {
+	interval := time.Duration(GetSessionPolicy().ReapIntervalMinutes) * time.Minute
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := a.reapStaleSessions(0, ""); err != nil {
+			log.Printf("⚠ Session reaper failed: %v", err)
+		} else if n > 0 {
+			log.Printf("🔒 Session reaper closed %d stale session(s)", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,564 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ==============================================================================
+// SETTINGS
+// ==============================================================================
+//
+// Every setting is named like its environment variable and resolved from four
+// layers, each overriding the one before it:
+//
+//  1. the default in settingDefs
+//  2. the config file, DigitalLogbook/config.json in the user's config folder
+//     (LOGBOOK_CONFIG points elsewhere)
+//  3. the environment variable
+//  4. the settings table, changed by admins with UpdateSettings
+//
+// A value that does not validate is logged and skipped, so the layer below it
+// applies. Database and offline settings are needed before the settings table
+// can be read; they are local to each PC and only come from the first three layers.
+
+// Setting value types
+const (
+	SettingString = "string"
+	SettingInt    = "int"
+	SettingBool   = "bool"
+	SettingChoice = "choice" // One of Options
+	SettingClock  = "clock"  // "HH:MM", or empty
+)
+
+// Layers a setting value is resolved from
+const (
+	SourceDefault  = "default"
+	SourceFile     = "file"
+	SourceEnv      = "env"
+	SourceDatabase = "database"
+)
+
+// settingDef describes one setting
+type settingDef struct {
+	Key         string
+	Group       string
+	Type        string
+	Default     string
+	Description string
+	Min         int      // Smallest accepted int
+	Options     []string // Accepted choice values; "" means not set
+	Local       bool     // Not read from the settings table
+	Secret      bool     // Value is not returned by GetSettings
+}
+
+// settingDefs lists every setting with its default, in the order of the settings screen
+var settingDefs = []settingDef{
+	{Key: "DB_DRIVER", Group: "database", Type: SettingChoice, Default: DriverMySQL, Options: []string{DriverMySQL, DriverSQLite}, Local: true, Description: "Database backend"},
+	{Key: "DB_SQLITE_PATH", Group: "database", Type: SettingString, Default: defaultSQLitePath(), Local: true, Description: "SQLite database file"},
+	{Key: "DB_HOST", Group: "database", Type: SettingString, Default: "localhost", Local: true, Description: "MySQL server host"},
+	{Key: "DB_PORT", Group: "database", Type: SettingString, Default: "3306", Local: true, Description: "MySQL server port"},
+	{Key: "DB_USERNAME", Group: "database", Type: SettingString, Default: "root", Local: true, Description: "MySQL user"},
+	{Key: "DB_PASSWORD", Group: "database", Type: SettingString, Default: "root", Local: true, Secret: true, Description: "MySQL password, plain or encrypted by the setup wizard"},
+	{Key: "DB_DATABASE", Group: "database", Type: SettingString, Default: "logbookdb", Local: true, Description: "MySQL database name"},
+	{Key: "DB_TLS_MODE", Group: "database", Type: SettingChoice, Default: TLSDisabled, Options: []string{TLSDisabled, TLSPreferred, TLSSkipVerify, TLSVerifyCA, TLSVerifyFull}, Local: true, Description: "Encryption of the MySQL connection"},
+	{Key: "DB_TLS_CA", Group: "database", Type: SettingString, Local: true, Description: "CA certificate (PEM) of the MySQL server; system CAs when empty"},
+	{Key: "DB_TLS_CERT", Group: "database", Type: SettingString, Local: true, Description: "Client certificate (PEM) for servers that require one"},
+	{Key: "DB_TLS_KEY", Group: "database", Type: SettingString, Local: true, Description: "Private key (PEM) of the client certificate"},
+	{Key: "DB_TLS_SERVER_NAME", Group: "database", Type: SettingString, Local: true, Description: "Name in the server certificate, when it differs from DB_HOST"},
+	{Key: "DB_AUTO_MIGRATE", Group: "database", Type: SettingBool, Default: "true", Local: true, Description: "Apply pending schema migrations at startup"},
+	{Key: "DB_MAX_OPEN_CONNS", Group: "database", Type: SettingInt, Default: "10", Min: 1, Local: true, Description: "Connections open at once, in use or idle"},
+	{Key: "DB_MAX_IDLE_CONNS", Group: "database", Type: SettingInt, Default: "5", Min: 1, Local: true, Description: "Idle connections kept for reuse"},
+	{Key: "DB_CONN_MAX_LIFETIME_MINUTES", Group: "database", Type: SettingInt, Default: "30", Min: 1, Local: true, Description: "Minutes before a connection is replaced"},
+	{Key: "DB_CONN_MAX_IDLE_MINUTES", Group: "database", Type: SettingInt, Default: "5", Min: 1, Local: true, Description: "Minutes before an idle connection is closed"},
+	{Key: "DB_QUERY_TIMEOUT_SECONDS", Group: "database", Type: SettingInt, Default: "15", Min: 1, Local: true, Description: "Seconds a statement may run before it is cancelled"},
+	{Key: "DB_TRANSACTION_TIMEOUT_SECONDS", Group: "database", Type: SettingInt, Default: "60", Min: 1, Local: true, Description: "Seconds a transaction may run before it is rolled back"},
+	{Key: "DB_HEALTH_CHECK_SECONDS", Group: "database", Type: SettingInt, Default: "30", Min: 1, Local: true, Description: "Seconds between pings while the database answers"},
+	{Key: "DB_RETRY_MIN_SECONDS", Group: "database", Type: SettingInt, Default: "1", Min: 1, Local: true, Description: "First retry delay after the database stops answering"},
+	{Key: "DB_RETRY_MAX_SECONDS", Group: "database", Type: SettingInt, Default: "30", Min: 1, Local: true, Description: "Longest retry delay"},
+	{Key: "DB_DEGRADED_LATENCY_MS", Group: "database", Type: SettingInt, Default: "1000", Min: 1, Local: true, Description: "Pings slower than this report the connection as degraded"},
+
+	{Key: "OFFLINE_MODE", Group: "offline", Type: SettingBool, Default: "true", Local: true, Description: "Queue sign-ins locally while the MySQL server is unreachable"},
+	{Key: "OFFLINE_QUEUE_PATH", Group: "offline", Type: SettingString, Default: defaultOfflinePath(), Local: true, Description: "Offline queue and credential cache file"},
+	{Key: "OFFLINE_CREDENTIAL_DAYS", Group: "offline", Type: SettingInt, Default: "14", Min: 1, Local: true, Description: "Days a cached sign-in is accepted offline"},
+
+	{Key: "LOGIN_MAX_FAILED_ATTEMPTS", Group: "login", Type: SettingInt, Default: "5", Min: 1, Description: "Failed sign-ins allowed before the account is locked"},
+	{Key: "LOGIN_FAILURE_WINDOW_MINUTES", Group: "login", Type: SettingInt, Default: "15", Min: 1, Description: "Minutes in which failed sign-ins are counted"},
+	{Key: "LOGIN_LOCKOUT_MINUTES", Group: "login", Type: SettingInt, Default: "15", Min: 1, Description: "Minutes a locked account stays locked"},
+
+	{Key: "PASSWORD_MIN_LENGTH", Group: "password", Type: SettingInt, Default: "8", Min: 1, Description: "Shortest accepted password"},
+	{Key: "PASSWORD_REQUIRE_UPPERCASE", Group: "password", Type: SettingBool, Default: "true", Description: "Passwords need an uppercase letter"},
+	{Key: "PASSWORD_REQUIRE_LOWERCASE", Group: "password", Type: SettingBool, Default: "true", Description: "Passwords need a lowercase letter"},
+	{Key: "PASSWORD_REQUIRE_DIGIT", Group: "password", Type: SettingBool, Default: "true", Description: "Passwords need a digit"},
+	{Key: "PASSWORD_REQUIRE_SYMBOL", Group: "password", Type: SettingBool, Default: "false", Description: "Passwords need a symbol"},
+	{Key: "PASSWORD_DISALLOW_STUDENT_CODE", Group: "password", Type: SettingBool, Default: "true", Description: "Reject the username or student code as a password"},
+	{Key: "PASSWORD_EXPIRY_DAYS", Group: "password", Type: SettingInt, Default: "0", Min: 0, Description: "Days before a password must be changed; 0 disables expiry"},
+
+	{Key: "CONCURRENT_SESSION_POLICY", Group: "session", Type: SettingChoice, Default: ConcurrentSessionKick, Options: []string{ConcurrentSessionAllow, ConcurrentSessionDeny, ConcurrentSessionKick}, Description: "What happens when a student signs in on a second PC"},
+	{Key: "SESSION_MAX_MINUTES", Group: "session", Type: SettingInt, Default: "480", Min: 0, Description: "Open sessions older than this are closed as timed out; 0 disables"},
+	{Key: "LAB_CLOSING_TIME", Group: "session", Type: SettingClock, Description: "Sessions still open after this time are closed; empty disables"},
+	{Key: "SESSION_REAP_INTERVAL_MINUTES", Group: "session", Type: SettingInt, Default: "5", Min: 1, Description: "Minutes between checks for stale sessions"},
+
+	{Key: "UNREGISTERED_PC_POLICY", Group: "computers", Type: SettingChoice, Default: UnregisteredPCFlag, Options: unregisteredPCPolicies, Description: "Sign-ins from PCs not approved in the registry"},
+	{Key: "UNREGISTERED_PC_POLICY_ADMIN", Group: "computers", Type: SettingChoice, Options: append([]string{""}, unregisteredPCPolicies...), Description: "Overrides the unregistered PC policy for admins"},
+	{Key: "UNREGISTERED_PC_POLICY_TEACHER", Group: "computers", Type: SettingChoice, Options: append([]string{""}, unregisteredPCPolicies...), Description: "Overrides the unregistered PC policy for teachers"},
+	{Key: "UNREGISTERED_PC_POLICY_STUDENT", Group: "computers", Type: SettingChoice, Options: append([]string{""}, unregisteredPCPolicies...), Description: "Overrides the unregistered PC policy for students"},
+	{Key: "UNREGISTERED_PC_POLICY_WORKING_STUDENT", Group: "computers", Type: SettingChoice, Options: append([]string{""}, unregisteredPCPolicies...), Description: "Overrides the unregistered PC policy for working students"},
+
+	{Key: "ATTENDANCE_EARLY_MINUTES", Group: "attendance", Type: SettingInt, Default: "30", Min: 0, Description: "Minutes before the class starts a login counts towards the class"},
+	{Key: "ATTENDANCE_LATE_MINUTES", Group: "attendance", Type: SettingInt, Default: "10", Min: 0, Description: "Minutes after the class starts before a login is marked late"},
+	{Key: "ATTENDANCE_ABSENT_MINUTES", Group: "attendance", Type: SettingInt, Default: "0", Min: 0, Description: "Minutes after the class starts before a login is marked absent; 0 keeps it late"},
+	{Key: "ATTENDANCE_MINIMUM_MINUTES", Group: "attendance", Type: SettingInt, Default: "0", Min: 0, Description: "Minutes a student must stay in class; 0 sets no minimum"},
+	{Key: "ATTENDANCE_AUTO_SCHEDULE", Group: "attendance", Type: SettingBool, Default: "true", Description: "Open each class meeting's attendance and mark students without a login absent when it ends"},
+
+	{Key: "EXPORT_DIR", Group: "exports", Type: SettingString, Default: defaultExportDir(), Description: "Folder exported reports are saved to"},
+}
+
+var unregisteredPCPolicies = []string{UnregisteredPCAllow, UnregisteredPCFlag, UnregisteredPCReject}
+
+// Setting is one setting as shown on the admin settings screen
+type Setting struct {
+	Key         string   `json:"key"`
+	Group       string   `json:"group"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Value       string   `json:"value"`
+	Default     string   `json:"default"`
+	Source      string   `json:"source"` // Layer the value comes from
+	Min         int      `json:"min"`
+	Options     []string `json:"options,omitempty"`
+	Editable    bool     `json:"editable"` // Local settings can only be changed in the config file or environment
+}
+
+// settingLayers holds the config file and settings table values
+type settingLayers struct {
+	mu       sync.RWMutex
+	fileOnce sync.Once
+	file     map[string]string
+	database map[string]string
+	warned   map[string]bool // Invalid values already logged
+}
+
+var appSettings settingLayers
+
+// findSetting returns the definition of key
+func findSetting(key string) (settingDef, bool) {
+	for _, def := range settingDefs {
+		if def.Key == key {
+			return def, true
+		}
+	}
+	return settingDef{}, false
+}
+
+// validate reports whether value is acceptable for the setting
+func (def settingDef) validate(value string) error {
+	switch def.Type {
+	case SettingInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", def.Key)
+		}
+		if n < def.Min {
+			return fmt.Errorf("%s must be at least %d", def.Key, def.Min)
+		}
+	case SettingBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false", def.Key)
+		}
+	case SettingChoice:
+		for _, option := range def.Options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s", def.Key, strings.Join(def.Options, ", "))
+	case SettingClock:
+		if value == "" {
+			return nil
+		}
+		if _, err := time.Parse("15:04", value); err != nil {
+			return fmt.Errorf("%s must be a time as HH:MM", def.Key)
+		}
+	}
+	return nil
+}
+
+// resolveSetting returns the value of a setting and the layer it comes from
+func resolveSetting(def settingDef) (string, string) {
+	appSettings.mu.RLock()
+	stored, inDatabase := appSettings.database[def.Key]
+	appSettings.mu.RUnlock()
+
+	return resolveLayers(def, stored, inDatabase && !def.Local)
+}
+
+// resolveLayers returns the value of a setting stored in the database as given,
+// and the layer it comes from
+func resolveLayers(def settingDef, stored string, inDatabase bool) (string, string) {
+	appSettings.fileOnce.Do(loadSettingsFile)
+
+	appSettings.mu.RLock()
+	inFile, fromFile := appSettings.file[def.Key]
+	appSettings.mu.RUnlock()
+
+	layers := []struct {
+		source string
+		value  string
+		set    bool
+	}{
+		{SourceDatabase, stored, inDatabase},
+		{SourceEnv, os.Getenv(def.Key), os.Getenv(def.Key) != ""},
+		{SourceFile, inFile, fromFile && inFile != ""},
+	}
+	for _, layer := range layers {
+		if !layer.set {
+			continue
+		}
+		if err := def.validate(layer.value); err != nil {
+			warnInvalidSetting(layer.source, layer.value, err)
+			continue
+		}
+		return layer.value, layer.source
+	}
+	return def.Default, SourceDefault
+}
+
+// warnInvalidSetting logs an invalid value the first time it is read
+func warnInvalidSetting(source, value string, err error) {
+	key := source + "\x00" + err.Error() + "\x00" + value
+	appSettings.mu.Lock()
+	defer appSettings.mu.Unlock()
+	if appSettings.warned[key] {
+		return
+	}
+	if appSettings.warned == nil {
+		appSettings.warned = make(map[string]bool)
+	}
+	appSettings.warned[key] = true
+	log.Printf("⚠ Ignoring %q from %s: %v", value, source, err)
+}
+
+// settingString returns the value of a setting
+func settingString(key string) string {
+	def, ok := findSetting(key)
+	if !ok {
+		log.Printf("⚠ Unknown setting %s", key)
+		return ""
+	}
+	value, _ := resolveSetting(def)
+	return value
+}
+
+// settingInt returns the value of an int setting
+func settingInt(key string) int {
+	value, _ := strconv.Atoi(settingString(key))
+	return value
+}
+
+// settingBool returns the value of a bool setting
+func settingBool(key string) bool {
+	value, _ := strconv.ParseBool(settingString(key))
+	return value
+}
+
+// settingsFilePath returns the config file location
+func settingsFilePath() string {
+	if path := os.Getenv("LOGBOOK_CONFIG"); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "config.json"
+	}
+	return filepath.Join(dir, "DigitalLogbook", "config.json")
+}
+
+// loadSettingsFile reads the config file, a JSON object of setting keys to
+// strings, numbers or booleans. A missing file is the same as an empty one.
+func loadSettingsFile() {
+	path := settingsFilePath()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠ Failed to read config file %s: %v", path, err)
+		}
+		return
+	}
+
+	var raw map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		log.Printf("⚠ Ignoring config file %s: %v", path, err)
+		return
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if _, ok := findSetting(key); !ok {
+			log.Printf("⚠ Unknown setting %s in config file %s", key, path)
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case json.Number, bool:
+			values[key] = fmt.Sprint(v)
+		default:
+			log.Printf("⚠ Ignoring %s in config file %s: expected a string, number or boolean", key, path)
+		}
+	}
+
+	appSettings.mu.Lock()
+	appSettings.file = values
+	appSettings.mu.Unlock()
+	log.Printf("✓ Loaded %d setting(s) from %s", len(values), path)
+}
+
+// saveSettingsFile writes values to the config file, keeping its other entries,
+// and reloads it. An empty value removes the key.
+func saveSettingsFile(values map[string]string) error {
+	path := settingsFilePath()
+	raw := make(map[string]interface{})
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		decoder := json.NewDecoder(strings.NewReader(string(data)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&raw); err != nil {
+			return fmt.Errorf("config file %s is not valid JSON: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	for key, value := range values {
+		if value == "" {
+			delete(raw, key)
+		} else {
+			raw[key] = value
+		}
+	}
+	data, err = json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write a temporary file first so a crash cannot leave a half-written config
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	loadSettingsFile()
+	return nil
+}
+
+// loadDatabaseSettings replaces the settings table layer with the stored values.
+// On failure the values read last time stay in effect.
+func (a *App) loadDatabaseSettings() {
+	rows, err := a.pool.Query(`SELECT setting_key, setting_value FROM settings`)
+	if err != nil {
+		log.Printf("⚠ Failed to load settings: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			log.Printf("⚠ Failed to load settings: %v", err)
+			return
+		}
+		values[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠ Failed to load settings: %v", err)
+		return
+	}
+
+	appSettings.mu.Lock()
+	appSettings.database = values
+	appSettings.mu.Unlock()
+}
+
+// GetSettings returns every setting with its current value and where it comes from
+func (a *App) GetSettings() ([]Setting, error) {
+	if _, err := a.authorize(PermManageSettings); err != nil {
+		return nil, err
+	}
+
+	settings := make([]Setting, 0, len(settingDefs))
+	for _, def := range settingDefs {
+		value, source := resolveSetting(def)
+		defaultValue := def.Default
+		if def.Secret {
+			value, defaultValue = "", ""
+		}
+		settings = append(settings, Setting{
+			Key:         def.Key,
+			Group:       def.Group,
+			Type:        def.Type,
+			Description: def.Description,
+			Value:       value,
+			Default:     defaultValue,
+			Source:      source,
+			Min:         def.Min,
+			Options:     def.Options,
+			Editable:    !def.Local,
+		})
+	}
+	return settings, nil
+}
+
+// UpdateSettings stores the given settings for every lab PC. An empty value
+// removes the stored one, so the config file, environment or default applies again.
+// Nothing is changed unless every value is valid.
+func (a *App) UpdateSettings(values map[string]string) error {
+	session, err := a.authorize(PermManageSettings)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key, value := range values {
+		def, ok := findSetting(key)
+		if !ok {
+			return fmt.Errorf("unknown setting %s", key)
+		}
+		if def.Local {
+			return fmt.Errorf("%s can only be changed in the config file or environment", key)
+		}
+		if value = strings.TrimSpace(value); value != "" {
+			if err := def.validate(value); err != nil {
+				return err
+			}
+		}
+		values[key] = value
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// The attendance cutoffs are only valid together
+	if err := pendingAttendancePolicy(values).validate(); err != nil {
+		return err
+	}
+
+	d := a.dialect
+	changed := 0
+	err = a.withTx(func(tx sqlExecutor) error {
+		changed = 0
+		for _, key := range keys {
+			var before interface{}
+			var previous string
+			err := tx.QueryRow(`SELECT setting_value FROM settings WHERE setting_key = ?`, key).Scan(&previous)
+			switch {
+			case err == nil:
+				before = map[string]string{"value": previous}
+			case err != sql.ErrNoRows:
+				return err
+			}
+
+			var after interface{}
+			if values[key] == "" {
+				if before == nil {
+					continue
+				}
+				if _, err := tx.Exec(`DELETE FROM settings WHERE setting_key = ?`, key); err != nil {
+					return err
+				}
+			} else {
+				if before != nil && previous == values[key] {
+					continue
+				}
+				_, err := tx.Exec(`
+					INSERT INTO settings (setting_key, setting_value, updated_by, updated_at)
+					VALUES (?, ?, ?, `+d.now()+`)
+					`+d.upsert()+`
+						setting_value = `+d.excluded("setting_value")+`,
+						updated_by = `+d.excluded("updated_by")+`,
+						updated_at = `+d.now()+`
+				`, key, values[key], session.UserID)
+				if err != nil {
+					return err
+				}
+				after = map[string]string{"value": values[key]}
+			}
+			if err := a.recordAudit(tx, "update", "setting", key, before, after); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update settings: %w", err)
+	}
+
+	a.loadDatabaseSettings()
+	log.Printf("✓ %d setting(s) updated by admin %d", changed, session.UserID)
+	return nil
+}
+
+// pendingSetting returns the value of a setting once values are saved to the database
+func pendingSetting(values map[string]string, key string) string {
+	value, ok := values[key]
+	if !ok {
+		return settingString(key)
+	}
+	def, _ := findSetting(key)
+	value, _ = resolveLayers(def, value, value != "")
+	return value
+}
+
+// pendingAttendancePolicy returns the default attendance policy once values are saved
+func pendingAttendancePolicy(values map[string]string) AttendancePolicy {
+	minutes := func(key string) int {
+		n, _ := strconv.Atoi(pendingSetting(values, key))
+		return n
+	}
+	return AttendancePolicy{
+		EarlyMinutes:   minutes("ATTENDANCE_EARLY_MINUTES"),
+		LateMinutes:    minutes("ATTENDANCE_LATE_MINUTES"),
+		AbsentMinutes:  minutes("ATTENDANCE_ABSENT_MINUTES"),
+		MinimumMinutes: minutes("ATTENDANCE_MINIMUM_MINUTES"),
+	}
+}
+
+// defaultExportDir returns the Downloads folder of the current user
+func defaultExportDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, "Downloads")
+}
+
+// exportPath returns where an exported report named name is saved, creating
+// the export folder if needed
+func exportPath(name string) string {
+	dir := GetExportDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("⚠ Failed to create export folder %s: %v", dir, err)
+	}
+	return filepath.Join(dir, name)
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// FIRST-RUN SETUP
+// ==============================================================================
+//
+// On a new lab PC the setup wizard asks for the database connection, tests it
+// and writes it to the config file. The MySQL password is stored encrypted with
+// AES-256-GCM under a key kept in a separate file of the PC's user config folder,
+// so a copied or backed-up config.json does not reveal it.
+//
+// The wizard needs no sign-in until it has saved a configuration and while no
+// database has been reached; after that only admins may run it again. The new
+// connection is used once the app is restarted.
+//
+// A new database, such as the SQLite file of a standalone lab, has no accounts.
+// Until it has an admin account the wizard's last step creates one, again without
+// a sign-in.
+
+// encryptedPrefix marks a setting value encrypted by encryptSecret
+const encryptedPrefix = "enc:"
+
+// SetupConfig is the database connection entered in the setup wizard
+type SetupConfig struct {
+	Driver        string `json:"driver"`
+	SQLitePath    string `json:"sqlite_path"`
+	Host          string `json:"host"`
+	Port          string `json:"port"`
+	Username      string `json:"username"`
+	Password      string `json:"password"` // Empty keeps the current password
+	Database      string `json:"database"`
+	TLSMode       string `json:"tls_mode"`
+	TLSCA         string `json:"tls_ca"`
+	TLSCert       string `json:"tls_cert"`
+	TLSKey        string `json:"tls_key"`
+	TLSServerName string `json:"tls_server_name"`
+}
+
+// SetupStatus tells the frontend whether to show the setup wizard
+type SetupStatus struct {
+	Required   bool        `json:"required"`    // Nothing configured and no database reached yet
+	Configured bool        `json:"configured"`  // The wizard has written the config file
+	NeedsAdmin bool        `json:"needs_admin"` // The database is reached but has no admin account yet
+	ConfigPath string      `json:"config_path"`
+	Config     SetupConfig `json:"config"` // Current connection, without the password
+}
+
+// ConnectionTest is the result of a successful connection test
+type ConnectionTest struct {
+	ServerVersion string `json:"server_version"`
+	TLSCipher     string `json:"tls_cipher"` // Empty when the connection is not encrypted
+	LatencyMS     int64  `json:"latency_ms"`
+}
+
+// configKeyPath returns where this PC's config encryption key is kept
+func configKeyPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "DigitalLogbook", "config.key"), nil
+}
+
+// configKey reads this PC's config encryption key, creating it if create is set
+func configKey(create bool) ([]byte, error) {
+	path, err := configKeyPath()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("config key %s is damaged", path)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) || !create {
+		return nil, fmt.Errorf("failed to read config key %s: %w", path, err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)), 0o600); err != nil {
+		return nil, err
+	}
+	log.Printf("🔒 Created config encryption key %s", path)
+	return key, nil
+}
+
+// configCipher returns the AEAD for config secrets
+func configCipher(create bool) (cipher.AEAD, error) {
+	key, err := configKey(create)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptSecret encrypts a setting value for the config file
+func encryptSecret(plain string) (string, error) {
+	gcm, err := configCipher(true)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptSecret returns a setting value in plain text. Values without the
+// encrypted prefix are returned unchanged.
+func decryptSecret(value string) (string, error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil {
+		return "", fmt.Errorf("encrypted value is damaged: %w", err)
+	}
+	gcm, err := configCipher(false)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is damaged")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("encrypted value does not match this PC's config key")
+	}
+	return string(plain), nil
+}
+
+// setupConfigured reports whether the setup wizard has written the config file
+func setupConfigured() bool {
+	appSettings.fileOnce.Do(loadSettingsFile)
+	appSettings.mu.RLock()
+	defer appSettings.mu.RUnlock()
+	_, ok := appSettings.file["DB_DRIVER"]
+	return ok
+}
+
+// databaseConfigured reports whether any database setting comes from the
+// config file or the environment rather than the defaults
+func databaseConfigured() bool {
+	for _, def := range settingDefs {
+		if def.Group != "database" {
+			continue
+		}
+		if _, source := resolveSetting(def); source != SourceDefault {
+			return true
+		}
+	}
+	return false
+}
+
+// firstRun reports whether the app has not been set up yet: the database it
+// reaches has no admin account, or it cannot reach one and nothing configures it
+func (a *App) firstRun() bool {
+	if a.requireDB() == nil {
+		return a.needsFirstAdmin()
+	}
+	return !databaseConfigured()
+}
+
+// authorizeSetup lets anyone run the wizard on first run; otherwise it requires an admin
+func (a *App) authorizeSetup() error {
+	if a.firstRun() {
+		return nil
+	}
+	_, err := a.authorize(PermManageSettings)
+	return err
+}
+
+// dbConfig returns the connection the wizard describes
+func (c SetupConfig) dbConfig() (DBConfig, error) {
+	config := GetDBConfig()
+	config.Driver = strings.TrimSpace(c.Driver)
+	if config.Driver != DriverMySQL && config.Driver != DriverSQLite {
+		return config, fmt.Errorf("choose a database backend: %s or %s", DriverMySQL, DriverSQLite)
+	}
+
+	if config.Driver == DriverSQLite {
+		if path := strings.TrimSpace(c.SQLitePath); path != "" {
+			config.Path = path
+		}
+		return config, nil
+	}
+
+	config.Host = strings.TrimSpace(c.Host)
+	config.Port = strings.TrimSpace(c.Port)
+	config.Username = strings.TrimSpace(c.Username)
+	config.Database = strings.TrimSpace(c.Database)
+	if c.Password != "" {
+		config.Password = c.Password
+	}
+	if config.Host == "" || config.Port == "" || config.Username == "" || config.Database == "" {
+		return config, fmt.Errorf("host, port, user and database name are required")
+	}
+
+	config.TLSMode = strings.TrimSpace(c.TLSMode)
+	if config.TLSMode == "" {
+		config.TLSMode = TLSDisabled
+	}
+	if def, _ := findSetting("DB_TLS_MODE"); def.validate(config.TLSMode) != nil {
+		return config, fmt.Errorf("unknown TLS mode %q", config.TLSMode)
+	}
+	config.TLSCA = strings.TrimSpace(c.TLSCA)
+	config.TLSCert = strings.TrimSpace(c.TLSCert)
+	config.TLSKey = strings.TrimSpace(c.TLSKey)
+	config.TLSServerName = strings.TrimSpace(c.TLSServerName)
+	return config, nil
+}
+
+// testConnection opens a separate pool for config and checks that it answers
+func testConnection(config DBConfig) (ConnectionTest, error) {
+	var result ConnectionTest
+	db, _, err := openDatabase(config)
+	if err != nil {
+		return result, err
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	if err := conn.PingContext(ctx); err != nil {
+		return result, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	result.LatencyMS = time.Since(start).Milliseconds()
+
+	if config.Driver == DriverSQLite {
+		err = conn.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&result.ServerVersion)
+		return result, err
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT VERSION()`).Scan(&result.ServerVersion); err != nil {
+		return result, err
+	}
+	var name string
+	err = conn.QueryRowContext(ctx, `SHOW SESSION STATUS LIKE 'Ssl_cipher'`).Scan(&name, &result.TLSCipher)
+	if err != nil && err != sql.ErrNoRows {
+		return result, err
+	}
+	return result, nil
+}
+
+// GetSetupStatus reports whether the setup wizard should be shown. It needs no
+// session so the wizard can open before anyone signs in.
+func (a *App) GetSetupStatus() SetupStatus {
+	config := GetDBConfig()
+	return SetupStatus{
+		Required:   !databaseConfigured() && !a.conn.isReady(),
+		Configured: setupConfigured(),
+		NeedsAdmin: a.needsFirstAdmin(),
+		ConfigPath: settingsFilePath(),
+		Config: SetupConfig{
+			Driver:        config.Driver,
+			SQLitePath:    config.Path,
+			Host:          config.Host,
+			Port:          config.Port,
+			Username:      config.Username,
+			Database:      config.Database,
+			TLSMode:       config.TLSMode,
+			TLSCA:         config.TLSCA,
+			TLSCert:       config.TLSCert,
+			TLSKey:        config.TLSKey,
+			TLSServerName: config.TLSServerName,
+		},
+	}
+}
+
+// TestDatabaseConnection connects with the wizard's settings without saving them
+func (a *App) TestDatabaseConnection(setup SetupConfig) (ConnectionTest, error) {
+	if err := a.authorizeSetup(); err != nil {
+		return ConnectionTest{}, err
+	}
+
+	config, err := setup.dbConfig()
+	if err != nil {
+		return ConnectionTest{}, err
+	}
+	return testConnection(config)
+}
+
+// SaveSetup tests the wizard's connection and writes it to the config file,
+// encrypting the password. The app uses it after a restart.
+func (a *App) SaveSetup(setup SetupConfig) error {
+	if err := a.authorizeSetup(); err != nil {
+		return err
+	}
+
+	config, err := setup.dbConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := testConnection(config); err != nil {
+		return err
+	}
+
+	values := map[string]string{"DB_DRIVER": config.Driver}
+	if config.Driver == DriverSQLite {
+		values["DB_SQLITE_PATH"] = strings.TrimSpace(setup.SQLitePath)
+	} else {
+		values["DB_HOST"] = config.Host
+		values["DB_PORT"] = config.Port
+		values["DB_USERNAME"] = config.Username
+		values["DB_DATABASE"] = config.Database
+		values["DB_TLS_MODE"] = config.TLSMode
+		values["DB_TLS_CA"] = config.TLSCA
+		values["DB_TLS_CERT"] = config.TLSCert
+		values["DB_TLS_KEY"] = config.TLSKey
+		values["DB_TLS_SERVER_NAME"] = config.TLSServerName
+		if setup.Password != "" {
+			encrypted, err := encryptSecret(setup.Password)
+			if err != nil {
+				return fmt.Errorf("failed to encrypt password: %w", err)
+			}
+			values["DB_PASSWORD"] = encrypted
+		}
+	}
+
+	if err := saveSettingsFile(values); err != nil {
+		return fmt.Errorf("failed to save configuration: %w", err)
+	}
+	for key := range values {
+		if os.Getenv(key) != "" {
+			log.Printf("⚠ %s is set in the environment and overrides the saved configuration", key)
+		}
+	}
+	log.Printf("✓ Database configuration saved to %s; restart the app to use it", settingsFilePath())
+	return nil
+}
+
+// needsFirstAdmin reports whether the database is reached and has no admin account
+func (a *App) needsFirstAdmin() bool {
+	if a.requireDB() != nil {
+		return false
+	}
+	count, err := a.store.Users.AdminCount()
+	if err != nil {
+		log.Printf("⚠ Failed to count admin accounts: %v", err)
+		return false
+	}
+	return count == 0
+}
+
+// CreateFirstAdmin creates the first admin account of a new database with a password
+// of their own. It needs no session and is refused once any admin account exists.
+func (a *App) CreateFirstAdmin(username, password, firstName, lastName string) error {
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	username = strings.TrimSpace(username)
+	firstName = strings.TrimSpace(firstName)
+	lastName = strings.TrimSpace(lastName)
+	if username == "" || firstName == "" || lastName == "" {
+		return fmt.Errorf("username, first name and last name are required")
+	}
+	if err := ValidatePassword(GetPasswordPolicyConfig(), password, username); err != nil {
+		return err
+	}
+	hashed, err := HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		users := a.txStore(tx).Users
+		count, err := users.AdminCount()
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return errForbidden("an admin account already exists, sign in with it to add more")
+		}
+
+		userID, err := users.Create(userAccount{Username: username, Password: hashed, Role: "admin", FirstName: firstName, LastName: lastName})
+		if err != nil {
+			return err
+		}
+		// The password was chosen by the admin, so it needs no change on first login
+		if err := users.SetPassword(username, hashed); err != nil {
+			return err
+		}
+		after, err := auditSnapshot(tx, userAuditQuery, userID)
+		if err != nil {
+			return err
+		}
+		return recordAuditAs(tx, nil, "create_first_admin", "user", fmt.Sprint(userID), nil, after)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("✓ First admin account %s created", username)
+	return nil
+}
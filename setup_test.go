@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCreateFirstAdmin(t *testing.T) {
+	a := newTestApp(t)
+	if !a.needsFirstAdmin() {
+		t.Fatal("a new database does not ask for its first admin")
+	}
+
+	if err := a.CreateFirstAdmin("root", "short", "Ada", "Admin"); err == nil {
+		t.Error("a password breaking the policy was accepted")
+	}
+	if err := a.CreateFirstAdmin("root", "Str0ng#Admin", "Ada", "Admin"); err != nil {
+		t.Fatalf("create first admin: %v", err)
+	}
+	if a.needsFirstAdmin() {
+		t.Error("still asking for a first admin")
+	}
+
+	// The chosen password needs no change, and the account works at once
+	user, err := a.Login("root", "Str0ng#Admin")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if user.Role != "admin" || user.MustChangePassword {
+		t.Errorf("role = %s, must change password = %v", user.Role, user.MustChangePassword)
+	}
+	if n := queryInt(t, a, `SELECT COUNT(*) FROM audit_log WHERE action = 'create_first_admin' AND actor_user_id IS NULL`); n != 1 {
+		t.Errorf("%d first admin audit entries, want 1", n)
+	}
+
+	if err := a.CreateFirstAdmin("second", "Str0ng#Admin", "Eve", "Admin"); err == nil {
+		t.Error("a second admin was created without signing in")
+	}
+}
+
+func TestAuthorizeSetup(t *testing.T) {
+	a := newTestApp(t)
+	if err := a.authorizeSetup(); err != nil {
+		t.Errorf("setup of a database without an admin: %v", err)
+	}
+	seedUser(t, a, 1, "admin", "Admin#123", "admin")
+	if err := a.authorizeSetup(); err == nil {
+		t.Error("setup of a database with an admin needed no sign in")
+	}
+
+	// Losing the database does not make a configured PC new again
+	a.conn.set(DBDisconnected, ErrDatabaseUnavailable, 0, false)
+	if err := a.authorizeSetup(); err == nil {
+		t.Error("setup of a configured PC needed no sign in while the database was down")
+	}
+	t.Setenv("DB_DRIVER", "")
+	t.Setenv("DB_SQLITE_PATH", "")
+	if err := a.authorizeSetup(); err != nil {
+		t.Errorf("setup of a PC nothing configures: %v", err)
+	}
+}
+
+func TestEncryptSecretRoundTrip(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, plain := range []string{"Db#Pass word", ""} {
+		sealed, err := encryptSecret(plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sealed, encryptedPrefix) || (plain != "" && strings.Contains(sealed, plain)) {
+			t.Errorf("encrypted %q = %q", plain, sealed)
+		}
+		if got, err := decryptSecret(sealed); err != nil || got != plain {
+			t.Errorf("decrypted %q = %q, %v", plain, got, err)
+		}
+	}
+
+	// Each value gets its own nonce
+	first, _ := encryptSecret("Db#Pass")
+	second, _ := encryptSecret("Db#Pass")
+	if first == second {
+		t.Error("two encryptions of the same value are equal")
+	}
+
+	// The key is created once, readable only by its owner
+	path, err := configKeyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("config key = %v, %v; want mode 0600", info, err)
+	}
+
+	// Values written before encryption are read as they are
+	if got, err := decryptSecret("legacy-password"); err != nil || got != "legacy-password" {
+		t.Errorf("plain value = %q, %v", got, err)
+	}
+}
+
+func TestDecryptSecretRejectsTampering(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	sealed, err := encryptSecret("Db#Pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, encryptedPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flipped returns the sealed value with one bit of byte i changed
+	flipped := func(i int) string {
+		b := append([]byte(nil), raw...)
+		b[i] ^= 0x01
+		return encryptedPrefix + base64.StdEncoding.EncodeToString(b)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"nonce", flipped(0)},
+		{"ciphertext", flipped(12)},
+		{"tag", flipped(len(raw) - 1)},
+		{"truncated", encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:len(raw)-1])},
+		{"shorter than a nonce", encryptedPrefix + base64.StdEncoding.EncodeToString(raw[:8])},
+		{"not base64", encryptedPrefix + "!!!!"},
+	}
+	for _, tt := range tests {
+		if got, err := decryptSecret(tt.value); err == nil {
+			t.Errorf("%s: decrypted to %q", tt.name, got)
+		}
+	}
+
+	// Another PC's key does not open it
+	path, err := configKeyPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptSecret(sealed); err == nil {
+		t.Error("decrypted without a config key")
+	}
+	if _, err := configKey(true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decryptSecret(sealed); err == nil {
+		t.Error("decrypted with another config key")
+	}
+
+	if err := os.WriteFile(path, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := encryptSecret("Db#Pass"); err == nil {
+		t.Error("encrypted with a damaged config key")
+	}
+}
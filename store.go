@@ -0,0 +1,293 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ==============================================================================
+// DATA ACCESS
+// ==============================================================================
+//
+// Users, classes, attendance, the academic calendar, login logs, departments,
+// lab computers, the integrity chain and feedback are read and written through
+// the repositories below rather than by SQL inside the App methods.
+// The repositories are written once against database/sql. The MySQL and SQLite
+// backends differ only in their sqlDialect, which spells out the date
+// functions, upserts and row locks the two databases write differently.
+//
+// A Store is bound either to the connection pool or to the transaction of an
+// audited change, so repository writes commit together with their audit log.
+
+// sqlDialect supplies the SQL fragments that differ between database backends
+type sqlDialect interface {
+	name() string // Driver name, also the migrations subdirectory
+
+	now() string         // Current local date and time
+	today() string       // Current local date
+	currentTime() string // Current local time of day
+	unixTimestamp() string
+
+	dateAdd(expr, amount, unit string) string // unit is "minute", "hour" or "day"
+	dateSub(expr, amount, unit string) string
+	dateDiff(end, start string) string // Whole days from start to end
+
+	upsert() string                // Introduces the update clause of an insert on a duplicate key
+	excluded(column string) string // The value an upsert tried to insert
+	forUpdate() string             // Row lock for SELECT inside a transaction, or empty
+	likeEscape() string            // ESCAPE clause making backslash the LIKE escape character
+	tableOptions() string          // Appended to CREATE TABLE
+
+	// Migration support
+	lockMigrations(conn *sql.Conn) (func(), error) // Keeps two PCs from migrating at once
+	tableExists(conn *sql.Conn, table string) (bool, error)
+}
+
+// UserRepository reads and writes user accounts and their role profiles
+type UserRepository interface {
+	FindForLogin(username string) (*loginAccount, error)
+	LoadProfile(user *User) error
+	List(filter userFilter) ([]User, error)
+	Teachers() ([]User, error)
+	CountByRole() (AdminDashboard, error)
+	AdminCount() (int, error)
+
+	Create(account userAccount) (int64, error)
+	UpdateProfile(id int, account userAccount) error
+	UpdateContact(id int, role, email, contactNumber string) error
+	UpdatePhoto(id int, role, photoURL string) error
+	Delete(id int) error
+	Exists(id int) (bool, error)
+	HasRole(id int, role string) error
+	SetActive(id int, active bool) error
+	StudentCount() (int, error)
+	HistoryCount(id int) (int, error)
+	ChainedCount(id int) (int, error)
+
+	PasswordForChange(username string) (string, string, error)
+	SetPassword(username, hashed string) error
+	ReplacePassword(id int, oldStored, hashed string) error
+	ResetPassword(id int, hashed string, resetBy int) error
+	Username(id int) (string, error)
+	ClassStudentAccounts(classID int) ([]credentialSlip, error)
+	SectionStudentAccounts(yearLevel, section string) ([]credentialSlip, error)
+
+	TOTPSecret(id int, enabled bool) (secret sql.NullString, lastStep sql.NullInt64, now int64, err error)
+	ClaimTOTPStep(id int, step int64) (bool, error)
+	UseRecoveryCode(id int, codeHash string) (bool, error)
+	TwoFactorStatus(id int) (bool, int, error)
+	SetTOTPSecret(id int, secret string) error
+	EnableTwoFactor(id int) error
+	ClearTwoFactor(id int) error
+	ReplaceRecoveryCodes(id int, codeHashes []string) error
+
+	LockState(id int) (sql.NullTime, bool, error)
+	CountFailedLogin(id, windowMinutes int) (int, error)
+	Lock(id, minutes int) error
+	Unlock(id int) error
+	ClearExpiredLock(id int) error
+	RecordLogin(id int) error
+}
+
+// ClassRepository reads and writes subjects, classes and enrollments
+type ClassRepository interface {
+	Subjects() ([]Subject, error)
+	SaveSubject(code, name, description string) error
+
+	ByTeacher(teacherUserID int) ([]CourseClass, error)
+	CreatedForTeacher(teacherUserID int) ([]CourseClass, error)
+	Active() ([]CourseClass, error)
+	ByCreator(createdBy int) ([]CourseClass, error)
+	BySubjectCode(subjectCode string) ([]CourseClass, error)
+	ForStudent(studentUserID int) ([]CourseClass, error)
+	WithoutMeetings() ([]CourseClass, error)
+	Meetings(classIDs ...int) (map[int][]ClassMeeting, error)
+	SetMeetings(classID int, meetings []ClassMeeting) error
+	AttendancePolicies(classIDs ...int) (map[int]AttendancePolicyOverride, error)
+	SetAttendancePolicy(classID int, o AttendancePolicyOverride, updatedBy int) error
+	Create(class classFields) (int64, error)
+	Update(classID int, class classFields) error
+	Deactivate(classID int) error
+	SubjectAndSection(classID int) (string, string, error)
+	Owners(classID int) (teacherUserID, createdBy int, err error)
+	EnrollmentCount() (int, error)
+
+	Students(classID int) ([]ClasslistEntry, error)
+	Candidates(classID int, availableOnly bool) ([]ClassStudent, error)
+	RegisteredStudents() ([]ClassStudent, error)
+	Enroll(classID, studentUserID int) error
+	IsEnrolled(classID, studentUserID int) (bool, error)
+	Drop(classID, studentUserID int) error
+	DropAll(classID int) error
+}
+
+// AttendanceRepository reads and writes attendance records
+type AttendanceRepository interface {
+	TodayForTeacher(teacherUserID int) ([]Attendance, error)
+	ForClass(classID int, date string) ([]Attendance, error)
+	ForExport(classID int) ([]Attendance, error)
+	ForStudent(studentUserID, limit int) ([]Attendance, error)
+	InitializedClasses(studentUserID int, date string) ([]int, error)
+
+	Record(classID, studentUserID int, date, timeIn, timeOut, status, remarks string) error
+	UpdateTimes(classID, studentUserID int, date, timeIn, timeOut string) error
+	Update(classID, studentUserID int, date, timeIn, timeOut, pcNumber, status, remarks string) error
+	Initialize(classID int, date string) error
+	Merge(classID, studentUserID, loginLogID int, date, timeIn, timeOut, pcNumber, status, remarks string) error
+	MergeTap(classID, studentUserID, loginLogID int, date, timeIn, pcNumber, status string, remark sql.NullString) error
+
+	SessionStays(loginLogID int) ([]attendanceStay, error)
+	Stays(classID int, date string) ([]attendanceStay, error)
+	SetStay(classID, studentUserID int, date, timeOut string, minutes sql.NullInt64, short bool) error
+
+	Uninitialized(classID int, date string) (int, error)
+	Placeholders(classID int, date string) ([]int, error)
+	UnfinalizedDays(from, to string) ([]ClassDay, error)
+	FinalizeAbsent(classID int, date, remark string) (int64, error)
+}
+
+// CalendarRepository reads and writes academic terms and calendar events
+type CalendarRepository interface {
+	Terms() ([]AcademicTerm, error)
+	CreateTerm(term AcademicTerm, createdBy int) (int64, error)
+	UpdateTerm(term AcademicTerm) (bool, error)
+	DeleteTerm(termID int) error
+	TermExists(schoolYear, semester string, termID int) (bool, error)
+
+	Events(from, to string) ([]CalendarEvent, error)
+	CreateEvent(ev CalendarEvent, createdBy int) (int64, error)
+	UpdateEvent(ev CalendarEvent) (bool, error)
+	DeleteEvent(eventID int) error
+
+	ClassScopes(classIDs ...int) (map[int]classScope, error)
+}
+
+// LogRepository reads and writes login logs
+type LogRepository interface {
+	List(userID, limit int) ([]LoginLog, error)
+	RecentLoginCount(hours int) (int, error)
+	FirstLogin(userID int, from, until time.Time) (logID int, loginTime, logoutTime sql.NullTime, pcNumber sql.NullString, err error)
+	Sessions(userID int, from, until time.Time) ([]loginSpan, error)
+
+	Open(userID int, pcNumber string, computerID sql.NullInt64, unregisteredPC bool) (int64, error)
+	OpenAt(userID int, pcNumber string, computerID sql.NullInt64, unregisteredPC bool, loginTime time.Time) (int64, error)
+	RecordFailure(userID int, pcNumber string) (int64, error)
+	Close(logID int64, userID int, status string, logoutTime time.Time) error
+	Kick(logID int64) error
+	OpenElsewhere(userID int, pcNumber string) ([]openLoginSession, error)
+	OpenSessions(userID int) ([]openLoginSession, time.Time, error)
+	Status(logID int) (string, error)
+
+	RecordConflict(userID int, newLogID int64, existing openLoginSession, attemptedPC, policy string) error
+	Conflicts(classID int, date string) ([]SessionConflict, error)
+}
+
+// DepartmentRepository reads and writes departments
+type DepartmentRepository interface {
+	List() ([]Department, error)
+	Exists(code string) (bool, error)
+	Create(code, name, description string) error
+	Update(oldCode, code, name, description string, isActive bool) error
+	Delete(code string) error
+}
+
+// ComputerRepository reads and writes the lab computer registry
+type ComputerRepository interface {
+	Fingerprint(id int, secretHash string) (string, error)
+	Seen(id int, hostname, macs string) error
+	Request(hostname, fingerprint, macs, secretHash string) (int64, error)
+	Lookup(id int, secretHash, fingerprint string) (displayName sql.NullString, status string, err error)
+
+	List() ([]Computer, error)
+	Exists(id int) (bool, error)
+	NameTaken(displayName string, id int) (bool, error)
+	Approve(id int, displayName, room, assetTag string, approvedBy int) (bool, error)
+	UpdateDetails(id int, displayName, room, assetTag string) error
+	Revoke(id int) error
+}
+
+// IntegrityRepository reads and appends to the log integrity chain
+type IntegrityRepository interface {
+	LockHead() (seq int64, hash string, err error)
+	Append(e chainEntry) error
+
+	Head() (ChainHead, error)
+	Entries(from, to string) ([]chainEntry, error)
+	EntryHash(seq int64) (string, error)
+	LiveRows(entityType, from, to string) ([]map[string]interface{}, error)
+	PreChainKeys(entityType, from, to string) (map[string]bool, error)
+	LatestStates(entityType string) (map[string]chainedState, error)
+	DeletedEntities(from, to string) ([]chainEntry, error)
+}
+
+// FeedbackRepository reads and writes equipment feedback
+type FeedbackRepository interface {
+	Forwarded() ([]Feedback, error)
+	Pending() ([]Feedback, error)
+	ForStudent(studentUserID int) ([]Feedback, error)
+	Create(fb Feedback) error
+	Forward(feedbackID, workingStudentID int, notes string) (bool, error)
+}
+
+// Store groups the repositories bound to one connection or transaction
+type Store struct {
+	Users       UserRepository
+	Classes     ClassRepository
+	Attendance  AttendanceRepository
+	Calendar    CalendarRepository
+	Logs        LogRepository
+	Departments DepartmentRepository
+	Computers   ComputerRepository
+	Integrity   IntegrityRepository
+	Feedback    FeedbackRepository
+}
+
+// newStore returns the repositories for q, which is the pool or a transaction
+func newStore(q sqlExecutor, d sqlDialect) *Store {
+	return &Store{
+		Users:       &sqlUserRepository{q: q, d: d},
+		Classes:     &sqlClassRepository{q: q, d: d},
+		Attendance:  &sqlAttendanceRepository{q: q, d: d},
+		Calendar:    &sqlCalendarRepository{q: q, d: d},
+		Logs:        &sqlLogRepository{q: q, d: d},
+		Departments: &sqlDepartmentRepository{q: q, d: d},
+		Computers:   &sqlComputerRepository{q: q, d: d},
+		Integrity:   &sqlIntegrityRepository{q: q, d: d},
+		Feedback:    &sqlFeedbackRepository{q: q, d: d},
+	}
+}
+
+// txStore returns the repositories bound to an audited change's transaction
+func (a *App) txStore(tx sqlExecutor) *Store {
+	return newStore(tx, a.dialect)
+}
+
+// dbTime scans a date or datetime computed by the database. MySQL returns these
+// as time.Time, while SQLite returns expressions such as datetime('now') as text.
+type dbTime struct {
+	time.Time
+}
+
+// Scan implements sql.Scanner
+func (t *dbTime) Scan(value interface{}) error {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		t.Time = v
+		return nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into a time", value)
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02"} {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			t.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as a time", text)
+}
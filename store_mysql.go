@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL TLS modes set by DB_TLS_MODE
+const (
+	TLSDisabled   = "disabled"    // Plain connection
+	TLSPreferred  = "preferred"   // TLS when the server offers it, without checking its certificate
+	TLSSkipVerify = "skip-verify" // TLS required, certificate not checked
+	TLSVerifyCA   = "verify-ca"   // TLS required, certificate must be signed by DB_TLS_CA or a system CA
+	TLSVerifyFull = "verify-full" // As verify-ca, and the certificate must also name the server
+)
+
+// mysqlDialect is the shared MySQL server backend used by a networked lab
+type mysqlDialect struct{}
+
+func (mysqlDialect) name() string          { return "mysql" }
+func (mysqlDialect) now() string           { return "NOW()" }
+func (mysqlDialect) today() string         { return "CURDATE()" }
+func (mysqlDialect) currentTime() string   { return "CURTIME()" }
+func (mysqlDialect) unixTimestamp() string { return "UNIX_TIMESTAMP()" }
+
+func (mysqlDialect) dateAdd(expr, amount, unit string) string {
+	return fmt.Sprintf("DATE_ADD(%s, INTERVAL %s %s)", expr, amount, strings.ToUpper(unit))
+}
+
+func (mysqlDialect) dateSub(expr, amount, unit string) string {
+	return fmt.Sprintf("DATE_SUB(%s, INTERVAL %s %s)", expr, amount, strings.ToUpper(unit))
+}
+
+func (mysqlDialect) dateDiff(end, start string) string {
+	return fmt.Sprintf("DATEDIFF(%s, %s)", end, start)
+}
+
+func (mysqlDialect) upsert() string                { return "ON DUPLICATE KEY UPDATE" }
+func (mysqlDialect) excluded(column string) string { return "VALUES(" + column + ")" }
+func (mysqlDialect) forUpdate() string             { return "FOR UPDATE" }
+func (mysqlDialect) likeEscape() string            { return "" } // Backslash is already the default
+func (mysqlDialect) tableOptions() string {
+	return "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+}
+
+// lockMigrations takes a named server lock, since lab PCs share one database
+func (mysqlDialect) lockMigrations(conn *sql.Conn) (func(), error) {
+	ctx := context.Background()
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 120)`, migrationLockName).Scan(&locked); err != nil {
+		return nil, err
+	}
+	if locked.Int64 != 1 {
+		return nil, fmt.Errorf("timed out waiting for another PC to finish migrating the database")
+	}
+	return func() { conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, migrationLockName) }, nil
+}
+
+func (mysqlDialect) tableExists(conn *sql.Conn, table string) (bool, error) {
+	var count int
+	err := conn.QueryRowContext(context.Background(), `
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = ?
+	`, table).Scan(&count)
+	return count > 0, err
+}
+
+// openMySQL connects to the configured MySQL server. The dial timeout is kept
+// short so an unreachable server sends lab PCs offline without a long wait.
+func openMySQL(config DBConfig) (*sql.DB, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = config.Username
+	cfg.Passwd = config.Password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(config.Host, config.Port)
+	cfg.DBName = config.Database
+	cfg.ParseTime = true
+	cfg.Timeout = 5 * time.Second
+	cfg.Params = map[string]string{"charset": "utf8mb4"}
+
+	tlsConfig, err := mysqlTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TLS = tlsConfig
+	cfg.AllowFallbackToPlaintext = config.TLSMode == TLSPreferred
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// mysqlTLSConfig builds the TLS settings of the connection, or returns nil when TLS is disabled
+func mysqlTLSConfig(config DBConfig) (*tls.Config, error) {
+	if config.TLSMode == "" || config.TLSMode == TLSDisabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: config.TLSServerName}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = config.Host
+	}
+
+	if config.TLSCA != "" {
+		pem, err := os.ReadFile(config.TLSCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read DB_TLS_CA: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("DB_TLS_CA %s contains no PEM certificates", config.TLSCA)
+		}
+	}
+
+	if config.TLSCert != "" || config.TLSKey != "" {
+		if config.TLSCert == "" || config.TLSKey == "" {
+			return nil, fmt.Errorf("DB_TLS_CERT and DB_TLS_KEY must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch config.TLSMode {
+	case TLSPreferred, TLSSkipVerify:
+		tlsConfig.InsecureSkipVerify = true
+	case TLSVerifyCA:
+		// The chain is checked by hand so a certificate issued for another name still passes
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyPeerCertificate = verifyCertificateChain(tlsConfig.RootCAs)
+	case TLSVerifyFull:
+	default:
+		return nil, fmt.Errorf("unknown DB_TLS_MODE %q", config.TLSMode)
+	}
+	return tlsConfig, nil
+}
+
+// verifyCertificateChain checks that the server certificate is signed by roots,
+// or by a system CA when roots is nil, without matching its host name
+func verifyCertificateChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server sent no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteDialect is the single-file backend for a standalone lab PC. The driver
+// is pure Go, so it needs no database server and no C compiler.
+//
+// SQLite has no row locks; transactions begin IMMEDIATE instead (see openSQLite),
+// which takes the write lock up front, so SELECTs that MySQL runs FOR UPDATE
+// already see data no other writer can change.
+type sqliteDialect struct{}
+
+func (sqliteDialect) name() string          { return "sqlite" }
+func (sqliteDialect) now() string           { return "datetime('now', 'localtime')" }
+func (sqliteDialect) today() string         { return "date('now', 'localtime')" }
+func (sqliteDialect) currentTime() string   { return "time('now', 'localtime')" }
+func (sqliteDialect) unixTimestamp() string { return "CAST(strftime('%s', 'now') AS INTEGER)" }
+
+func (sqliteDialect) dateAdd(expr, amount, unit string) string {
+	return fmt.Sprintf("datetime(%s, '+' || (%s) || ' %s')", expr, amount, unit)
+}
+
+func (sqliteDialect) dateSub(expr, amount, unit string) string {
+	return fmt.Sprintf("datetime(%s, '-' || (%s) || ' %s')", expr, amount, unit)
+}
+
+func (sqliteDialect) dateDiff(end, start string) string {
+	return fmt.Sprintf("CAST(julianday(date(%s)) - julianday(date(%s)) AS INTEGER)", end, start)
+}
+
+func (sqliteDialect) upsert() string                { return "ON CONFLICT DO UPDATE SET" }
+func (sqliteDialect) excluded(column string) string { return "excluded." + column }
+func (sqliteDialect) forUpdate() string             { return "" }
+func (sqliteDialect) likeEscape() string            { return ` ESCAPE '\'` }
+func (sqliteDialect) tableOptions() string          { return "" }
+
+// lockMigrations is a no-op: the database file belongs to this PC alone
+func (sqliteDialect) lockMigrations(conn *sql.Conn) (func(), error) {
+	return func() {}, nil
+}
+
+func (sqliteDialect) tableExists(conn *sql.Conn, table string) (bool, error) {
+	var count int
+	err := conn.QueryRowContext(context.Background(),
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
+
+// defaultSQLitePath keeps the database next to this install's computer identity
+func defaultSQLitePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "logbook.db"
+	}
+	return filepath.Join(dir, "DigitalLogbook", "logbook.db")
+}
+
+// openSQLite opens (creating if needed) the database file at config.Path
+func openSQLite(config DBConfig) (*sql.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(config.Path), 0o700); err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(wal)") // Readers do not wait for a writer to commit
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+	return sql.Open("sqlite", "file:"+filepath.ToSlash(config.Path)+"?"+params.Encode())
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ==============================================================================
+// TWO-FACTOR AUTHENTICATION (TOTP)
+// ==============================================================================
+//
+// Admins and teachers can enroll an authenticator app (RFC 6238: SHA-1,
+// 6 digits, 30 second steps). Once enabled, Login only verifies the password
+// and returns a pending challenge; VerifyTwoFactorLogin completes the sign-in
+// with a current code or one of the single-use recovery codes. Codes are
+// checked against the database clock, and the last accepted time step is
+// stored so a code cannot be replayed.
+
+const (
+	totpIssuer        = "Digital Logbook"
+	totpDigits        = 6
+	totpPeriodSeconds = 30
+	totpSkewSteps     = 1 // Accept codes one step either side of now
+
+	twoFactorChallengeTTL = 5 * time.Minute
+	twoFactorMaxAttempts  = 5
+
+	recoveryCodeCount    = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLength   = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// twoFactorChallenge is a login waiting for its second factor
+type twoFactorChallenge struct {
+	Token     string
+	User      User
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+// TwoFactorEnrollment is returned when enrollment starts. URI is meant to be shown as a QR code;
+// Secret is for typing into the authenticator app by hand.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorStatus describes the signed-in user's two-factor setup
+type TwoFactorStatus struct {
+	Available              bool `json:"available"` // The user's role may enroll
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// twoFactorRole reports whether a role may enroll in two-factor authentication
+func twoFactorRole(role string) bool {
+	return role == "admin" || role == "teacher"
+}
+
+// totpCode computes the code for a secret at a time step
+func totpCode(secret []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// matchTOTP returns the time step whose code matches, looking one step either side of unixTime
+func matchTOTP(encodedSecret, code string, unixTime int64) (int64, bool) {
+	secret, err := totpEncoding.DecodeString(encodedSecret)
+	if err != nil {
+		return 0, false
+	}
+	current := unixTime / totpPeriodSeconds
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(secret, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// totpURI builds the otpauth:// URI understood by authenticator apps
+func totpURI(username, secret string) string {
+	label := url.PathEscape(totpIssuer) + ":" + url.PathEscape(username)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", strconv.Itoa(totpDigits))
+	params.Set("period", strconv.Itoa(totpPeriodSeconds))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// normalizeTwoFactorCode strips the spaces and dashes users type between code groups
+func normalizeTwoFactorCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
+
+// isTOTPCode reports whether a normalized code looks like a TOTP code rather than a recovery code
+func isTOTPCode(code string) bool {
+	if len(code) != totpDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRecoveryCode hashes a normalized recovery code. Recovery codes are random,
+// so a plain SHA-256 is enough and keeps checking all of a user's codes cheap.
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateRecoveryCodes returns new recovery codes formatted as "xxxxx-xxxxx"
+func generateRecoveryCodes() ([]string, error) {
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	codes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength)
+		for j := range b {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, err
+			}
+			b[j] = recoveryCodeAlphabet[n.Int64()]
+		}
+		codes[i] = string(b[:recoveryCodeLength/2]) + "-" + string(b[recoveryCodeLength/2:])
+	}
+	return codes, nil
+}
+
+// replaceRecoveryCodes discards a user's recovery codes and stores new ones through users
+func replaceRecoveryCodes(users UserRepository, userID int) ([]string, error) {
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(normalizeTwoFactorCode(code))
+	}
+	if err := users.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifyTOTP checks a code against a user's secret and records its time step so it
+// cannot be used again. With pending set it checks a secret that is not yet enabled.
+func (a *App) verifyTOTP(userID int, code string, pending bool) (bool, error) {
+	secret, lastStep, now, err := a.store.Users.TOTPSecret(userID, !pending)
+	if err == sql.ErrNoRows || (err == nil && !secret.Valid) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	step, ok := matchTOTP(secret.String, code, now)
+	if !ok || (lastStep.Valid && step <= lastStep.Int64) {
+		return false, nil
+	}
+
+	return a.store.Users.ClaimTOTPStep(userID, step)
+}
+
+// verifyTwoFactorCode accepts a current TOTP code or an unused recovery code
+func (a *App) verifyTwoFactorCode(userID int, code string) (bool, error) {
+	code = normalizeTwoFactorCode(code)
+	if code == "" {
+		return false, nil
+	}
+	if isTOTPCode(code) {
+		return a.verifyTOTP(userID, code, false)
+	}
+
+	used, err := a.store.Users.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, err
+	}
+	if used {
+		log.Printf("🔑 Recovery code used by user %d", userID)
+	}
+	return used, nil
+}
+
+// beginTwoFactorChallenge parks a password-verified login until the second factor is supplied
+func (a *App) beginTwoFactorChallenge(user *User) (*User, error) {
+	token, err := newSessionToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sign-in challenge: %w", err)
+	}
+
+	a.sessionMu.Lock()
+	a.twoFactor = &twoFactorChallenge{
+		Token:     token,
+		User:      *user,
+		ExpiresAt: time.Now().Add(twoFactorChallengeTTL),
+	}
+	a.sessionMu.Unlock()
+
+	log.Printf("🔑 Two-factor code required for %s", user.Name)
+	return &User{
+		Name:              user.Name,
+		Role:              user.Role,
+		TwoFactorRequired: true,
+		TwoFactorToken:    token,
+	}, nil
+}
+
+// VerifyTwoFactorLogin completes a login that returned two_factor_required,
+// using a code from the authenticator app or a recovery code
+func (a *App) VerifyTwoFactorLogin(token, code string) (*User, error) {
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	a.sessionMu.Lock()
+	challenge := a.twoFactor
+	if challenge == nil || subtle.ConstantTimeCompare([]byte(challenge.Token), []byte(token)) != 1 {
+		a.sessionMu.Unlock()
+		return nil, fmt.Errorf("sign-in expired, please enter your password again")
+	}
+	if time.Now().After(challenge.ExpiresAt) {
+		a.twoFactor = nil
+		a.sessionMu.Unlock()
+		return nil, fmt.Errorf("sign-in expired, please enter your password again")
+	}
+	a.sessionMu.Unlock()
+
+	user := challenge.User
+	if err := a.checkAccountLock(user.ID); err != nil {
+		a.clearTwoFactorChallenge(token)
+		return nil, err
+	}
+
+	ok, err := a.verifyTwoFactorCode(user.ID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		a.sessionMu.Lock()
+		challenge.Attempts++
+		attempts := challenge.Attempts
+		a.sessionMu.Unlock()
+
+		if lockErr := a.recordFailedLogin(user.ID, user.Name); lockErr != nil {
+			a.clearTwoFactorChallenge(token)
+			return nil, lockErr
+		}
+		if attempts >= twoFactorMaxAttempts {
+			a.clearTwoFactorChallenge(token)
+			return nil, fmt.Errorf("too many invalid codes, please enter your password again")
+		}
+		return nil, fmt.Errorf("invalid authentication code")
+	}
+
+	a.clearTwoFactorChallenge(token)
+	a.resetFailedLogins(user.ID)
+	return a.completeLogin(&user)
+}
+
+// clearTwoFactorChallenge discards the pending challenge if it is still the given one
+func (a *App) clearTwoFactorChallenge(token string) {
+	a.sessionMu.Lock()
+	defer a.sessionMu.Unlock()
+	if a.twoFactor != nil && a.twoFactor.Token == token {
+		a.twoFactor = nil
+	}
+}
+
+// GetTwoFactorStatus returns the signed-in user's two-factor setup
+func (a *App) GetTwoFactorStatus() (TwoFactorStatus, error) {
+	session, err := a.authorizeAuthenticated()
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return TwoFactorStatus{}, err
+	}
+
+	status := TwoFactorStatus{Available: twoFactorRole(session.Role)}
+	status.Enabled, status.RecoveryCodesRemaining, err = a.store.Users.TwoFactorStatus(session.UserID)
+	return status, err
+}
+
+// BeginTwoFactorEnrollment generates a new secret for the signed-in admin or teacher.
+// Two-factor stays off until ConfirmTwoFactorEnrollment receives a valid code.
+func (a *App) BeginTwoFactorEnrollment() (TwoFactorEnrollment, error) {
+	session, err := a.authorizeAuthenticated()
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	if !twoFactorRole(session.Role) {
+		return TwoFactorEnrollment{}, errForbidden("two-factor authentication is only available to admins and teachers")
+	}
+
+	if err := a.requireDB(); err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	enabled, _, err := a.store.Users.TwoFactorStatus(session.UserID)
+	if err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+	if enabled {
+		return TwoFactorEnrollment{}, fmt.Errorf("two-factor authentication is already enabled")
+	}
+
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return TwoFactorEnrollment{}, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := totpEncoding.EncodeToString(raw)
+
+	if err := a.store.Users.SetTOTPSecret(session.UserID, secret); err != nil {
+		return TwoFactorEnrollment{}, err
+	}
+
+	return TwoFactorEnrollment{Secret: secret, URI: totpURI(session.Username, secret)}, nil
+}
+
+// ConfirmTwoFactorEnrollment enables two-factor once the authenticator app produces a valid
+// code, and returns the recovery codes. They are only shown this once.
+func (a *App) ConfirmTwoFactorEnrollment(code string) ([]string, error) {
+	session, err := a.authorizeAuthenticated()
+	if err != nil {
+		return nil, err
+	}
+	if !twoFactorRole(session.Role) {
+		return nil, errForbidden("two-factor authentication is only available to admins and teachers")
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	ok, err := a.verifyTOTP(session.UserID, normalizeTwoFactorCode(code), true)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid authentication code, check the time on your phone and try again")
+	}
+
+	var codes []string
+	err = a.withTx(func(tx sqlExecutor) error {
+		users := a.txStore(tx).Users
+		if err := users.EnableTwoFactor(session.UserID); err != nil {
+			return err
+		}
+		if codes, err = replaceRecoveryCodes(users, session.UserID); err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "enable_2fa", "user", strconv.Itoa(session.UserID), nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Two-factor accounts no longer sign in offline
+	a.offline.forget(session.UserID)
+	log.Printf("🔑 Two-factor authentication enabled for %s", session.Username)
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the signed-in user's recovery codes after checking a current code
+func (a *App) RegenerateRecoveryCodes(code string) ([]string, error) {
+	session, err := a.authorizeAuthenticated()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return nil, err
+	}
+
+	ok, err := a.verifyTwoFactorCode(session.UserID, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid authentication code")
+	}
+
+	var codes []string
+	err = a.withTx(func(tx sqlExecutor) error {
+		if codes, err = replaceRecoveryCodes(a.txStore(tx).Users, session.UserID); err != nil {
+			return err
+		}
+		return a.recordAudit(tx, "regenerate_recovery_codes", "user", strconv.Itoa(session.UserID), nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns off two-factor for the signed-in user after checking a current code
+func (a *App) DisableTwoFactor(code string) error {
+	session, err := a.authorizeAuthenticated()
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	ok, err := a.verifyTwoFactorCode(session.UserID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("invalid authentication code")
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		return a.clearTwoFactor(tx, "disable_2fa", session.UserID)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Two-factor authentication disabled by %s", session.Username)
+	return nil
+}
+
+// AdminResetTwoFactor removes a user's authenticator and recovery codes, for a lost device.
+// The user signs in with their password alone and can enroll again.
+func (a *App) AdminResetTwoFactor(userID int) error {
+	session, err := a.authorize(PermManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if err := a.requireDB(); err != nil {
+		return err
+	}
+
+	if exists, err := a.store.Users.Exists(userID); err != nil || !exists {
+		return fmt.Errorf("user not found")
+	}
+
+	err = a.withTx(func(tx sqlExecutor) error {
+		return a.clearTwoFactor(tx, "reset_2fa", userID)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🔑 Two-factor authentication reset for user %d by admin %d", userID, session.UserID)
+	return nil
+}
+
+// clearTwoFactor removes a user's secret and recovery codes inside tx and audits it
+func (a *App) clearTwoFactor(tx sqlExecutor, action string, userID int) error {
+	if err := a.txStore(tx).Users.ClearTwoFactor(userID); err != nil {
+		return err
+	}
+	return a.recordAudit(tx, action, "user", strconv.Itoa(userID), nil, nil)
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCodeRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes; ours are their last 6 digits
+	tests := []struct {
+		unixTime int64
+		code     string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	encoded := totpEncoding.EncodeToString(rfc6238Secret)
+	for _, tt := range tests {
+		step := tt.unixTime / totpPeriodSeconds
+		if code := totpCode(rfc6238Secret, step); code != tt.code {
+			t.Errorf("code at %d = %s, want %s", tt.unixTime, code, tt.code)
+		}
+		if got, ok := matchTOTP(encoded, tt.code, tt.unixTime); !ok || got != step {
+			t.Errorf("match at %d = step %d, %v; want step %d", tt.unixTime, got, ok, step)
+		}
+	}
+}
+
+func TestMatchTOTPWindow(t *testing.T) {
+	encoded := totpEncoding.EncodeToString(rfc6238Secret)
+	// The code of step 10, which runs from 300 to 329, is accepted one step either side
+	code := totpCode(rfc6238Secret, 10)
+
+	tests := []struct {
+		unixTime int64
+		ok       bool
+	}{
+		{269, false},
+		{270, true},
+		{299, true},
+		{300, true},
+		{329, true},
+		{330, true},
+		{359, true},
+		{360, false},
+	}
+	for _, tt := range tests {
+		step, ok := matchTOTP(encoded, code, tt.unixTime)
+		if ok != tt.ok || (ok && step != 10) {
+			t.Errorf("at %d = step %d, %v; want %v", tt.unixTime, step, ok, tt.ok)
+		}
+	}
+
+	for _, code := range []string{"", "28708", "2870820", "abcdef"} {
+		if _, ok := matchTOTP(encoded, code, 300); ok {
+			t.Errorf("%q was accepted", code)
+		}
+	}
+	if _, ok := matchTOTP("not base32!", code, 300); ok {
+		t.Error("a code was accepted for an undecodable secret")
+	}
+}